@@ -19,8 +19,12 @@ package abi
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/crypto"
 )
 
 // The ABI holds information about a contract's context and available
@@ -145,3 +149,39 @@ func (abi *ABI) MethodById(sigdata []byte) (*Method, error) {
 	}
 	return nil, fmt.Errorf("no method with id: %#x", sigdata[:4])
 }
+
+// revertSelector is the method id of `Error(string)`, which revert reasons are
+// encoded as calls to.
+var revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+// revertArgs are the arguments of an `Error(string)` revert reason.
+var revertArgs = func() Arguments {
+	typ, err := NewType("string", nil)
+	if err != nil {
+		panic(err)
+	}
+	return Arguments{{Type: typ}}
+}()
+
+// PackRevert encodes reason the way Solidity encodes revert reasons, as if it
+// were a call to a function `Error(string)`.
+func PackRevert(reason string) ([]byte, error) {
+	data, err := revertArgs.Pack(reason)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.CopyBytes(revertSelector), data...), nil
+}
+
+// UnpackRevert resolves the reason of a revert encoded by PackRevert or by a
+// Solidity revert or require statement.
+func UnpackRevert(data []byte) (string, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], revertSelector) {
+		return "", errors.New("abi: invalid revert reason encoding")
+	}
+	values, err := revertArgs.UnpackValues(data[4:])
+	if err != nil {
+		return "", err
+	}
+	return values[0].(string), nil
+}
@@ -745,3 +745,28 @@ func TestABI_MethodById(t *testing.T) {
 		t.Errorf("Expected error, nil is short to decode data")
 	}
 }
+
+func TestUnpackRevert(t *testing.T) {
+	// Revert reason emitted by `revert("revert reason")` in Solidity
+	data := common.FromHex("0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d72657665727420726561736f6e00000000000000000000000000000000000000")
+
+	reason, err := UnpackRevert(data)
+	if err != nil {
+		t.Fatalf("failed to unpack revert reason: %v", err)
+	}
+	if reason != "revert reason" {
+		t.Errorf("revert reason mismatch: have %q, want %q", reason, "revert reason")
+	}
+	packed, err := PackRevert("revert reason")
+	if err != nil {
+		t.Fatalf("failed to pack revert reason: %v", err)
+	}
+	if !bytes.Equal(packed, data) {
+		t.Errorf("packed revert reason mismatch: have %x, want %x", packed, data)
+	}
+	for _, data := range [][]byte{nil, {0x08, 0xc3, 0x79}, data[:4], common.FromHex("0xdeadbeef")} {
+		if _, err := UnpackRevert(data); err == nil {
+			t.Errorf("expected error unpacking %x", data)
+		}
+	}
+}
@@ -19,17 +19,18 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"runtime"
 	"strconv"
 	"sync/atomic"
 	"time"
 
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/tangerine-network/go-tangerine/cmd/utils"
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/console"
 	"github.com/tangerine-network/go-tangerine/core"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/core/types"
 	"github.com/tangerine-network/go-tangerine/eth/downloader"
@@ -69,6 +70,7 @@ It expects the genesis file as argument.`,
 			utils.GCModeFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.ImportWorkersFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -76,7 +78,12 @@ The import command imports blocks from an RLP-encoded form. The form can be one
 with several RLP-encoded blocks, or several files can be used.
 
 If only one file is used, import error will result in failure. If several files are used,
-processing will proceed even if an individual RLP-file import failure occurs.`,
+processing will proceed even if an individual RLP-file import failure occurs.
+
+An argument may also be the directory of a segmented export. Its segments are checked
+against the checksums of the index and decoded, with the senders of their transactions
+recovered, in parallel ahead of the in-order insertion. Segments already imported are
+skipped, so an interrupted import can simply be restarted.`,
 	}
 	exportCommand = cli.Command{
 		Action:    utils.MigrateFlags(exportChain),
@@ -87,6 +94,9 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 			utils.DataDirFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
+			utils.ExportRoundsFlag,
+			utils.ExportCompressionFlag,
+			utils.ExportResumeFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -94,7 +104,13 @@ Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
-be gzipped.`,
+be gzipped.
+
+With --export.rounds, the first argument is a directory instead, and the
+blocks are written into segment files of that many rounds each, compressed
+as selected by --export.compression, along with an index and a SHA256SUMS
+checksum manifest. With --export.resume, the intact segments of a previous
+export into the same directory are kept.`,
 	}
 	importPreimagesCommand = cli.Command{
 		Action:    utils.MigrateFlags(importPreimages),
@@ -167,6 +183,42 @@ Remove blockchain and state databases`,
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.`,
 	}
+	verifyFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to verify",
+	}
+	verifyToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to verify (default = head block)",
+		Value: math.MaxUint64,
+	}
+	verifyRollbackFlag = cli.BoolFlag{
+		Name:  "rollback",
+		Usage: "Roll the head back to the last consistent block if issues are found",
+	}
+	verifyChainCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyChain),
+		Name:      "verify-chain",
+		Usage:     "Verify the integrity of the chain database",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			verifyFromFlag,
+			verifyToFlag,
+			verifyRollbackFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The verify-chain command rechecks the canonical blocks between --from and --to
+in place: the header linkage, the transaction and receipt roots, the presence of
+the head and round boundary states, the gov state proofs of round boundaries and
+the round index against the governance state. It prints a JSON report of all
+issues found.
+
+With --rollback, the head is rewound to the last consistent block so that the
+node resyncs the rest. The node must not be running while verifying.`,
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -229,13 +281,20 @@ func importChain(ctx *cli.Context) error {
 	// Import the chain
 	start := time.Now()
 
+	importFile := func(fn string) error {
+		if core.IsChainArchive(fn) {
+			config := core.ChainImportConfig{Workers: ctx.GlobalInt(utils.ImportWorkersFlag.Name)}
+			return utils.ImportChainArchive(chain, fn, config)
+		}
+		return utils.ImportChain(chain, fn)
+	}
 	if len(ctx.Args()) == 1 {
-		if err := utils.ImportChain(chain, ctx.Args().First()); err != nil {
+		if err := importFile(ctx.Args().First()); err != nil {
 			log.Error("Import error", "err", err)
 		}
 	} else {
 		for _, arg := range ctx.Args() {
-			if err := utils.ImportChain(chain, arg); err != nil {
+			if err := importFile(arg); err != nil {
 				log.Error("Import error", "file", arg, "err", err)
 			}
 		}
@@ -244,19 +303,8 @@ func importChain(ctx *cli.Context) error {
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
 	// Output pre-compaction stats mostly to see the import trashing
-	db := chainDb.(*ethdb.LDBDatabase)
-
-	stats, err := db.LDB().GetProperty("leveldb.stats")
-	if err != nil {
-		utils.Fatalf("Failed to read database stats: %v", err)
-	}
-	fmt.Println(stats)
-
-	ioStats, err := db.LDB().GetProperty("leveldb.iostats")
-	if err != nil {
-		utils.Fatalf("Failed to read database iostats: %v", err)
-	}
-	fmt.Println(ioStats)
+	db := rawdb.KeyValueStore(chainDb).(ethdb.KeyValueStore)
+	printDatabaseStats(db)
 
 	fmt.Printf("Trie cache misses:  %d\n", trie.CacheMisses())
 	fmt.Printf("Trie cache unloads: %d\n\n", trie.CacheUnloads())
@@ -277,24 +325,33 @@ func importChain(ctx *cli.Context) error {
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.LDB().CompactRange(util.Range{}); err != nil {
+	if err := db.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.LDB().GetProperty("leveldb.stats")
+	printDatabaseStats(db)
+	return nil
+}
+
+// printDatabaseStats prints the internal statistics of a leveldb database.
+// Other engines don't expose any.
+func printDatabaseStats(db ethdb.KeyValueStore) {
+	ldb, ok := db.(*ethdb.LDBDatabase)
+	if !ok {
+		return
+	}
+	stats, err := ldb.LDB().GetProperty("leveldb.stats")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
 	fmt.Println(stats)
 
-	ioStats, err = db.LDB().GetProperty("leveldb.iostats")
+	ioStats, err := ldb.LDB().GetProperty("leveldb.iostats")
 	if err != nil {
 		utils.Fatalf("Failed to read database iostats: %v", err)
 	}
 	fmt.Println(ioStats)
-
-	return nil
 }
 
 func exportChain(ctx *cli.Context) error {
@@ -307,7 +364,23 @@ func exportChain(ctx *cli.Context) error {
 
 	var err error
 	fp := ctx.Args().First()
-	if len(ctx.Args()) < 3 {
+	if ctx.GlobalIsSet(utils.ExportRoundsFlag.Name) {
+		config := core.ChainExportConfig{
+			Last:             chain.CurrentBlock().NumberU64(),
+			RoundsPerSegment: ctx.GlobalUint64(utils.ExportRoundsFlag.Name),
+			Compression:      ctx.GlobalString(utils.ExportCompressionFlag.Name),
+			Resume:           ctx.GlobalBool(utils.ExportResumeFlag.Name),
+		}
+		if len(ctx.Args()) >= 3 {
+			first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+			last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+			if ferr != nil || lerr != nil {
+				utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+			}
+			config.First, config.Last = first, last
+		}
+		_, err = core.ExportChainArchive(chain, fp, config)
+	} else if len(ctx.Args()) < 3 {
 		err = utils.ExportChain(chain, fp)
 	} else {
 		// This can be improved to allow for numbers larger than 9223372036854775807
@@ -335,7 +408,7 @@ func importPreimages(ctx *cli.Context) error {
 		utils.Fatalf("This command requires an argument.")
 	}
 	stack := makeFullNode(ctx)
-	diskdb := utils.MakeChainDatabase(ctx, stack).(*ethdb.LDBDatabase)
+	diskdb := rawdb.KeyValueStore(utils.MakeChainDatabase(ctx, stack)).(ethdb.KeyValueStore)
 
 	start := time.Now()
 	if err := utils.ImportPreimages(diskdb, ctx.Args().First()); err != nil {
@@ -351,7 +424,7 @@ func exportPreimages(ctx *cli.Context) error {
 		utils.Fatalf("This command requires an argument.")
 	}
 	stack := makeFullNode(ctx)
-	diskdb := utils.MakeChainDatabase(ctx, stack).(*ethdb.LDBDatabase)
+	diskdb := rawdb.KeyValueStore(utils.MakeChainDatabase(ctx, stack)).(ethdb.KeyValueStore)
 
 	start := time.Now()
 	if err := utils.ExportPreimages(diskdb, ctx.Args().First()); err != nil {
@@ -401,7 +474,7 @@ func copyDb(ctx *cli.Context) error {
 	// Compact the entire database to remove any sync overhead
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = chainDb.(*ethdb.LDBDatabase).LDB().CompactRange(util.Range{}); err != nil {
+	if err = rawdb.KeyValueStore(chainDb).(ethdb.Compacter).Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
@@ -464,6 +537,35 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+// verifyChain checks the chain database and optionally rolls back the head.
+func verifyChain(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	report, err := core.VerifyChain(chainDb, ctx.Uint64(verifyFromFlag.Name), ctx.Uint64(verifyToFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to verify chain: %v", err)
+	}
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode report: %v", err)
+	}
+	fmt.Println(string(out))
+
+	if len(report.Issues) == 0 || !ctx.Bool(verifyRollbackFlag.Name) {
+		return nil
+	}
+	if report.LastConsistent == nil {
+		utils.Fatalf("No consistent block with state in the verified range to roll back to")
+	}
+	if err := core.RollbackChain(chainDb, *report.LastConsistent); err != nil {
+		utils.Fatalf("Failed to roll back chain: %v", err)
+	}
+	log.Info("Rolled back chain head", "number", *report.LastConsistent)
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
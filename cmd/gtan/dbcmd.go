@@ -0,0 +1,192 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of go-tangerine.
+//
+// go-tangerine is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-tangerine is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-tangerine. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/cmd/utils"
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "Low level database operations",
+		Category: "DATABASE COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:   "inspect",
+				Usage:  "Report the space taken by each kind of chain data",
+				Action: utils.MigrateFlags(inspectDB),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+				},
+				Description: `
+    gtan db inspect
+
+walks the entire chain database and prints the number and size of the entries
+of each kind, like headers, bodies, receipts, governance states or trie nodes.
+It also counts dangling entries, such as receipts without a body or governance
+states of non-canonical blocks.`,
+			},
+			{
+				Name:      "convert",
+				Usage:     "Migrate a database to another key-value engine",
+				ArgsUsage: "[<name>]",
+				Action:    utils.MigrateFlags(convertDB),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.DBEngineFlag,
+				},
+				Description: `
+    gtan db convert --db.engine bolt [chaindata]
+
+copies every entry of the named database (chaindata by default) into a fresh
+database of the --db.engine engine and swaps it in place of the original. Any
+other data kept in the database directory, like the ancient store, is carried
+over. The node must not be running while converting.`,
+			},
+		},
+	}
+)
+
+// inspectDB prints the usage statistics of the chain database.
+func inspectDB(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	if err := rawdb.InspectDatabase(chainDb); err != nil {
+		utils.Fatalf("Failed to inspect database: %v", err)
+	}
+	return nil
+}
+
+// convertDB migrates a database to the key-value engine selected by flag.
+func convertDB(ctx *cli.Context) error {
+	if ctx.NArg() > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	name := "chaindata"
+	if ctx.NArg() == 1 {
+		name = ctx.Args().First()
+	}
+	stack, _ := makeConfigNode(ctx)
+
+	dir := stack.ResolvePath(name)
+	if dir == "" {
+		utils.Fatalf("Cannot convert an in-memory database")
+	}
+	source, target := ethdb.DetectEngine(dir), ctx.GlobalString(utils.DBEngineFlag.Name)
+	switch {
+	case source == "":
+		utils.Fatalf("No database found in %s", dir)
+	case source == target:
+		utils.Fatalf("Database %s already uses %s", name, target)
+	case target != ethdb.EngineLevelDB && target != ethdb.EngineBolt:
+		utils.Fatalf("Unknown database engine %q", target)
+	}
+	cache := ctx.GlobalInt(utils.CacheFlag.Name)
+
+	src, err := ethdb.Open(source, dir, cache, 256)
+	if err != nil {
+		utils.Fatalf("Failed to open %s database: %v", source, err)
+	}
+	tmp := dir + ".converting"
+	if err := os.RemoveAll(tmp); err != nil {
+		utils.Fatalf("Failed to remove stale conversion: %v", err)
+	}
+	dst, err := ethdb.Open(target, tmp, cache, 256)
+	if err != nil {
+		utils.Fatalf("Failed to create %s database: %v", target, err)
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		count  int
+		size   common.StorageSize
+		batch  = dst.NewBatch()
+		it     = src.NewIterator()
+	)
+	log.Info("Converting database", "name", name, "from", source, "to", target)
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			utils.Fatalf("Failed to write entry: %v", err)
+		}
+		count++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				utils.Fatalf("Failed to write batch: %v", err)
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Converting database", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		utils.Fatalf("Failed to iterate %s database: %v", source, err)
+	}
+	if err := batch.Write(); err != nil {
+		utils.Fatalf("Failed to write batch: %v", err)
+	}
+	src.Close()
+	dst.Close()
+
+	// Carry over the rest of the directory, e.g. the ancient store, and swap
+	// the converted database in
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		utils.Fatalf("Failed to list database directory: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, entry.Name()), filepath.Join(tmp, entry.Name())); err != nil {
+			utils.Fatalf("Failed to move %s: %v", entry.Name(), err)
+		}
+	}
+	old := dir + ".old"
+	if err := os.Rename(dir, old); err != nil {
+		utils.Fatalf("Failed to move original database: %v", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		utils.Fatalf("Failed to move converted database, original kept in %s: %v", old, err)
+	}
+	if err := os.RemoveAll(old); err != nil {
+		utils.Fatalf("Failed to remove original database: %v", err)
+	}
+	fmt.Printf("Converted %d entries (%v) to %s in %v\n", count, size, target, time.Since(start))
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of go-tangerine.
+//
+// go-tangerine is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-tangerine is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-tangerine. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tangerine-network/go-tangerine/accounts/keystore"
+	"github.com/tangerine-network/go-tangerine/cmd/utils"
+	dexDB "github.com/tangerine-network/go-tangerine/dex/db"
+	"github.com/tangerine-network/go-tangerine/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dkgNewPasswordFileFlag = cli.StringFlag{
+		Name:  "dkg.newpassword",
+		Usage: "Password file to re-encrypt the DKG private keys with (default = derived from the node key)",
+	}
+
+	dkgCommand = cli.Command{
+		Name:     "dkg",
+		Usage:    "Manage the DKG private keys stored by block proposers",
+		Category: "BLOCK PROPOSER COMMANDS",
+		Description: `
+The DKG private keys and protocol state of a block proposer are stored in the
+chain database encrypted with a key protected by the --dkg.password passphrase,
+or one derived from the node key if no passphrase is given.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "rotate-key",
+				Usage:  "Re-encrypt the DKG records with a new key",
+				Action: utils.MigrateFlags(rotateDKGKey),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.DKGPasswordFileFlag,
+					dkgNewPasswordFileFlag,
+				},
+				Description: `
+    gtan dkg rotate-key --dkg.password <old> --dkg.newpassword <new>
+
+re-encrypts all DKG records with a freshly generated key, protected by the
+passphrase in the --dkg.newpassword file. Records still stored in plain are
+encrypted along the way. Omitting either password file selects the key derived
+from the node key.`,
+			},
+		},
+	}
+)
+
+// rotateDKGKey re-encrypts the DKG records of the node with a new key.
+func rotateDKGKey(ctx *cli.Context) error {
+	stack, cfg := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	nodeKey := cfg.Node.NodeKey()
+	auth, scryptN, scryptP := cfg.Dex.DKGPassphrase, keystore.StandardScryptN, keystore.StandardScryptP
+	if auth == "" {
+		auth, scryptN, scryptP = dexDB.NodeKeyAuth(nodeKey), keystore.LightScryptN, keystore.LightScryptP
+	}
+	db, err := dexDB.NewEncryptedDatabase(chainDb, auth, scryptN, scryptP)
+	if err != nil {
+		utils.Fatalf("Failed to unlock DKG records: %v", err)
+	}
+	migrated, err := db.MigrateDKGRecords()
+	if err != nil {
+		utils.Fatalf("Failed to encrypt DKG records: %v", err)
+	}
+	if migrated > 0 {
+		log.Info("Encrypted DKG records at rest", "count", migrated)
+	}
+	auth, scryptN, scryptP = dexDB.NodeKeyAuth(nodeKey), keystore.LightScryptN, keystore.LightScryptP
+	if file := ctx.String(dkgNewPasswordFileFlag.Name); file != "" {
+		auth, scryptN, scryptP = utils.ReadPasswordFile(file), keystore.StandardScryptN, keystore.StandardScryptP
+	}
+	if err := db.RotateDKGKey(auth, scryptN, scryptP); err != nil {
+		utils.Fatalf("Failed to rotate DKG key: %v", err)
+	}
+	fmt.Println("DKG records re-encrypted")
+	return nil
+}
@@ -60,6 +60,9 @@ var (
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.AncientRoundsFlag,
+		utils.DBEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
 		utils.DashboardEnabledFlag,
@@ -76,19 +79,26 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolRemoteJournalFlag,
+		utils.TxPoolRemoteJournalLimitFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolGovSlotsFlag,
+		utils.TxPoolSenderRateFlag,
+		utils.TxPoolSenderBurstFlag,
 		utils.TxPoolLifetimeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.StateRetentionFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
 		utils.WhitelistFlag,
+		utils.CheckpointFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
@@ -98,6 +108,7 @@ var (
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
 		utils.BlockProposerEnabledFlag,
+		utils.DKGPasswordFileFlag,
 		utils.MiningEnabledFlag,
 		utils.MinerThreadsFlag,
 		utils.MinerLegacyThreadsFlag,
@@ -189,6 +200,12 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		verifyChainCommand,
+		// See dkgcmd.go:
+		dkgCommand,
+		pruneStateCommand,
+		dbCommand,
+		snapshotCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
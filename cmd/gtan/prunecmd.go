@@ -0,0 +1,136 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of go-tangerine.
+//
+// go-tangerine is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-tangerine is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-tangerine. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/cmd/utils"
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	pruneStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(pruneState),
+		Name:      "prune-state",
+		Usage:     "Delete all states but the recent and round boundary ones",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.StateRetentionFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The prune-state command deletes every state trie node and contract code from
+the chain database which is not reachable from the state of one of the most
+recent --gcmode.retain blocks or of the first block of a round. Blocks are final
+once delivered, so no other state is needed to run the node.
+
+The node must not be running while pruning.`,
+	}
+)
+
+// pruneState marks the states to retain and sweeps the rest of the database.
+func pruneState(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	diskdb := rawdb.KeyValueStore(chainDb).(ethdb.KeyValueStore)
+	head := rawdb.ReadHeadBlockHash(chainDb)
+	number := rawdb.ReadHeaderNumber(chainDb, head)
+	if number == nil {
+		utils.Fatalf("No head block found in the chain database")
+	}
+	// Marked nodes are tracked in a bloom filter sized by the cache allowance,
+	// backed by an exact set in a scratch database
+	marksDir := stack.ResolvePath("prunemarks")
+	os.RemoveAll(marksDir)
+	marks, err := ethdb.NewLDBDatabase(marksDir, 16, 16)
+	if err != nil {
+		utils.Fatalf("Failed to open pruner scratch database: %v", err)
+	}
+	defer os.RemoveAll(marksDir)
+	defer marks.Close()
+
+	var (
+		start    = time.Now()
+		pruner   = state.NewPruner(diskdb, marks, uint64(ctx.GlobalInt(utils.CacheFlag.Name))*1024*1024)
+		retain   = ctx.GlobalUint64(utils.StateRetentionFlag.Name)
+		retained = make(map[common.Hash]struct{})
+	)
+	mark := func(n uint64, reason string) {
+		header := rawdb.ReadHeader(chainDb, rawdb.ReadCanonicalHash(chainDb, n), n)
+		if header == nil {
+			utils.Fatalf("Canonical header #%d missing", n)
+		}
+		if _, ok := retained[header.Root]; ok {
+			return
+		}
+		retained[header.Root] = struct{}{}
+
+		// States only held in memory by the node are gone, nothing to retain
+		if ok, _ := diskdb.Has(header.Root.Bytes()); !ok {
+			log.Debug("State not persisted, skipping", "number", n, "root", header.Root)
+			return
+		}
+		if err := pruner.Retain(header.Root); err != nil {
+			utils.Fatalf("Failed to mark %s state #%d: %v", reason, n, err)
+		}
+	}
+	// Governance rebuilds round configurations from the round boundary states
+	var round uint64
+	for n := uint64(0); n <= *number; n++ {
+		header := rawdb.ReadHeader(chainDb, rawdb.ReadCanonicalHash(chainDb, n), n)
+		if header == nil {
+			utils.Fatalf("Canonical header #%d missing", n)
+		}
+		if n == 0 || header.Round != round {
+			round = header.Round
+			mark(n, "round boundary")
+		}
+	}
+	for n := *number; n+retain > *number; n-- {
+		mark(n, "recent")
+		if n == 0 {
+			break
+		}
+	}
+	log.Info("Marked retained states", "roots", len(retained), "nodes", pruner.Retained(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	deleted, size, err := pruner.Prune()
+	if err != nil {
+		utils.Fatalf("Failed to prune state: %v", err)
+	}
+	log.Info("Pruned stale states", "nodes", deleted, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Compact the entire database to actually reclaim the disk space
+	fmt.Println("Compacting entire database...")
+	if err := diskdb.Compact(nil, nil); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Pruning done in %v\n", time.Since(start))
+	return nil
+}
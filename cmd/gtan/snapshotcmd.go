@@ -0,0 +1,135 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of go-tangerine.
+//
+// go-tangerine is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-tangerine is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-tangerine. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/cmd/utils"
+	"github.com/tangerine-network/go-tangerine/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Export and import state snapshots for fast bootstrap",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the state at a given height into a snapshot file",
+				ArgsUsage: "<filename> [<number>]",
+				Action:    utils.MigrateFlags(exportSnapshot),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+				},
+				Description: `
+    gtan snapshot export <filename> [<number>]
+
+writes the state of the given block (the current head by default), its
+governance state and the canonical header chain leading to it, along with the
+governance states of all round boundaries, into a gzip compressed snapshot
+file. The state of the block must be available, so on pruning nodes only
+recent blocks and round boundaries can be exported.`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Bootstrap an empty node from a snapshot file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(importSnapshot),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+				},
+				Description: `
+    gtan snapshot import <filename>
+
+loads a snapshot into a node initialized with the same genesis and no further
+blocks. The header chain is checked against the local genesis and verified
+against the governance states of its rounds like during fast sync, and the
+rebuilt state is checked against the snapshot header before the snapshot block
+becomes the head of the chain. The node then continues syncing from that block.`,
+			},
+		},
+	}
+)
+
+// exportSnapshot writes a state snapshot of the local chain into a file.
+func exportSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		utils.Fatalf("This command requires a file name and an optional block number.")
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	number := chain.CurrentBlock().NumberU64()
+	if ctx.NArg() == 2 {
+		n, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid block number: %v", err)
+		}
+		number = n
+	}
+	fn := ctx.Args().First()
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		utils.Fatalf("Failed to create snapshot file: %v", err)
+	}
+	start := time.Now()
+	if err := core.ExportSnapshot(chain, fh, number); err != nil {
+		fh.Close()
+		os.Remove(fn)
+		utils.Fatalf("Snapshot export failed: %v", err)
+	}
+	if err := fh.Close(); err != nil {
+		utils.Fatalf("Failed to write snapshot file: %v", err)
+	}
+	fmt.Printf("Exported snapshot of block #%d in %v\n", number, time.Since(start))
+	return nil
+}
+
+// importSnapshot bootstraps the local chain from a state snapshot.
+func importSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("This command requires a file name.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	fh, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to open snapshot file: %v", err)
+	}
+	defer fh.Close()
+
+	start := time.Now()
+	block, err := core.ImportSnapshot(chainDb, fh)
+	if err != nil {
+		utils.Fatalf("Snapshot import failed: %v", err)
+	}
+	fmt.Printf("Imported snapshot of block #%d [%x…] in %v\n", block.NumberU64(), block.Hash().Bytes()[:4], time.Since(start))
+	return nil
+}
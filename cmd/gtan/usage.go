@@ -69,18 +69,23 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			configFileFlag,
 			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.AncientRoundsFlag,
+			utils.DBEngineFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.StateRetentionFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.CheckpointFlag,
 		},
 	},
 	{
@@ -118,12 +123,17 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
+			utils.TxPoolRemoteJournalFlag,
+			utils.TxPoolRemoteJournalLimitFlag,
 			utils.TxPoolPriceLimitFlag,
 			utils.TxPoolPriceBumpFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolGovSlotsFlag,
+			utils.TxPoolSenderRateFlag,
+			utils.TxPoolSenderBurstFlag,
 			utils.TxPoolLifetimeFlag,
 		},
 	},
@@ -187,6 +197,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "BLOCK PROPOSER",
 		Flags: []cli.Flag{
 			utils.BlockProposerEnabledFlag,
+			utils.DKGPasswordFileFlag,
 		},
 	},
 	{
@@ -85,20 +85,30 @@ func StartNode(stack *node.Node) {
 	}()
 }
 
-func ImportChain(chain *core.BlockChain, fn string) error {
-	// Watch for Ctrl-C while the import is running.
-	// If a signal is received, the import will stop at the next batch.
+// watchInterrupt returns a channel closed when the process receives an
+// interrupt signal, and a function releasing the signal handler.
+func watchInterrupt() (<-chan struct{}, func()) {
 	interrupt := make(chan os.Signal, 1)
 	stop := make(chan struct{})
 	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
-	defer signal.Stop(interrupt)
-	defer close(interrupt)
 	go func() {
 		if _, ok := <-interrupt; ok {
 			log.Info("Interrupted during import, stopping at next batch")
 		}
 		close(stop)
 	}()
+	return stop, func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+	}
+}
+
+func ImportChain(chain *core.BlockChain, fn string) error {
+	// Watch for Ctrl-C while the import is running.
+	// If a signal is received, the import will stop at the next batch.
+	stop, release := watchInterrupt()
+	defer release()
+
 	checkInterrupt := func() bool {
 		select {
 		case <-stop:
@@ -168,6 +178,17 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 	return nil
 }
 
+// ImportChainArchive imports a segmented chain archive. Rerunning an
+// interrupted import skips the segments already imported.
+func ImportChainArchive(chain *core.BlockChain, dir string, config core.ChainImportConfig) error {
+	stop, release := watchInterrupt()
+	defer release()
+
+	n, err := core.ImportChainArchive(chain, dir, config, stop)
+	log.Info("Imported chain archive", "dir", dir, "blocks", n)
+	return err
+}
+
 func missingBlocks(chain *core.BlockChain, blocks []*types.Block) []*types.Block {
 	head := chain.CurrentBlock()
 	for i, block := range blocks {
@@ -238,7 +259,7 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 }
 
 // ImportPreimages imports a batch of exported hash preimages into the database.
-func ImportPreimages(db *ethdb.LDBDatabase, fn string) error {
+func ImportPreimages(db ethdb.Database, fn string) error {
 	log.Info("Importing preimages", "file", fn)
 
 	// Open the file handle and potentially unwrap the gzip stream
@@ -285,7 +306,7 @@ func ImportPreimages(db *ethdb.LDBDatabase, fn string) error {
 
 // ExportPreimages exports all known hash preimages into the specified file,
 // truncating any data already present in the file.
-func ExportPreimages(db *ethdb.LDBDatabase, fn string) error {
+func ExportPreimages(db ethdb.KeyValueStore, fn string) error {
 	log.Info("Exporting preimages", "file", fn)
 
 	// Open the file handle and potentially wrap with a gzip stream
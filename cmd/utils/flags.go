@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -36,6 +37,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/consensus/clique"
 	"github.com/tangerine-network/go-tangerine/consensus/ethash"
 	"github.com/tangerine-network/go-tangerine/core"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/crypto"
@@ -120,6 +122,19 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
+	}
+	AncientRoundsFlag = cli.Uint64Flag{
+		Name:  "ancient.rounds",
+		Usage: "Number of recent rounds to keep before moving blocks into the ancient store (0 = disabled)",
+	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: `Key-value engine of newly created databases ("leveldb", "bolt")`,
+		Value: ethdb.EngineLevelDB,
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -161,14 +176,19 @@ var (
 	defaultSyncMode = dex.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "snap", "full", or "light")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
-		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Usage: `Blockchain garbage collection mode ("full", "archive", "final")`,
 		Value: "full",
 	}
+	StateRetentionFlag = cli.Uint64Flag{
+		Name:  "gcmode.retain",
+		Usage: "Number of recent states to retain besides round boundaries in final garbage collection mode",
+		Value: 128,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -187,6 +207,10 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "JSON file of a signed governance checkpoint to start header verification from",
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  metrics.DashboardEnabledFlag,
@@ -256,6 +280,16 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for remote pending transactions to survive node restarts (empty = disabled)",
+		Value: core.DefaultTxPoolConfig.RemoteJournal,
+	}
+	TxPoolRemoteJournalLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.remotejournallimit",
+		Usage: "Maximum number of remote transactions to keep in the journal",
+		Value: core.DefaultTxPoolConfig.RemoteJournalLimit,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -286,6 +320,21 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: eth.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolGovSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.govslots",
+		Usage: "Number of transaction slots reserved for governance transactions of the node",
+		Value: eth.DefaultConfig.TxPool.GovSlots,
+	}
+	TxPoolSenderRateFlag = cli.Float64Flag{
+		Name:  "txpool.senderrate",
+		Usage: "Remote transactions admitted per second for each sender (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.SenderRate,
+	}
+	TxPoolSenderBurstFlag = cli.Uint64Flag{
+		Name:  "txpool.senderburst",
+		Usage: "Maximum number of remote transactions admitted at once for each sender",
+		Value: eth.DefaultConfig.TxPool.SenderBurst,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
@@ -322,6 +371,10 @@ var (
 		Name:  "bp",
 		Usage: "Enable block proposer mode (node set)",
 	}
+	DKGPasswordFileFlag = cli.StringFlag{
+		Name:  "dkg.password",
+		Usage: "Password file to encrypt the DKG private keys at rest (default = derived from the node key)",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -426,6 +479,23 @@ var (
 		Name:  "nocompaction",
 		Usage: "Disables db compaction after import",
 	}
+	ExportRoundsFlag = cli.Uint64Flag{
+		Name:  "export.rounds",
+		Usage: "Export into a directory of segments holding this many rounds each",
+	}
+	ExportCompressionFlag = cli.StringFlag{
+		Name:  "export.compression",
+		Usage: `Compression of exported segments ("none", "gzip", "zstd")`,
+		Value: core.CompressionZstd,
+	}
+	ExportResumeFlag = cli.BoolFlag{
+		Name:  "export.resume",
+		Usage: "Keep the intact segments of an interrupted export and continue after them",
+	}
+	ImportWorkersFlag = cli.IntFlag{
+		Name:  "import.workers",
+		Usage: "Number of segments decoded and checked in parallel during import (0 = number of CPUs)",
+	}
 	// RPC settings
 	RPCEnabledFlag = cli.BoolFlag{
 		Name:  "rpc",
@@ -929,6 +999,15 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
+// ReadPasswordFile returns the first line of the given password file.
+func ReadPasswordFile(path string) string {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		Fatalf("Failed to read password file: %v", err)
+	}
+	return strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
+}
+
 func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setNodeKey(ctx, cfg)
 	setNAT(ctx, cfg)
@@ -1005,6 +1084,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setNodeUserIdent(ctx, cfg)
 	setDataDir(ctx, cfg)
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		switch engine := ctx.GlobalString(DBEngineFlag.Name); engine {
+		case ethdb.EngineLevelDB, ethdb.EngineBolt:
+			cfg.DBEngine = engine
+		default:
+			Fatalf("--%s must be either '%s' or '%s'", DBEngineFlag.Name, ethdb.EngineLevelDB, ethdb.EngineBolt)
+		}
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -1056,6 +1143,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalLimitFlag.Name) {
+		cfg.RemoteJournalLimit = ctx.GlobalUint64(TxPoolRemoteJournalLimitFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1074,6 +1167,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolGovSlotsFlag.Name) {
+		cfg.GovSlots = ctx.GlobalUint64(TxPoolGovSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSenderRateFlag.Name) {
+		cfg.SenderRate = ctx.GlobalFloat64(TxPoolSenderRateFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSenderBurstFlag.Name) {
+		cfg.SenderBurst = ctx.GlobalUint64(TxPoolSenderBurstFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
@@ -1123,6 +1225,23 @@ func setWhitelist(ctx *cli.Context, cfg *dex.Config) {
 	}
 }
 
+// setCheckpoint loads the trusted governance checkpoint from the file given on
+// the command line, if any.
+func setCheckpoint(ctx *cli.Context, cfg *dex.Config) {
+	file := ctx.GlobalString(CheckpointFlag.Name)
+	if file == "" {
+		return
+	}
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		Fatalf("Failed to read checkpoint file: %v", err)
+	}
+	cfg.Checkpoint = new(params.GovernanceCheckpoint)
+	if err := json.Unmarshal(blob, cfg.Checkpoint); err != nil {
+		Fatalf("Invalid checkpoint file %s: %v", file, err)
+	}
+}
+
 // checkExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1187,6 +1306,7 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
 	setWhitelist(ctx, cfg)
+	setCheckpoint(ctx, cfg)
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
@@ -1203,16 +1323,27 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 	if ctx.GlobalIsSet(BlockProposerEnabledFlag.Name) {
 		cfg.BlockProposerEnabled = ctx.GlobalBool(BlockProposerEnabledFlag.Name)
 	}
+	if file := ctx.GlobalString(DKGPasswordFileFlag.Name); file != "" {
+		cfg.DKGPassphrase = ReadPasswordFile(file)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheDatabaseFlag.Name) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRoundsFlag.Name) {
+		cfg.AncientRounds = ctx.GlobalUint64(AncientRoundsFlag.Name)
+	}
 
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "final" {
+		Fatalf("--%s must be either 'full', 'archive' or 'final'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.FinalityPruning = ctx.GlobalString(GCModeFlag.Name) == "final"
+	cfg.StateRetention = ctx.GlobalUint64(StateRetentionFlag.Name)
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
@@ -1313,17 +1444,17 @@ func SetDashboardConfig(ctx *cli.Context, cfg *dashboard.Config) {
 func RegisterDexService(stack *node.Node, cfg *dex.Config) {
 	var err error
 	if cfg.SyncMode == downloader.LightSync {
-		//err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-		//	return les.New(ctx, cfg)
-		//})
+		err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return les.New(ctx, cfg)
+		})
 	} else {
 		err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			cfg.PrivateKey = ctx.ServerConfig.PrivateKey
 			fullNode, err := dex.New(ctx, cfg)
-			//if fullNode != nil && cfg.LightServ > 0 {
-			//	ls, _ := les.NewLesServer(fullNode, cfg)
-			//	fullNode.AddLesServer(ls)
-			//}
+			if fullNode != nil && cfg.LightServ > 0 {
+				ls, _ := les.NewLesServer(fullNode, cfg)
+				fullNode.AddLesServer(ls)
+			}
 			return fullNode, err
 		})
 	}
@@ -1417,6 +1548,17 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
+	if name == "chaindata" {
+		freezer := ctx.GlobalString(AncientFlag.Name)
+		if freezer == "" {
+			freezer = "chaindata/ancient"
+		}
+		if freezer = stack.ResolvePath(freezer); freezer != "" {
+			if chainDb, err = rawdb.NewDatabaseWithFreezer(chainDb, freezer, 0); err != nil {
+				Fatalf("Could not open ancient database: %v", err)
+			}
+		}
+	}
 	return chainDb
 }
 
@@ -1455,14 +1597,16 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 			}, nil, false)
 		}
 	}
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "final" {
+		Fatalf("--%s must be either 'full', 'archive' or 'final'", GCModeFlag.Name)
 	}
 	cache := &core.CacheConfig{
-		Disabled:       ctx.GlobalString(GCModeFlag.Name) == "archive",
-		TrieCleanLimit: eth.DefaultConfig.TrieCleanCache,
-		TrieDirtyLimit: eth.DefaultConfig.TrieDirtyCache,
-		TrieTimeLimit:  eth.DefaultConfig.TrieTimeout,
+		Disabled:        ctx.GlobalString(GCModeFlag.Name) == "archive",
+		TrieCleanLimit:  eth.DefaultConfig.TrieCleanCache,
+		TrieDirtyLimit:  eth.DefaultConfig.TrieDirtyCache,
+		TrieTimeLimit:   eth.DefaultConfig.TrieTimeout,
+		FinalityPruning: ctx.GlobalString(GCModeFlag.Name) == "final",
+		StateRetention:  ctx.GlobalUint64(StateRetentionFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cache.TrieCleanLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
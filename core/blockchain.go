@@ -24,11 +24,13 @@ import (
 	"io"
 	"math/big"
 	mrand "math/rand"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
+	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
 	dexCore "github.com/tangerine-network/tangerine-consensus/core"
 	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
 
@@ -71,7 +73,13 @@ const (
 	triesInMemory       = 128
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
-	BlockChainVersion uint64 = 3
+	//
+	// During the process of upgrading the database version from 3 to 4,
+	// the following database changes were added.
+	// * the `RevertReason` field is appended to the storage encoding of receipts.
+	//   Receipts written by v3 are still decoded, with an empty revert reason,
+	//   so v3 databases are upgraded in place.
+	BlockChainVersion uint64 = 4
 )
 
 // CacheConfig contains the configuration values for the trie caching/pruning
@@ -81,6 +89,9 @@ type CacheConfig struct {
 	TrieCleanLimit int           // Memory allowance (MB) to use for caching trie nodes in memory
 	TrieDirtyLimit int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieTimeLimit  time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	FinalityPruning bool   // Whether to only retain recent and round boundary states, relying on deterministic finality
+	StateRetention  uint64 // Number of recent states to retain in finality pruning mode
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -217,49 +228,25 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	bc.gov = NewGovernance(NewGovernanceStateDB(bc))
 	bc.verifierCache = dexCore.NewTSigVerifierCache(bc.gov, 5)
 
-	// Init round height map
-	curblock := bc.CurrentBlock()
-	r := curblock.Round()
-
-	// Blocks will interleave during round change, so we need to init current
-	// and previous round.
-	if r == 0 {
-		// No previous round.
-		log.Debug("Init round height", "height", curblock.NumberU64(), "round", curblock.Round())
-		bc.storeRoundHeight(uint64(0), uint64(0))
-	} else {
-		prevh := bc.gov.GetRoundHeight(r - 1)
-		if prevh == uint64(0) && (r-1) != uint64(0) {
-			// Previous round height should be already snapshoted
-			// in governance state at this moment.
-			panic("can not init previous round height map")
-		}
-		log.Debug("Init previous round height", "height", prevh, "round", r-1)
-		bc.storeRoundHeight(r-1, prevh)
-
-		curh := bc.gov.GetRoundHeight(r)
-
-		// Current round height is not snapshoted in governance state yet,
-		if curh == uint64(0) {
-			// Linear search the first block of current round
-			// from previous round height.
-			h := prevh
-			for h <= curblock.NumberU64() {
-				b := bc.GetBlockByNumber(h)
-				if b.Round() == r {
-					curh = h
-					break
-				}
-			}
-
-			// This case is impossible.
-			if curh == uint64(0) {
-				panic("can find current round height")
+	// Index the rounds of blocks written before the round index existed
+	complete := bc.indexRounds()
+
+	// Init round height map. Blocks will interleave during round change, so
+	// we need to init current and previous round.
+	r := bc.CurrentBlock().Round()
+	rounds := []uint64{r}
+	if r > 0 {
+		rounds = append(rounds, r-1)
+	}
+	for _, round := range rounds {
+		h, ok := rawdb.ReadRoundHeight(bc.db, round)
+		if !ok || !complete {
+			if h, ok = bc.findRoundHeight(round); !ok {
+				return nil, fmt.Errorf("round %d not found in canonical chain", round)
 			}
 		}
-
-		log.Debug("Init current round height", "height", curh, "round", r)
-		bc.storeRoundHeight(r, curh)
+		log.Debug("Init round height", "height", h, "round", round)
+		bc.storeRoundHeight(round, h)
 	}
 
 	// Take ownership of this particular state
@@ -492,6 +479,7 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 
 	bc.genesisBlock = genesis
 	bc.insert(bc.genesisBlock)
+	bc.writeRoundIndex(bc.db, bc.genesisBlock)
 	bc.currentBlock.Store(bc.genesisBlock)
 	bc.hc.SetGenesis(bc.genesisBlock.Header())
 	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
@@ -768,12 +756,18 @@ func (bc *BlockChain) Stop() {
 	if !bc.cacheConfig.Disabled {
 		triedb := bc.stateCache.TrieDB()
 
-		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+		offsets := []uint64{0, 1, triesInMemory - 1}
+		if bc.cacheConfig.FinalityPruning {
+			// Delivered blocks are never reorged, only the head state is needed
+			offsets = []uint64{0}
+		}
+		for _, offset := range offsets {
 			if number := bc.CurrentBlock().NumberU64(); number > offset {
-				recent := bc.GetBlockByNumber(number - offset)
+				// Only headers are available below an imported snapshot
+				recent := bc.GetHeaderByNumber(number - offset)
 
-				log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
-				if err := triedb.Commit(recent.Root(), true); err != nil {
+				log.Info("Writing cached state to disk", "block", recent.Number, "hash", recent.Hash(), "root", recent.Root)
+				if err := triedb.Commit(recent.Root, true); err != nil {
 					log.Error("Failed to commit recent state trie", "err", err)
 				}
 			}
@@ -983,6 +977,54 @@ func (bc *BlockChain) WriteBlockWithoutState(block *types.Block, td *big.Int) (e
 	return nil
 }
 
+// pruneFinalState keeps the state of a delivered block in memory until it falls
+// out of the retention window and is dropped. Round boundary states are always
+// committed to disk before reaching here, as governance needs them to rebuild
+// configurations. Since delivered blocks are final, no older state needs to be
+// flushed; only the newest one is, once the time allowance is exceeded, so that
+// a crash doesn't rewind the chain to the last round boundary. Nodes spilled to
+// disk under memory pressure or by those flushes are left for an offline prune
+// to reclaim.
+func (bc *BlockChain) pruneFinalState(root common.Hash, number uint64) error {
+	triedb := bc.stateCache.TrieDB()
+	triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
+	bc.triegc.Push(root, -int64(number))
+
+	var (
+		nodes, imgs = triedb.Size()
+		limit       = common.StorageSize(bc.cacheConfig.TrieDirtyLimit) * 1024 * 1024
+	)
+	if nodes > limit || imgs > 4*1024*1024 {
+		triedb.Cap(limit - ethdb.IdealBatchSize)
+	}
+	// If we exceeded our time allowance, flush the newest state to disk
+	if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
+		log.Debug("Flushing retained state to disk", "number", number, "root", root, "time", bc.gcproc)
+		if err := triedb.Commit(root, true); err != nil {
+			return err
+		}
+		lastWrite = number
+		bc.gcproc = 0
+	}
+	retention := bc.cacheConfig.StateRetention
+	if retention == 0 {
+		retention = triesInMemory
+	}
+	if number <= retention {
+		return nil
+	}
+	chosen := number - retention
+	for !bc.triegc.Empty() {
+		root, number := bc.triegc.Pop()
+		if uint64(-number) > chosen {
+			bc.triegc.Push(root, number)
+			break
+		}
+		triedb.Dereference(root.(common.Hash))
+	}
+	return nil
+}
+
 // WriteBlockWithState writes the block and all associated state to the database.
 func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.Receipt, statedb *state.StateDB) (status WriteStatus, err error) {
 	bc.wg.Add(1)
@@ -1050,6 +1092,10 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		if err := triedb.Commit(root, false); err != nil {
 			return NonStatTy, err
 		}
+	} else if bc.cacheConfig.FinalityPruning {
+		if err := bc.pruneFinalState(root, block.NumberU64()); err != nil {
+			return NonStatTy, err
+		}
 	} else {
 		// Full but not archive node, do proper garbage collection
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
@@ -1130,6 +1176,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		// Write the positional metadata for transaction/receipt lookups and preimages
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, statedb.Preimages())
+		bc.writeRoundIndex(batch, block)
 
 		status = CanonStatTy
 	} else {
@@ -2060,6 +2107,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}
 		// Write lookup entries for hash based transaction/receipt searches
 		rawdb.WriteTxLookupEntries(bc.db, newChain[i])
+		bc.writeRoundIndex(bc.db, newChain[i])
 		addedTxs = append(addedTxs, newChain[i].Transactions()...)
 	}
 	// When transactions get deleted from the database, the receipts that were
@@ -2200,10 +2248,26 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int) (i
 	return bc.hc.InsertHeaderChain(chain, whFunc, start)
 }
 
+// InsertTangerineHeaderChain verifies and inserts a batch of headers into the
+// local chain. A nil governance skips the content verification of headers that
+// are secured by a trusted checkpoint, only their linkage and witnesses are
+// checked.
 func (bc *BlockChain) InsertTangerineHeaderChain(chain []*types.HeaderWithGovState,
 	gov dexcon.GovernanceStateFetcher, verifierCache *dexCore.TSigVerifierCache) (int, error) {
+	return bc.insertTangerineHeaderChain(chain, gov, verifierCache, false)
+}
+
+// insertSnapshotHeaderChain inserts a batch of headers of an imported snapshot,
+// rejecting any header whose threshold signature doesn't verify.
+func (bc *BlockChain) insertSnapshotHeaderChain(chain []*types.HeaderWithGovState,
+	gov dexcon.GovernanceStateFetcher, verifierCache *dexCore.TSigVerifierCache) (int, error) {
+	return bc.insertTangerineHeaderChain(chain, gov, verifierCache, true)
+}
+
+func (bc *BlockChain) insertTangerineHeaderChain(chain []*types.HeaderWithGovState,
+	gov dexcon.GovernanceStateFetcher, verifierCache *dexCore.TSigVerifierCache, strict bool) (int, error) {
 	start := time.Now()
-	if i, err := bc.hc.ValidateTangerineHeaderChain(chain, gov, verifierCache, bc.Validator()); err != nil {
+	if i, err := bc.hc.validateTangerineHeaderChain(chain, gov, verifierCache, bc.Validator(), strict); err != nil {
 		return i, err
 	}
 
@@ -2339,15 +2403,144 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
 }
 
+// Denylist returns the addresses denied in the given round.
+func (bc *BlockChain) Denylist(round uint64) (map[common.Address]struct{}, error) {
+	return bc.gov.Denylist(round)
+}
+
 // GetRoundHeight returns the height of a given round.
 func (bc *BlockChain) GetRoundHeight(round uint64) (uint64, bool) {
 	h, ok := bc.roundHeightMap.Load(round)
 	if !ok {
-		return 0, false
+		height, ok := rawdb.ReadRoundHeight(bc.db, round)
+		if !ok {
+			return 0, false
+		}
+		bc.storeRoundHeight(round, height)
+		return height, true
 	}
 	return h.(uint64), true
 }
 
+// GetRoundLastHeight returns the height of the last canonical block of a
+// given round.
+func (bc *BlockChain) GetRoundLastHeight(round uint64) (uint64, bool) {
+	return rawdb.ReadRoundLastHeight(bc.db, round)
+}
+
+// GetRoundCRS returns the common reference string of a given round.
+func (bc *BlockChain) GetRoundCRS(round uint64) (common.Hash, bool) {
+	return rawdb.ReadRoundCRS(bc.db, round)
+}
+
+// writeRoundIndex adds a new canonical block to the round index, writing into
+// the batch of the block itself. The CRS is looked up once, when the first
+// block of a round is indexed. The index head is only advanced while the index
+// is contiguous, a gap (e.g. fast synced blocks) is left to the backfill.
+func (bc *BlockChain) writeRoundIndex(db ethdb.Putter, block *types.Block) {
+	round, number := block.Round(), block.NumberU64()
+	if first, ok := rawdb.ReadRoundHeight(bc.db, round); !ok || number < first {
+		rawdb.WriteRoundHeight(db, round, number)
+
+		// The genesis is inserted before governance is up, the backfill covers it
+		if bc.gov != nil {
+			if crs := bc.gov.CRS(round); crs != (coreCommon.Hash{}) {
+				rawdb.WriteRoundCRS(db, round, common.Hash(crs))
+			}
+		}
+	}
+	if last, ok := rawdb.ReadRoundLastHeight(bc.db, round); !ok || number > last {
+		rawdb.WriteRoundLastHeight(db, round, number)
+	}
+	if head, ok := rawdb.ReadRoundIndexHead(bc.db); (!ok && number == 0) || (ok && number <= head+1) {
+		rawdb.WriteRoundIndexHead(db, number)
+	}
+}
+
+// indexRounds backfills the round index with the canonical blocks written
+// before it existed or skipped by it. The index is written in chunks, each
+// advancing the index head only once its CRSs are in, so an interrupted
+// backfill resumes from the last complete chunk. It returns false if the
+// index could not be completed up to the head.
+func (bc *BlockChain) indexRounds() bool {
+	var (
+		head = bc.CurrentBlock().NumberU64()
+		from uint64
+	)
+	if number, ok := rawdb.ReadRoundIndexHead(bc.db); ok {
+		if number >= head {
+			return true
+		}
+		from = number + 1
+	}
+	var (
+		start   = time.Now()
+		indexed int
+	)
+	log.Info("Indexing rounds", "from", from, "to", head)
+	for from <= head {
+		var (
+			batch  = bc.db.NewBatch()
+			firsts = make(map[uint64]uint64)
+			lasts  = make(map[uint64]uint64)
+			number = from
+		)
+		for ; number <= head && batch.ValueSize() < ethdb.IdealBatchSize; number++ {
+			header := bc.GetHeaderByNumber(number)
+			if header == nil {
+				log.Error("Canonical header missing, round index incomplete", "number", number)
+				return false
+			}
+			round := header.Round
+			if _, ok := firsts[round]; !ok {
+				if first, ok := rawdb.ReadRoundHeight(bc.db, round); !ok || number < first {
+					rawdb.WriteRoundHeight(batch, round, number)
+				}
+				firsts[round] = number
+			}
+			if last, ok := rawdb.ReadRoundLastHeight(bc.db, round); !ok || number > last {
+				rawdb.WriteRoundLastHeight(batch, round, number)
+			}
+			lasts[round] = number
+		}
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write round index", "err", err)
+		}
+		// CRSs are read from the governance states, which need the heights above
+		batch.Reset()
+		for round := range firsts {
+			if _, ok := rawdb.ReadRoundCRS(bc.db, round); ok {
+				continue
+			}
+			if crs := bc.gov.CRS(round); crs != (coreCommon.Hash{}) {
+				rawdb.WriteRoundCRS(batch, round, common.Hash(crs))
+			}
+		}
+		rawdb.WriteRoundIndexHead(batch, number-1)
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write round index", "err", err)
+		}
+		indexed += len(lasts)
+		from = number
+	}
+	log.Info("Indexed rounds", "rounds", indexed, "elapsed", common.PrettyDuration(time.Since(start)))
+	return true
+}
+
+// findRoundHeight searches the canonical chain for the first block of a round,
+// for when the round index is incomplete.
+func (bc *BlockChain) findRoundHeight(round uint64) (uint64, bool) {
+	head := bc.CurrentBlock().NumberU64()
+	number := uint64(sort.Search(int(head)+1, func(i int) bool {
+		header := bc.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Round >= round
+	}))
+	if header := bc.GetHeaderByNumber(number); header == nil || header.Round != round {
+		return 0, false
+	}
+	return number, true
+}
+
 func (bc *BlockChain) storeRoundHeight(round uint64, height uint64) {
 	bc.roundHeightMap.Store(round, height)
 }
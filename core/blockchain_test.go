@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
 	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
 
 	"github.com/tangerine-network/go-tangerine/common"
@@ -1965,3 +1966,165 @@ func TestLowDiffLongChain(t *testing.T) {
 		header = chain.GetHeader(header.ParentHash, number-1)
 	}
 }
+
+// Tests that the round index is written along with the canonical blocks, and
+// that an interrupted backfill is resumed on the next start.
+func TestRoundIndex(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	// Blocks 1-2 are in round 0, then three blocks per round up to round 3
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 9, func(i int, b *BlockGen) {
+		b.header.Round = uint64(i+1) / 3
+	})
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	check := func(chain *BlockChain) {
+		t.Helper()
+		for round := uint64(0); round <= 3; round++ {
+			wantFirst, wantLast := round*3, round*3+2
+			if round == 3 {
+				wantLast = 9
+			}
+			if first, ok := rawdb.ReadRoundHeight(db, round); !ok || first != wantFirst {
+				t.Errorf("round %d: first height mismatch: have %d (%v), want %d", round, first, ok, wantFirst)
+			}
+			if last, ok := chain.GetRoundLastHeight(round); !ok || last != wantLast {
+				t.Errorf("round %d: last height mismatch: have %d (%v), want %d", round, last, ok, wantLast)
+			}
+			if want := chain.gov.CRS(round); want != (coreCommon.Hash{}) {
+				if crs, ok := chain.GetRoundCRS(round); !ok || crs != common.Hash(want) {
+					t.Errorf("round %d: CRS mismatch: have %x (%v), want %x", round, crs, ok, want)
+				}
+			}
+		}
+		if head, ok := rawdb.ReadRoundIndexHead(db); !ok || head != 9 {
+			t.Errorf("index head mismatch: have %d (%v), want %d", head, ok, 9)
+		}
+	}
+	check(chain)
+	chain.Stop()
+
+	// Simulate a backfill interrupted after block 4 and ensure it's resumed
+	rawdb.WriteRoundIndexHead(db, 4)
+	rawdb.WriteRoundLastHeight(db, 1, 4)
+	for round := uint64(2); round <= 3; round++ {
+		rawdb.DeleteRoundIndex(db, round)
+	}
+	if chain, err = NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil); err != nil {
+		t.Fatalf("failed to reopen tester chain: %v", err)
+	}
+	defer chain.Stop()
+	check(chain)
+
+	// The first block of a round must be found from the chain too
+	for round := uint64(0); round <= 3; round++ {
+		if number, ok := chain.findRoundHeight(round); !ok || number != round*3 {
+			t.Errorf("round %d: searched height mismatch: have %d (%v), want %d", round, number, ok, round*3)
+		}
+	}
+	if _, ok := chain.findRoundHeight(4); ok {
+		t.Errorf("future round found in chain")
+	}
+}
+
+// Tests that in finality pruning mode only the recent and round boundary states
+// are retained, and that the newest state survives both a clean restart and,
+// once the time allowance is exceeded, a crash.
+func TestFinalityPruning(t *testing.T) {
+	const (
+		roundLength = 8
+		retention   = 4
+	)
+	var (
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	// Blocks 1-7 are in round 0, then eight blocks per round up to round 4
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 4*roundLength+2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+		b.header.Round = uint64(i+1) / roundLength
+	})
+	head := blocks[len(blocks)-1]
+
+	newChain := func(diskdb ethdb.Database, timeLimit time.Duration) *BlockChain {
+		t.Helper()
+		cacheConfig := &CacheConfig{
+			TrieDirtyLimit:  256,
+			TrieTimeLimit:   timeLimit,
+			FinalityPruning: true,
+			StateRetention:  retention,
+		}
+		chain, err := NewBlockChain(diskdb, cacheConfig, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		return chain
+	}
+	// Import the chain without exceeding the time allowance
+	diskdb := ethdb.NewMemDatabase()
+	gspec.MustCommit(diskdb)
+
+	chain := newChain(diskdb, time.Hour)
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, block := range blocks {
+		var (
+			number   = block.NumberU64()
+			boundary = number%roundLength == 0
+			recent   = number > head.NumberU64()-retention
+		)
+		// Recently committed tries are cached by the state database, so look the
+		// root up in the trie database itself
+		_, err := chain.stateCache.TrieDB().Node(block.Root())
+		switch {
+		case boundary:
+			if ok, _ := diskdb.Has(block.Root().Bytes()); !ok {
+				t.Errorf("block %d: round boundary state not on disk", number)
+			}
+		case recent:
+			if err != nil {
+				t.Errorf("block %d: recent state missing: %v", number, err)
+			}
+		default:
+			if err == nil {
+				t.Errorf("block %d: stale state retained", number)
+			}
+		}
+	}
+	// Restart the chain and ensure the head state was stored on the way out
+	chain.Stop()
+
+	chain = newChain(diskdb, time.Hour)
+	if current := chain.CurrentBlock(); current.Hash() != head.Hash() {
+		t.Errorf("head mismatch after restart: have #%d, want #%d", current.NumberU64(), head.NumberU64())
+	}
+	chain.Stop()
+
+	// Import the chain into a new database exceeding the time allowance on every
+	// block, and ensure the head state is on disk without stopping the chain
+	diskdb = ethdb.NewMemDatabase()
+	gspec.MustCommit(diskdb)
+
+	chain = newChain(diskdb, 0)
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	crashed := newChain(diskdb, 0)
+	defer crashed.Stop()
+
+	if current := crashed.CurrentBlock(); current.Hash() != head.Hash() {
+		t.Errorf("head mismatch after crash: have #%d, want #%d", current.NumberU64(), head.NumberU64())
+	}
+}
@@ -0,0 +1,592 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/params"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+const (
+	// ChainArchiveIndexFile is the name of the index of a segmented chain
+	// archive.
+	ChainArchiveIndexFile = "index.json"
+
+	// ChainArchiveManifestFile is the name of the checksum manifest of a
+	// segmented chain archive, in the format understood by sha256sum -c.
+	ChainArchiveManifestFile = "SHA256SUMS"
+
+	chainArchiveVersion = 1
+
+	// chainArchiveBatchSize is the number of blocks inserted at once while
+	// importing an archive.
+	chainArchiveBatchSize = 2500
+)
+
+// Compression schemes of chain archive segments.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var errChainArchiveInterrupted = errors.New("interrupted")
+
+// ChainSegment describes a single segment file of a chain archive. Segments
+// always contain whole rounds, except for the ends of the exported range.
+type ChainSegment struct {
+	File       string      `json:"file"`
+	First      uint64      `json:"first"`
+	Last       uint64      `json:"last"`
+	FirstRound uint64      `json:"firstRound"`
+	LastRound  uint64      `json:"lastRound"`
+	Hash       common.Hash `json:"hash"`
+	Size       int64       `json:"size"`
+	Checksum   string      `json:"sha256"`
+}
+
+// ChainArchiveIndex is the index of a segmented chain archive.
+type ChainArchiveIndex struct {
+	Version     int             `json:"version"`
+	Genesis     common.Hash     `json:"genesis"`
+	Compression string          `json:"compression"`
+	First       uint64          `json:"first"`
+	Last        uint64          `json:"last"`
+	Segments    []*ChainSegment `json:"segments"`
+}
+
+// ChainExportConfig contains the options of a segmented chain export.
+type ChainExportConfig struct {
+	First            uint64 // First block to export
+	Last             uint64 // Last block to export
+	RoundsPerSegment uint64 // Number of rounds stored in a segment file
+	Compression      string // Compression scheme of the segment files
+	Resume           bool   // Keep the valid segments of a previous export
+}
+
+// ChainImportConfig contains the options of a segmented chain import.
+type ChainImportConfig struct {
+	First   uint64 // Blocks below are skipped
+	Last    uint64 // Blocks above are skipped, zero means no limit
+	Workers int    // Number of segments decoded and checked in parallel
+}
+
+// ReadChainArchiveIndex loads the index of the chain archive in dir.
+func ReadChainArchiveIndex(dir string) (*ChainArchiveIndex, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(dir, ChainArchiveIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	index := new(ChainArchiveIndex)
+	if err := json.Unmarshal(blob, index); err != nil {
+		return nil, fmt.Errorf("invalid chain archive index: %v", err)
+	}
+	if index.Version != chainArchiveVersion {
+		return nil, fmt.Errorf("unsupported chain archive version %d", index.Version)
+	}
+	if err := checkCompression(index.Compression); err != nil {
+		return nil, err
+	}
+	for i, seg := range index.Segments {
+		if seg.First > seg.Last {
+			return nil, fmt.Errorf("segment %s has an invalid range", seg.File)
+		}
+		if i > 0 && seg.First != index.Segments[i-1].Last+1 {
+			return nil, fmt.Errorf("segment %s does not follow %s", seg.File, index.Segments[i-1].File)
+		}
+	}
+	return index, nil
+}
+
+// IsChainArchive reports whether path is the directory of a segmented chain
+// archive.
+func IsChainArchive(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ChainArchiveIndexFile))
+	return err == nil
+}
+
+// write atomically stores the index and the checksum manifest of an archive.
+func (index *ChainArchiveIndex) write(dir string) error {
+	blob, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, ChainArchiveIndexFile), blob); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(blob)
+
+	var manifest []byte
+	for _, seg := range index.Segments {
+		manifest = append(manifest, fmt.Sprintf("%s  %s\n", seg.Checksum, seg.File)...)
+	}
+	manifest = append(manifest, fmt.Sprintf("%x  %s\n", sum, ChainArchiveIndexFile)...)
+	return writeFileAtomic(filepath.Join(dir, ChainArchiveManifestFile), manifest)
+}
+
+// ExportChainArchive writes the canonical blocks of the configured range into
+// dir, split into segments of whole rounds, together with an index and a
+// checksum manifest. When resuming, the segments of a previous export of the
+// same range that still match their checksum are kept and only the rest of
+// the range is exported.
+func ExportChainArchive(bc *BlockChain, dir string, config ChainExportConfig) (*ChainArchiveIndex, error) {
+	if err := checkCompression(config.Compression); err != nil {
+		return nil, err
+	}
+	if config.First > config.Last {
+		return nil, fmt.Errorf("export failed: first (%d) is greater than last (%d)", config.First, config.Last)
+	}
+	if head := bc.CurrentBlock().NumberU64(); config.Last > head {
+		return nil, fmt.Errorf("export failed: last (%d) is beyond the head (%d)", config.Last, head)
+	}
+	if config.RoundsPerSegment == 0 {
+		config.RoundsPerSegment = 1
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	index := &ChainArchiveIndex{
+		Version:     chainArchiveVersion,
+		Genesis:     bc.Genesis().Hash(),
+		Compression: config.Compression,
+		First:       config.First,
+		Last:        config.Last,
+	}
+	next := config.First
+	if config.Resume {
+		segments, err := resumableSegments(dir, index)
+		if err != nil {
+			return nil, err
+		}
+		index.Segments = segments
+		if len(segments) > 0 {
+			next = segments[len(segments)-1].Last + 1
+			log.Info("Resuming chain export", "segments", len(segments), "next", next)
+		}
+	}
+	start, exported := time.Now(), uint64(0)
+	for next <= config.Last {
+		seg, err := exportSegment(bc, dir, next, config)
+		if err != nil {
+			return nil, err
+		}
+		index.Segments = append(index.Segments, seg)
+		if err := index.write(dir); err != nil {
+			return nil, err
+		}
+		exported += seg.Last - seg.First + 1
+		next = seg.Last + 1
+
+		log.Info("Exported chain segment", "file", seg.File, "rounds", fmt.Sprintf("%d-%d", seg.FirstRound, seg.LastRound),
+			"blocks", seg.Last-seg.First+1, "size", common.StorageSize(seg.Size), "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	// Write the index even if nothing was left to export
+	if err := index.write(dir); err != nil {
+		return nil, err
+	}
+	log.Info("Exported chain archive", "dir", dir, "segments", len(index.Segments), "exported", exported, "elapsed", common.PrettyDuration(time.Since(start)))
+	return index, nil
+}
+
+// resumableSegments returns the leading segments of an existing archive in dir
+// that belong to the same export and whose files are intact.
+func resumableSegments(dir string, index *ChainArchiveIndex) ([]*ChainSegment, error) {
+	old, err := ReadChainArchiveIndex(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if old.Genesis != index.Genesis || old.Compression != index.Compression || old.First != index.First {
+		return nil, errors.New("existing chain archive does not match the export, remove it or export without resume")
+	}
+	var kept []*ChainSegment
+	for _, seg := range old.Segments {
+		if seg.Last > index.Last {
+			break
+		}
+		sum, err := fileChecksum(filepath.Join(dir, seg.File))
+		if err != nil || sum != seg.Checksum {
+			log.Warn("Discarding damaged chain segment", "file", seg.File)
+			break
+		}
+		kept = append(kept, seg)
+	}
+	return kept, nil
+}
+
+// exportSegment writes the blocks of whole rounds starting at the given block
+// into a new segment file, stopping at the configured end of the export.
+func exportSegment(bc *BlockChain, dir string, first uint64, config ChainExportConfig) (*ChainSegment, error) {
+	block := bc.GetBlockByNumber(first)
+	if block == nil {
+		return nil, fmt.Errorf("export failed on #%d: not found", first)
+	}
+	seg := &ChainSegment{First: first, FirstRound: block.Round()}
+
+	tmp, err := ioutil.TempFile(dir, "segment-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, hasher)}
+	w, err := newArchiveWriter(counter, config.Compression)
+	if err != nil {
+		return nil, err
+	}
+	for rounds := uint64(1); ; {
+		if err := block.EncodeRLP(w); err != nil {
+			return nil, err
+		}
+		seg.Last, seg.LastRound, seg.Hash = block.NumberU64(), block.Round(), block.Hash()
+		if seg.Last == config.Last {
+			break
+		}
+		next := bc.GetBlockByNumber(seg.Last + 1)
+		if next == nil {
+			return nil, fmt.Errorf("export failed on #%d: not found", seg.Last+1)
+		}
+		if next.Round() != block.Round() {
+			if rounds == config.RoundsPerSegment {
+				break
+			}
+			rounds++
+		}
+		block = next
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	seg.File = fmt.Sprintf("blocks-%010d-%010d.rlp%s", seg.First, seg.Last, compressionExt(config.Compression))
+	seg.Size = counter.n
+	seg.Checksum = hex.EncodeToString(hasher.Sum(nil))
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, seg.File)); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+// segmentResult is a decoded and verified segment, ready for insertion.
+type segmentResult struct {
+	blocks []*types.Block
+	err    error
+}
+
+// ImportChainArchive inserts the blocks of the chain archive in dir into the
+// chain. Segments are decoded, checked against the index and have the senders
+// of their transactions recovered in parallel, while the blocks themselves are
+// verified by the insertion, which proceeds in order. Segments already part of
+// the canonical chain are skipped without being read, so an interrupted import
+// resumes where it stopped. It returns the number of blocks inserted.
+func ImportChainArchive(bc *BlockChain, dir string, config ChainImportConfig, stop <-chan struct{}) (int, error) {
+	index, err := ReadChainArchiveIndex(dir)
+	if err != nil {
+		return 0, err
+	}
+	if index.Genesis != bc.Genesis().Hash() {
+		return 0, fmt.Errorf("chain archive genesis mismatch: have %x, want %x", index.Genesis, bc.Genesis().Hash())
+	}
+	var todo []*ChainSegment
+	for _, seg := range index.Segments {
+		if seg.Last < config.First || (config.Last != 0 && seg.First > config.Last) {
+			continue
+		}
+		if rawdb.ReadCanonicalHash(bc.db, seg.Last) == seg.Hash && bc.HasBlock(seg.Hash, seg.Last) {
+			continue
+		}
+		todo = append(todo, seg)
+	}
+	log.Info("Importing chain archive", "dir", dir, "segments", len(index.Segments), "pending", len(todo))
+	if len(todo) == 0 {
+		return 0, nil
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	// Decode segments ahead of the insertion, keeping at most as many decoded
+	// segments in memory as there are workers.
+	var (
+		results = make([]chan segmentResult, len(todo))
+		slots   = make(chan struct{}, workers)
+		abort   = make(chan struct{})
+	)
+	defer close(abort)
+	for i := range results {
+		results[i] = make(chan segmentResult, 1)
+	}
+	go func() {
+		for i, seg := range todo {
+			select {
+			case slots <- struct{}{}:
+			case <-abort:
+				return
+			}
+			go func(i int, seg *ChainSegment) {
+				blocks, err := loadSegment(dir, seg, index.Compression, bc.Config())
+				results[i] <- segmentResult{blocks, err}
+			}(i, seg)
+		}
+	}()
+	var (
+		start    = time.Now()
+		inserted int
+	)
+	for i, seg := range todo {
+		var res segmentResult
+		select {
+		case res = <-results[i]:
+			<-slots
+		case <-stop:
+			return inserted, errChainArchiveInterrupted
+		}
+		if res.err != nil {
+			return inserted, fmt.Errorf("segment %s: %v", seg.File, res.err)
+		}
+		blocks := res.blocks[:0]
+		for _, block := range res.blocks {
+			number := block.NumberU64()
+			if number == 0 || number < config.First || (config.Last != 0 && number > config.Last) {
+				continue
+			}
+			blocks = append(blocks, block)
+		}
+		blocks = missingArchiveBlocks(bc, blocks)
+		for len(blocks) > 0 {
+			select {
+			case <-stop:
+				return inserted, errChainArchiveInterrupted
+			default:
+			}
+			batch := blocks
+			if len(batch) > chainArchiveBatchSize {
+				batch = batch[:chainArchiveBatchSize]
+			}
+			if n, err := bc.InsertChain(batch); err != nil {
+				return inserted + n, fmt.Errorf("segment %s: invalid block #%d: %v", seg.File, batch[n].NumberU64(), err)
+			}
+			inserted += len(batch)
+			blocks = blocks[len(batch):]
+		}
+		log.Info("Imported chain segment", "file", seg.File, "number", seg.Last, "inserted", inserted, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return inserted, nil
+}
+
+// loadSegment reads a segment file, checking its checksum and contents
+// against the index entry and recovering the senders of all transactions.
+func loadSegment(dir string, seg *ChainSegment, compression string, config *params.ChainConfig) ([]*types.Block, error) {
+	fh, err := os.Open(filepath.Join(dir, seg.File))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	hasher := sha256.New()
+	raw := io.TeeReader(fh, hasher)
+	r, err := newArchiveReader(raw, compression)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var (
+		stream = rlp.NewStream(r, 0)
+		blocks = make([]*types.Block, 0, seg.Last-seg.First+1)
+	)
+	for {
+		block := new(types.Block)
+		if err := stream.Decode(block); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("block %d: failed to parse: %v", len(blocks), err)
+		}
+		blocks = append(blocks, block)
+	}
+	if err := verifyChecksum(raw, hasher, seg.Checksum); err != nil {
+		return nil, err
+	}
+	if uint64(len(blocks)) != seg.Last-seg.First+1 {
+		return nil, fmt.Errorf("have %d blocks, want %d", len(blocks), seg.Last-seg.First+1)
+	}
+	for i, block := range blocks {
+		if block.NumberU64() != seg.First+uint64(i) {
+			return nil, fmt.Errorf("block #%d out of order, want #%d", block.NumberU64(), seg.First+uint64(i))
+		}
+		if i > 0 && block.ParentHash() != blocks[i-1].Hash() {
+			return nil, fmt.Errorf("block #%d not linked to its parent", block.NumberU64())
+		}
+		if hash := types.DeriveSha(block.Transactions()); hash != block.TxHash() {
+			return nil, fmt.Errorf("block #%d transaction root mismatch", block.NumberU64())
+		}
+		signer := types.MakeSigner(config, block.Number())
+		for _, tx := range block.Transactions() {
+			if _, err := types.Sender(signer, tx); err != nil {
+				return nil, fmt.Errorf("block #%d: invalid signature of transaction %x: %v", block.NumberU64(), tx.Hash(), err)
+			}
+		}
+	}
+	if blocks[len(blocks)-1].Hash() != seg.Hash {
+		return nil, fmt.Errorf("last block hash mismatch")
+	}
+	return blocks, nil
+}
+
+// missingArchiveBlocks drops the leading blocks already present in the chain.
+func missingArchiveBlocks(bc *BlockChain, blocks []*types.Block) []*types.Block {
+	head := bc.CurrentBlock().NumberU64()
+	for i, block := range blocks {
+		if block.NumberU64() > head || !bc.HasBlock(block.Hash(), block.NumberU64()) {
+			return blocks[i:]
+		}
+	}
+	return nil
+}
+
+// verifyChecksum consumes what is left of a hashed stream and compares the
+// digest with the expected checksum.
+func verifyChecksum(r io.Reader, hasher hash.Hash, want string) error {
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return err
+	}
+	if have := hex.EncodeToString(hasher.Sum(nil)); have != want {
+		return fmt.Errorf("checksum mismatch: have %s, want %s", have, want)
+	}
+	return nil
+}
+
+// fileChecksum returns the hex encoded SHA-256 digest of a file.
+func fileChecksum(path string) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, fh); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func checkCompression(compression string) error {
+	switch compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	}
+	return fmt.Errorf("unknown compression %q", compression)
+}
+
+func compressionExt(compression string) string {
+	switch compression {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newArchiveWriter wraps w with the compressor of the given scheme.
+func newArchiveWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unknown compression %q", compression)
+}
+
+// newArchiveReader wraps r with the decompressor of the given scheme.
+func newArchiveReader(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return ioutil.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zstdReadCloser{dec}, nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", compression)
+}
+
+type zstdReadCloser struct{ *zstd.Decoder }
+
+func (r zstdReadCloser) Close() error {
+	r.Decoder.Close()
+	return nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// writeFileAtomic replaces the file at path with data, so readers never see
+// a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
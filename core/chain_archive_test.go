@@ -0,0 +1,267 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/consensus/ethash"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/params"
+)
+
+var (
+	archiveTestKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	archiveTestAddress  = crypto.PubkeyToAddress(archiveTestKey.PublicKey)
+	archiveTestGenesis  = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{archiveTestAddress: {Balance: big.NewInt(1000000000)}}}
+	archiveTestBlocks   = 20
+	archiveTestRoundLen = 3
+)
+
+// newArchiveTestChain creates a chain of archiveTestBlocks blocks, three per
+// round and each with a transaction, and an empty chain of the same genesis.
+func newArchiveTestChain(t *testing.T) (*BlockChain, *BlockChain) {
+	t.Helper()
+
+	db := ethdb.NewMemDatabase()
+	genesis := archiveTestGenesis.MustCommit(db)
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainID)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, archiveTestBlocks, func(i int, b *BlockGen) {
+		b.header.Round = uint64(i+1) / uint64(archiveTestRoundLen)
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(archiveTestAddress), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil), signer, archiveTestKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	src := newArchiveTestBlockChain(t)
+	if n, err := src.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	return src, newArchiveTestBlockChain(t)
+}
+
+func newArchiveTestBlockChain(t *testing.T) *BlockChain {
+	t.Helper()
+
+	db := ethdb.NewMemDatabase()
+	archiveTestGenesis.MustCommit(db)
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	return chain
+}
+
+func newArchiveTestDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "chain-archive-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	return dir
+}
+
+// Tests that an exported archive is split into segments of whole rounds and
+// imports into an empty chain, for all compression schemes.
+func TestChainArchiveRoundTrip(t *testing.T) {
+	for _, compression := range []string{CompressionNone, CompressionGzip, CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			src, dst := newArchiveTestChain(t)
+			defer src.Stop()
+			defer dst.Stop()
+
+			dir := newArchiveTestDir(t)
+			defer os.RemoveAll(dir)
+
+			config := ChainExportConfig{First: 0, Last: uint64(archiveTestBlocks), RoundsPerSegment: 2, Compression: compression}
+			index, err := ExportChainArchive(src, dir, config)
+			if err != nil {
+				t.Fatalf("failed to export: %v", err)
+			}
+			// Rounds 0-6 have three blocks each, the last segment holds round 6 only
+			if len(index.Segments) != 4 {
+				t.Fatalf("segment count mismatch: have %d, want 4", len(index.Segments))
+			}
+			for i, seg := range index.Segments {
+				want := uint64(1)
+				if i == len(index.Segments)-1 {
+					want = 0
+				}
+				if seg.LastRound-seg.FirstRound != want {
+					t.Errorf("segment %d: round range mismatch: have %d-%d", i, seg.FirstRound, seg.LastRound)
+				}
+				if i > 0 && src.GetBlockByNumber(seg.First-1).Round() == seg.FirstRound {
+					t.Errorf("segment %d: starts within round %d", i, seg.FirstRound)
+				}
+			}
+			reread, err := ReadChainArchiveIndex(dir)
+			if err != nil {
+				t.Fatalf("failed to read index: %v", err)
+			}
+			if len(reread.Segments) != len(index.Segments) {
+				t.Fatalf("reread segment count mismatch: have %d, want %d", len(reread.Segments), len(index.Segments))
+			}
+			n, err := ImportChainArchive(dst, dir, ChainImportConfig{Workers: 2}, nil)
+			if err != nil {
+				t.Fatalf("failed to import: %v", err)
+			}
+			if n != archiveTestBlocks {
+				t.Errorf("inserted block count mismatch: have %d, want %d", n, archiveTestBlocks)
+			}
+			if have, want := dst.CurrentBlock().Hash(), src.CurrentBlock().Hash(); have != want {
+				t.Errorf("head mismatch: have %x, want %x", have, want)
+			}
+			// A second import finds every segment in the chain already
+			if n, err := ImportChainArchive(dst, dir, ChainImportConfig{}, nil); err != nil || n != 0 {
+				t.Errorf("reimport: have %d, %v, want 0, nil", n, err)
+			}
+		})
+	}
+}
+
+// Tests that damaged segments are rejected on import, after the intact
+// segments before them have been inserted.
+func TestChainArchiveCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(dir string, index *ChainArchiveIndex) error
+		want    string
+	}{
+		{
+			name: "flipped byte",
+			corrupt: func(dir string, index *ChainArchiveIndex) error {
+				path := filepath.Join(dir, index.Segments[2].File)
+				blob, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				blob[len(blob)-1] ^= 0xff
+				return ioutil.WriteFile(path, blob, 0644)
+			},
+			want: "segment blocks-0000000012-0000000017.rlp",
+		},
+		{
+			name: "index checksum",
+			corrupt: func(dir string, index *ChainArchiveIndex) error {
+				index.Segments[2].Checksum = strings.Repeat("00", 32)
+				return index.write(dir)
+			},
+			want: "checksum mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := newArchiveTestChain(t)
+			defer src.Stop()
+			defer dst.Stop()
+
+			dir := newArchiveTestDir(t)
+			defer os.RemoveAll(dir)
+
+			index, err := ExportChainArchive(src, dir, ChainExportConfig{Last: uint64(archiveTestBlocks), RoundsPerSegment: 2, Compression: CompressionNone})
+			if err != nil {
+				t.Fatalf("failed to export: %v", err)
+			}
+			if err := tt.corrupt(dir, index); err != nil {
+				t.Fatalf("failed to corrupt archive: %v", err)
+			}
+			n, err := ImportChainArchive(dst, dir, ChainImportConfig{}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error mismatch: have %v, want %q", err, tt.want)
+			}
+			if last := index.Segments[1].Last; n != int(last) || dst.CurrentBlock().NumberU64() != last {
+				t.Errorf("progress mismatch: have %d blocks, head #%d, want #%d", n, dst.CurrentBlock().NumberU64(), last)
+			}
+		})
+	}
+}
+
+// Tests that a resumed export keeps the intact segments and rewrites the
+// damaged ones, and that a resumed import inserts only what is missing.
+func TestChainArchiveResume(t *testing.T) {
+	src, dst := newArchiveTestChain(t)
+	defer src.Stop()
+	defer dst.Stop()
+
+	dir := newArchiveTestDir(t)
+	defer os.RemoveAll(dir)
+
+	config := ChainExportConfig{Last: uint64(archiveTestBlocks), RoundsPerSegment: 2, Compression: CompressionGzip}
+	index, err := ExportChainArchive(src, dir, config)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	// Truncate the third segment, as if the export was killed while writing it
+	damaged := index.Segments[2]
+	if err := os.Truncate(filepath.Join(dir, damaged.File), damaged.Size/2); err != nil {
+		t.Fatalf("failed to truncate segment: %v", err)
+	}
+	if _, err := ImportChainArchive(dst, dir, ChainImportConfig{}, nil); err == nil {
+		t.Fatalf("truncated segment imported")
+	}
+	imported := dst.CurrentBlock().NumberU64()
+	if imported != index.Segments[1].Last {
+		t.Fatalf("head mismatch after failed import: have #%d, want #%d", imported, index.Segments[1].Last)
+	}
+	// Resuming the export must keep the intact leading segments only
+	kept, err := resumableSegments(dir, &ChainArchiveIndex{Genesis: index.Genesis, Compression: index.Compression, First: index.First, Last: index.Last})
+	if err != nil {
+		t.Fatalf("failed to check segments: %v", err)
+	}
+	if len(kept) != 2 {
+		t.Fatalf("kept segment count mismatch: have %d, want 2", len(kept))
+	}
+	config.Resume = true
+	resumed, err := ExportChainArchive(src, dir, config)
+	if err != nil {
+		t.Fatalf("failed to resume export: %v", err)
+	}
+	if len(resumed.Segments) != len(index.Segments) {
+		t.Fatalf("segment count mismatch: have %d, want %d", len(resumed.Segments), len(index.Segments))
+	}
+	for i, seg := range resumed.Segments {
+		if *seg != *index.Segments[i] {
+			t.Errorf("segment %d mismatch: have %+v, want %+v", i, seg, index.Segments[i])
+		}
+	}
+	// A resumed export of a different archive must be refused
+	if _, err := ExportChainArchive(src, dir, ChainExportConfig{First: 1, Last: config.Last, Compression: config.Compression, Resume: true}); err == nil {
+		t.Errorf("resumed export of a different range succeeded")
+	}
+	// Resuming the import inserts the rest of the chain only
+	n, err := ImportChainArchive(dst, dir, ChainImportConfig{}, nil)
+	if err != nil {
+		t.Fatalf("failed to resume import: %v", err)
+	}
+	if want := archiveTestBlocks - int(imported); n != want {
+		t.Errorf("inserted block count mismatch: have %d, want %d", n, want)
+	}
+	if have, want := dst.CurrentBlock().Hash(), src.CurrentBlock().Hash(); have != want {
+		t.Errorf("head mismatch: have %x, want %x", have, want)
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+)
+
+// The checks run by VerifyChain, as reported in chain issues.
+const (
+	CheckHeader     = "header"
+	CheckLinkage    = "linkage"
+	CheckBody       = "body"
+	CheckReceipts   = "receipts"
+	CheckState      = "state"
+	CheckGovState   = "govstate"
+	CheckRoundIndex = "roundindex"
+)
+
+// ChainIssue is an inconsistency found in the chain database.
+type ChainIssue struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Check  string      `json:"check"`
+	Error  string      `json:"error"`
+}
+
+// ChainReport is the outcome of verifying a range of the canonical chain.
+type ChainReport struct {
+	From    uint64       `json:"from"`
+	To      uint64       `json:"to"`
+	Checked uint64       `json:"checked"`
+	Issues  []ChainIssue `json:"issues"`
+
+	// LastConsistent is the last block before the first issue that has its
+	// state present, i.e. a valid rollback target. It is nil if there is no
+	// such block in the range.
+	LastConsistent *uint64 `json:"lastConsistent"`
+}
+
+// chainVerifier runs the checks of VerifyChain, collecting their issues.
+type chainVerifier struct {
+	db     ethdb.Database
+	gov    *vm.GovernanceState
+	report *ChainReport
+}
+
+func (v *chainVerifier) fail(number uint64, hash common.Hash, check string, format string, args ...interface{}) {
+	v.report.Issues = append(v.report.Issues, ChainIssue{
+		Number: number,
+		Hash:   hash,
+		Check:  check,
+		Error:  fmt.Sprintf(format, args...),
+	})
+}
+
+// VerifyChain rechecks the canonical blocks in [from, to] of a chain database
+// offline: the header linkage, the transaction and receipt roots, the presence
+// of the states governance depends on, the gov state proofs of round boundaries
+// and the round index against the governance state of the head block.
+func VerifyChain(db ethdb.Database, from, to uint64) (*ChainReport, error) {
+	head := rawdb.ReadHeadBlockHash(db)
+	headNumber := rawdb.ReadHeaderNumber(db, head)
+	if headNumber == nil {
+		return nil, errors.New("no head block")
+	}
+	if to > *headNumber {
+		to = *headNumber
+	}
+	if from > to {
+		return nil, fmt.Errorf("empty range [%d, %d]", from, to)
+	}
+	v := &chainVerifier{
+		db:     db,
+		report: &ChainReport{From: from, To: to},
+	}
+	headHeader := rawdb.ReadHeader(db, head, *headNumber)
+	if headHeader == nil {
+		return nil, errors.New("head header missing")
+	}
+	if statedb, err := state.New(headHeader.Root, state.NewDatabase(db)); err == nil {
+		v.gov = &vm.GovernanceState{StateDB: statedb}
+	} else {
+		v.fail(*headNumber, head, CheckState, "head state unavailable, skipping governance checks: %v", err)
+	}
+	var (
+		parent   *types.Header
+		maxRound uint64
+		clean    = len(v.report.Issues) // Issues not tied to the verified range
+		start    = time.Now()
+		logged   = time.Now()
+	)
+	if from > 0 {
+		parent = rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, from-1), from-1)
+		if parent != nil {
+			maxRound = parent.Round
+		}
+	}
+	for number := from; number <= to; number++ {
+		header := v.verifyBlock(number, parent, number == *headNumber)
+
+		// Round boundaries carry the states and gov states governance needs
+		if header != nil && (number == 0 || header.Round > maxRound) {
+			maxRound = header.Round
+			v.verifyRoundBoundary(header)
+		}
+		if len(v.report.Issues) == clean && header != nil && v.hasState(header) {
+			n := number
+			v.report.LastConsistent = &n
+		}
+		parent = header
+		v.report.Checked++
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying chain", "number", number, "issues", len(v.report.Issues), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return v.report, nil
+}
+
+// verifyBlock checks the header, linkage, body and receipts of a canonical
+// block, returning its header if there is one.
+func (v *chainVerifier) verifyBlock(number uint64, parent *types.Header, isHead bool) *types.Header {
+	hash := rawdb.ReadCanonicalHash(v.db, number)
+	if hash == (common.Hash{}) {
+		v.fail(number, hash, CheckHeader, "canonical hash missing")
+		return nil
+	}
+	header := rawdb.ReadHeader(v.db, hash, number)
+	if header == nil {
+		v.fail(number, hash, CheckHeader, "header missing")
+		return nil
+	}
+	if have := header.Hash(); have != hash {
+		v.fail(number, hash, CheckHeader, "header hash mismatch: have %x", have)
+	}
+	if header.Number.Uint64() != number {
+		v.fail(number, hash, CheckHeader, "header number mismatch: have %d", header.Number)
+	}
+	if parent != nil && header.ParentHash != parent.Hash() {
+		v.fail(number, hash, CheckLinkage, "parent hash mismatch: have %x, want %x", header.ParentHash, parent.Hash())
+	}
+	body := rawdb.ReadBody(v.db, hash, number)
+	if body == nil {
+		v.fail(number, hash, CheckBody, "body missing")
+	} else {
+		if root := types.DeriveSha(types.Transactions(body.Transactions)); root != header.TxHash {
+			v.fail(number, hash, CheckBody, "transaction root mismatch: have %x, want %x", root, header.TxHash)
+		}
+		if uncles := types.CalcUncleHash(body.Uncles); uncles != header.UncleHash {
+			v.fail(number, hash, CheckBody, "uncle hash mismatch: have %x, want %x", uncles, header.UncleHash)
+		}
+	}
+	if !rawdb.HasReceipts(v.db, hash, number) {
+		v.fail(number, hash, CheckReceipts, "receipts missing")
+	} else if root := types.DeriveSha(rawdb.ReadReceipts(v.db, hash, number)); root != header.ReceiptHash {
+		v.fail(number, hash, CheckReceipts, "receipt root mismatch: have %x, want %x", root, header.ReceiptHash)
+	}
+	if isHead {
+		v.verifyState(header)
+	}
+	return header
+}
+
+// hasState reports whether the state trie root of a block is present.
+func (v *chainVerifier) hasState(header *types.Header) bool {
+	if header.Root == types.EmptyRootHash {
+		return true
+	}
+	ok, _ := v.db.Has(header.Root.Bytes())
+	return ok
+}
+
+// verifyState checks that the state trie root of a block is present.
+func (v *chainVerifier) verifyState(header *types.Header) {
+	if !v.hasState(header) {
+		v.fail(header.Number.Uint64(), header.Hash(), CheckState, "state root %x missing", header.Root)
+	}
+}
+
+// verifyRoundBoundary checks the state, gov state and round index entries of
+// the first block of a round.
+func (v *chainVerifier) verifyRoundBoundary(header *types.Header) {
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	v.verifyState(header)
+
+	// The genesis gov state is never stored, its state is always at hand
+	if number > 0 {
+		if govState := rawdb.ReadGovState(v.db, hash); govState == nil {
+			v.fail(number, hash, CheckGovState, "gov state of round %d missing", header.Round)
+		} else if govState.BlockHash != hash || govState.Root != header.Root {
+			v.fail(number, hash, CheckGovState, "gov state for block %x root %x", govState.BlockHash, govState.Root)
+		} else if err := state.VerifyGovState(govState, vm.GovernanceContractAddress); err != nil {
+			v.fail(number, hash, CheckGovState, "%v", err)
+		}
+	}
+	if height, ok := rawdb.ReadRoundHeight(v.db, header.Round); !ok {
+		v.fail(number, hash, CheckRoundIndex, "round %d not indexed", header.Round)
+	} else if height != number {
+		v.fail(number, hash, CheckRoundIndex, "round %d indexed at height %d", header.Round, height)
+	}
+	if v.gov != nil {
+		height := v.gov.RoundHeight(new(big.Int).SetUint64(header.Round)).Uint64()
+		if (height != 0 || header.Round == 0) && height != number {
+			v.fail(number, hash, CheckRoundIndex, "governance has round %d at height %d", header.Round, height)
+		}
+	}
+}
+
+// RollbackChain rewinds the head of a chain database to the given canonical
+// block, dropping the canonical mappings, transaction lookups and round index
+// entries above it and rewinding the consensus core to it, all in a single
+// batch. Blocks already moved to the ancient store can't be rolled back.
+func RollbackChain(db ethdb.Database, number uint64) error {
+	hash := rawdb.ReadCanonicalHash(db, number)
+	header := rawdb.ReadHeader(db, hash, number)
+	if header == nil {
+		return fmt.Errorf("canonical block #%d missing", number)
+	}
+	if _, err := state.New(header.Root, state.NewDatabase(db)); err != nil {
+		return fmt.Errorf("state of block #%d unavailable: %v", number, err)
+	}
+	if adb, ok := db.(ethdb.AncientReader); ok {
+		if frozen, err := adb.Ancients(); err == nil && frozen > number+1 {
+			return fmt.Errorf("block #%d already frozen, ancient store holds %d blocks", number, frozen)
+		}
+	}
+	var (
+		batch  = db.NewBatch()
+		rounds = make(map[uint64]struct{})
+	)
+	for n := number + 1; ; n++ {
+		h := rawdb.ReadCanonicalHash(db, n)
+		if h == (common.Hash{}) {
+			break
+		}
+		if dropped := rawdb.ReadHeader(db, h, n); dropped != nil && dropped.Round > header.Round {
+			rounds[dropped.Round] = struct{}{}
+		}
+		if body := rawdb.ReadBody(db, h, n); body != nil {
+			for _, tx := range body.Transactions {
+				rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+			}
+		}
+		rawdb.DeleteCanonicalHash(batch, n)
+	}
+	for round := range rounds {
+		rawdb.DeleteRoundIndex(batch, round)
+	}
+	if last, ok := rawdb.ReadRoundLastHeight(db, header.Round); ok && last > number {
+		rawdb.WriteRoundLastHeight(batch, header.Round, number)
+	}
+	if indexed, ok := rawdb.ReadRoundIndexHead(db); ok && indexed > number {
+		rawdb.WriteRoundIndexHead(batch, number)
+	}
+	rawdb.WriteHeadHeaderHash(batch, hash)
+	rawdb.WriteHeadBlockHash(batch, hash)
+	rawdb.WriteHeadFastBlockHash(batch, hash)
+
+	// The consensus core must redeliver everything past the new head
+	if _, height := rawdb.ReadCoreCompactionChainTip(db); height > number {
+		var meta coreTypes.Block
+		if err := rlp.DecodeBytes(header.DexconMeta, &meta); err != nil {
+			return fmt.Errorf("invalid dexcon meta of block #%d: %v", number, err)
+		}
+		if err := rawdb.WriteCoreCompactionChainTip(batch, coreCommon.Hash(meta.Hash), number); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
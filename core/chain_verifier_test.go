@@ -0,0 +1,175 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/consensus/ethash"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/params"
+)
+
+// newVerifierTestChain creates an archive chain database of 9 blocks with a
+// transaction each, three blocks per round, and waits for the gov states of
+// the round boundaries to be written.
+func newVerifierTestChain(t *testing.T) (ethdb.Database, []*types.Block) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = ethdb.NewMemDatabase()
+		config  = params.TestnetChainConfig
+		signer  = types.NewEIP155Signer(config.ChainID)
+		gspec   = &Genesis{Config: config, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(params.Ether), Staked: big.NewInt(0)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(config, genesis, ethash.NewFaker(), db, 9, func(i int, b *BlockGen) {
+		b.header.Round = uint64(i+1) / 3
+
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for _, n := range []int{3, 6, 9} {
+		for deadline := time.Now().Add(5 * time.Second); rawdb.ReadGovState(db, blocks[n-1].Hash()) == nil; {
+			if time.Now().After(deadline) {
+				t.Fatalf("gov state of block %d not written", n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return db, append([]*types.Block{genesis}, blocks...)
+}
+
+// Tests that chain verification detects corruptions of the chain database and
+// reports the last block with state before them as the rollback target.
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(db ethdb.Database, blocks []*types.Block)
+		check   string // Check expected to fail, empty for a clean chain
+		number  uint64 // Block expected to fail the check
+		last    uint64 // Expected rollback target
+	}{
+		{"clean", func(db ethdb.Database, blocks []*types.Block) {}, "", 0, 9},
+		{"missing body", func(db ethdb.Database, blocks []*types.Block) {
+			rawdb.DeleteBody(db, blocks[5].Hash(), 5)
+		}, CheckBody, 5, 4},
+		{"missing receipts", func(db ethdb.Database, blocks []*types.Block) {
+			rawdb.DeleteReceipts(db, blocks[5].Hash(), 5)
+		}, CheckReceipts, 5, 4},
+		{"missing gov state", func(db ethdb.Database, blocks []*types.Block) {
+			rawdb.DeleteGovState(db, blocks[6].Hash())
+		}, CheckGovState, 6, 5},
+		{"misplaced round", func(db ethdb.Database, blocks []*types.Block) {
+			rawdb.WriteRoundHeight(db, 2, 7)
+		}, CheckRoundIndex, 6, 5},
+		{"pruned state before issue", func(db ethdb.Database, blocks []*types.Block) {
+			db.Delete(blocks[4].Root().Bytes())
+			rawdb.DeleteBody(db, blocks[5].Hash(), 5)
+		}, CheckBody, 5, 3},
+	}
+	for _, tt := range tests {
+		db, blocks := newVerifierTestChain(t)
+		tt.corrupt(db, blocks)
+
+		report, err := VerifyChain(db, 0, 100)
+		if err != nil {
+			t.Fatalf("%s: failed to verify chain: %v", tt.name, err)
+		}
+		if report.Checked != 10 {
+			t.Errorf("%s: checked block count mismatch: have %d, want %d", tt.name, report.Checked, 10)
+		}
+		if tt.check == "" {
+			if len(report.Issues) != 0 {
+				t.Errorf("%s: unexpected issues: %v", tt.name, report.Issues)
+			}
+		} else if len(report.Issues) == 0 || report.Issues[0].Check != tt.check || report.Issues[0].Number != tt.number {
+			t.Errorf("%s: issue mismatch: have %v, want %s at #%d", tt.name, report.Issues, tt.check, tt.number)
+		}
+		if report.LastConsistent == nil || *report.LastConsistent != tt.last {
+			t.Errorf("%s: rollback target mismatch: have %v, want %d", tt.name, report.LastConsistent, tt.last)
+		}
+	}
+}
+
+// Tests that rolling back a chain drops everything above the target and that
+// the chain reopens on it.
+func TestRollbackChain(t *testing.T) {
+	db, blocks := newVerifierTestChain(t)
+
+	// A block without state can't be rolled back to
+	db.Delete(blocks[2].Root().Bytes())
+	if err := RollbackChain(db, 2); err == nil {
+		t.Fatalf("rolled back to block without state")
+	}
+	if err := RollbackChain(db, 4); err != nil {
+		t.Fatalf("failed to roll back chain: %v", err)
+	}
+	if head := rawdb.ReadHeadBlockHash(db); head != blocks[4].Hash() {
+		t.Fatalf("head block mismatch: have %x, want %x", head, blocks[4].Hash())
+	}
+	for i, block := range blocks {
+		kept := i <= 4
+		if have := rawdb.ReadCanonicalHash(db, uint64(i)); (have == block.Hash()) != kept {
+			t.Errorf("block %d: canonical hash presence mismatch: have %x, kept %v", i, have, kept)
+		}
+		for _, tx := range block.Transactions() {
+			if _, hash, _, _ := rawdb.ReadTransaction(db, tx.Hash()); (hash == block.Hash()) != kept {
+				t.Errorf("block %d: transaction lookup presence mismatch: kept %v", i, kept)
+			}
+		}
+	}
+	if last, ok := rawdb.ReadRoundLastHeight(db, 1); !ok || last != 4 {
+		t.Errorf("round 1 last height mismatch: have %d (%v), want %d", last, ok, 4)
+	}
+	for round := uint64(2); round <= 3; round++ {
+		if _, ok := rawdb.ReadRoundHeight(db, round); ok {
+			t.Errorf("round %d left in round index", round)
+		}
+	}
+	if head, ok := rawdb.ReadRoundIndexHead(db); !ok || head != 4 {
+		t.Errorf("round index head mismatch: have %d (%v), want %d", head, ok, 4)
+	}
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, params.TestnetChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen rolled back chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock().NumberU64(); head != 4 {
+		t.Fatalf("reopened chain head mismatch: have %d, want %d", head, 4)
+	}
+}
@@ -66,6 +66,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		GetHash:        GetHashFn(header, chain),
 		StateAtNumber:  StateAtNumberFn(chain),
 		GetRoundHeight: GetRoundHeightFn(chain),
+		GetDenylist:    GetDenylistFn(chain),
 		Origin:         msg.From(),
 		Coinbase:       beneficiary,
 		BlockNumber:    new(big.Int).Set(header.Number),
@@ -96,6 +97,17 @@ func GetRoundHeightFn(chain ChainContext) func(uint64) (uint64, bool) {
 	}
 }
 
+// GetDenylistFn returns the denylist retrieval of chains caching the denylist
+// of each round, or nil for the others.
+func GetDenylistFn(chain ChainContext) func(uint64) (map[common.Address]struct{}, error) {
+	if chain, ok := chain.(interface {
+		Denylist(uint64) (map[common.Address]struct{}, error)
+	}); ok {
+		return chain.Denylist
+	}
+	return nil
+}
+
 // GetHashFn returns a GetHashFunc which retrieves header hashes by number
 func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash {
 	var cache map[uint64]common.Hash
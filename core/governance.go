@@ -22,7 +22,10 @@ import (
 	"github.com/tangerine-network/go-tangerine/log"
 )
 
-const dkgCacheSize = 5
+const (
+	dkgCacheSize      = 5
+	denylistCacheSize = 5
+)
 
 type GovernanceStateDB interface {
 	State() (*state.StateDB, error)
@@ -64,6 +67,9 @@ type Governance struct {
 	dkgCache     *simplelru.LRU
 	dkgCacheMu   sync.RWMutex
 	util         vm.GovUtil
+
+	denylistCache   *simplelru.LRU
+	denylistCacheMu sync.Mutex
 }
 
 func NewGovernance(db GovernanceStateDB) *Governance {
@@ -72,9 +78,15 @@ func NewGovernance(db GovernanceStateDB) *Governance {
 		log.Error("Failed to initialize DKG cache", "error", err)
 		return nil
 	}
+	denylistCache, err := simplelru.NewLRU(denylistCacheSize, nil)
+	if err != nil {
+		log.Error("Failed to initialize denylist cache", "error", err)
+		return nil
+	}
 	g := &Governance{
-		db:       db,
-		dkgCache: cache,
+		db:            db,
+		dkgCache:      cache,
+		denylistCache: denylistCache,
 	}
 	g.nodeSetCache = dexCore.NewNodeSetCache(g)
 	g.util = vm.GovUtil{g}
@@ -98,6 +110,28 @@ func (g *Governance) GetConfigState(round uint64) (*vm.GovernanceState, error) {
 	return g.util.GetConfigState(round)
 }
 
+// Denylist returns the addresses denied in the given round, read from the
+// governance state of the round's configuration. The configuration state of a
+// round is final, so the denylist is cached per round.
+func (g *Governance) Denylist(round uint64) (map[common.Address]struct{}, error) {
+	g.denylistCacheMu.Lock()
+	defer g.denylistCacheMu.Unlock()
+
+	if v, ok := g.denylistCache.Get(round); ok {
+		return v.(map[common.Address]struct{}), nil
+	}
+	s, err := g.util.GetConfigState(round)
+	if err != nil {
+		return nil, err
+	}
+	denied := make(map[common.Address]struct{})
+	for _, addr := range s.AddressDenylists() {
+		denied[addr] = struct{}{}
+	}
+	g.denylistCache.Add(round, denied)
+	return denied, nil
+}
+
 func (g *Governance) GetStateForDKGAtRound(round uint64) (*vm.GovernanceState, error) {
 	gs, err := g.GetHeadGovState()
 	if err != nil {
@@ -373,27 +373,39 @@ func (hc *HeaderChain) WriteTangerineHeader(header *types.HeaderWithGovState) (s
 	// Store the govState
 	if govState := header.GovState; govState != nil {
 		rawdb.WriteGovState(hc.chainDb, header.Hash(), header.GovState)
-		batch := hc.chainDb.NewBatch()
-		for _, node := range govState.Proof {
-			batch.Put(crypto.Keccak256(node), node)
-		}
-		if err := batch.Write(); err != nil {
+		if err := writeGovStateTrie(hc.chainDb, govState); err != nil {
 			panic(fmt.Errorf("DB write error: %v", err))
 		}
+	}
+	return
+}
 
-		triedb := trie.NewDatabase(hc.chainDb)
-		t, err := trie.New(common.Hash{}, triedb)
-		if err != nil {
-			panic(err)
-		}
-
-		for _, kv := range govState.Storage {
-			t.TryUpdate(kv[0], kv[1])
+// writeGovStateTrie writes the governance account proof and storage trie of
+// a gov state into the database, so the governance contract can be read at
+// its state root.
+func writeGovStateTrie(db ethdb.Database, govState *types.GovState) error {
+	batch := db.NewBatch()
+	for _, node := range govState.Proof {
+		batch.Put(crypto.Keccak256(node), node)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	triedb := trie.NewDatabase(db)
+	t, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return err
+	}
+	for _, kv := range govState.Storage {
+		if err := t.TryUpdate(kv[0], kv[1]); err != nil {
+			return err
 		}
-		t.Commit(nil)
-		triedb.Commit(t.Hash(), false)
 	}
-	return
+	root, err := t.Commit(nil)
+	if err != nil {
+		return err
+	}
+	return triedb.Commit(root, false)
 }
 
 type Wh2Callback func(*types.HeaderWithGovState) error
@@ -469,6 +481,15 @@ func (c *headerVerifierCache) configuration(round uint64) *params.DexconConfig {
 func (hc *HeaderChain) ValidateTangerineHeaderChain(chain []*types.HeaderWithGovState,
 	gov dexcon.GovernanceStateFetcher,
 	verifierCache *dexCore.TSigVerifierCache, validator Validator) (int, error) {
+	return hc.validateTangerineHeaderChain(chain, gov, verifierCache, validator, false)
+}
+
+// validateTangerineHeaderChain checks a batch of headers. Threshold signature
+// failures are only logged unless strict, in which case they reject the chain,
+// as needed by snapshot import where no peer vouches for the headers.
+func (hc *HeaderChain) validateTangerineHeaderChain(chain []*types.HeaderWithGovState,
+	gov dexcon.GovernanceStateFetcher,
+	verifierCache *dexCore.TSigVerifierCache, validator Validator, strict bool) (int, error) {
 	// Do a sanity check that the provided chain is actually ordered and linked
 	for i := 1; i < len(chain); i++ {
 		if chain[i].Number.Uint64() != chain[i-1].Number.Uint64()+1 || chain[i].ParentHash != chain[i-1].Hash() {
@@ -481,11 +502,23 @@ func (hc *HeaderChain) ValidateTangerineHeaderChain(chain []*types.HeaderWithGov
 		}
 	}
 
-	// If the last TSig pass the verification, we don't need to verify others.
-	cache := newHeaderVerifierCache(verifierCache, gov)
-	verifyTSig := false
-	if err := hc.verifyTangerineHeader(chain[len(chain)-1].Header, gov, cache, true); err != nil {
-		verifyTSig = true
+	// Headers secured by a trusted checkpoint (nil governance) only need to
+	// link up, their content is endorsed by the notary set of the checkpoint.
+	var (
+		cache      *headerVerifierCache
+		verifyTSig bool
+	)
+	if gov != nil {
+		// If the last TSig pass the verification, we don't need to verify others.
+		cache = newHeaderVerifierCache(verifierCache, gov)
+		if err := hc.verifyTangerineHeader(chain[len(chain)-1].Header, gov, cache, true, strict); err != nil {
+			// The verifier cache only moves forward in rounds, so a strict
+			// failure can't be narrowed down to an earlier header.
+			if strict {
+				return len(chain) - 1, err
+			}
+			verifyTSig = true
+		}
 	}
 	// Iterate over the headers and ensure they all check out
 	for i, header := range chain {
@@ -502,8 +535,10 @@ func (hc *HeaderChain) ValidateTangerineHeaderChain(chain []*types.HeaderWithGov
 			}
 		}
 
-		if err := hc.verifyTangerineHeader(header.Header, gov, cache, verifyTSig); err != nil {
-			return i, err
+		if gov != nil {
+			if err := hc.verifyTangerineHeader(header.Header, gov, cache, verifyTSig, strict); err != nil {
+				return i, err
+			}
 		}
 
 		// Verify witness
@@ -543,7 +578,7 @@ func (hc *HeaderChain) VerifyTangerineHeader(header *types.Header,
 		return consensus.ErrUnknownAncestor
 	}
 	cache := newHeaderVerifierCache(verifierCache, gov)
-	if err := hc.verifyTangerineHeader(header, gov, cache, true); err != nil {
+	if err := hc.verifyTangerineHeader(header, gov, cache, true, false); err != nil {
 		return err
 	}
 
@@ -570,7 +605,7 @@ func (hc *HeaderChain) VerifyTangerineHeader(header *types.Header,
 
 func (hc *HeaderChain) verifyTangerineHeader(header *types.Header,
 	gov dexcon.GovernanceStateFetcher,
-	cache *headerVerifierCache, verifyTSig bool) error {
+	cache *headerVerifierCache, verifyTSig, strict bool) error {
 
 	// If the header is a banned one, straight out abort
 	if BadHashes[header.Hash()] {
@@ -590,9 +625,11 @@ func (hc *HeaderChain) verifyTangerineHeader(header *types.Header,
 	}
 
 	if verifyTSig {
-		if err := hc.verifyTSig(&coreBlock, cache.verifierCache); err != nil {
-			log.Debug("verify header sig fail, number=%d, err=%v",
-				header.Number.Uint64(), err)
+		if err := hc.verifyTSig(&coreBlock, cache.verifierCache, strict); err != nil {
+			log.Debug("Verify header signature failed", "number", header.Number.Uint64(), "err", err)
+			if strict {
+				return err
+			}
 		}
 	}
 
@@ -634,8 +671,11 @@ func (hc *HeaderChain) verifyTangerineHeader(header *types.Header,
 	return nil
 }
 
+// verifyTSig checks the randomness of a core block against the DKG set of its
+// round. A round without a verifier is a programming error unless strict, when
+// it's caused by the governance states of an untrusted snapshot.
 func (hc *HeaderChain) verifyTSig(coreBlock *coreTypes.Block,
-	verifierCache *dexCore.TSigVerifierCache) error {
+	verifierCache *dexCore.TSigVerifierCache, strict bool) error {
 
 	round := coreBlock.Position.Round
 	randomness := coreBlock.Randomness
@@ -647,11 +687,18 @@ func (hc *HeaderChain) verifyTSig(coreBlock *coreTypes.Block,
 	// Verify threshold signature
 	v, ok, err := verifierCache.UpdateAndGet(round)
 	if err != nil {
-		panic(err)
+		if !strict {
+			panic(err)
+		}
+		return err
 	}
 
 	if !ok {
-		panic(fmt.Errorf("DKG of round %d is not finished", round))
+		err := fmt.Errorf("DKG of round %d is not finished", round)
+		if !strict {
+			panic(err)
+		}
+		return err
 	}
 
 	if !v.VerifySignature(coreBlock.Hash, coreCrypto.Signature{
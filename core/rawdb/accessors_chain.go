@@ -24,6 +24,7 @@ import (
 
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/log"
 	"github.com/tangerine-network/go-tangerine/rlp"
 )
@@ -32,7 +33,12 @@ import (
 func ReadCanonicalHash(db DatabaseReader, number uint64) common.Hash {
 	data, _ := db.Get(headerHashKey(number))
 	if len(data) == 0 {
-		return common.Hash{}
+		if adb, ok := db.(ethdb.AncientReader); ok {
+			data, _ = adb.Ancient(freezerHashTable, number)
+		}
+		if len(data) == 0 {
+			return common.Hash{}
+		}
 	}
 	return common.BytesToHash(data)
 }
@@ -127,16 +133,40 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 	}
 }
 
+// ReadSnapSyncProgress retrieves the serialized progress of an interrupted snap
+// sync, allowing it to resume instead of starting over.
+func ReadSnapSyncProgress(db DatabaseReader) []byte {
+	data, _ := db.Get(snapSyncProgressKey)
+	return data
+}
+
+// WriteSnapSyncProgress stores the serialized progress of a running snap sync.
+func WriteSnapSyncProgress(db DatabaseWriter, progress []byte) {
+	if err := db.Put(snapSyncProgressKey, progress); err != nil {
+		log.Crit("Failed to store snap sync progress", "err", err)
+	}
+}
+
+// DeleteSnapSyncProgress removes the progress of a completed snap sync.
+func DeleteSnapSyncProgress(db DatabaseDeleter) {
+	if err := db.Delete(snapSyncProgressKey); err != nil {
+		log.Crit("Failed to delete snap sync progress", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerHeaderTable, hash, number)
+	}
 	return data
 }
 
 // HasHeader verifies the existence of a block header corresponding to the hash.
 func HasHeader(db DatabaseReader, hash common.Hash, number uint64) bool {
 	if has, err := db.Has(headerKey(number, hash)); !has || err != nil {
-		return false
+		return readAncient(db, freezerHeaderTable, hash, number) != nil
 	}
 	return true
 }
@@ -192,6 +222,9 @@ func DeleteHeader(db DatabaseDeleter, hash common.Hash, number uint64) {
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func ReadBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(blockBodyKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerBodiesTable, hash, number)
+	}
 	return data
 }
 
@@ -205,7 +238,7 @@ func WriteBodyRLP(db DatabaseWriter, hash common.Hash, number uint64, rlp rlp.Ra
 // HasBody verifies the existence of a block body corresponding to the hash.
 func HasBody(db DatabaseReader, hash common.Hash, number uint64) bool {
 	if has, err := db.Has(blockBodyKey(number, hash)); !has || err != nil {
-		return false
+		return readAncient(db, freezerBodiesTable, hash, number) != nil
 	}
 	return true
 }
@@ -243,6 +276,9 @@ func DeleteBody(db DatabaseDeleter, hash common.Hash, number uint64) {
 // ReadTd retrieves a block's total difficulty corresponding to the hash.
 func ReadTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
 	data, _ := db.Get(headerTDKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerDifficultyTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -276,7 +312,7 @@ func DeleteTd(db DatabaseDeleter, hash common.Hash, number uint64) {
 // to a block.
 func HasReceipts(db DatabaseReader, hash common.Hash, number uint64) bool {
 	if has, err := db.Has(blockReceiptsKey(number, hash)); !has || err != nil {
-		return false
+		return readAncient(db, freezerReceiptTable, hash, number) != nil
 	}
 	return true
 }
@@ -285,6 +321,9 @@ func HasReceipts(db DatabaseReader, hash common.Hash, number uint64) bool {
 func ReadReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	// Retrieve the flattened receipt slice
 	data, _ := db.Get(blockReceiptsKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerReceiptTable, hash, number)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -387,6 +426,11 @@ func FindCommonAncestor(db DatabaseReader, a, b *types.Header) *types.Header {
 // ReadGovStateRLP retrieves
 func ReadGovStateRLP(db DatabaseReader, hash common.Hash) rlp.RawValue {
 	data, _ := db.Get(govStateKey(hash))
+	if len(data) == 0 {
+		if number := ReadHeaderNumber(db, hash); number != nil {
+			data = readAncient(db, freezerGovStateTable, hash, *number)
+		}
+	}
 	return data
 }
 
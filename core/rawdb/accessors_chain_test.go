@@ -317,3 +317,53 @@ func TestBlockReceiptStorage(t *testing.T) {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
+
+// Tests that receipts stored by a v3 database, before revert reasons were part
+// of the storage encoding, can still be retrieved next to v4 receipts.
+func TestLegacyBlockReceiptStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	// v3 storage encoding of a receipt, without the trailing revert reason
+	type v3StoredReceiptRLP struct {
+		PostStateOrStatus []byte
+		CumulativeGasUsed uint64
+		Bloom             types.Bloom
+		TxHash            common.Hash
+		ContractAddress   common.Address
+		Logs              []*types.LogForStorage
+		GasUsed           uint64
+	}
+	legacy := &v3StoredReceiptRLP{
+		PostStateOrStatus: []byte{0x01},
+		CumulativeGasUsed: 1,
+		TxHash:            common.BytesToHash([]byte{0x11, 0x11}),
+		Logs:              []*types.LogForStorage{},
+		GasUsed:           111111,
+	}
+	current := &types.Receipt{
+		Status:            types.ReceiptStatusFailed,
+		CumulativeGasUsed: 2,
+		Logs:              []*types.Log{},
+		TxHash:            common.BytesToHash([]byte{0x22, 0x22}),
+		GasUsed:           222222,
+		RevertReason:      "caller is not the owner",
+	}
+	blob, err := rlp.EncodeToBytes([]interface{}{legacy, (*types.ReceiptForStorage)(current)})
+	if err != nil {
+		t.Fatalf("failed to encode receipts: %v", err)
+	}
+	hash := common.BytesToHash([]byte{0x03, 0x14})
+	if err := db.Put(blockReceiptsKey(0, hash), blob); err != nil {
+		t.Fatalf("failed to store receipts: %v", err)
+	}
+	rs := ReadReceipts(db, hash, 0)
+	if len(rs) != 2 {
+		t.Fatalf("receipt count mismatch: have %d, want %d", len(rs), 2)
+	}
+	if rs[0].Status != types.ReceiptStatusSuccessful || rs[0].TxHash != legacy.TxHash || rs[0].GasUsed != legacy.GasUsed || rs[0].RevertReason != "" {
+		t.Errorf("legacy receipt mismatch: %+v", rs[0])
+	}
+	if rs[1].Status != current.Status || rs[1].TxHash != current.TxHash || rs[1].RevertReason != current.RevertReason {
+		t.Errorf("receipt mismatch: have %+v, want %+v", rs[1], current)
+	}
+}
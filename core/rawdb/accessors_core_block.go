@@ -2,16 +2,21 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 
 	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
 
 	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/log"
 	"github.com/tangerine-network/go-tangerine/rlp"
 )
 
 func ReadCoreBlockRLP(db DatabaseReader, hash common.Hash) rlp.RawValue {
 	data, _ := db.Get(coreBlockKey(hash))
+	if len(data) == 0 {
+		data = readAncientCoreBlock(db, hash)
+	}
 	return data
 }
 
@@ -23,7 +28,7 @@ func WriteCoreBlockRLP(db DatabaseWriter, hash common.Hash, rlp rlp.RawValue) {
 
 func HasCoreBlock(db DatabaseReader, hash common.Hash) bool {
 	if has, err := db.Has(coreBlockKey(hash)); !has || err != nil {
-		return false
+		return readAncientCoreBlock(db, hash) != nil
 	}
 	return true
 }
@@ -49,3 +54,46 @@ func WriteCoreBlock(db DatabaseWriter, hash common.Hash, block *coreTypes.Block)
 	}
 	WriteCoreBlockRLP(db, hash, data)
 }
+
+// DeleteCoreBlock removes a core block from the key-value store.
+func DeleteCoreBlock(db DatabaseDeleter, hash common.Hash) {
+	if err := db.Delete(coreBlockKey(hash)); err != nil {
+		log.Crit("Failed to delete core block", "err", err)
+	}
+}
+
+// ReadCoreBlockNumber returns the number of the chain block a frozen core
+// block was delivered in.
+func ReadCoreBlockNumber(db DatabaseReader, hash common.Hash) *uint64 {
+	data, _ := db.Get(coreBlockNumberKey(hash))
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteCoreBlockNumber stores the number of the chain block a core block was
+// delivered in, locating it once frozen into the ancient store.
+func WriteCoreBlockNumber(db DatabaseWriter, hash common.Hash, number uint64) {
+	if err := db.Put(coreBlockNumberKey(hash), encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store core block number", "err", err)
+	}
+}
+
+// readAncientCoreBlock retrieves a core block from the ancient store.
+func readAncientCoreBlock(db DatabaseReader, hash common.Hash) []byte {
+	adb, ok := db.(ethdb.AncientReader)
+	if !ok {
+		return nil
+	}
+	number := ReadCoreBlockNumber(db, hash)
+	if number == nil {
+		return nil
+	}
+	data, _ := adb.Ancient(freezerCoreBlockTable, *number)
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
@@ -2,7 +2,9 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 
+	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/log"
 	"github.com/tangerine-network/go-tangerine/rlp"
 	coreDKG "github.com/tangerine-network/tangerine-consensus/core/crypto/dkg"
@@ -26,6 +28,21 @@ func WriteCoreDKGPrivateKeyRLP(db DatabaseWriter, round uint64, rlp rlp.RawValue
 	return err
 }
 
+// ReadCoreDKGPrivateKeyRounds retrieves the rounds of all the stored core DKG
+// private keys, in no particular order.
+func ReadCoreDKGPrivateKeyRounds(db ethdb.Iteratee) []uint64 {
+	it := db.NewIteratorWithPrefix(coreDKGPrivateKeyPrefix)
+	defer it.Release()
+
+	var rounds []uint64
+	for it.Next() {
+		if key := it.Key(); len(key) == len(coreDKGPrivateKeyPrefix)+8 {
+			rounds = append(rounds, binary.LittleEndian.Uint64(key[len(coreDKGPrivateKeyPrefix):]))
+		}
+	}
+	return rounds
+}
+
 func ReadCoreDKGPrivateKey(db DatabaseReader, round, reset uint64) *coreDKG.PrivateKey {
 	data := ReadCoreDKGPrivateKeyRLP(db, round)
 	if len(data) == 0 {
@@ -56,3 +73,20 @@ func WriteCoreDKGPrivateKey(db DatabaseWriter, round, reset uint64, pk *coreDKG.
 	}
 	return WriteCoreDKGPrivateKeyRLP(db, round, data)
 }
+
+// ReadCoreDKGKeyEnvelope retrieves the encrypted key used to seal the DKG
+// private keys and protocol state at rest.
+func ReadCoreDKGKeyEnvelope(db DatabaseReader) []byte {
+	data, _ := db.Get(coreDKGKeyEnvelopeKey)
+	return data
+}
+
+// WriteCoreDKGKeyEnvelope stores the encrypted key used to seal the DKG
+// private keys and protocol state at rest.
+func WriteCoreDKGKeyEnvelope(db DatabaseWriter, envelope []byte) error {
+	err := db.Put(coreDKGKeyEnvelopeKey, envelope)
+	if err != nil {
+		log.Crit("Failed to store core DKG key envelope", "err", err)
+	}
+	return err
+}
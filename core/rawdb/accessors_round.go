@@ -0,0 +1,96 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/log"
+)
+
+// readRoundHeight retrieves a block number stored in the round index.
+func readRoundHeight(db DatabaseReader, key []byte) (uint64, bool) {
+	data, _ := db.Get(key)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// ReadRoundHeight retrieves the number of the first block of a round.
+func ReadRoundHeight(db DatabaseReader, round uint64) (uint64, bool) {
+	return readRoundHeight(db, roundIndexKey(round, roundFirstSuffix))
+}
+
+// WriteRoundHeight stores the number of the first block of a round.
+func WriteRoundHeight(db DatabaseWriter, round uint64, number uint64) {
+	if err := db.Put(roundIndexKey(round, roundFirstSuffix), encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store round height", "err", err)
+	}
+}
+
+// ReadRoundLastHeight retrieves the number of the last block of a round seen
+// so far.
+func ReadRoundLastHeight(db DatabaseReader, round uint64) (uint64, bool) {
+	return readRoundHeight(db, roundIndexKey(round, roundLastSuffix))
+}
+
+// WriteRoundLastHeight stores the number of the last block of a round.
+func WriteRoundLastHeight(db DatabaseWriter, round uint64, number uint64) {
+	if err := db.Put(roundIndexKey(round, roundLastSuffix), encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store round last height", "err", err)
+	}
+}
+
+// ReadRoundCRS retrieves the common reference string of a round.
+func ReadRoundCRS(db DatabaseReader, round uint64) (common.Hash, bool) {
+	data, _ := db.Get(roundIndexKey(round, roundCRSSuffix))
+	if len(data) != common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data), true
+}
+
+// WriteRoundCRS stores the common reference string of a round.
+func WriteRoundCRS(db DatabaseWriter, round uint64, crs common.Hash) {
+	if err := db.Put(roundIndexKey(round, roundCRSSuffix), crs.Bytes()); err != nil {
+		log.Crit("Failed to store round CRS", "err", err)
+	}
+}
+
+// DeleteRoundIndex removes all index entries of a round.
+func DeleteRoundIndex(db DatabaseDeleter, round uint64) {
+	for _, suffix := range [][]byte{roundFirstSuffix, roundLastSuffix, roundCRSSuffix} {
+		if err := db.Delete(roundIndexKey(round, suffix)); err != nil {
+			log.Crit("Failed to delete round index", "err", err)
+		}
+	}
+}
+
+// ReadRoundIndexHead retrieves the number of the latest block in the round
+// index.
+func ReadRoundIndexHead(db DatabaseReader) (uint64, bool) {
+	return readRoundHeight(db, roundIndexHeadKey)
+}
+
+// WriteRoundIndexHead stores the number of the latest block in the round index.
+func WriteRoundIndexHead(db DatabaseWriter, number uint64) {
+	if err := db.Put(roundIndexHeadKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store round index head", "err", err)
+	}
+}
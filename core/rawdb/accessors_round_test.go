@@ -0,0 +1,75 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+)
+
+// Tests round index storage and retrieval operations.
+func TestRoundIndexStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if _, ok := ReadRoundHeight(db, 7); ok {
+		t.Fatalf("Non existent round height returned")
+	}
+	if _, ok := ReadRoundLastHeight(db, 7); ok {
+		t.Fatalf("Non existent round last height returned")
+	}
+	if _, ok := ReadRoundCRS(db, 7); ok {
+		t.Fatalf("Non existent round CRS returned")
+	}
+	if _, ok := ReadRoundIndexHead(db); ok {
+		t.Fatalf("Non existent round index head returned")
+	}
+	crs := common.HexToHash("0xdeadbeef")
+	WriteRoundHeight(db, 7, 100)
+	WriteRoundLastHeight(db, 7, 150)
+	WriteRoundCRS(db, 7, crs)
+	WriteRoundHeight(db, 8, 151)
+	WriteRoundIndexHead(db, 151)
+
+	if first, ok := ReadRoundHeight(db, 7); !ok || first != 100 {
+		t.Fatalf("Round height mismatch: have %d (%v), want %d", first, ok, 100)
+	}
+	if last, ok := ReadRoundLastHeight(db, 7); !ok || last != 150 {
+		t.Fatalf("Round last height mismatch: have %d (%v), want %d", last, ok, 150)
+	}
+	if have, ok := ReadRoundCRS(db, 7); !ok || have != crs {
+		t.Fatalf("Round CRS mismatch: have %x (%v), want %x", have, ok, crs)
+	}
+	if head, ok := ReadRoundIndexHead(db); !ok || head != 151 {
+		t.Fatalf("Round index head mismatch: have %d (%v), want %d", head, ok, 151)
+	}
+	// Delete the round and ensure the neighbouring one is left intact
+	DeleteRoundIndex(db, 7)
+	if _, ok := ReadRoundHeight(db, 7); ok {
+		t.Fatalf("Deleted round height returned")
+	}
+	if _, ok := ReadRoundLastHeight(db, 7); ok {
+		t.Fatalf("Deleted round last height returned")
+	}
+	if _, ok := ReadRoundCRS(db, 7); ok {
+		t.Fatalf("Deleted round CRS returned")
+	}
+	if first, ok := ReadRoundHeight(db, 8); !ok || first != 151 {
+		t.Fatalf("Neighbouring round height mismatch: have %d (%v), want %d", first, ok, 151)
+	}
+}
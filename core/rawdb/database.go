@@ -0,0 +1,117 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"os"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+)
+
+// The tables of the ancient store.
+const (
+	// freezerHashTable indicates the name of the freezer canonical hash table.
+	freezerHashTable = "hashes"
+
+	// freezerHeaderTable indicates the name of the freezer header table.
+	freezerHeaderTable = "headers"
+
+	// freezerBodiesTable indicates the name of the freezer block body table.
+	freezerBodiesTable = "bodies"
+
+	// freezerReceiptTable indicates the name of the freezer receipts table.
+	freezerReceiptTable = "receipts"
+
+	// freezerDifficultyTable indicates the name of the freezer total difficulty table.
+	freezerDifficultyTable = "diffs"
+
+	// freezerGovStateTable indicates the name of the freezer governance state table.
+	freezerGovStateTable = "govstates"
+
+	// freezerCoreBlockTable indicates the name of the freezer core block table.
+	freezerCoreBlockTable = "coreblocks"
+)
+
+// freezerdb is a database wrapper that enables freezer data retrievals.
+type freezerdb struct {
+	ethdb.Database
+	*freezer
+}
+
+// Close implements ethdb.Database, closing both the fast key-value store as
+// well as the slow ancient tables.
+func (frdb *freezerdb) Close() {
+	if err := frdb.freezer.close(); err != nil {
+		log.Error("Failed to close ancient database", "err", err)
+	}
+	frdb.Database.Close()
+}
+
+// NewDatabaseWithFreezer creates a high level database on top of a given key-
+// value data store with a freezer moving the blocks of all but the most recent
+// rounds into the ancient store. If rounds is zero, no blocks are moved and the
+// ancient store is only read from, if it was ever created.
+func NewDatabaseWithFreezer(db ethdb.Database, freezer string, rounds uint64) (ethdb.Database, error) {
+	if rounds == 0 {
+		if _, err := os.Stat(freezer); os.IsNotExist(err) {
+			return db, nil
+		}
+	}
+	frdb, err := newFreezer(freezer, rounds)
+	if err != nil {
+		return nil, err
+	}
+	if rounds > 0 {
+		frdb.wg.Add(1)
+		go frdb.freeze(db)
+	}
+	return &freezerdb{
+		Database: db,
+		freezer:  frdb,
+	}, nil
+}
+
+// KeyValueStore returns the key-value store backing the given database,
+// stripping the ancient store wrapper if there is one.
+func KeyValueStore(db ethdb.Database) ethdb.Database {
+	if frdb, ok := db.(*freezerdb); ok {
+		return frdb.Database
+	}
+	return db
+}
+
+// readAncient retrieves an item of a frozen block from the ancient store, if
+// the database has one and the block is frozen under the given hash.
+func readAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	adb, ok := db.(ethdb.AncientReader)
+	if !ok {
+		return nil
+	}
+	if has, err := adb.HasAncient(kind, number); !has || err != nil {
+		return nil
+	}
+	if frozen, _ := adb.Ancient(freezerHashTable, number); common.BytesToHash(frozen) != hash {
+		return nil
+	}
+	data, _ := adb.Ancient(kind, number)
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
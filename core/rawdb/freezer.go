@@ -0,0 +1,336 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+)
+
+// errUnknownTable is returned if the user attempts to read from a table that is
+// not tracked by the freezer.
+var errUnknownTable = errors.New("unknown table")
+
+const (
+	// freezerRecheckInterval is the frequency to check the key-value database for
+	// chain progression that might permit new blocks to be frozen into immutable
+	// storage.
+	freezerRecheckInterval = time.Minute
+
+	// freezerBatchLimit is the maximum number of blocks to freeze in one batch
+	// before doing an fsync and deleting it from the key-value store.
+	freezerBatchLimit = 30000
+)
+
+// The list of tables in the ancient store, along with whether their content
+// is already incompressible.
+var freezerNoSnappy = map[string]bool{
+	freezerHashTable:       true,
+	freezerHeaderTable:     false,
+	freezerBodiesTable:     false,
+	freezerReceiptTable:    false,
+	freezerDifficultyTable: true,
+	freezerGovStateTable:   false,
+	freezerCoreBlockTable:  false,
+}
+
+// freezer is an append-only database to store immutable chain data into flat
+// files, accessed through positional reads and writes on plain file handles:
+//
+//   - The append only nature ensures that disk writes are minimized.
+//   - Dexcon blocks are final once delivered, so frozen data never needs to be
+//     rewound because of a reorg.
+type freezer struct {
+	frozen uint64 // Number of blocks already frozen (atomic)
+	rounds uint64 // Number of recent rounds to keep in the key-value store
+
+	tables map[string]*freezerTable // Data tables for storing everything
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newFreezer creates a chain freezer that moves ancient chain data into
+// append-only flat file containers, creating the data directory if needed.
+func newFreezer(datadir string, rounds uint64) (*freezer, error) {
+	if err := os.MkdirAll(datadir, 0755); err != nil {
+		return nil, err
+	}
+	f := &freezer{
+		rounds: rounds,
+		tables: make(map[string]*freezerTable),
+		quit:   make(chan struct{}),
+	}
+	for name, disableSnappy := range freezerNoSnappy {
+		table, err := newTable(datadir, name, disableSnappy)
+		if err != nil {
+			for _, table := range f.tables {
+				table.Close()
+			}
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	if err := f.repair(); err != nil {
+		f.close()
+		return nil, err
+	}
+	log.Info("Opened ancient database", "database", datadir, "frozen", f.frozen, "rounds", rounds)
+	return f, nil
+}
+
+// close terminates the chain freezer, closing all the data files.
+func (f *freezer) close() error {
+	close(f.quit)
+	f.wg.Wait()
+
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// HasAncient returns an indicator whether the specified ancient data exists
+// in the freezer.
+func (f *freezer) HasAncient(kind string, number uint64) (bool, error) {
+	if table := f.tables[kind]; table != nil {
+		return number < atomic.LoadUint64(&f.frozen) && table.has(number), nil
+	}
+	return false, nil
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (f *freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	if table := f.tables[kind]; table != nil {
+		if number >= atomic.LoadUint64(&f.frozen) {
+			return nil, errOutOfBounds
+		}
+		return table.Retrieve(number)
+	}
+	return nil, errUnknownTable
+}
+
+// Ancients returns the length of the frozen items.
+func (f *freezer) Ancients() (uint64, error) {
+	return atomic.LoadUint64(&f.frozen), nil
+}
+
+// appendBlock injects all the data of a block into the freezer. If any of the
+// tables fails, they are truncated back to the previous block.
+func (f *freezer) appendBlock(number uint64, blobs map[string][]byte) (err error) {
+	defer func() {
+		if err != nil {
+			for _, table := range f.tables {
+				table.truncate(number)
+			}
+		}
+	}()
+	for name, table := range f.tables {
+		if err := table.Append(number, blobs[name]); err != nil {
+			log.Error("Failed to append ancient data", "table", name, "number", number, "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// sync flushes all data tables to disk.
+func (f *freezer) sync() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// repair truncates all data tables to the same length.
+func (f *freezer) repair() error {
+	min := uint64(math.MaxUint64)
+	for _, table := range f.tables {
+		if items := atomic.LoadUint64(&table.items); min > items {
+			min = items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(min); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, min)
+	return nil
+}
+
+// freeze is a background thread that periodically checks the blockchain for
+// any import progress and moves blocks of rounds old enough into the ancient
+// store. The genesis block is kept in the key-value store too.
+func (f *freezer) freeze(db ethdb.Database) {
+	defer f.wg.Done()
+
+	backoff := false
+	for {
+		if backoff {
+			select {
+			case <-time.After(freezerRecheckInterval):
+			case <-f.quit:
+				return
+			}
+		}
+		backoff = true
+
+		// Retrieve the freezing threshold from the current head round
+		hash := ReadHeadBlockHash(db)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		number := ReadHeaderNumber(db, hash)
+		if number == nil {
+			continue
+		}
+		head := ReadHeader(db, hash, *number)
+		if head == nil || head.Round < f.rounds {
+			continue
+		}
+		limit := head.Round - f.rounds
+
+		// Move the blocks of rounds below the threshold into the freezer
+		var (
+			start    = time.Now()
+			first    = atomic.LoadUint64(&f.frozen)
+			frozen   = first
+			hashes   []common.Hash
+			cores    []common.Hash
+			complete = true
+		)
+		for frozen < *number && frozen-first < freezerBatchLimit {
+			hash := ReadCanonicalHash(db, frozen)
+			if hash == (common.Hash{}) {
+				log.Error("Canonical hash missing, can't freeze", "number", frozen)
+				complete = false
+				break
+			}
+			header := ReadHeaderRLP(db, hash, frozen)
+			decoded := ReadHeader(db, hash, frozen)
+			if decoded == nil {
+				log.Error("Block header missing, can't freeze", "number", frozen, "hash", hash)
+				complete = false
+				break
+			}
+			if decoded.Round >= limit {
+				break
+			}
+			body := ReadBodyRLP(db, hash, frozen)
+			receipts, _ := db.Get(blockReceiptsKey(frozen, hash))
+			if len(body) == 0 || len(receipts) == 0 {
+				log.Error("Block data missing, can't freeze", "number", frozen, "hash", hash)
+				complete = false
+				break
+			}
+			td, _ := db.Get(headerTDKey(frozen, hash))
+			var (
+				core     []byte
+				coreHash common.Hash
+				meta     coreTypes.Block
+			)
+			if err := rlp.DecodeBytes(decoded.DexconMeta, &meta); err == nil {
+				coreHash = common.Hash(meta.Hash)
+				core = ReadCoreBlockRLP(db, coreHash)
+			}
+			err := f.appendBlock(frozen, map[string][]byte{
+				freezerHashTable:       hash.Bytes(),
+				freezerHeaderTable:     header,
+				freezerBodiesTable:     body,
+				freezerReceiptTable:    receipts,
+				freezerDifficultyTable: td,
+				freezerGovStateTable:   ReadGovStateRLP(db, hash),
+				freezerCoreBlockTable:  core,
+			})
+			if err != nil {
+				complete = false
+				break
+			}
+			hashes = append(hashes, hash)
+			cores = append(cores, coreHash)
+			frozen++
+		}
+		if frozen == first {
+			continue
+		}
+		// Batch of blocks have been frozen, flush them before wiping from leveldb
+		if err := f.sync(); err != nil {
+			log.Crit("Failed to flush frozen tables", "err", err)
+		}
+		atomic.StoreUint64(&f.frozen, frozen)
+
+		batch := db.NewBatch()
+		for i, hash := range hashes {
+			number := first + uint64(i)
+			if cores[i] != (common.Hash{}) {
+				WriteCoreBlockNumber(batch, cores[i], number)
+				DeleteCoreBlock(batch, cores[i])
+			}
+			if number == 0 {
+				continue
+			}
+			DeleteCanonicalHash(batch, number)
+			if err := batch.Delete(headerKey(number, hash)); err != nil {
+				log.Crit("Failed to delete frozen header", "err", err)
+			}
+			DeleteBody(batch, hash, number)
+			DeleteReceipts(batch, hash, number)
+			DeleteTd(batch, hash, number)
+			DeleteGovState(batch, hash)
+
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					log.Crit("Failed to delete frozen blocks", "err", err)
+				}
+				batch.Reset()
+			}
+		}
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to delete frozen blocks", "err", err)
+		}
+		log.Info("Moved ancient blocks into the freezer", "blocks", frozen-first, "number", frozen-1, "elapsed", common.PrettyDuration(time.Since(start)))
+
+		// Avoid database thrashing with tiny writes
+		if complete && frozen-first >= freezerBatchLimit {
+			backoff = false
+		}
+	}
+}
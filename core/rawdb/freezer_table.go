@@ -0,0 +1,274 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+)
+
+var (
+	// errClosed is returned if an operation attempts to read from or write to
+	// the freezer table after it has already been closed.
+	errClosed = errors.New("closed")
+
+	// errOutOfBounds is returned if the item requested is not contained within
+	// the freezer table.
+	errOutOfBounds = errors.New("out of bounds")
+
+	// errOutOrderInsertion is returned if the user attempts to inject out-of-order
+	// binary blobs into the freezer.
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+)
+
+// indexEntrySize is the size of an index entry, holding the end offset of the
+// item within the data file.
+const indexEntrySize = 8
+
+// freezerTable represents a single chained data table within the freezer
+// (e.g. blocks). It consists of an append-only data file holding the raw
+// binary blobs and an index file holding the end offset of each of them.
+type freezerTable struct {
+	items uint64 // Number of items stored in the table (atomic)
+
+	noCompression bool     // if true, disables snappy compression
+	index         *os.File // File descriptor for the index file of the table
+	data          *os.File // File descriptor for the data file of the table
+	size          uint64   // Number of bytes stored in the data file
+
+	lock sync.RWMutex // Mutex protecting the data file descriptors
+}
+
+// newTable opens a freezer table, creating the data and index files if they
+// are non existent. Both files are truncated to the shortest common length to
+// ensure they don't go out of sync. The data file of a snappy compressed table
+// is suffixed .cdat, the one of a raw table .rdat.
+func newTable(path string, name string, disableSnappy bool) (*freezerTable, error) {
+	ext := "cdat"
+	if disableSnappy {
+		ext = "rdat"
+	}
+	index, err := os.OpenFile(filepath.Join(path, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%s.%s", name, ext)), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	tab := &freezerTable{
+		noCompression: disableSnappy,
+		index:         index,
+		data:          data,
+	}
+	if err := tab.repair(); err != nil {
+		tab.Close()
+		return nil, err
+	}
+	return tab, nil
+}
+
+// repair cross checks the index and data files, truncating them to be in sync
+// with each other after a potential crash or data loss.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	// Drop any partially written index entry
+	items := uint64(stat.Size()) / indexEntrySize
+	if stat, err = t.data.Stat(); err != nil {
+		return err
+	}
+	dataSize := uint64(stat.Size())
+
+	// Drop index entries pointing past the end of the data file
+	for items > 0 {
+		end, err := t.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		if end <= dataSize {
+			dataSize = end
+			break
+		}
+		items--
+	}
+	if items == 0 {
+		dataSize = 0
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(dataSize)); err != nil {
+		return err
+	}
+	t.size = dataSize
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// truncate discards any recent data above the provided threshold number.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if atomic.LoadUint64(&t.items) <= items {
+		return nil
+	}
+	var end uint64
+	if items > 0 {
+		var err error
+		if end, err = t.readOffset(items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.size = end
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// Close closes all opened files.
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	for _, f := range []*os.File{t.index, t.data} {
+		if f == nil {
+			continue
+		}
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.index, t.data = nil, nil
+
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// Append injects a binary blob at the end of the freezer table. The item number
+// is a precautionary parameter to ensure data correctness, but the table will
+// reject already existing data.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.data == nil {
+		return errClosed
+	}
+	if atomic.LoadUint64(&t.items) != item {
+		return errOutOrderInsertion
+	}
+	if !t.noCompression {
+		blob = snappy.Encode(nil, blob)
+	}
+	// Write the data first, so a crash in between leaves a dangling blob that
+	// the repair on the next open discards
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	t.size += uint64(len(blob))
+
+	var entry [indexEntrySize]byte
+	binary.BigEndian.PutUint64(entry[:], t.size)
+	if _, err := t.index.WriteAt(entry[:], int64(item*indexEntrySize)); err != nil {
+		return err
+	}
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve looks up the data offset of an item with the given number and
+// retrieves the raw binary blob from the data file.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil || t.data == nil {
+		return nil, errClosed
+	}
+	if atomic.LoadUint64(&t.items) <= item {
+		return nil, errOutOfBounds
+	}
+	var start uint64
+	if item > 0 {
+		var err error
+		if start, err = t.readOffset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.readOffset(item)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	if t.noCompression {
+		return blob, nil
+	}
+	return snappy.Decode(nil, blob)
+}
+
+// has returns an indicator whether the specified number data exists in the
+// freezer table.
+func (t *freezerTable) has(number uint64) bool {
+	return atomic.LoadUint64(&t.items) > number
+}
+
+// Sync pushes any pending data from memory out to disk. This is an expensive
+// operation, so use it with care.
+func (t *freezerTable) Sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.data == nil {
+		return errClosed
+	}
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// readOffset reads the end offset of an item from the index file.
+func (t *freezerTable) readOffset(item uint64) (uint64, error) {
+	var entry [indexEntrySize]byte
+	if _, err := t.index.ReadAt(entry[:], int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(entry[:]), nil
+}
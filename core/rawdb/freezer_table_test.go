@@ -0,0 +1,119 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// getChunk returns a chunk of data of the given size, filled with b.
+func getChunk(size int, b int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(b)
+	}
+	return data
+}
+
+// Tests that items can be appended to a table and read back, also after the
+// table has been reopened.
+func TestFreezerTableRoundtrip(t *testing.T) {
+	for _, noSnappy := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "freezer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		name := fmt.Sprintf("roundtrip-%v", noSnappy)
+		tab, err := newTable(dir, name, noSnappy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 255; i++ {
+			if err := tab.Append(uint64(i), getChunk(15, i)); err != nil {
+				t.Fatalf("failed to append item %d: %v", i, err)
+			}
+		}
+		tab.Close()
+
+		if tab, err = newTable(dir, name, noSnappy); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 255; i++ {
+			got, err := tab.Retrieve(uint64(i))
+			if err != nil {
+				t.Fatalf("failed to retrieve item %d: %v", i, err)
+			}
+			if want := getChunk(15, i); !bytes.Equal(got, want) {
+				t.Fatalf("item %d mismatch: have %x, want %x", i, got, want)
+			}
+		}
+		if _, err := tab.Retrieve(255); err != errOutOfBounds {
+			t.Fatalf("out of bounds retrieval error mismatch: have %v, want %v", err, errOutOfBounds)
+		}
+		if err := tab.Append(300, getChunk(15, 0)); err != errOutOrderInsertion {
+			t.Fatalf("out of order insertion error mismatch: have %v, want %v", err, errOutOrderInsertion)
+		}
+		tab.Close()
+	}
+}
+
+// Tests that a table whose data file lost some of its tail, e.g. due to a crash
+// between the data and index writes, is repaired on open.
+func TestFreezerTableRepairDanglingIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tab, err := newTable(dir, "dangling", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := tab.Append(uint64(i), getChunk(20, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tab.Close()
+
+	// Cut the data file in the middle of the 8th item
+	if err := os.Truncate(filepath.Join(dir, "dangling.rdat"), 7*20+5); err != nil {
+		t.Fatal(err)
+	}
+	if tab, err = newTable(dir, "dangling", true); err != nil {
+		t.Fatal(err)
+	}
+	defer tab.Close()
+
+	if tab.has(7) || !tab.has(6) {
+		t.Fatalf("repaired table item count mismatch: have %d, want %d", tab.items, 7)
+	}
+	if err := tab.Append(7, getChunk(20, 0xff)); err != nil {
+		t.Fatalf("failed to append after repair: %v", err)
+	}
+	if got, _ := tab.Retrieve(7); !bytes.Equal(got, getChunk(20, 0xff)) {
+		t.Fatalf("item mismatch after repair: have %x", got)
+	}
+}
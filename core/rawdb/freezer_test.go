@@ -0,0 +1,197 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// writeFreezerTestChain writes a canonical chain of blocks, three per round,
+// along with their receipts, total difficulties, governance states and core
+// blocks into the database.
+func writeFreezerTestChain(t *testing.T, db ethdb.Database, n int) []*types.Block {
+	var blocks []*types.Block
+	for i := 0; i < n; i++ {
+		core := &coreTypes.Block{Hash: coreCommon.Hash{byte(i + 1)}, Payload: []byte{byte(i)}}
+		meta, err := rlp.EncodeToBytes(core)
+		if err != nil {
+			t.Fatalf("failed to encode core block: %v", err)
+		}
+		header := &types.Header{
+			Number:     big.NewInt(int64(i)),
+			Round:      uint64(i / 3),
+			Extra:      []byte("freezer test"),
+			DexconMeta: meta,
+		}
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		block := types.NewBlockWithHeader(header)
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i), Logs: []*types.Log{}}
+
+		WriteBlock(db, block)
+		WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		WriteReceipts(db, block.Hash(), block.NumberU64(), types.Receipts{receipt})
+		WriteTd(db, block.Hash(), block.NumberU64(), big.NewInt(int64(i+1)))
+		WriteGovState(db, block.Hash(), &types.GovState{BlockHash: block.Hash(), Number: block.Number(), Root: common.Hash{byte(i)}})
+		WriteCoreBlock(db, common.Hash(core.Hash), core)
+
+		blocks = append(blocks, block)
+	}
+	WriteHeadBlockHash(db, blocks[n-1].Hash())
+	return blocks
+}
+
+// Tests that the freezer moves the blocks of old rounds into the ancient store,
+// wiping all but the genesis from the key-value store, and that the accessors
+// fall back to the ancient store for them.
+func TestFreezerFreezeAndFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb := ethdb.NewMemDatabase()
+	blocks := writeFreezerTestChain(t, kvdb, 9)
+
+	// Keep one round besides the head round, freezing round 0 (blocks 0-2)
+	db, err := NewDatabaseWithFreezer(kvdb, dir, 1)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	defer db.Close()
+
+	adb := db.(ethdb.AncientReader)
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if frozen, _ := adb.Ancients(); frozen == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			frozen, _ := adb.Ancients()
+			t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, 3)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+
+		// Frozen blocks besides the genesis must be gone from the key-value store
+		wiped := i > 0 && i < 3
+		if has := HasHeader(kvdb, hash, number); has == wiped {
+			t.Errorf("block %d: header presence in key-value store mismatch: have %v, want %v", i, has, !wiped)
+		}
+		if has := HasBody(kvdb, hash, number); has == wiped {
+			t.Errorf("block %d: body presence in key-value store mismatch: have %v, want %v", i, has, !wiped)
+		}
+		// All blocks must be retrievable through the freezer database
+		if have := ReadCanonicalHash(db, number); have != hash {
+			t.Errorf("block %d: canonical hash mismatch: have %x, want %x", i, have, hash)
+		}
+		if header := ReadHeader(db, hash, number); header == nil || header.Hash() != hash {
+			t.Errorf("block %d: header mismatch: have %v", i, header)
+		}
+		if body := ReadBody(db, hash, number); body == nil {
+			t.Errorf("block %d: body missing", i)
+		}
+		if receipts := ReadReceipts(db, hash, number); len(receipts) != 1 || receipts[0].CumulativeGasUsed != uint64(i) {
+			t.Errorf("block %d: receipts mismatch: have %v", i, receipts)
+		}
+		if td := ReadTd(db, hash, number); td == nil || td.Int64() != int64(i+1) {
+			t.Errorf("block %d: total difficulty mismatch: have %v, want %d", i, td, i+1)
+		}
+		if gs := ReadGovState(db, hash); gs == nil || gs.Root != (common.Hash{byte(i)}) {
+			t.Errorf("block %d: governance state mismatch: have %v", i, gs)
+		}
+		if core := ReadCoreBlock(db, common.Hash{byte(i + 1)}); core == nil || core.Payload[0] != byte(i) {
+			t.Errorf("block %d: core block mismatch: have %v", i, core)
+		}
+		// Frozen data must not be served for a block of the same number but a different hash
+		if header := ReadHeader(db, common.Hash{0xff}, number); header != nil {
+			t.Errorf("block %d: header returned for foreign hash", i)
+		}
+	}
+}
+
+// Tests that opening a disabled freezer doesn't create its directory, but an
+// existing ancient store is still read from.
+func TestFreezerLazyCreation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb := ethdb.NewMemDatabase()
+	blocks := writeFreezerTestChain(t, kvdb, 9)
+
+	path := filepath.Join(dir, "ancient")
+	db, err := NewDatabaseWithFreezer(kvdb, path, 0)
+	if err != nil {
+		t.Fatalf("failed to open disabled freezer: %v", err)
+	}
+	if _, ok := db.(ethdb.AncientReader); ok {
+		t.Fatalf("missing ancient store opened by a disabled freezer")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("disabled freezer created its directory: %v", err)
+	}
+	// Freeze the first round, then reopen the ancient store with freezing disabled
+	f, err := newFreezer(path, 1)
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	f.wg.Add(1)
+	go f.freeze(kvdb)
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if frozen, _ := f.Ancients(); frozen == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("blocks not frozen")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := f.close(); err != nil {
+		t.Fatalf("failed to close freezer: %v", err)
+	}
+	if db, err = NewDatabaseWithFreezer(kvdb, path, 0); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer db.Close()
+
+	hash := blocks[1].Hash()
+	if HasHeader(kvdb, hash, 1) {
+		t.Fatalf("frozen header left in key-value store")
+	}
+	if header := ReadHeader(db, hash, 1); header == nil || header.Hash() != hash {
+		t.Fatalf("frozen header not read from reopened ancient store: %v", header)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// inspectStat accumulates the number and size of a category of entries.
+type inspectStat struct {
+	count int
+	size  common.StorageSize
+}
+
+func (s *inspectStat) add(size int) {
+	s.count++
+	s.size += common.StorageSize(size)
+}
+
+// The categories of entries reported by InspectDatabase, in display order.
+const (
+	inspectHeaders = iota
+	inspectTds
+	inspectCanonicalHashes
+	inspectHeaderNumbers
+	inspectBodies
+	inspectReceipts
+	inspectTxLookups
+	inspectBloomBits
+	inspectGovStates
+	inspectRoundIndex
+	inspectCoreBlocks
+	inspectCoreBlockNumbers
+	inspectDKGKeys
+	inspectCoreMetadata
+	inspectPreimages
+	inspectTrieNodes
+	inspectChainConfigs
+	inspectChainIndexes
+	inspectMetadata
+	inspectUnaccounted
+	inspectCategories
+)
+
+var inspectNames = [inspectCategories]string{
+	"Headers",
+	"Total difficulties",
+	"Canonical hashes",
+	"Header number index",
+	"Bodies",
+	"Receipts",
+	"Transaction lookups",
+	"Bloom bits",
+	"Governance states",
+	"Round index",
+	"Core blocks",
+	"Core block number index",
+	"DKG private keys",
+	"Core metadata",
+	"Preimages",
+	"Trie nodes and codes",
+	"Chain configs",
+	"Chain index progress",
+	"Head metadata",
+	"Unaccounted",
+}
+
+// The kinds of dangling entries detected by InspectDatabase, in display order.
+const (
+	danglingReceipts = iota
+	danglingBodies
+	danglingGovStates
+	danglingTxLookups
+	danglingKinds
+)
+
+var danglingNames = [danglingKinds]string{
+	"Receipts without body",
+	"Bodies without header",
+	"Governance states of non-canonical blocks",
+	"Transaction lookups into non-canonical blocks",
+}
+
+// chainIndexPrefixes are the tables chain indexers track their progress in. The
+// ones of the LES indexers are spelled out, light depending on this package.
+var chainIndexPrefixes = [][]byte{
+	BloomBitsIndexPrefix,
+	[]byte("chtIndex-"),
+	[]byte("bltIndex-"),
+}
+
+// isChainIndexKey reports whether the key is in the table of a chain indexer.
+func isChainIndexKey(key []byte) bool {
+	for _, prefix := range chainIndexPrefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectResult is the outcome of walking a key-value store.
+type inspectResult struct {
+	stats    [inspectCategories]inspectStat
+	dangling [danglingKinds]int
+	total    common.StorageSize
+	count    int
+}
+
+// InspectDatabase walks the entire key-value store backing a database and
+// prints the number and size of the entries of every schema category, along
+// with the number of dangling entries left behind by crashes or bugs.
+func InspectDatabase(db ethdb.Database) error {
+	res, err := inspectDatabase(db)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Category", "Entries", "Size"})
+	table.SetFooter([]string{"Total", fmt.Sprint(res.count), res.total.String()})
+	for i, stat := range res.stats {
+		table.Append([]string{inspectNames[i], fmt.Sprint(stat.count), stat.size.String()})
+	}
+	table.Render()
+
+	if adb, ok := db.(ethdb.AncientReader); ok {
+		if frozen, err := adb.Ancients(); err == nil {
+			fmt.Printf("Ancient store: %d blocks\n", frozen)
+		}
+	}
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Dangling entries", "Count"})
+	for i, n := range res.dangling {
+		table.Append([]string{danglingNames[i], fmt.Sprint(n)})
+	}
+	table.Render()
+	return nil
+}
+
+// inspectDatabase categorizes every entry of the key-value store backing a
+// database, counting the dangling ones.
+func inspectDatabase(db ethdb.Database) (*inspectResult, error) {
+	it, ok := KeyValueStore(db).(ethdb.Iteratee)
+	if !ok {
+		return nil, fmt.Errorf("database does not support iteration")
+	}
+	iter := it.NewIterator()
+	defer iter.Release()
+
+	var (
+		res      = new(inspectResult)
+		stats    = &res.stats
+		dangling = &res.dangling
+		start    = time.Now()
+		logged   = time.Now()
+	)
+	isCanonical := func(hash common.Hash) bool {
+		number := ReadHeaderNumber(db, hash)
+		return number != nil && ReadCanonicalHash(db, *number) == hash
+	}
+	for iter.Next() {
+		var (
+			key  = iter.Key()
+			size = len(key) + len(iter.Value())
+		)
+		res.total += common.StorageSize(size)
+		res.count++
+
+		switch {
+		case bytes.Equal(key, databaseVerisionKey), bytes.Equal(key, headHeaderKey),
+			bytes.Equal(key, headBlockKey), bytes.Equal(key, headFastBlockKey),
+			bytes.Equal(key, fastTrieProgressKey), bytes.Equal(key, snapSyncProgressKey),
+			bytes.Equal(key, roundIndexHeadKey):
+			stats[inspectMetadata].add(size)
+		case bytes.Equal(key, coreCompactionChainTipKey), bytes.Equal(key, coreDKGProtocolKey),
+			bytes.Equal(key, coreDKGKeyEnvelopeKey):
+			stats[inspectCoreMetadata].add(size)
+		case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength:
+			stats[inspectHeaders].add(size)
+		case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+common.HashLength+len(headerTDSuffix) && bytes.HasSuffix(key, headerTDSuffix):
+			stats[inspectTds].add(size)
+		case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+len(headerHashSuffix) && bytes.HasSuffix(key, headerHashSuffix):
+			stats[inspectCanonicalHashes].add(size)
+		case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == len(headerNumberPrefix)+common.HashLength:
+			stats[inspectHeaderNumbers].add(size)
+		case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == len(blockBodyPrefix)+8+common.HashLength:
+			stats[inspectBodies].add(size)
+			number, hash := binary.BigEndian.Uint64(key[1:9]), common.BytesToHash(key[9:])
+			if !HasHeader(db, hash, number) {
+				dangling[danglingBodies]++
+			}
+		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == len(blockReceiptsPrefix)+8+common.HashLength:
+			stats[inspectReceipts].add(size)
+			number, hash := binary.BigEndian.Uint64(key[1:9]), common.BytesToHash(key[9:])
+			if !HasBody(db, hash, number) {
+				dangling[danglingReceipts]++
+			}
+		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == len(txLookupPrefix)+common.HashLength:
+			stats[inspectTxLookups].add(size)
+			var entry TxLookupEntry
+			if err := rlp.DecodeBytes(iter.Value(), &entry); err != nil || !isCanonical(entry.BlockHash) {
+				dangling[danglingTxLookups]++
+			}
+		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == len(bloomBitsPrefix)+10+common.HashLength:
+			stats[inspectBloomBits].add(size)
+		case bytes.HasPrefix(key, govStatePrefix) && len(key) == len(govStatePrefix)+common.HashLength:
+			stats[inspectGovStates].add(size)
+			if !isCanonical(common.BytesToHash(key[1:])) {
+				dangling[danglingGovStates]++
+			}
+		case bytes.HasPrefix(key, roundIndexPrefix) && len(key) == len(roundIndexPrefix)+8+1:
+			stats[inspectRoundIndex].add(size)
+		case bytes.HasPrefix(key, coreDKGPrivateKeyPrefix) && len(key) == len(coreDKGPrivateKeyPrefix)+8:
+			stats[inspectDKGKeys].add(size)
+		case bytes.HasPrefix(key, coreBlockPrefix) && len(key) == len(coreBlockPrefix)+common.HashLength:
+			stats[inspectCoreBlocks].add(size)
+		case bytes.HasPrefix(key, coreBlockNumberPrefix) && len(key) == len(coreBlockNumberPrefix)+common.HashLength:
+			stats[inspectCoreBlockNumbers].add(size)
+		case bytes.HasPrefix(key, preimagePrefix) && len(key) == len(preimagePrefix)+common.HashLength:
+			stats[inspectPreimages].add(size)
+		case bytes.HasPrefix(key, configPrefix) && len(key) == len(configPrefix)+common.HashLength:
+			stats[inspectChainConfigs].add(size)
+		case len(key) == common.HashLength:
+			stats[inspectTrieNodes].add(size)
+		case isChainIndexKey(key):
+			stats[inspectChainIndexes].add(size)
+		default:
+			stats[inspectUnaccounted].add(size)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Inspecting database", "entries", res.count, "size", res.total, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/params"
+)
+
+// Tests that the database inspection sorts entries into the right categories
+// and detects the dangling ones.
+func TestInspectDatabase(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	// Three canonical blocks with a transaction each, along with their metadata
+	var blocks []*types.Block
+	for i := 0; i < 3; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{0x11}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		block := types.NewBlock(&types.Header{Number: big.NewInt(int64(i)), Extra: []byte("inspect")}, []*types.Transaction{tx}, nil, nil)
+
+		WriteBlock(db, block)
+		WriteTd(db, block.Hash(), block.NumberU64(), big.NewInt(int64(i)))
+		WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		WriteReceipts(db, block.Hash(), block.NumberU64(), types.Receipts{})
+		WriteTxLookupEntries(db, block)
+		WriteGovState(db, block.Hash(), &types.GovState{BlockHash: block.Hash(), Number: block.Number()})
+		blocks = append(blocks, block)
+	}
+	WriteHeadBlockHash(db, blocks[2].Hash())
+	WriteRoundHeight(db, 0, 0)
+	WriteRoundLastHeight(db, 0, 2)
+	WriteRoundIndexHead(db, 2)
+
+	// A side block whose body, receipts, governance state and transaction
+	// lookup are all left behind without a canonical header
+	side := types.NewBlock(&types.Header{Number: big.NewInt(2), Extra: []byte("side")},
+		[]*types.Transaction{types.NewTransaction(9, common.Address{0x22}, big.NewInt(1), 21000, big.NewInt(1), nil)}, nil, nil)
+	WriteBody(db, side.Hash(), 2, side.Body())
+	WriteGovState(db, side.Hash(), &types.GovState{BlockHash: side.Hash(), Number: side.Number()})
+	WriteTxLookupEntries(db, side)
+
+	// Receipts without any body at all
+	for i := 0; i < 2; i++ {
+		WriteReceipts(db, common.Hash{byte(i + 1)}, 5, types.Receipts{})
+	}
+	// Assorted other data
+	WritePreimages(db, map[common.Hash][]byte{common.Hash{0x33}: []byte("preimage")})
+	WriteChainConfig(db, blocks[0].Hash(), params.TestChainConfig)
+	db.Put(common.Hash{0x44}.Bytes(), []byte("trie node"))
+	db.Put(common.Hash{'i', 'B'}.Bytes(), []byte("trie node")) // looks like a chain index key
+	db.Put(append([]byte("iB"), []byte("count")...), []byte{0x01})
+	db.Put(append([]byte("chtIndex-"), []byte("count")...), []byte{0x01})
+	db.Put([]byte("unknown"), []byte("junk"))
+
+	// A core block number next to a LES key sharing its first byte
+	WriteCoreBlockNumber(db, common.Hash{0x55}, 7)
+	db.Put(append([]byte("chtRoot-"), make([]byte, 8)...), common.Hash{0x66}.Bytes())
+
+	res, err := inspectDatabase(db)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	counts := map[int]int{
+		inspectHeaders:          3,
+		inspectTds:              3,
+		inspectCanonicalHashes:  3,
+		inspectHeaderNumbers:    3,
+		inspectBodies:           4,
+		inspectReceipts:         5,
+		inspectTxLookups:        4,
+		inspectGovStates:        4,
+		inspectRoundIndex:       2,
+		inspectCoreBlockNumbers: 1,
+		inspectPreimages:        1,
+		inspectTrieNodes:        2,
+		inspectChainConfigs:     1,
+		inspectChainIndexes:     2,
+		inspectMetadata:         2,
+		inspectUnaccounted:      2,
+	}
+	total := 0
+	for i, stat := range res.stats {
+		if stat.count != counts[i] {
+			t.Errorf("%s: entry count mismatch: have %d, want %d", inspectNames[i], stat.count, counts[i])
+		}
+		total += counts[i]
+	}
+	if res.count != total {
+		t.Errorf("total entry count mismatch: have %d, want %d", res.count, total)
+	}
+	dangling := [danglingKinds]int{
+		danglingReceipts:  2,
+		danglingBodies:    1,
+		danglingGovStates: 1,
+		danglingTxLookups: 1,
+	}
+	if res.dangling != dangling {
+		t.Errorf("dangling entries mismatch: have %v, want %v", res.dangling, dangling)
+	}
+}
@@ -41,6 +41,12 @@ var (
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// snapSyncProgressKey tracks the partially retrieved state of an interrupted snap sync.
+	snapSyncProgressKey = []byte("SnapSync")
+
+	// roundIndexHeadKey tracks the number of the latest block in the round index.
+	roundIndexHeadKey = []byte("LastRoundIndexed")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -55,10 +61,21 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	roundIndexPrefix = []byte("R") // roundIndexPrefix + round (uint64 big endian) + suffix -> round index entry
+	roundFirstSuffix = []byte("f") // roundIndexPrefix + round (uint64 big endian) + roundFirstSuffix -> first height
+	roundLastSuffix  = []byte("l") // roundIndexPrefix + round (uint64 big endian) + roundLastSuffix -> last height
+	roundCRSSuffix   = []byte("c") // roundIndexPrefix + round (uint64 big endian) + roundCRSSuffix -> CRS
+
+	// Frozen core blocks are looked up by hash while the ancient store is indexed
+	// by chain height, so the height a core block was delivered in stays in the
+	// key-value store under "cN" (for core number), clear of the LES "cht-",
+	// "chtIndex-" and "chtRoot-" keys.
 	coreBlockPrefix           = []byte("D")
+	coreBlockNumberPrefix     = []byte("cN") // coreBlockNumberPrefix + core block hash -> num (uint64 big endian) of a frozen core block
 	coreDKGPrivateKeyPrefix   = []byte("DPK")
 	coreCompactionChainTipKey = []byte("CoreChainTip")
 	coreDKGProtocolKey        = []byte("CoreDKGProtocol")
+	coreDKGKeyEnvelopeKey     = []byte("CoreDKGKeyEnvelope")
 
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
@@ -124,11 +141,21 @@ func govStateKey(hash common.Hash) []byte {
 	return append(govStatePrefix, hash.Bytes()...)
 }
 
+// roundIndexKey = roundIndexPrefix + round (uint64 big endian) + suffix
+func roundIndexKey(round uint64, suffix []byte) []byte {
+	return append(append(roundIndexPrefix, encodeBlockNumber(round)...), suffix...)
+}
+
 // coreBlockKey = coreBlockPrefix + hash
 func coreBlockKey(hash common.Hash) []byte {
 	return append(coreBlockPrefix, hash.Bytes()...)
 }
 
+// coreBlockNumberKey = coreBlockNumberPrefix + hash
+func coreBlockNumberKey(hash common.Hash) []byte {
+	return append(coreBlockNumberPrefix, hash.Bytes()...)
+}
+
 // coreDKGPrivateKeyKey = coreDKGPrivateKeyPrefix + round
 func coreDKGPrivateKeyKey(round uint64) []byte {
 	ret := make([]byte, len(coreDKGPrivateKeyPrefix)+8)
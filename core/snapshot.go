@@ -0,0 +1,587 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/consensus/dexcon"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/params"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	"github.com/tangerine-network/go-tangerine/trie"
+	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
+	dexCore "github.com/tangerine-network/tangerine-consensus/core"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+)
+
+// snapshotVersion is the version of the snapshot file format.
+const snapshotVersion = 2
+
+// snapshotHeaderBatch is the number of headers verified and inserted at once
+// while importing a snapshot.
+const snapshotHeaderBatch = 2048
+
+// A snapshot file is a gzip compressed stream of RLP encoded items, each
+// tagged with its kind. Items appear in the following order: the meta item,
+// the governance state of the snapshot block, the canonical headers from
+// genesis up to the snapshot block, the snapshot block, every account in trie
+// order each followed by its storage slots, and finally the end item. The
+// first header of every round carries the governance state at that height,
+// which is needed to verify the headers of later rounds and, once imported,
+// to serve the configuration and DKG state of past rounds.
+const (
+	snapshotKindMeta uint8 = iota
+	snapshotKindHeader
+	snapshotKindBlock
+	snapshotKindGovState
+	snapshotKindAccount
+	snapshotKindSlot
+	snapshotKindEnd
+)
+
+var (
+	emptySnapshotRoot = types.EmptyRootHash
+	emptySnapshotCode = crypto.Keccak256(nil)
+)
+
+type snapshotItem struct {
+	Kind uint8
+	Data rlp.RawValue
+}
+
+type snapshotMeta struct {
+	Version uint64
+	Number  uint64
+	Hash    common.Hash
+	Root    common.Hash
+}
+
+// snapshotAccount is an account trie leaf keyed by the hashed address. Code
+// is only carried by the first account using it.
+type snapshotAccount struct {
+	Hash    common.Hash
+	Account rlp.RawValue
+	Code    []byte
+}
+
+// snapshotSlot is a storage trie leaf of the preceding account, keyed by the
+// hashed slot.
+type snapshotSlot struct {
+	Hash  common.Hash
+	Value []byte
+}
+
+type snapshotEnd struct {
+	Accounts uint64
+	Slots    uint64
+}
+
+// snapshotWriter encodes tagged items into a snapshot stream.
+type snapshotWriter struct {
+	w io.Writer
+}
+
+func (sw *snapshotWriter) write(kind uint8, val interface{}) error {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(sw.w, &snapshotItem{Kind: kind, Data: data})
+}
+
+// snapshotReader decodes tagged items from a snapshot stream.
+type snapshotReader struct {
+	stream *rlp.Stream
+}
+
+func (sr *snapshotReader) next() (*snapshotItem, error) {
+	item := new(snapshotItem)
+	if err := sr.stream.Decode(item); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("truncated snapshot: %v", err)
+	}
+	return item, nil
+}
+
+func (sr *snapshotReader) read(kind uint8, val interface{}) error {
+	item, err := sr.next()
+	if err != nil {
+		return err
+	}
+	if item.Kind != kind {
+		return fmt.Errorf("unexpected snapshot item kind %d, want %d", item.Kind, kind)
+	}
+	return rlp.DecodeBytes(item.Data, val)
+}
+
+// ExportSnapshot writes the state of the given canonical block, together with
+// its governance state and the header chain leading to it, into a compressed
+// snapshot. The governance states of all round heights are included along
+// with the headers.
+func ExportSnapshot(bc *BlockChain, w io.Writer, number uint64) error {
+	block := bc.GetBlockByNumber(number)
+	if block == nil {
+		return fmt.Errorf("block #%d not found", number)
+	}
+	if _, err := bc.StateCache().OpenTrie(block.Root()); err != nil {
+		return fmt.Errorf("state of block #%d not available: %v", number, err)
+	}
+	govState, err := bc.GetGovStateByNumber(number)
+	if err != nil {
+		return fmt.Errorf("governance state of block #%d not available: %v", number, err)
+	}
+	log.Info("Exporting snapshot", "number", number, "hash", block.Hash(), "root", block.Root())
+
+	gz := gzip.NewWriter(w)
+	sw := &snapshotWriter{w: gz}
+
+	meta := &snapshotMeta{
+		Version: snapshotVersion,
+		Number:  number,
+		Hash:    block.Hash(),
+		Root:    block.Root(),
+	}
+	if err := sw.write(snapshotKindMeta, meta); err != nil {
+		return err
+	}
+	if err := sw.write(snapshotKindGovState, govState); err != nil {
+		return err
+	}
+	var parent *types.Header
+	for n := uint64(0); n <= number; n++ {
+		header := bc.GetHeaderByNumber(n)
+		if header == nil {
+			return fmt.Errorf("canonical header #%d missing", n)
+		}
+		item := &types.HeaderWithGovState{Header: header}
+		if n > 0 && header.Round != parent.Round {
+			if item.GovState, err = bc.GetGovStateByNumber(n); err != nil {
+				return fmt.Errorf("governance state of round %d at #%d not available: %v", header.Round, n, err)
+			}
+		}
+		if err := sw.write(snapshotKindHeader, item); err != nil {
+			return err
+		}
+		parent = header
+	}
+	if err := sw.write(snapshotKindBlock, block); err != nil {
+		return err
+	}
+	if err := exportSnapshotState(sw, bc.StateCache(), block.Root()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// exportSnapshotState writes every account, contract code and storage slot of
+// the given state, followed by the end item.
+func exportSnapshotState(sw *snapshotWriter, db state.Database, root common.Hash) error {
+	accTrie, err := db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	var (
+		end    snapshotEnd
+		codes  = make(map[common.Hash]struct{})
+		start  = time.Now()
+		logged = time.Now()
+	)
+	it := trie.NewIterator(accTrie.NodeIterator(nil))
+	for it.Next() {
+		var account state.Account
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			return fmt.Errorf("invalid account %x: %v", it.Key, err)
+		}
+		addrHash := common.BytesToHash(it.Key)
+		entry := &snapshotAccount{Hash: addrHash, Account: it.Value}
+
+		codeHash := common.BytesToHash(account.CodeHash)
+		if _, ok := codes[codeHash]; !ok && !bytes.Equal(account.CodeHash, emptySnapshotCode) {
+			code, err := db.ContractCode(addrHash, codeHash)
+			if err != nil {
+				return fmt.Errorf("missing code %x of account %x: %v", codeHash, addrHash, err)
+			}
+			codes[codeHash] = struct{}{}
+			entry.Code = code
+		}
+		if err := sw.write(snapshotKindAccount, entry); err != nil {
+			return err
+		}
+		end.Accounts++
+
+		if account.Root != emptySnapshotRoot {
+			storage, err := db.OpenStorageTrie(addrHash, account.Root)
+			if err != nil {
+				return fmt.Errorf("missing storage of account %x: %v", addrHash, err)
+			}
+			sit := trie.NewIterator(storage.NodeIterator(nil))
+			for sit.Next() {
+				if err := sw.write(snapshotKindSlot, &snapshotSlot{Hash: common.BytesToHash(sit.Key), Value: sit.Value}); err != nil {
+					return err
+				}
+				end.Slots++
+			}
+			if sit.Err != nil {
+				return sit.Err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting snapshot state", "accounts", end.Accounts, "slots", end.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+	log.Info("Exported snapshot state", "accounts", end.Accounts, "slots", end.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+	return sw.write(snapshotKindEnd, &end)
+}
+
+// ImportSnapshot loads a snapshot into a freshly initialized database and
+// makes its block the head of the chain. The header chain must extend the
+// local genesis and is verified against the governance states carried by the
+// snapshot, including the randomness signatures of every round, the same way
+// headers are verified during fast sync. The rebuilt state root and the block
+// body are checked against the snapshot header before the head is moved.
+func ImportSnapshot(db ethdb.Database, r io.Reader) (*types.Block, error) {
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return nil, errors.New("database not initialized with a genesis block")
+	}
+	if head := rawdb.ReadHeadBlockHash(db); head != genesis {
+		return nil, errors.New("database already contains blocks beyond genesis")
+	}
+	config := rawdb.ReadChainConfig(db, genesis)
+	if config == nil {
+		return nil, errors.New("chain config not found")
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	sr := &snapshotReader{stream: rlp.NewStream(gz, 0)}
+
+	var meta snapshotMeta
+	if err := sr.read(snapshotKindMeta, &meta); err != nil {
+		return nil, err
+	}
+	if meta.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", meta.Version)
+	}
+	log.Info("Importing snapshot", "number", meta.Number, "hash", meta.Hash, "root", meta.Root)
+
+	govState := new(types.GovState)
+	if err := sr.read(snapshotKindGovState, govState); err != nil {
+		return nil, err
+	}
+	if govState.BlockHash != meta.Hash || govState.Root != meta.Root || govState.Number == nil || govState.Number.Uint64() != meta.Number {
+		return nil, errors.New("governance state does not belong to the snapshot block")
+	}
+	if err := state.VerifyGovState(govState, vm.GovernanceContractAddress); err != nil {
+		return nil, fmt.Errorf("invalid governance state: %v", err)
+	}
+	header, err := importSnapshotHeaders(sr, db, config, genesis, meta.Number, govState)
+	if err != nil {
+		return nil, err
+	}
+	if header.Hash() != meta.Hash || header.Root != meta.Root {
+		return nil, fmt.Errorf("header chain ends at %x, want %x", header.Hash(), meta.Hash)
+	}
+	block := new(types.Block)
+	if err := sr.read(snapshotKindBlock, block); err != nil {
+		return nil, err
+	}
+	if block.Hash() != meta.Hash {
+		return nil, fmt.Errorf("snapshot block mismatch: have %x, want %x", block.Hash(), meta.Hash)
+	}
+	if hash := types.DeriveSha(block.Transactions()); hash != block.TxHash() {
+		return nil, fmt.Errorf("snapshot block transaction root mismatch: have %x, want %x", hash, block.TxHash())
+	}
+	// Rebuild the state and only move the head once it matches the header
+	if err := importSnapshotState(sr, db, meta.Root); err != nil {
+		return nil, err
+	}
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteTxLookupEntries(db, block)
+	rawdb.WriteGovState(db, block.Hash(), govState)
+
+	if len(block.Header().DexconMeta) > 0 {
+		var dexconMeta coreTypes.Block
+		if err := rlp.DecodeBytes(block.Header().DexconMeta, &dexconMeta); err != nil {
+			return nil, fmt.Errorf("invalid dexcon meta: %v", err)
+		}
+		if err := rawdb.WriteCoreCompactionChainTip(db, coreCommon.Hash(dexconMeta.Hash), block.NumberU64()); err != nil {
+			return nil, err
+		}
+	}
+	rawdb.WriteHeadHeaderHash(db, block.Hash())
+	rawdb.WriteHeadFastBlockHash(db, block.Hash())
+	rawdb.WriteHeadBlockHash(db, block.Hash())
+	return block, nil
+}
+
+// snapshotGovStateDB serves the governance states carried by a snapshot to
+// verify its header chain, much like the governance used during fast sync.
+// The states are written into the target database as they arrive.
+type snapshotGovStateDB struct {
+	db    ethdb.Database
+	head  common.Hash
+	roots map[uint64]common.Hash
+}
+
+func (g *snapshotGovStateDB) State() (*state.StateDB, error) {
+	return state.New(g.head, state.NewDatabase(g.db))
+}
+
+func (g *snapshotGovStateDB) StateAt(height uint64) (*state.StateDB, error) {
+	root, ok := g.roots[height]
+	if !ok {
+		return nil, fmt.Errorf("governance state at #%d not in snapshot", height)
+	}
+	return state.New(root, state.NewDatabase(g.db))
+}
+
+func (g *snapshotGovStateDB) store(s *types.GovState) error {
+	if err := writeGovStateTrie(g.db, s); err != nil {
+		return err
+	}
+	g.roots[s.Number.Uint64()] = s.Root
+	return nil
+}
+
+// importSnapshotHeaders verifies and inserts the header chain of a snapshot in
+// batches, returning its last header. The governance state of the snapshot
+// block serves as the governance head, the states of the round heights are
+// checked against their headers and the head before each batch is verified.
+func importSnapshotHeaders(sr *snapshotReader, db ethdb.Database, config *params.ChainConfig,
+	genesis common.Hash, number uint64, head *types.GovState) (*types.Header, error) {
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, config, dexcon.New(), vm.Config{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+
+	govDB := &snapshotGovStateDB{
+		db:    db,
+		head:  head.Root,
+		roots: map[uint64]common.Hash{0: chain.Genesis().Root()},
+	}
+	if err := govDB.store(head); err != nil {
+		return nil, err
+	}
+	var (
+		gov           = NewGovernance(govDB)
+		verifierCache = dexCore.NewTSigVerifierCache(gov, 5)
+		chunk         []*types.HeaderWithGovState
+		parent        *types.Header
+	)
+	insert := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if n, err := chain.insertSnapshotHeaderChain(chunk, gov, verifierCache); err != nil {
+			return fmt.Errorf("invalid header #%d: %v", chunk[n].Number, err)
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+	for n := uint64(0); n <= number; n++ {
+		header := new(types.HeaderWithGovState)
+		if err := sr.read(snapshotKindHeader, header); err != nil {
+			return nil, err
+		}
+		hash := header.Hash()
+		switch {
+		case header.Number.Uint64() != n:
+			return nil, fmt.Errorf("header #%d out of order, want #%d", header.Number, n)
+		case n == 0 && hash != genesis:
+			return nil, fmt.Errorf("genesis mismatch: have %x, want %x", hash, genesis)
+		case n > 0 && header.ParentHash != parent.Hash():
+			return nil, fmt.Errorf("header #%d not linked to its parent", n)
+		}
+		if n > 0 && header.Round != parent.Round {
+			if height := gov.GetRoundHeight(header.Round); height != n {
+				return nil, fmt.Errorf("round %d starts at #%d, governance has #%d", header.Round, n, height)
+			}
+			s := header.GovState
+			if s == nil {
+				return nil, fmt.Errorf("missing governance state of round %d at #%d", header.Round, n)
+			}
+			if s.BlockHash != hash || s.Root != header.Root || s.Number == nil || s.Number.Uint64() != n {
+				return nil, fmt.Errorf("governance state does not belong to header #%d", n)
+			}
+			if err := state.VerifyGovState(s, vm.GovernanceContractAddress); err != nil {
+				return nil, fmt.Errorf("invalid governance state at #%d: %v", n, err)
+			}
+			if err := govDB.store(s); err != nil {
+				return nil, err
+			}
+		} else if header.GovState != nil {
+			return nil, fmt.Errorf("unexpected governance state at #%d", n)
+		}
+		if n > 0 {
+			chunk = append(chunk, header)
+		}
+		if len(chunk) >= snapshotHeaderBatch {
+			if err := insert(); err != nil {
+				return nil, err
+			}
+		}
+		parent = header.Header
+	}
+	if err := insert(); err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// importSnapshotState rebuilds the account and storage tries from the state
+// items of a snapshot and writes them to disk, failing if any storage root or
+// the state root does not match. Storage tries are flushed as soon as their
+// account is complete, so only the account trie is held in memory.
+func importSnapshotState(sr *snapshotReader, db ethdb.Database, root common.Hash) error {
+	triedb := trie.NewDatabase(db)
+	accTrie, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return err
+	}
+	var (
+		count   snapshotEnd
+		current *snapshotAccount
+		account state.Account
+		storage *trie.Trie
+		start   = time.Now()
+		logged  = time.Now()
+	)
+	// flush completes the pending account by checking its storage root and
+	// writing the storage trie to disk.
+	flush := func() error {
+		if storage == nil {
+			return nil
+		}
+		hash, err := storage.Commit(nil)
+		if err != nil {
+			return err
+		}
+		if hash != account.Root {
+			return fmt.Errorf("storage root mismatch for account %x: have %x, want %x", current.Hash, hash, account.Root)
+		}
+		storage = nil
+		return triedb.Commit(hash, false)
+	}
+	for {
+		item, err := sr.next()
+		if err != nil {
+			return err
+		}
+		switch item.Kind {
+		case snapshotKindAccount:
+			if err := flush(); err != nil {
+				return err
+			}
+			current = new(snapshotAccount)
+			if err := rlp.DecodeBytes(item.Data, current); err != nil {
+				return err
+			}
+			if err := rlp.DecodeBytes(current.Account, &account); err != nil {
+				return fmt.Errorf("invalid account %x: %v", current.Hash, err)
+			}
+			codeHash := account.CodeHash
+			if len(current.Code) > 0 {
+				if !bytes.Equal(crypto.Keccak256(current.Code), codeHash) {
+					return fmt.Errorf("code hash mismatch for account %x", current.Hash)
+				}
+				if err := db.Put(codeHash, current.Code); err != nil {
+					return err
+				}
+			} else if !bytes.Equal(codeHash, emptySnapshotCode) {
+				if ok, _ := db.Has(codeHash); !ok {
+					return fmt.Errorf("missing code %x of account %x", codeHash, current.Hash)
+				}
+			}
+			if account.Root != emptySnapshotRoot {
+				if storage, err = trie.New(common.Hash{}, triedb); err != nil {
+					return err
+				}
+			}
+			if err := accTrie.TryUpdate(current.Hash[:], current.Account); err != nil {
+				return err
+			}
+			count.Accounts++
+
+		case snapshotKindSlot:
+			if storage == nil {
+				return errors.New("storage slot without a matching account")
+			}
+			var slot snapshotSlot
+			if err := rlp.DecodeBytes(item.Data, &slot); err != nil {
+				return err
+			}
+			if err := storage.TryUpdate(slot.Hash[:], slot.Value); err != nil {
+				return err
+			}
+			count.Slots++
+
+		case snapshotKindEnd:
+			if err := flush(); err != nil {
+				return err
+			}
+			var end snapshotEnd
+			if err := rlp.DecodeBytes(item.Data, &end); err != nil {
+				return err
+			}
+			if end != count {
+				return fmt.Errorf("snapshot item count mismatch: have %d accounts and %d slots, want %d and %d", count.Accounts, count.Slots, end.Accounts, end.Slots)
+			}
+			hash, err := accTrie.Commit(nil)
+			if err != nil {
+				return err
+			}
+			if hash != root {
+				return fmt.Errorf("state root mismatch: have %x, want %x", hash, root)
+			}
+			if err := triedb.Commit(hash, false); err != nil {
+				return err
+			}
+			log.Info("Imported snapshot state", "accounts", count.Accounts, "slots", count.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			return nil
+
+		default:
+			return fmt.Errorf("unexpected snapshot item kind %d", item.Kind)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Importing snapshot state", "accounts", count.Accounts, "slots", count.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+}
@@ -0,0 +1,426 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	dexCore "github.com/tangerine-network/tangerine-consensus/core"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+	coreUtils "github.com/tangerine-network/tangerine-consensus/core/utils"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/consensus"
+	"github.com/tangerine-network/go-tangerine/consensus/dexcon"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/params"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// snapshotTestEngine is a fake dexcon engine producing headers which pass the
+// dexcon header verification: the dexcon meta matches the header and its
+// randomness is signed by the DKG set of the round.
+type snapshotTestEngine struct {
+	*dexcon.FakeDexcon
+	nodes *dexcon.NodeSet
+}
+
+func (e *snapshotTestEngine) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	var coreBlock coreTypes.Block
+	if err := rlp.DecodeBytes(header.DexconMeta, &coreBlock); err != nil {
+		return err
+	}
+	coreBlock.Timestamp = time.Unix(0, int64(header.Time)*int64(time.Millisecond))
+	hash, err := coreUtils.HashBlock(&coreBlock)
+	if err != nil {
+		return err
+	}
+	coreBlock.Hash = hash
+	coreBlock.Randomness = e.nodes.Randomness(header.Round, common.Hash(hash))
+	header.Randomness = coreBlock.Randomness
+
+	header.DexconMeta, err = rlp.EncodeToBytes(&coreBlock)
+	return err
+}
+
+// snapshotTestFetcher serves the genesis governance state to the engine.
+type snapshotTestFetcher struct {
+	db   state.Database
+	root common.Hash
+}
+
+func (f *snapshotTestFetcher) GetConfigState(round uint64) (*vm.GovernanceState, error) {
+	s, err := state.New(f.root, f.db)
+	if err != nil {
+		return nil, err
+	}
+	return &vm.GovernanceState{StateDB: s}, nil
+}
+
+func (f *snapshotTestFetcher) DKGSetNodeKeyAddresses(round uint64) (map[common.Address]struct{}, error) {
+	return make(map[common.Address]struct{}), nil
+}
+
+const snapshotTestRoundLength = 10
+
+// newSnapshotTestChain creates an archive chain of the given length, running
+// the DKG of every next round within the current one so that the headers of
+// all rounds carry valid randomness.
+func newSnapshotTestChain(t *testing.T, n int) (*Genesis, *BlockChain, *dexcon.NodeSet) {
+	var (
+		db    = ethdb.NewMemDatabase()
+		keys  []*ecdsa.PrivateKey
+		ether = big.NewInt(1e18)
+		gspec = &Genesis{Config: params.TestnetChainConfig, Alloc: GenesisAlloc{}}
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		gspec.Alloc[crypto.PubkeyToAddress(key.PublicKey)] = GenesisAccount{
+			Balance:   new(big.Int).Mul(big.NewInt(2e6), ether),
+			Staked:    new(big.Int).Mul(big.NewInt(1e6), ether),
+			PublicKey: crypto.FromECDSAPub(&key.PublicKey),
+		}
+	}
+	genesis := gspec.MustCommit(db)
+
+	signer := types.NewEIP155Signer(gspec.Config.ChainID)
+	nodes := dexcon.NewNodeSet(0, []byte(gspec.Config.Dexcon.GenesisCRSText), signer, keys)
+	engine := &snapshotTestEngine{FakeDexcon: dexcon.NewFaker(nodes), nodes: nodes}
+	engine.SetGovStateFetcher(&snapshotTestFetcher{db: state.NewDatabase(db), root: genesis.Root()})
+
+	round := uint64(0)
+	addTx := func(b *TangerineBlockGen, node *dexcon.Node, data []byte, err error) {
+		if err != nil {
+			t.Fatalf("failed to pack governance call: %v", err)
+		}
+		b.AddTx(node.CreateGovTx(b.TxNonce(node.Address()), data))
+	}
+	blocks, receipts := GenerateTangerineChain(gspec.Config, genesis, engine, db, n, func(i int, b *TangerineBlockGen) {
+		b.header.Difficulty = big.NewInt(1)
+		b.SetPosition(coreTypes.Position{Round: round, Height: uint64(i + 1)})
+
+		half := snapshotTestRoundLength / 2
+		switch i % snapshotTestRoundLength {
+		case half:
+			// The CRS of the rounds up to the DKG delay is derived, not proposed
+			nodes.SignCRS(round)
+			if round >= dexCore.DKGDelayRound {
+				data, err := vm.PackProposeCRS(round+1, nodes.SignedCRS(round+1))
+				addTx(b, nodes.Nodes(round)[0], data, err)
+			}
+		case half + 1:
+			nodes.RunDKG(round+1, 2)
+			for _, node := range nodes.Nodes(round + 1) {
+				data, err := vm.PackAddDKGMasterPublicKey(node.MasterPublicKey(round + 1))
+				addTx(b, node, data, err)
+			}
+		case half + 2:
+			for _, node := range nodes.Nodes(round + 1) {
+				data, err := vm.PackAddDKGMPKReady(node.DKGMPKReady(round + 1))
+				addTx(b, node, data, err)
+			}
+		case half + 3:
+			for _, node := range nodes.Nodes(round + 1) {
+				data, err := vm.PackAddDKGFinalize(node.DKGFinalize(round + 1))
+				addTx(b, node, data, err)
+			}
+		case snapshotTestRoundLength - 1:
+			round++
+		}
+	})
+	td := new(big.Int).Set(genesis.Difficulty())
+	for i, block := range blocks {
+		td.Add(td, block.Difficulty())
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		rawdb.WriteTd(db, block.Hash(), block.NumberU64(), td)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	}
+	head := blocks[len(blocks)-1].Hash()
+	rawdb.WriteHeadHeaderHash(db, head)
+	rawdb.WriteHeadFastBlockHash(db, head)
+	rawdb.WriteHeadBlockHash(db, head)
+
+	chain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, gspec.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return gspec, chain, nodes
+}
+
+// decodeSnapshot splits a snapshot into its items.
+func decodeSnapshot(t *testing.T, blob []byte) []*snapshotItem {
+	gz, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	stream := rlp.NewStream(gz, 0)
+
+	var items []*snapshotItem
+	for {
+		item := new(snapshotItem)
+		if err := stream.Decode(item); err == io.EOF {
+			return items
+		} else if err != nil {
+			t.Fatalf("failed to decode snapshot item: %v", err)
+		}
+		items = append(items, item)
+	}
+}
+
+// encodeSnapshot assembles a snapshot from its items.
+func encodeSnapshot(t *testing.T, items []*snapshotItem) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	for _, item := range items {
+		if err := rlp.Encode(gz, item); err != nil {
+			t.Fatalf("failed to encode snapshot item: %v", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close snapshot: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// updateSnapshotItem decodes the data of an item, lets it be modified and
+// encodes it back.
+func updateSnapshotItem(t *testing.T, item *snapshotItem, val interface{}, update func()) {
+	if err := rlp.DecodeBytes(item.Data, val); err != nil {
+		t.Fatalf("failed to decode snapshot item: %v", err)
+	}
+	update()
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatalf("failed to encode snapshot item: %v", err)
+	}
+	item.Data = data
+}
+
+// Tests that a snapshot imports into a fresh database, carrying the governance
+// states of all rounds needed to serve their configurations.
+func TestSnapshotRoundTrip(t *testing.T) {
+	gspec, chain, _ := newSnapshotTestChain(t, 25)
+	defer chain.Stop()
+
+	buf := new(bytes.Buffer)
+	if err := ExportSnapshot(chain, buf, 25); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+
+	head := chain.CurrentBlock()
+	block, err := ImportSnapshot(db, buf)
+	if err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	if block.Hash() != head.Hash() {
+		t.Fatalf("imported block mismatch: have %x, want %x", block.Hash(), head.Hash())
+	}
+	imported, err := NewBlockChain(db, nil, gspec.Config, dexcon.New(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to open imported chain: %v", err)
+	}
+	defer imported.Stop()
+
+	if have := imported.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("imported head mismatch: have %x, want %x", have, head.Hash())
+	}
+	for _, number := range []uint64{11, 21} {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if rawdb.ReadGovState(db, hash) == nil {
+			t.Errorf("governance state of round height #%d not imported", number)
+		}
+	}
+	var (
+		want = NewGovernance(NewGovernanceStateDB(chain))
+		have = NewGovernance(NewGovernanceStateDB(imported))
+	)
+	for round := uint64(0); round <= 2; round++ {
+		if have.GetRoundHeight(round) != want.GetRoundHeight(round) {
+			t.Errorf("round %d: height mismatch: have %d, want %d", round, have.GetRoundHeight(round), want.GetRoundHeight(round))
+		}
+		if _, err := have.GetConfigState(round); err != nil {
+			t.Errorf("round %d: config state not available: %v", round, err)
+		}
+		if _, err := have.GetStateForDKGAtRound(round); err != nil {
+			t.Errorf("round %d: DKG state not available: %v", round, err)
+		}
+		if have.CRS(round) != want.CRS(round) {
+			t.Errorf("round %d: CRS mismatch: have %x, want %x", round, have.CRS(round), want.CRS(round))
+		}
+	}
+}
+
+// Tests that importing a snapshot fails if its header chain or the governance
+// states needed to verify it have been tampered with.
+func TestSnapshotTamper(t *testing.T) {
+	gspec, chain, nodes := newSnapshotTestChain(t, 25)
+	defer chain.Stop()
+
+	buf := new(bytes.Buffer)
+	if err := ExportSnapshot(chain, buf, 25); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	blob := buf.Bytes()
+
+	// Items are the meta, the head gov state and then the headers from genesis
+	const headers = 2
+	tests := []struct {
+		name   string
+		tamper func(items []*snapshotItem)
+		err    string
+	}{
+		{
+			name: "unlinked header",
+			tamper: func(items []*snapshotItem) {
+				header := new(types.HeaderWithGovState)
+				updateSnapshotItem(t, items[headers+5], header, func() { header.Extra = []byte("forged") })
+			},
+			err: "not linked",
+		},
+		{
+			name: "missing round governance state",
+			tamper: func(items []*snapshotItem) {
+				header := new(types.HeaderWithGovState)
+				updateSnapshotItem(t, items[headers+11], header, func() { header.GovState = nil })
+			},
+			err: "missing governance state",
+		},
+		{
+			name: "forged round governance state",
+			tamper: func(items []*snapshotItem) {
+				header := new(types.HeaderWithGovState)
+				updateSnapshotItem(t, items[headers+21], header, func() {
+					// Storage is unordered, make sure the value really changes
+					header.GovState.Storage[0][1] = append([]byte{0xff}, header.GovState.Storage[0][1]...)
+				})
+			},
+			err: "invalid governance state",
+		},
+		{
+			name: "forged randomness",
+			tamper: func(items []*snapshotItem) {
+				// Sign a different hash with the right DKG set, then fix up every
+				// item referencing the snapshot block hash.
+				header := new(types.HeaderWithGovState)
+				updateSnapshotItem(t, items[headers+25], header, func() {
+					var coreBlock coreTypes.Block
+					if err := rlp.DecodeBytes(header.DexconMeta, &coreBlock); err != nil {
+						t.Fatalf("failed to decode dexcon meta: %v", err)
+					}
+					coreBlock.Randomness = nodes.Randomness(header.Round, common.Hash{0x01})
+					header.Randomness = coreBlock.Randomness
+					header.DexconMeta, _ = rlp.EncodeToBytes(&coreBlock)
+				})
+				hash := header.Hash()
+
+				meta := new(snapshotMeta)
+				updateSnapshotItem(t, items[0], meta, func() { meta.Hash = hash })
+				govState := new(types.GovState)
+				updateSnapshotItem(t, items[1], govState, func() { govState.BlockHash = hash })
+				block := new(types.Block)
+				updateSnapshotItem(t, items[headers+26], block, func() {
+					*block = *types.NewBlockWithHeader(header.Header).WithBody(block.Transactions(), block.Uncles())
+				})
+			},
+			err: "signature invalid",
+		},
+	}
+	for _, tt := range tests {
+		items := decodeSnapshot(t, blob)
+		tt.tamper(items)
+
+		db := ethdb.NewMemDatabase()
+		gspec.MustCommit(db)
+		_, err := ImportSnapshot(db, bytes.NewReader(encodeSnapshot(t, items)))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: import error mismatch: have %v, want %q", tt.name, err, tt.err)
+		}
+		if head := rawdb.ReadHeadBlockHash(db); head != gspec.ToBlock(nil).Hash() {
+			t.Errorf("%s: head moved to %x", tt.name, head)
+		}
+	}
+	// The untampered snapshot still imports
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	if _, err := ImportSnapshot(db, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+}
+
+// Tests that headers failing the threshold signature verification are only
+// rejected on snapshot import, the other header paths keep logging them.
+func TestSnapshotStrictTSig(t *testing.T) {
+	gspec, chain, nodes := newSnapshotTestChain(t, 25)
+	defer chain.Stop()
+
+	var headers []*types.HeaderWithGovState
+	for n := uint64(1); n <= 25; n++ {
+		headers = append(headers, &types.HeaderWithGovState{Header: chain.GetHeaderByNumber(n)})
+	}
+	// Sign a different hash with the right DKG set for the last header
+	last := types.CopyHeader(headers[len(headers)-1].Header)
+	var coreBlock coreTypes.Block
+	if err := rlp.DecodeBytes(last.DexconMeta, &coreBlock); err != nil {
+		t.Fatalf("failed to decode dexcon meta: %v", err)
+	}
+	coreBlock.Randomness = nodes.Randomness(last.Round, common.Hash{0x01})
+	last.Randomness = coreBlock.Randomness
+	last.DexconMeta, _ = rlp.EncodeToBytes(&coreBlock)
+	headers[len(headers)-1] = &types.HeaderWithGovState{Header: last}
+
+	for _, strict := range []bool{false, true} {
+		db := ethdb.NewMemDatabase()
+		gspec.MustCommit(db)
+
+		imported, err := NewBlockChain(db, &CacheConfig{Disabled: true}, gspec.Config, dexcon.New(), vm.Config{}, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		var (
+			gov           = NewGovernance(NewGovernanceStateDB(chain))
+			verifierCache = dexCore.NewTSigVerifierCache(gov, 5)
+			insert        = imported.InsertTangerineHeaderChain
+		)
+		if strict {
+			insert = imported.insertSnapshotHeaderChain
+		}
+		n, err := insert(headers, gov, verifierCache)
+		switch {
+		case !strict && err != nil:
+			t.Errorf("header #%d rejected: %v", headers[n].Number, err)
+		case strict && (err == nil || n != len(headers)-1):
+			t.Errorf("strict insert mismatch: have #%d (%v), want #%d rejected", headers[n].Number, err, len(headers))
+		}
+		imported.Stop()
+	}
+}
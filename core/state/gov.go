@@ -1,8 +1,13 @@
 package state
 
 import (
+	"fmt"
+
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/rlp"
 	"github.com/tangerine-network/go-tangerine/trie"
 )
 
@@ -29,3 +34,37 @@ func GetGovState(statedb *StateDB, header *types.Header,
 	}
 	return govState, nil
 }
+
+// VerifyGovState checks that the account proof of a gov state is valid against
+// its state root and that the storage entries rebuild the storage root of the
+// proven account.
+func VerifyGovState(govState *types.GovState, addr common.Address) error {
+	proofDb := ethdb.NewMemDatabase()
+	for _, node := range govState.Proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	blob, _, err := trie.VerifyProof(govState.Root, crypto.Keccak256(addr.Bytes()), proofDb)
+	if err != nil {
+		return fmt.Errorf("invalid account proof: %v", err)
+	}
+	if blob == nil {
+		return fmt.Errorf("account %x not in state", addr)
+	}
+	var account Account
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return fmt.Errorf("invalid account: %v", err)
+	}
+	storage, err := trie.New(common.Hash{}, trie.NewDatabase(ethdb.NewMemDatabase()))
+	if err != nil {
+		return err
+	}
+	for _, entry := range govState.Storage {
+		if err := storage.TryUpdate(entry[0], entry[1]); err != nil {
+			return err
+		}
+	}
+	if root := storage.Hash(); root != account.Root {
+		return fmt.Errorf("storage root mismatch: have %x, want %x", root, account.Root)
+	}
+	return nil
+}
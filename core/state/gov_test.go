@@ -0,0 +1,62 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+)
+
+// Tests that gov states are verified against their state root.
+func TestVerifyGovState(t *testing.T) {
+	var (
+		addr  = common.BytesToAddress([]byte{0xde, 0xad})
+		db    = NewDatabase(ethdb.NewMemDatabase())
+		state *StateDB
+	)
+	state, _ = New(common.Hash{}, db)
+	state.AddBalance(addr, big.NewInt(1))
+	state.AddBalance(common.BytesToAddress([]byte{0x01}), big.NewInt(2))
+	for i := byte(1); i < 32; i++ {
+		state.SetState(addr, common.Hash{i}, common.Hash{i, i})
+	}
+	root, _ := state.Commit(false)
+	state, _ = New(root, db)
+
+	header := &types.Header{Number: big.NewInt(1), Root: root}
+	govState, err := GetGovState(state, header, addr)
+	if err != nil {
+		t.Fatalf("failed to get gov state: %v", err)
+	}
+	if err := VerifyGovState(govState, addr); err != nil {
+		t.Fatalf("valid gov state rejected: %v", err)
+	}
+	// Tampered storage must not rebuild the proven storage root
+	govState.Storage[0][1] = []byte{0x01}
+	if err := VerifyGovState(govState, addr); err == nil {
+		t.Fatalf("tampered storage accepted")
+	}
+	// Nor must a proof against another root
+	govState.Root = common.Hash{0x01}
+	if err := VerifyGovState(govState, addr); err == nil {
+		t.Fatalf("proof against foreign root accepted")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// Pruner removes the trie nodes and contract codes of a database that are not
+// reachable from a set of retained state roots. Blocks are final as soon as
+// they are delivered, so no state besides the retained ones will ever be
+// needed again.
+//
+// Marked hashes are tracked by a bloom filter of fixed size, backed by an exact
+// set kept on disk which is only consulted when the filter reports a hit, so
+// memory use doesn't grow with the size of the live state.
+//
+// Pruning is a mark and sweep over the entire database and must only be run
+// while no one else is writing to it.
+type Pruner struct {
+	db    ethdb.KeyValueStore
+	state Database
+
+	bloom   *markBloom
+	marks   ethdb.Database           // Exact set of marked hashes
+	pending map[common.Hash]struct{} // Marks not yet flushed to the set
+	batch   ethdb.Batch
+	marked  int
+	err     error // Failure to flush the marks, reported by Retain
+}
+
+// NewPruner creates a state pruner on top of the given key-value store, using
+// a bloom filter of bloomSize bytes and keeping the marked hashes in the given
+// scratch database.
+func NewPruner(db ethdb.KeyValueStore, marks ethdb.Database, bloomSize uint64) *Pruner {
+	return &Pruner{
+		db:      db,
+		state:   NewDatabase(db),
+		bloom:   newMarkBloom(bloomSize),
+		marks:   marks,
+		pending: make(map[common.Hash]struct{}),
+		batch:   marks.NewBatch(),
+	}
+}
+
+// Retain marks every trie node and contract code reachable from the given state
+// root, so they survive the next Prune.
+func (p *Pruner) Retain(root common.Hash) error {
+	tr, err := p.state.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = p.mark(it.Hash())
+		if !it.Leaf() {
+			continue
+		}
+		var account Account
+		if err := rlp.Decode(bytes.NewReader(it.LeafBlob()), &account); err != nil {
+			return err
+		}
+		if err := p.retainStorage(common.BytesToHash(it.LeafKey()), account.Root); err != nil {
+			return err
+		}
+		if !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			p.mark(common.BytesToHash(account.CodeHash))
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return p.err
+}
+
+// retainStorage marks every node of a contract storage trie.
+func (p *Pruner) retainStorage(addrHash, root common.Hash) error {
+	if p.isMarked(root) {
+		return nil
+	}
+	tr, err := p.state.OpenStorageTrie(addrHash, root)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = p.mark(it.Hash())
+	}
+	return it.Error()
+}
+
+// mark flags a node as reachable, returning whether it was not yet marked and
+// its children still need to be visited. Embedded nodes without a hash of
+// their own are always visited.
+func (p *Pruner) mark(hash common.Hash) bool {
+	if hash == (common.Hash{}) {
+		return true
+	}
+	if p.isMarked(hash) {
+		return false
+	}
+	p.bloom.add(hash)
+	p.pending[hash] = struct{}{}
+	p.batch.Put(hash.Bytes(), nil)
+	p.marked++
+
+	if p.batch.ValueSize() >= ethdb.IdealBatchSize && p.err == nil {
+		p.err = p.flush()
+	}
+	return true
+}
+
+// isMarked reports whether a hash was marked, only looking it up in the exact
+// set if the bloom filter reports it.
+func (p *Pruner) isMarked(hash common.Hash) bool {
+	if !p.bloom.contains(hash) {
+		return false
+	}
+	if _, ok := p.pending[hash]; ok {
+		return true
+	}
+	ok, _ := p.marks.Has(hash.Bytes())
+	return ok
+}
+
+// flush writes the pending marks into the exact set.
+func (p *Pruner) flush() error {
+	if err := p.batch.Write(); err != nil {
+		return err
+	}
+	p.batch.Reset()
+	p.pending = make(map[common.Hash]struct{})
+	return nil
+}
+
+// Retained returns the number of trie nodes and contract codes marked so far.
+func (p *Pruner) Retained() int {
+	return p.marked
+}
+
+// Prune deletes every trie node and contract code not marked by Retain,
+// returning the number of entries and bytes removed.
+func (p *Pruner) Prune() (int, common.StorageSize, error) {
+	if p.err != nil {
+		return 0, 0, p.err
+	}
+	if err := p.flush(); err != nil {
+		return 0, 0, err
+	}
+	var (
+		deleted int
+		size    common.StorageSize
+		batch   = p.db.NewBatch()
+		it      = p.db.NewIterator()
+	)
+	defer it.Release()
+
+	for it.Next() {
+		// Trie nodes and contract codes are the only entries keyed by bare hashes
+		key := it.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		if p.isMarked(common.BytesToHash(key)) {
+			continue
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return deleted, size, err
+		}
+		deleted++
+		size += common.StorageSize(len(key) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return deleted, size, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return deleted, size, fmt.Errorf("database iteration failed: %v", err)
+	}
+	return deleted, size, batch.Write()
+}
+
+// markBloomHashes is the number of bits set in the filter per marked hash.
+const markBloomHashes = 4
+
+// markBloom is a bloom filter over node hashes. Hashes are uniformly distributed
+// already, so the bits are indexed by slices of the hash itself.
+type markBloom struct {
+	bits []uint64
+}
+
+// newMarkBloom creates a bloom filter of the given size in bytes.
+func newMarkBloom(size uint64) *markBloom {
+	if size < 8 {
+		size = 8
+	}
+	return &markBloom{bits: make([]uint64, size/8)}
+}
+
+// add sets the bits of a hash.
+func (b *markBloom) add(hash common.Hash) {
+	for i := 0; i < markBloomHashes; i++ {
+		bit := b.index(hash, i)
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// contains reports whether all the bits of a hash are set, which might also be
+// the case for a hash never added.
+func (b *markBloom) contains(hash common.Hash) bool {
+	for i := 0; i < markBloomHashes; i++ {
+		bit := b.index(hash, i)
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// index returns the i-th bit position of a hash.
+func (b *markBloom) index(hash common.Hash, i int) uint64 {
+	return binary.BigEndian.Uint64(hash[i*8:]) % (uint64(len(b.bits)) * 64)
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+)
+
+// Tests that pruning keeps the retained states intact and drops the others,
+// whether the bloom filter of the marks is sized properly or saturated.
+func TestPrunerRetainsOnlyMarkedStates(t *testing.T) {
+	testPrunerRetainsOnlyMarkedStates(t, 1024*1024)
+}
+
+func TestPrunerRetainsOnlyMarkedStatesSaturated(t *testing.T) {
+	testPrunerRetainsOnlyMarkedStates(t, 8)
+}
+
+func testPrunerRetainsOnlyMarkedStates(t *testing.T, bloomSize uint64) {
+	dir, err := ioutil.TempDir("", "pruner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskdb, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer diskdb.Close()
+
+	// Write two successive states, the second overwriting most of the first
+	db := NewDatabase(diskdb)
+	state, _ := New(common.Hash{}, db)
+	for i := byte(0); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		state.SetState(addr, common.Hash{i}, common.Hash{i, i})
+		state.SetCode(addr, []byte{i, 1})
+	}
+	stale, _ := state.Commit(false)
+	if err := db.TrieDB().Commit(stale, false); err != nil {
+		t.Fatal(err)
+	}
+	for i := byte(0); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(1))
+		state.SetState(addr, common.Hash{i}, common.Hash{i, i, i})
+		state.SetCode(addr, []byte{i, 2})
+	}
+	root, _ := state.Commit(false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	pruner := NewPruner(diskdb, ethdb.NewMemDatabase(), bloomSize)
+	if err := pruner.Retain(root); err != nil {
+		t.Fatalf("failed to retain state: %v", err)
+	}
+	deleted, _, err := pruner.Prune()
+	if err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	if deleted == 0 {
+		t.Fatalf("no stale state entries deleted")
+	}
+	// The retained state must be complete, the stale root gone
+	if err := checkStateConsistency(diskdb, root); err != nil {
+		t.Fatalf("retained state inconsistent: %v", err)
+	}
+	if ok, _ := diskdb.Has(stale.Bytes()); ok {
+		t.Fatalf("stale state root not pruned")
+	}
+	retained, _ := New(root, NewDatabase(diskdb))
+	for i := byte(0); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		if code := retained.GetCode(addr); len(code) != 2 || code[1] != 2 {
+			t.Fatalf("account %d: code mismatch: %x", i, code)
+		}
+		if val := retained.GetState(addr, common.Hash{i}); val != (common.Hash{i, i, i}) {
+			t.Fatalf("account %d: storage mismatch: %x", i, val)
+		}
+	}
+}
@@ -89,7 +89,7 @@ type Message interface {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error) {
+func IntrinsicGas(data []byte, contractCreation, homestead bool, isEIP2028 bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if contractCreation && homestead {
@@ -107,10 +107,14 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 			}
 		}
 		// Make sure we don't exceed uint64 for all data combinations
-		if (math.MaxUint64-gas)/params.TxDataNonZeroGas < nz {
+		nonZeroGas := params.TxDataNonZeroGas
+		if isEIP2028 {
+			nonZeroGas = params.TxDataNonZeroGasEIP2028
+		}
+		if (math.MaxUint64-gas)/nonZeroGas < nz {
 			return 0, vm.ErrOutOfGas
 		}
-		gas += nz * params.TxDataNonZeroGas
+		gas += nz * nonZeroGas
 
 		z := uint64(len(data)) - nz
 		if (math.MaxUint64-gas)/params.TxDataZeroGas < z {
@@ -238,20 +242,68 @@ func (st *StateTransition) inExtendedRound() bool {
 	return res
 }
 
+// isDenied returns whether the sender or the recipient of the message is on
+// the denylist. The denylist is read from the governance state of the round's
+// configuration, which is known before the parent block is delivered, so that
+// block payloads are verified against the same denylist. Failing to read it is
+// a consensus error, the transaction must not be let through.
+func (st *StateTransition) isDenied() (bool, error) {
+	// The same as in inExtendedRound, there is no blockchain instance to
+	// retrieve the configuration state from with chain_makers.go.
+	if TestingMode || !st.evm.ChainConfig().IsDenylist(st.evm.BlockNumber) {
+		return false, nil
+	}
+	round := st.evm.Round.Uint64()
+
+	var denied map[common.Address]struct{}
+	if st.evm.GetDenylist != nil {
+		var err error
+		if denied, err = st.evm.GetDenylist(round); err != nil {
+			return false, err
+		}
+	} else {
+		rgs, err := vm.GovUtil{st}.GetConfigState(round)
+		if err != nil {
+			return false, err
+		}
+		denied = make(map[common.Address]struct{})
+		for _, addr := range rgs.AddressDenylists() {
+			denied[addr] = struct{}{}
+		}
+	}
+	if _, exist := denied[st.msg.From()]; exist {
+		return true, nil
+	}
+	if to := st.msg.To(); to != nil {
+		_, exist := denied[*to]
+		return exist, nil
+	}
+	return false, nil
+}
+
 // TransitionDb will transition the state by applying the current message and
 // returning the result including the used gas. It returns an error if failed.
 // An error indicates a consensus issue.
 func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bool, err error) {
+	denied, err := st.isDenied()
+	if err != nil {
+		log.Error("Failed to get denylist", "round", st.evm.Round, "err", err)
+		return nil, 0, false, err
+	}
+	if denied {
+		return nil, 0, false, ErrDenied
+	}
 	if err = st.preCheck(); err != nil {
 		return
 	}
 	msg := st.msg
 	sender := vm.AccountRef(msg.From())
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.BlockNumber)
+	istanbul := st.evm.ChainConfig().IsIstanbul(st.evm.BlockNumber)
 	contractCreation := msg.To() == nil
 
 	// Pay intrinsic gas
-	gas, err := IntrinsicGas(st.data, contractCreation, homestead)
+	gas, err := IntrinsicGas(st.data, contractCreation, homestead, istanbul)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -0,0 +1,145 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/params"
+)
+
+// Tests the intrinsic gas of transactions, in particular the cheaper calldata
+// of EIP-2028 from Istanbul on.
+func TestIntrinsicGas(t *testing.T) {
+	var (
+		mixed = []byte{0x00, 0x01, 0x00, 0x02}
+		huge  = make([]byte, 1024)
+	)
+	for i := range huge {
+		huge[i] = 0xff
+	}
+	tests := []struct {
+		name      string
+		data      []byte
+		creation  bool
+		homestead bool
+		istanbul  bool
+		want      uint64
+	}{
+		{"empty", nil, false, true, false, 21000},
+		{"empty istanbul", nil, false, true, true, 21000},
+		{"creation", nil, true, true, true, 53000},
+		{"creation before homestead", nil, true, false, false, 21000},
+		{"mixed", mixed, false, true, false, 21000 + 2*4 + 2*68},
+		{"mixed istanbul", mixed, false, true, true, 21000 + 2*4 + 2*16},
+		{"creation mixed istanbul", mixed, true, true, true, 53000 + 2*4 + 2*16},
+		{"non-zero", huge, false, true, false, 21000 + 1024*68},
+		{"non-zero istanbul", huge, false, true, true, 21000 + 1024*16},
+	}
+	for _, tt := range tests {
+		gas, err := IntrinsicGas(tt.data, tt.creation, tt.homestead, tt.istanbul)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if gas != tt.want {
+			t.Errorf("%s: gas mismatch: have %d, want %d", tt.name, gas, tt.want)
+		}
+	}
+}
+
+// Tests that messages from or to denied accounts are rejected once the denylist
+// fork is active.
+func TestDeniedMessage(t *testing.T) {
+	defer func(mode bool) { TestingMode = mode }(TestingMode)
+	TestingMode = false
+
+	var (
+		denied  = common.Address{0x01}
+		allowed = common.Address{0x02}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.AddBalance(denied, big.NewInt(1000000))
+	statedb.AddBalance(allowed, big.NewInt(1000000))
+	gs := &vm.GovernanceState{StateDB: statedb}
+	gs.UpdateConfiguration(params.TestnetChainConfig.Dexcon)
+	gs.AddToDenylist(denied)
+
+	denylist := *params.TestChainConfig
+	denylist.DenylistBlock = big.NewInt(0)
+
+	istanbul := *params.TestChainConfig
+	istanbul.IstanbulBlock = big.NewInt(0)
+
+	tests := []struct {
+		name   string
+		config *params.ChainConfig
+		from   common.Address
+		to     common.Address
+		err    error
+	}{
+		{"denied sender", &denylist, denied, allowed, ErrDenied},
+		{"denied recipient", &denylist, allowed, denied, ErrDenied},
+		{"allowed", &denylist, allowed, allowed, nil},
+		{"denied before the fork", params.TestChainConfig, denied, allowed, nil},
+		{"denied on istanbul", &istanbul, denied, allowed, nil},
+	}
+	for i, tt := range tests {
+		context := vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			StateAtNumber: func(uint64) (*state.StateDB, error) {
+				return statedb, nil
+			},
+			BlockNumber: big.NewInt(int64(i + 1)),
+			Round:       big.NewInt(0),
+			GasPrice:    big.NewInt(0),
+		}
+		evm := vm.NewEVM(context, statedb.Copy(), tt.config, vm.Config{})
+		msg := types.NewMessage(tt.from, &tt.to, 0, big.NewInt(1), params.TxGas, big.NewInt(0), nil, false)
+
+		_, _, _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(params.TxGas))
+		if err != tt.err {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	// Messages are not let through if the denylist can't be retrieved
+	errNoState := errors.New("state unavailable")
+	context := vm.Context{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		GetDenylist: func(uint64) (map[common.Address]struct{}, error) {
+			return nil, errNoState
+		},
+		BlockNumber: big.NewInt(1),
+		Round:       big.NewInt(0),
+		GasPrice:    big.NewInt(0),
+	}
+	evm := vm.NewEVM(context, statedb.Copy(), &denylist, vm.Config{})
+	msg := types.NewMessage(allowed, &allowed, 0, big.NewInt(1), params.TxGas, big.NewInt(0), nil, false)
+
+	if _, _, _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(params.TxGas)); err != errNoState {
+		t.Errorf("unavailable denylist: error mismatch: have %v, want %v", err, errNoState)
+	}
+}
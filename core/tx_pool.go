@@ -76,6 +76,14 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrDenied is returned if the sender or the recipient of a transaction is
+	// on the denylist of the governance contract.
+	ErrDenied = errors.New("address denied")
+
+	// ErrSenderRateLimited is returned if the sender of a remote transaction
+	// submits transactions faster than the pool admits for a single account.
+	ErrSenderRateLimited = errors.New("sender rate limit exceeded")
 )
 
 var (
@@ -99,6 +107,7 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+	throttledTxCounter   = metrics.NewRegisteredCounter("txpool/throttled", nil)
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -129,6 +138,9 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	RemoteJournal      string // Journal of remote pending transactions to survive node restarts (empty = disabled)
+	RemoteJournalLimit uint64 // Maximum number of remote transactions to keep in the journal
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -136,6 +148,10 @@ type TxPoolConfig struct {
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+	GovSlots     uint64 // Number of slots reserved for governance transactions of priority accounts
+
+	SenderRate  float64 // Remote transactions admitted per second for each sender (0 = unlimited)
+	SenderBurst uint64  // Maximum number of remote transactions admitted at once for each sender
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 }
@@ -146,6 +162,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	RemoteJournalLimit: 4096,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -153,6 +171,10 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalSlots:  40960,
 	AccountQueue: 1024,
 	GlobalQueue:  20240,
+	GovSlots:     128,
+
+	SenderRate:  16,
+	SenderBurst: 1024,
 
 	Lifetime: 3 * time.Hour,
 }
@@ -165,6 +187,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.RemoteJournal != "" && conf.RemoteJournalLimit < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal limit", "provided", conf.RemoteJournalLimit, "updated", DefaultTxPoolConfig.RemoteJournalLimit)
+		conf.RemoteJournalLimit = DefaultTxPoolConfig.RemoteJournalLimit
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -189,6 +215,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool global queue", "provided", conf.GlobalQueue, "updated", DefaultTxPoolConfig.GlobalQueue)
 		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
 	}
+	if conf.SenderRate > 0 && conf.SenderBurst < 1 {
+		log.Warn("Sanitizing invalid txpool sender burst", "provided", conf.SenderBurst, "updated", DefaultTxPoolConfig.SenderBurst)
+		conf.SenderBurst = DefaultTxPoolConfig.SenderBurst
+	}
 	if conf.Lifetime < 1 {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
@@ -220,8 +250,16 @@ type TxPool struct {
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	configState *vm.GovernanceState // Governance state of the head round's configuration
+	denylist    *vm.GovernanceState // Configuration state the denylist is read from, nil if not active
+
+	locals   *accountSet    // Set of local transaction to exempt from eviction rules
+	priority *accountSet    // Set of accounts whose governance transactions are prioritized
+	limiter  *txRateLimiter // Admission rate limiter of remote transactions per sender
+	journal  *txJournal     // Journal of local transaction to back up to disk
+	remotes  *txJournal     // Journal of remote pending transactions to back up to disk
+
+	reloading bool // Whether journaled transactions are being reloaded, bypassing the limiter
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -232,6 +270,7 @@ type TxPool struct {
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead bool
+	istanbul  bool
 }
 
 // NewTxPool creates a new transaction pool to gather, sort and filter inbound
@@ -254,6 +293,8 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	pool.priority = newAccountSet(pool.signer)
+	pool.limiter = newTxRateLimiter(config.SenderRate, config.SenderBurst)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
@@ -272,6 +313,21 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, reload the remote transactions, which
+	// are revalidated against the current state and gas prices like any other
+	if config.RemoteJournal != "" {
+		pool.remotes = newTxJournal(config.RemoteJournal)
+
+		// Journaled remotes were admitted before, reload them unthrottled
+		pool.reloading = true
+		if err := pool.remotes.load(pool.AddRemotes); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+		pool.reloading = false
+		if err := pool.remotes.rotate(pool.remote()); err != nil {
+			log.Warn("Failed to rotate remote transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -313,6 +369,9 @@ func (pool *TxPool) loop() {
 				if pool.chainconfig.IsHomestead(ev.Block.Number()) {
 					pool.homestead = true
 				}
+				if pool.chainconfig.IsIstanbul(ev.Block.Number()) {
+					pool.istanbul = true
+				}
 				pool.reset(head.Header(), ev.Block.Header())
 				head = ev.Block
 
@@ -349,6 +408,7 @@ func (pool *TxPool) loop() {
 					}
 				}
 			}
+			pool.limiter.expire(time.Now())
 			pool.mu.Unlock()
 
 		// Handle local transaction journal rotation
@@ -360,6 +420,13 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remotes != nil {
+				pool.mu.Lock()
+				if err := pool.remotes.rotate(pool.remote()); err != nil {
+					log.Warn("Failed to rotate remote tx journal", "err", err)
+				}
+				pool.mu.Unlock()
+			}
 		}
 	}
 }
@@ -373,6 +440,23 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 	pool.reset(oldHead, newHead)
 }
 
+// nextRound returns the round the block following head is expected in, which
+// is the next one once the head round reached its length. A round extended
+// past its length is only noticed once its next block is delivered.
+func (pool *TxPool) nextRound(head *types.Header) uint64 {
+	gs := vm.GovernanceState{pool.currentState}
+	end := gs.RoundHeight(new(big.Int).SetUint64(head.Round)).Uint64() + pool.configState.RoundLength().Uint64()
+
+	// Round 0 starts at height 0 instead of height 1.
+	if head.Round == 0 {
+		end++
+	}
+	if head.Number.Uint64()+1 >= end {
+		return head.Round + 1
+	}
+	return head.Round
+}
+
 func (pool *TxPool) GetHeadGovState() (*vm.GovernanceState, error) {
 	return &vm.GovernanceState{pool.currentState}, nil
 }
@@ -407,8 +491,27 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 			panic(err)
 		}
 		pool.setGovPrice(gs.MinGasPrice())
+		pool.configState = gs
+	}
+	// The denylist of the next block is read from its round's configuration
+	// and activated at its height, the same as when the block is prepared,
+	// verified and executed.
+	pool.denylist = nil
+	if pool.chainconfig.IsDenylist(new(big.Int).Add(newHead.Number, big.NewInt(1))) {
+		round := pool.nextRound(newHead)
+		if round == newHead.Round {
+			pool.denylist = pool.configState
+		} else if gs, err := (vm.GovUtil{pool}).GetConfigState(round); err != nil {
+			log.Error("Failed to get config state", "round", round, "err", err)
+			pool.denylist = pool.configState
+		} else {
+			pool.denylist = gs
+		}
 	}
 
+	// Drop the transactions of accounts that have been denied since
+	pool.removeDenied()
+
 	// validate the pool of pending transactions, this will remove
 	// any transactions that have been included in the block or
 	// have been invalidated because of another transaction (e.g.
@@ -445,6 +548,15 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	// Snapshot the remote pending set so it survives the restart
+	if pool.remotes != nil {
+		pool.mu.Lock()
+		if err := pool.remotes.rotate(pool.remote()); err != nil {
+			log.Warn("Failed to rotate remote tx journal", "err", err)
+		}
+		pool.mu.Unlock()
+		pool.remotes.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -534,6 +646,157 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// Reasons reported by QueueReasons for a transaction not being executable.
+const (
+	QueueReasonStale       = "stale nonce"
+	QueueReasonNonceGap    = "nonce gap"
+	QueueReasonNoFunds     = "insufficient balance"
+	QueueReasonUnderpriced = "below governance gas price"
+	QueueReasonPromotable  = "awaiting promotion"
+)
+
+// QueueReasons retrieves why each queued transaction is not executable yet,
+// grouped by account and keyed by nonce. Transactions after a non-executable
+// one are reported with the reason of the one blocking them.
+func (pool *TxPool) QueueReasons() map[common.Address]map[uint64]string {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	reasons := make(map[common.Address]map[uint64]string)
+	for addr, list := range pool.queue {
+		var (
+			next    = pool.pendingState.GetNonce(addr)
+			balance = new(big.Int).Set(pool.currentState.GetBalance(addr))
+			account = make(map[uint64]string)
+			blocked string
+		)
+		for _, tx := range list.Flatten() {
+			switch {
+			case tx.Nonce() < next:
+				account[tx.Nonce()] = QueueReasonStale
+			case pool.govGasPrice.Cmp(tx.GasPrice()) > 0:
+				account[tx.Nonce()] = QueueReasonUnderpriced
+				if blocked == "" {
+					blocked = QueueReasonUnderpriced
+				}
+			case blocked != "":
+				account[tx.Nonce()] = blocked
+			case tx.Nonce() != next:
+				account[tx.Nonce()] = QueueReasonNonceGap
+				blocked = QueueReasonNonceGap
+			case balance.Cmp(tx.Cost()) < 0:
+				account[tx.Nonce()] = QueueReasonNoFunds
+				blocked = QueueReasonNoFunds
+			default:
+				account[tx.Nonce()] = QueueReasonPromotable
+				balance.Sub(balance, tx.Cost())
+				next++
+			}
+		}
+		reasons[addr] = account
+	}
+	return reasons
+}
+
+// SenderThrottled reports whether remote transactions of the account were
+// recently rejected for exceeding the per-sender admission rate.
+func (pool *TxPool) SenderThrottled(addr common.Address) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.limiter.isThrottled(addr, time.Now())
+}
+
+// AccountSlots is the number of pool slots used by an account.
+type AccountSlots struct {
+	Pending  int  `json:"pending"`
+	Queued   int  `json:"queued"`
+	Local    bool `json:"local"`
+	Priority bool `json:"priority"`
+}
+
+// Slots retrieves the pool slot usage of every account having transactions in
+// the pool.
+func (pool *TxPool) Slots() map[common.Address]AccountSlots {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	slots := make(map[common.Address]AccountSlots)
+	for addr, list := range pool.pending {
+		usage := slots[addr]
+		usage.Pending = list.Len()
+		slots[addr] = usage
+	}
+	for addr, list := range pool.queue {
+		usage := slots[addr]
+		usage.Queued = list.Len()
+		slots[addr] = usage
+	}
+	for addr, usage := range slots {
+		usage.Local = pool.locals.contains(addr)
+		usage.Priority = pool.priority.contains(addr)
+		slots[addr] = usage
+	}
+	return slots
+}
+
+// RemoveTransaction evicts a transaction from the pool, moving any subsequent
+// pending transactions of its sender back to the queue. It returns whether the
+// transaction was found.
+func (pool *TxPool) RemoveTransaction(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return false
+	}
+	pool.removeTx(hash, true)
+	pool.rotateJournals()
+	log.Info("Evicted transaction from pool", "hash", hash)
+	return true
+}
+
+// RemoveSender evicts all transactions of an account from the pool and returns
+// the number of transactions dropped.
+func (pool *TxPool) RemoveSender(addr common.Address) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var txs types.Transactions
+	if list := pool.pending[addr]; list != nil {
+		txs = append(txs, list.Flatten()...)
+	}
+	if list := pool.queue[addr]; list != nil {
+		txs = append(txs, list.Flatten()...)
+	}
+	for _, tx := range txs {
+		pool.removeTx(tx.Hash(), true)
+	}
+	if len(txs) > 0 {
+		pool.rotateJournals()
+		log.Info("Evicted account transactions from pool", "address", addr, "count", len(txs))
+	}
+	return len(txs)
+}
+
+// rotateJournals regenerates the local and remote transaction journals from
+// the current pool contents, so that evicted transactions are not reloaded on
+// the next start.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) rotateJournals() {
+	if pool.journal != nil {
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			log.Warn("Failed to rotate local tx journal", "err", err)
+		}
+	}
+	if pool.remotes != nil {
+		if err := pool.remotes.rotate(pool.remote()); err != nil {
+			log.Warn("Failed to rotate remote tx journal", "err", err)
+		}
+	}
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -556,6 +819,34 @@ func (pool *TxPool) Locals() []common.Address {
 	return pool.locals.flatten()
 }
 
+// AddPriorityAccount marks an account as a governance transactor, typically the
+// node key submitting DKG and CRS transactions. Its transactions are treated as
+// local, and the ones calling the governance contract may use the reserved pool
+// slots and are placed first into block payloads.
+func (pool *TxPool) AddPriorityAccount(addr common.Address) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	log.Info("Setting new priority account", "address", addr)
+	pool.priority.add(addr)
+	pool.locals.add(addr)
+}
+
+// PriorityAccounts retrieves the accounts whose governance transactions are
+// prioritized by the pool.
+func (pool *TxPool) PriorityAccounts() []common.Address {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.priority.flatten()
+}
+
+// isPriorityTx reports whether the transaction is a governance transaction sent
+// by a priority account.
+func (pool *TxPool) isPriorityTx(from common.Address, tx *types.Transaction) bool {
+	return pool.priority.contains(from) && tx.To() != nil && *tx.To() == vm.GovernanceContractAddress
+}
+
 // local retrieves all currently known local transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -572,6 +863,40 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves the pending transactions of the non-local accounts, capped to
+// the remote journal limit. Accounts paying the most for their next transaction
+// are kept first, and only nonce-contiguous prefixes of an account are kept.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	var (
+		addrs   = make([]common.Address, 0, len(pool.pending))
+		pending = make(map[common.Address]types.Transactions, len(pool.pending))
+	)
+	for addr, list := range pool.pending {
+		if pool.locals.contains(addr) || list.Empty() {
+			continue
+		}
+		addrs = append(addrs, addr)
+		pending[addr] = list.Flatten()
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return pending[addrs[i]][0].GasPrice().Cmp(pending[addrs[j]][0].GasPrice()) > 0
+	})
+	txs := make(map[common.Address]types.Transactions)
+	limit := int(pool.config.RemoteJournalLimit)
+	for _, addr := range addrs {
+		if limit <= 0 {
+			break
+		}
+		list := pending[addr]
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		txs[addr] = list
+		limit -= len(list)
+	}
+	return txs
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -593,6 +918,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
+	// Drop all transactions from or to denied accounts
+	if pool.denylist != nil && pool.denylist.IsDeniedTransaction(from, tx.To()) {
+		return ErrDenied
+	}
 	// Drop all transactions under governance minimum gas price.
 	if pool.govGasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderpriced
@@ -611,7 +940,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -642,8 +971,23 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+	// Throttle remote senders submitting faster than their admission rate. Only
+	// relayed transactions are throttled: finalized blocks are never reorged,
+	// so there are no transactions to reinject, and journaled remotes are
+	// reloaded unthrottled.
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if !local && !pool.reloading && !pool.locals.contains(from) && !pool.limiter.allow(from, time.Now()) {
+		log.Trace("Discarding rate limited transaction", "hash", hash, "from", from)
+		throttledTxCounter.Inc(1)
+		return false, ErrSenderRateLimited
+	}
+	// If the transaction pool is full, discard underpriced transactions. Only
+	// governance transactions of priority accounts may use the reserved slots.
+	capacity := pool.config.GlobalSlots + pool.config.GlobalQueue
+	if !pool.isPriorityTx(from, tx) && capacity > pool.config.GovSlots {
+		capacity -= pool.config.GovSlots
+	}
+	if uint64(pool.all.Count()) >= capacity {
 		// If the new transaction is underpriced, don't accept it
 		if !local && pool.priced.Underpriced(tx, pool.locals) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
@@ -651,7 +995,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(pool.all.Count()-int(capacity-1), pool.locals)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
@@ -659,7 +1003,6 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -1105,6 +1448,29 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 	}
 }
 
+// removeDenied removes all transactions from or to accounts on the denylist
+// the next block is executed against.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) removeDenied() {
+	gs := pool.denylist
+	if gs == nil || gs.LenDenylist().Sign() == 0 {
+		return
+	}
+	var denied []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		if gs.IsDeniedTransaction(from, tx.To()) {
+			denied = append(denied, hash)
+		}
+		return true
+	})
+	for _, hash := range denied {
+		log.Trace("Removed denied transaction", "hash", hash)
+		pool.removeTx(hash, true)
+	}
+}
+
 // demoteUnexecutables removes invalid and processed transactions from the pools
 // executable/pending queue and any subsequent transactions that become unexecutable
 // are moved back into the future queue.
@@ -23,12 +23,14 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/crypto"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/event"
@@ -42,6 +44,7 @@ var testTxPoolConfig TxPoolConfig
 func init() {
 	testTxPoolConfig = DefaultTxPoolConfig
 	testTxPoolConfig.Journal = ""
+	testTxPoolConfig.SenderRate = 0
 }
 
 type testBlockChain struct {
@@ -332,6 +335,93 @@ func TestTransactionNegativeValue(t *testing.T) {
 	}
 }
 
+// Tests that transactions of denied accounts are rejected and dropped only once
+// the denylist fork is active for the next block.
+func TestTransactionDenied(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	tests := []struct {
+		fork *big.Int
+		err  error
+	}{
+		{nil, nil},
+		{big.NewInt(2), nil},
+		{big.NewInt(1), ErrDenied},
+	}
+	for i, tt := range tests {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		statedb.AddBalance(from, big.NewInt(1000000))
+		(&vm.GovernanceState{StateDB: statedb}).AddToDenylist(from)
+
+		config := *params.TestChainConfig
+		config.DenylistBlock = tt.fork
+		blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+		pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+
+		if err := pool.AddRemote(transaction(0, 100000, key)); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		pool.Stop()
+	}
+	// Accounts denied after their transactions were pooled are dropped on reset
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.AddBalance(from, big.NewInt(1000000))
+
+	config := *params.TestChainConfig
+	config.DenylistBlock = big.NewInt(0)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	(&vm.GovernanceState{StateDB: statedb}).AddToDenylist(from)
+	pool.lockedReset(nil, nil)
+
+	if pending, queued := pool.Stats(); pending+queued != 0 {
+		t.Errorf("denied transactions not dropped: %d pending, %d queued", pending, queued)
+	}
+}
+
+// Tests that the denylist of the next block is taken from the next round once
+// the head round reached its length.
+func TestTransactionDenylistNextRound(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	gs := &vm.GovernanceState{StateDB: statedb}
+	config := *params.TestnetChainConfig.Dexcon
+	config.RoundLength = 10
+	gs.UpdateConfiguration(&config)
+	gs.PushRoundHeight(big.NewInt(0))
+	gs.PushRoundHeight(big.NewInt(11))
+
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	tests := []struct {
+		number, round uint64
+		next          uint64
+	}{
+		{9, 0, 0},
+		{10, 0, 1}, // Round 0 starts at height 0
+		{19, 1, 1},
+		{20, 1, 2},
+		{25, 1, 2}, // Extended round
+	}
+	for i, tt := range tests {
+		head := &types.Header{Number: new(big.Int).SetUint64(tt.number), Round: tt.round}
+		if next := pool.nextRound(head); next != tt.next {
+			t.Errorf("test %d: next round mismatch: have %d, want %d", i, next, tt.next)
+		}
+	}
+}
+
 func TestTransactionChainFork(t *testing.T) {
 	t.Parallel()
 
@@ -1426,6 +1516,231 @@ func TestTransactionPoolUnderpricing(t *testing.T) {
 	}
 }
 
+// Tests that remote transactions of a sender are throttled once its admission
+// allowance is used up, while local ones are still accepted.
+func TestTransactionSenderRateLimit(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.SenderRate = 0.001
+	config.SenderBurst = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	for i := uint64(0); i < 2; i++ {
+		if err := pool.AddRemote(transaction(i, 100000, key)); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if pool.SenderThrottled(account) {
+		t.Fatalf("sender throttled within its burst")
+	}
+	if err := pool.AddRemote(transaction(2, 100000, key)); err != ErrSenderRateLimited {
+		t.Fatalf("adding transaction over the sender rate error mismatch: have %v, want %v", err, ErrSenderRateLimited)
+	}
+	if !pool.SenderThrottled(account) {
+		t.Fatalf("sender not throttled over its burst")
+	}
+	if err := pool.AddLocal(transaction(2, 100000, key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that queued transactions report why they are not executable, and that
+// transactions can be evicted by hash and by sender.
+func TestTransactionInspectAndEvict(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	txs := []*types.Transaction{
+		transaction(0, 100000, key),
+		transaction(1, 100000, key),
+		transaction(3, 100000, key),
+	}
+	for i, tx := range txs {
+		if err := pool.AddRemote(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if slots := pool.Slots()[account]; slots.Pending != 2 || slots.Queued != 1 {
+		t.Fatalf("slot usage mismatch: have %d/%d, want %d/%d", slots.Pending, slots.Queued, 2, 1)
+	}
+	if reason := pool.QueueReasons()[account][3]; reason != QueueReasonNonceGap {
+		t.Fatalf("queue reason mismatch: have %q, want %q", reason, QueueReasonNonceGap)
+	}
+	// Evicting a pending transaction should demote the subsequent ones
+	if !pool.RemoveTransaction(txs[0].Hash()) {
+		t.Fatalf("failed to evict pending transaction")
+	}
+	if pool.RemoveTransaction(txs[0].Hash()) {
+		t.Fatalf("evicted already removed transaction")
+	}
+	pending, queued := pool.Stats()
+	if pending != 0 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 0)
+	}
+	if queued != 2 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 2)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Evicting the sender should drop everything left
+	if dropped := pool.RemoveSender(account); dropped != 2 {
+		t.Fatalf("evicted transactions mismatch: have %d, want %d", dropped, 2)
+	}
+	if pending, queued = pool.Stats(); pending+queued != 0 {
+		t.Fatalf("pool not empty: pending %d, queued %d", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that queue reasons account for the cost of the transactions ahead,
+// report stale nonces and label transactions after a blocked one with the
+// reason blocking them.
+func TestTransactionQueueReasons(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	pool.govGasPrice = big.NewInt(1)
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	addrs := make([]common.Address, len(keys))
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	// The first account can only afford two of its transactions
+	pool.currentState.AddBalance(addrs[0], big.NewInt(250000))
+	pool.currentState.AddBalance(addrs[1], big.NewInt(1000000))
+	pool.currentState.AddBalance(addrs[2], big.NewInt(1000000))
+	pool.pendingState.SetNonce(addrs[2], 2)
+
+	txs := []*types.Transaction{
+		transaction(0, 100000, keys[0]),
+		transaction(1, 100000, keys[0]),
+		transaction(2, 100000, keys[0]),
+		transaction(3, 100000, keys[0]),
+
+		pricedTransaction(0, 100000, big.NewInt(0), keys[1]),
+		transaction(1, 100000, keys[1]),
+		transaction(3, 100000, keys[1]),
+
+		transaction(1, 100000, keys[2]),
+		transaction(3, 100000, keys[2]),
+		transaction(4, 100000, keys[2]),
+	}
+	for _, tx := range txs {
+		pool.enqueueTx(tx.Hash(), tx)
+	}
+	want := map[common.Address]map[uint64]string{
+		addrs[0]: {
+			0: QueueReasonPromotable,
+			1: QueueReasonPromotable,
+			2: QueueReasonNoFunds,
+			3: QueueReasonNoFunds,
+		},
+		addrs[1]: {
+			0: QueueReasonUnderpriced,
+			1: QueueReasonUnderpriced,
+			3: QueueReasonUnderpriced,
+		},
+		addrs[2]: {
+			1: QueueReasonStale,
+			3: QueueReasonNonceGap,
+			4: QueueReasonNonceGap,
+		},
+	}
+	reasons := pool.QueueReasons()
+	for i, addr := range addrs {
+		if len(reasons[addr]) != len(want[addr]) {
+			t.Errorf("account %d: reason count mismatch: have %d, want %d", i, len(reasons[addr]), len(want[addr]))
+		}
+		for nonce, reason := range want[addr] {
+			if have := reasons[addr][nonce]; have != reason {
+				t.Errorf("account %d, nonce %d: reason mismatch: have %q, want %q", i, nonce, have, reason)
+			}
+		}
+	}
+}
+
+// Tests that governance transactions of priority accounts may use the pool
+// slots reserved for them, while others are rejected once only those are left.
+func TestTransactionPoolGovSlots(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the reservation with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.GovSlots = 1
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// Create a number of test accounts and fund them, the last one being the
+	// governance transactor
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	govAddr := crypto.PubkeyToAddress(keys[3].PublicKey)
+	pool.AddPriorityAccount(govAddr)
+
+	if accounts := pool.PriorityAccounts(); len(accounts) != 1 || accounts[0] != govAddr {
+		t.Fatalf("priority accounts mismatch: have %v, want [%x]", accounts, govAddr)
+	}
+	// Fill the pool up to the reserved slots
+	for i := 0; i < 3; i++ {
+		if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(1), keys[i])); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// Ensure that normal transactions can't take the reserved slot
+	if err := pool.AddRemote(pricedTransaction(1, 100000, big.NewInt(1), keys[0])); err != ErrUnderpriced {
+		t.Fatalf("adding transaction into reserved slot error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Ensure that the governance transaction can
+	tx, _ := types.SignTx(types.NewTransaction(0, vm.GovernanceContractAddress, big.NewInt(0), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, keys[3])
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add governance transaction: %v", err)
+	}
+	pending, queued := pool.Stats()
+	if pending != 4 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that more expensive transactions push out cheap ones from the pool, but
 // without producing instability by creating gaps that start jumping transactions
 // back and forth between queued/pending.
@@ -1687,6 +2002,189 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that remote pending transactions are journaled up to the configured
+// limit when enabled, and revalidated against the current state when reloaded.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.RemoteJournal = journal
+	config.RemoteJournalLimit = 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	// Create a cheap and an expensive remote sender, exceeding the limit together
+	cheap, _ := crypto.GenerateKey()
+	expensive, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(cheap.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(expensive.PublicKey), big.NewInt(1000000000))
+
+	for i := uint64(0); i < 2; i++ {
+		if err := pool.AddRemote(pricedTransaction(i, 100000, big.NewInt(1), cheap)); err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+		if err := pool.AddRemote(pricedTransaction(i, 100000, big.NewInt(2), expensive)); err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 4 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	}
+	// Terminate the pool, bump the nonce of the expensive sender and ensure only
+	// the journaled transactions still valid survive
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(expensive.PublicKey), 1)
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued := pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if pool.Get(pricedTransaction(1, 100000, big.NewInt(1), cheap).Hash()) != nil {
+		t.Fatalf("transaction over the journal limit survived")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that journaled remote transactions are reloaded without consuming the
+// admission rate of their senders, which is enforced on relays afterwards.
+func TestTransactionRemoteJournalingUnthrottled(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.RemoteJournal = filepath.Join(dir, "remotes.rlp")
+	config.SenderRate = 0
+
+	// Fill the journal from an unlimited pool
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	for i := uint64(0); i < 3; i++ {
+		if err := pool.AddRemote(pricedTransaction(i, 100000, big.NewInt(1), key)); err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	pool.Stop()
+
+	// Reload it into a pool admitting a single transaction per sender
+	config.SenderRate = 0.001
+	config.SenderBurst = 1
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, _ := pool.Stats(); pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if err := pool.AddRemote(pricedTransaction(3, 100000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add relayed transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(4, 100000, big.NewInt(1), key)); err != ErrSenderRateLimited {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSenderRateLimited)
+	}
+}
+
+// Tests that evicted transactions are dropped from the local and the remote
+// journals right away, so they do not come back on the next start.
+func TestTransactionEvictionJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create the temporary files for the journals, only their paths are needed
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+	config.RemoteJournal = filepath.Join(dir, "remotes.rlp")
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	local, _ := crypto.GenerateKey()
+	remote1, _ := crypto.GenerateKey()
+	remote2, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{local, remote1, remote2} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	locals := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), local),
+		pricedTransaction(1, 100000, big.NewInt(1), local),
+	}
+	if errs := pool.AddLocals(locals); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("failed to add local transactions: %v", errs)
+	}
+	remotes := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), remote1),
+		pricedTransaction(0, 100000, big.NewInt(1), remote2),
+	}
+	if errs := pool.AddRemotes(remotes); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("failed to add remote transactions: %v", errs)
+	}
+	journaled := func(path string) map[common.Hash]bool {
+		hashes := make(map[common.Hash]bool)
+		newTxJournal(path).load(func(txs []*types.Transaction) []error {
+			for _, tx := range txs {
+				hashes[tx.Hash()] = true
+			}
+			return make([]error, len(txs))
+		})
+		return hashes
+	}
+	// Evict a local transaction and a remote sender
+	if !pool.RemoveTransaction(locals[1].Hash()) {
+		t.Fatalf("failed to evict local transaction")
+	}
+	if dropped := pool.RemoveSender(crypto.PubkeyToAddress(remote1.PublicKey)); dropped != 1 {
+		t.Fatalf("evicted transactions mismatch: have %d, want %d", dropped, 1)
+	}
+	if hashes := journaled(config.Journal); len(hashes) != 1 || !hashes[locals[0].Hash()] {
+		t.Errorf("local journal mismatch: have %d transactions, want only %x", len(hashes), locals[0].Hash())
+	}
+	if hashes := journaled(config.RemoteJournal); len(hashes) != 1 || !hashes[remotes[1].Hash()] {
+		t.Errorf("remote journal mismatch: have %d transactions, want only %x", len(hashes), remotes[1].Hash())
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
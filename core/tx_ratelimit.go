@@ -0,0 +1,99 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+)
+
+// txThrottleWindow is the time a sender is reported as throttled after one of
+// its transactions was rejected for exceeding the admission rate.
+const txThrottleWindow = time.Minute
+
+// txBucket is a token bucket tracking the admission allowance of a sender.
+type txBucket struct {
+	tokens float64   // Number of transactions currently allowed
+	last   time.Time // Last time the allowance was refilled
+}
+
+// txRateLimiter limits the rate at which remote transactions of each sender
+// are admitted into the pool, remembering the senders going over budget.
+type txRateLimiter struct {
+	rate      float64 // Transactions refilled per second, zero disables limiting
+	burst     float64 // Maximum number of transactions admitted at once
+	buckets   map[common.Address]*txBucket
+	throttled map[common.Address]time.Time
+}
+
+// newTxRateLimiter creates a rate limiter admitting rate transactions per
+// second with bursts of at most burst transactions for each sender.
+func newTxRateLimiter(rate float64, burst uint64) *txRateLimiter {
+	return &txRateLimiter{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   make(map[common.Address]*txBucket),
+		throttled: make(map[common.Address]time.Time),
+	}
+}
+
+// allow consumes one admission of the sender, returning false and marking the
+// sender throttled if its allowance is exhausted.
+func (l *txRateLimiter) allow(addr common.Address, now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	bucket := l.buckets[addr]
+	if bucket == nil {
+		bucket = &txBucket{tokens: l.burst, last: now}
+		l.buckets[addr] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		l.throttled[addr] = now
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// isThrottled reports whether the sender went over budget recently.
+func (l *txRateLimiter) isThrottled(addr common.Address, now time.Time) bool {
+	at, ok := l.throttled[addr]
+	return ok && now.Sub(at) < txThrottleWindow
+}
+
+// expire drops the buckets refilled to their full burst and the throttle marks
+// older than the throttle window.
+func (l *txRateLimiter) expire(now time.Time) {
+	for addr, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, addr)
+		}
+	}
+	for addr, at := range l.throttled {
+		if now.Sub(at) >= txThrottleWindow {
+			delete(l.throttled, addr)
+		}
+	}
+}
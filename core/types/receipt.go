@@ -55,6 +55,7 @@ type Receipt struct {
 	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
 	ContractAddress common.Address `json:"contractAddress"`
 	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
+	RevertReason    string         `json:"revertReason,omitempty"`
 }
 
 type receiptMarshaling struct {
@@ -80,6 +81,19 @@ type receiptStorageRLP struct {
 	ContractAddress   common.Address
 	Logs              []*LogForStorage
 	GasUsed           uint64
+	RevertReason      string
+}
+
+// legacyReceiptStorageRLP is the storage encoding of receipts written before
+// revert reasons were stored.
+type legacyReceiptStorageRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             Bloom
+	TxHash            common.Hash
+	ContractAddress   common.Address
+	Logs              []*LogForStorage
+	GasUsed           uint64
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -164,6 +178,7 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 		ContractAddress:   r.ContractAddress,
 		Logs:              make([]*LogForStorage, len(r.Logs)),
 		GasUsed:           r.GasUsed,
+		RevertReason:      r.RevertReason,
 	}
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
@@ -174,10 +189,27 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 // DecodeRLP implements rlp.Decoder, and loads both consensus and implementation
 // fields of a receipt from an RLP stream.
 func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
-	var dec receiptStorageRLP
-	if err := s.Decode(&dec); err != nil {
+	blob, err := s.Raw()
+	if err != nil {
 		return err
 	}
+	var dec receiptStorageRLP
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		// Fall back to receipts stored without a revert reason
+		var legacy legacyReceiptStorageRLP
+		if rlp.DecodeBytes(blob, &legacy) != nil {
+			return err
+		}
+		dec = receiptStorageRLP{
+			PostStateOrStatus: legacy.PostStateOrStatus,
+			CumulativeGasUsed: legacy.CumulativeGasUsed,
+			Bloom:             legacy.Bloom,
+			TxHash:            legacy.TxHash,
+			ContractAddress:   legacy.ContractAddress,
+			Logs:              legacy.Logs,
+			GasUsed:           legacy.GasUsed,
+		}
+	}
 	if err := (*Receipt)(r).setStatus(dec.PostStateOrStatus); err != nil {
 		return err
 	}
@@ -189,6 +221,7 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	}
 	// Assign the implementation fields
 	r.TxHash, r.ContractAddress, r.GasUsed = dec.TxHash, dec.ContractAddress, dec.GasUsed
+	r.RevertReason = dec.RevertReason
 	return nil
 }
 
@@ -0,0 +1,69 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// Tests that receipts round trip through the storage encoding along with their
+// revert reason, and that receipts stored without one can still be decoded.
+func TestReceiptStorageEncoding(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceiptStatusFailed,
+		CumulativeGasUsed: 42000,
+		Logs:              []*Log{},
+		TxHash:            common.HexToHash("0x1234"),
+		GasUsed:           21000,
+		RevertReason:      "caller is not the owner",
+	}
+	blob, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	dec := new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(blob, dec); err != nil {
+		t.Fatalf("failed to decode receipt: %v", err)
+	}
+	if dec.RevertReason != receipt.RevertReason {
+		t.Errorf("revert reason mismatch: have %q, want %q", dec.RevertReason, receipt.RevertReason)
+	}
+	if dec.TxHash != receipt.TxHash || dec.GasUsed != receipt.GasUsed || dec.Status != receipt.Status {
+		t.Errorf("receipt mismatch: have %+v, want %+v", dec, receipt)
+	}
+	// Decode a receipt stored before revert reasons were
+	blob, err = rlp.EncodeToBytes(&legacyReceiptStorageRLP{
+		PostStateOrStatus: receiptStatusSuccessfulRLP,
+		CumulativeGasUsed: 42000,
+		TxHash:            receipt.TxHash,
+		Logs:              []*LogForStorage{},
+		GasUsed:           21000,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode legacy receipt: %v", err)
+	}
+	dec = new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(blob, dec); err != nil {
+		t.Fatalf("failed to decode legacy receipt: %v", err)
+	}
+	if dec.RevertReason != "" || dec.Status != ReceiptStatusSuccessful || dec.TxHash != receipt.TxHash || dec.GasUsed != 21000 {
+		t.Errorf("legacy receipt mismatch: %+v", dec)
+	}
+}
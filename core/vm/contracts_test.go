@@ -17,11 +17,15 @@
 package vm
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"testing"
 
+	"github.com/tangerine-network/bls/ffi/go/bls"
 	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -336,8 +340,28 @@ var bn256PairingTests = []precompiledTest{
 	},
 }
 
+// blake2FTests are the test data for the Blake2 F compression precompile,
+// taken from the EIP-152 examples.
+var blake2FTests = []precompiledTest{
+	{
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		name:     "vector 4",
+	},
+}
+
+// blake2FMalformedTests are inputs the Blake2 F precompile must reject.
+var blake2FMalformedTests = []struct {
+	input string
+	err   error
+}{
+	{"", errBlake2FInvalidInputLength},
+	{"00000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001", errBlake2FInvalidInputLength},
+	{"0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000002", errBlake2FInvalidFinalFlag},
+}
+
 func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := PrecompiledContractsIstanbul[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
 		nil, new(big.Int), p.RequiredGas(in))
@@ -354,7 +378,7 @@ func benchmarkPrecompiled(addr string, test precompiledTest, bench *testing.B) {
 	if test.noBenchmark {
 		return
 	}
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := PrecompiledContractsIstanbul[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	reqGas := p.RequiredGas(in)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
@@ -481,3 +505,219 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// Tests the sample inputs from the Blake2 F compression EIP 152.
+func TestPrecompiledBlake2F(t *testing.T) {
+	for _, test := range blake2FTests {
+		testPrecompiled("09", test, t)
+	}
+}
+
+// Tests that malformed inputs are rejected by the Blake2 F precompile.
+func TestPrecompiledBlake2FMalformed(t *testing.T) {
+	p := PrecompiledContractsIstanbul[common.HexToAddress("09")]
+	for i, test := range blake2FMalformedTests {
+		in := common.Hex2Bytes(test.input)
+		contract := NewContract(AccountRef(common.HexToAddress("1337")),
+			nil, new(big.Int), p.RequiredGas(in))
+		if _, err := RunPrecompiledContract(p, in, contract); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+// Benchmarks the sample inputs from the Blake2 F compression EIP 152.
+func BenchmarkPrecompiledBlake2F(bench *testing.B) {
+	for _, test := range blake2FTests {
+		benchmarkPrecompiled("09", test, bench)
+	}
+}
+
+// Tests that the BLS verification precompiles accept signatures made with the
+// consensus keys and reject mismatching ones.
+func TestPrecompiledBlsVerify(t *testing.T) {
+	var (
+		hash  = crypto.Keccak256([]byte("notary ack"))
+		other = crypto.Keccak256([]byte("forged ack"))
+		keys  = make([]bls.SecretKey, 3)
+		pubs  []byte
+		agg   *bls.Sign
+	)
+	for i := range keys {
+		keys[i].SetByCSPRNG()
+		pubs = append(pubs, keys[i].GetPublicKey().Serialize()...)
+
+		sig := keys[i].Sign(string(hash))
+		if agg == nil {
+			agg = sig
+		} else {
+			agg.Add(sig)
+		}
+	}
+	single := keys[0].Sign(string(hash)).Serialize()
+
+	tests := []struct {
+		addr  string
+		input []byte
+		want  []byte
+	}{
+		{"0a", concat(hash, single, pubs[:blsPublicKeyLength]), true32Byte},
+		{"0a", concat(other, single, pubs[:blsPublicKeyLength]), false32Byte},
+		{"0a", concat(hash, single, pubs[blsPublicKeyLength:2*blsPublicKeyLength]), false32Byte},
+		{"0b", concat(hash, agg.Serialize(), pubs), true32Byte},
+		{"0b", concat(hash, agg.Serialize(), pubs[:2*blsPublicKeyLength]), false32Byte},
+		{"0b", concat(hash, single, pubs[:blsPublicKeyLength]), true32Byte},
+	}
+	for i, test := range tests {
+		p := PrecompiledContractsBls[common.HexToAddress(test.addr)]
+		contract := NewContract(AccountRef(common.HexToAddress("1337")),
+			nil, new(big.Int), p.RequiredGas(test.input))
+		res, err := RunPrecompiledContract(p, test.input, contract)
+		if err != nil {
+			t.Errorf("test %d: failed to run: %v", i, err)
+		} else if common.Bytes2Hex(res) != common.Bytes2Hex(test.want) {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, res, test.want)
+		}
+	}
+	// Malformed inputs must be rejected
+	for i, input := range [][]byte{nil, hash, concat(hash, single), concat(hash, single, pubs[1:])} {
+		for _, addr := range []string{"0a", "0b"} {
+			p := PrecompiledContractsBls[common.HexToAddress(addr)]
+			contract := NewContract(AccountRef(common.HexToAddress("1337")),
+				nil, new(big.Int), p.RequiredGas(input))
+			if _, err := RunPrecompiledContract(p, input, contract); err != errBadBlsInput {
+				t.Errorf("malformed %d at %s: error mismatch: have %v, want %v", i, addr, err, errBadBlsInput)
+			}
+		}
+	}
+}
+
+// Tests that the BLS verification precompiles reject public keys and signatures
+// that are not valid encodings of points in the prime order subgroups.
+func TestPrecompiledBlsVerifyInvalidPoints(t *testing.T) {
+	var (
+		hash = crypto.Keccak256([]byte("notary ack"))
+		key  bls.SecretKey
+	)
+	key.SetByCSPRNG()
+	pub := key.GetPublicKey().Serialize()
+	sig := key.Sign(string(hash)).Serialize()
+
+	// Points on the curves, but outside of the prime order subgroups
+	badPub := make([]byte, blsPublicKeyLength)
+	badPub[0], badPub[len(badPub)-1] = 0x7b, 0x80
+	badSig := make([]byte, blsSignatureLength)
+	badSig[0], badSig[len(badSig)-1] = 0x7c, 0x80
+
+	// Coordinates beyond the field modulus
+	junkPub := bytes.Repeat([]byte{0xff}, blsPublicKeyLength)
+	junkSig := bytes.Repeat([]byte{0xff}, blsSignatureLength)
+
+	tests := []struct {
+		addr  string
+		input []byte
+	}{
+		{"0a", concat(hash, sig, badPub)},
+		{"0a", concat(hash, badSig, pub)},
+		{"0a", concat(hash, sig, junkPub)},
+		{"0a", concat(hash, junkSig, pub)},
+		{"0b", concat(hash, sig, pub, badPub)},
+		{"0b", concat(hash, badSig, pub)},
+		{"0b", concat(hash, sig, junkPub, pub)},
+		{"0b", concat(hash, junkSig, pub, pub)},
+	}
+	for i, test := range tests {
+		p := PrecompiledContractsBls[common.HexToAddress(test.addr)]
+		contract := NewContract(AccountRef(common.HexToAddress("1337")),
+			nil, new(big.Int), p.RequiredGas(test.input))
+		if res, err := RunPrecompiledContract(p, test.input, contract); err == nil {
+			t.Errorf("test %d: invalid point accepted, result %x", i, res)
+		}
+	}
+}
+
+// Tests that the BLS verification precompiles charge the base verification
+// price plus the aggregation price of every public key.
+func TestPrecompiledBlsVerifyGas(t *testing.T) {
+	var (
+		hash = crypto.Keccak256([]byte("notary ack"))
+		keys = make([]bls.SecretKey, 4)
+		pubs []byte
+		agg  *bls.Sign
+	)
+	for i := range keys {
+		keys[i].SetByCSPRNG()
+		pubs = append(pubs, keys[i].GetPublicKey().Serialize()...)
+
+		sig := keys[i].Sign(string(hash))
+		if agg == nil {
+			agg = sig
+		} else {
+			agg.Add(sig)
+		}
+	}
+	single := keys[0].Sign(string(hash)).Serialize()
+
+	tests := []struct {
+		addr  string
+		input []byte
+		gas   uint64
+	}{
+		{"0a", concat(hash, single, pubs[:blsPublicKeyLength]), params.BlsVerifyGas},
+		{"0b", concat(hash, single, pubs[:blsPublicKeyLength]), params.BlsVerifyGas + params.BlsAggregatePerKeyGas},
+		{"0b", concat(hash, agg.Serialize(), pubs), params.BlsVerifyGas + 4*params.BlsAggregatePerKeyGas},
+	}
+	for i, test := range tests {
+		p := PrecompiledContractsBls[common.HexToAddress(test.addr)]
+		if gas := p.RequiredGas(test.input); gas != test.gas {
+			t.Errorf("test %d: required gas mismatch: have %d, want %d", i, gas, test.gas)
+		}
+		// Run with a surplus and check that exactly the price was deducted
+		contract := NewContract(AccountRef(common.HexToAddress("1337")),
+			nil, new(big.Int), test.gas+1000)
+		res, err := RunPrecompiledContract(p, test.input, contract)
+		if err != nil {
+			t.Fatalf("test %d: failed to run: %v", i, err)
+		}
+		if common.Bytes2Hex(res) != common.Bytes2Hex(true32Byte) {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, res, true32Byte)
+		}
+		if contract.Gas != 1000 {
+			t.Errorf("test %d: charged gas mismatch: have %d, want %d", i, test.gas+1000-contract.Gas, test.gas)
+		}
+		// One gas short of the price must fail
+		contract = NewContract(AccountRef(common.HexToAddress("1337")),
+			nil, new(big.Int), test.gas-1)
+		if _, err := RunPrecompiledContract(p, test.input, contract); err != ErrOutOfGas {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrOutOfGas)
+		}
+	}
+}
+
+// Tests that the BLS verification precompiles are only enabled by their own
+// fork, and not by Istanbul alone.
+func TestPrecompiledBlsFork(t *testing.T) {
+	config := *params.TestChainConfig
+	config.IstanbulBlock = big.NewInt(0)
+	config.BlsBlock = big.NewInt(10)
+
+	for _, test := range []struct {
+		number int64
+		want   bool
+	}{{0, false}, {9, false}, {10, true}} {
+		evm := NewEVM(Context{BlockNumber: big.NewInt(test.number)}, nil, &config, Config{})
+		for _, addr := range []string{"0a", "0b"} {
+			if _, ok := evm.precompiles()[common.HexToAddress(addr)]; ok != test.want {
+				t.Errorf("block %d, precompile %s: enabled mismatch: have %v, want %v", test.number, addr, ok, test.want)
+			}
+		}
+	}
+}
+
+func concat(blobs ...[]byte) []byte {
+	var res []byte
+	for _, blob := range blobs {
+		res = append(res, blob...)
+	}
+	return res
+}
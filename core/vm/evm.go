@@ -43,18 +43,32 @@ type (
 	StateAtNumberFunc func(uint64) (*state.StateDB, error)
 	// GetRoundHeightFunc returns the round height.
 	GetRoundHeightFunc func(uint64) (uint64, bool)
+	// GetDenylistFunc returns the addresses denied in a round.
+	GetDenylistFunc func(uint64) (map[common.Address]struct{}, error)
 )
 
+// precompiles returns the set of precompiled contracts active at the
+// current block number.
+func (evm *EVM) precompiles() map[common.Address]PrecompiledContract {
+	switch {
+	case evm.ChainConfig().IsIstanbul(evm.BlockNumber) && evm.ChainConfig().IsBls(evm.BlockNumber):
+		return PrecompiledContractsBls
+	case evm.ChainConfig().IsIstanbul(evm.BlockNumber):
+		return PrecompiledContractsIstanbul
+	case evm.ChainConfig().IsByzantium(evm.BlockNumber):
+		return PrecompiledContractsByzantium
+	default:
+		return PrecompiledContractsHomestead
+	}
+}
+
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
 	if contract.CodeAddr != nil {
 		if o := OracleContracts[*contract.CodeAddr]; o != nil {
 			return RunOracleContract(o(), evm, input, contract)
 		}
-		precompiles := PrecompiledContractsHomestead
-		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
-			precompiles = PrecompiledContractsByzantium
-		}
+		precompiles := evm.precompiles()
 		if p := precompiles[*contract.CodeAddr]; p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
@@ -89,6 +103,9 @@ type Context struct {
 	StateAtNumber StateAtNumberFunc
 	// GetRoundHeight returns the round height.
 	GetRoundHeight GetRoundHeightFunc
+	// GetDenylist returns the addresses denied in a round, nil if the chain
+	// doesn't keep them.
+	GetDenylist GetDenylistFunc
 
 	// Message information
 	Origin   common.Address // Provides information for ORIGIN
@@ -213,10 +230,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		precompiles := PrecompiledContractsHomestead
-		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
-			precompiles = PrecompiledContractsByzantium
-		}
+		precompiles := evm.precompiles()
 		if precompiles[addr] == nil && OracleContracts[addr] == nil &&
 			evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
@@ -22,7 +22,9 @@ import (
 	"testing"
 
 	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/params"
 )
 
@@ -587,3 +589,47 @@ func TestCreate2Addreses(t *testing.T) {
 
 	}
 }
+
+// Tests that CHAINID and SELFBALANCE are only available from Istanbul on, and
+// that they push the chain id and the balance of the executing contract.
+func TestIstanbulInstructions(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *params.ChainConfig
+		code   string
+		want   common.Hash // Value stored into slot zero
+		used   uint64
+		err    string
+	}{
+		{"chainid", istanbulTestConfig, "0x46600055", common.BigToHash(istanbulTestConfig.ChainID), 2 + 3 + 20000, ""},
+		{"selfbalance", istanbulTestConfig, "0x47600055", common.BigToHash(big.NewInt(1000)), 5 + 3 + 20000, ""},
+		{"chainid before istanbul", params.TestChainConfig, "0x46600055", common.Hash{}, 100000, "invalid opcode 0x46"},
+		{"selfbalance before istanbul", params.TestChainConfig, "0x47600055", common.Hash{}, 100000, "invalid opcode 0x47"},
+	}
+	for _, tt := range tests {
+		address := common.BytesToAddress([]byte("contract"))
+
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, common.FromHex(tt.code))
+		statedb.AddBalance(address, big.NewInt(1000))
+
+		vmctx := Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(0),
+		}
+		vmenv := NewEVM(vmctx, statedb, tt.config, Config{})
+
+		_, gas, err := vmenv.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int))
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%s: error mismatch: have %v, want %q", tt.name, err, tt.err)
+		}
+		if used := 100000 - gas; used != tt.used {
+			t.Errorf("%s: gas used mismatch: have %d, want %d", tt.name, used, tt.used)
+		}
+		if have := statedb.GetState(address, common.Hash{}); have != tt.want {
+			t.Errorf("%s: stored value mismatch: have %x, want %x", tt.name, have, tt.want)
+		}
+	}
+}
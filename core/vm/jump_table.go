@@ -55,8 +55,31 @@ var (
 	homesteadInstructionSet      = newHomesteadInstructionSet()
 	byzantiumInstructionSet      = newByzantiumInstructionSet()
 	constantinopleInstructionSet = newConstantinopleInstructionSet()
+	istanbulInstructionSet       = newIstanbulInstructionSet()
 )
 
+// newIstanbulInstructionSet returns the frontier, homestead, byzantium,
+// constantinople and istanbul instructions.
+func newIstanbulInstructionSet() [256]operation {
+	instructionSet := newConstantinopleInstructionSet()
+	instructionSet[CHAINID] = operation{ // EIP-1344
+		execute:       opChainID,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	instructionSet[SELFBALANCE] = operation{ // EIP-1884
+		execute:       opSelfBalance,
+		gasCost:       constGasFunc(GasFastStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	// Net gas metering of EIP-2200, SLOAD, BALANCE and EXTCODEHASH are
+	// repriced through the istanbul gas table (EIP-1884)
+	instructionSet[SSTORE].gasCost = gasSStoreEIP2200
+	return instructionSet
+}
+
 // NewConstantinopleInstructionSet returns the frontier, homestead
 // byzantium and contantinople instructions.
 func newConstantinopleInstructionSet() [256]operation {
@@ -104,6 +104,38 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
+// OracleTracer is implemented by tracers interested in the execution of oracle
+// contracts, which runs natively outside of the interpreter loop and is thus
+// never seen by CaptureState. CaptureOracle is called once the oracle call has
+// finished.
+type OracleTracer interface {
+	CaptureOracle(env *EVM, call *OracleCall) error
+}
+
+// OracleCall is the execution of an oracle contract.
+type OracleCall struct {
+	From    common.Address         `json:"from"`
+	To      common.Address         `json:"to"`
+	Value   *hexutil.Big           `json:"value"`
+	Method  string                 `json:"method,omitempty"` // Name of the called ABI method, empty if unknown
+	Args    map[string]interface{} `json:"args,omitempty"`   // Decoded arguments of the called ABI method
+	Input   hexutil.Bytes          `json:"input"`
+	Output  hexutil.Bytes          `json:"output,omitempty"`
+	Gas     uint64                 `json:"gas"`
+	GasUsed uint64                 `json:"gasUsed"`
+	Events  []OracleEvent          `json:"events,omitempty"`
+	Depth   int                    `json:"depth"`
+	Error   string                 `json:"error,omitempty"`
+	Reason  string                 `json:"revertReason,omitempty"` // Decoded reason of a reverted call
+}
+
+// OracleEvent is an event emitted by an oracle contract.
+type OracleEvent struct {
+	Name   string        `json:"name,omitempty"` // Name of the ABI event, empty if unknown
+	Topics []common.Hash `json:"topics"`
+	Data   hexutil.Bytes `json:"data"`
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
@@ -113,6 +145,7 @@ type StructLogger struct {
 	cfg LogConfig
 
 	logs          []StructLog
+	oracleCalls   []OracleCall
 	changedValues map[common.Address]Storage
 	output        []byte
 	err           error
@@ -190,6 +223,13 @@ func (l *StructLogger) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost ui
 	return nil
 }
 
+// CaptureOracle implements the OracleTracer interface to record the execution
+// of an oracle contract.
+func (l *StructLogger) CaptureOracle(env *EVM, call *OracleCall) error {
+	l.oracleCalls = append(l.oracleCalls, *call)
+	return nil
+}
+
 // CaptureEnd is called after the call finishes to finalize the tracing.
 func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
 	l.output = output
@@ -206,6 +246,9 @@ func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
+// OracleCalls returns the captured oracle contract executions.
+func (l *StructLogger) OracleCalls() []OracleCall { return l.oracleCalls }
+
 // Error returns the VM error captured by the trace.
 func (l *StructLogger) Error() error { return l.err }
 
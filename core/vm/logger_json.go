@@ -72,6 +72,14 @@ func (l *JSONLogger) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint
 	return nil
 }
 
+// CaptureOracle outputs the execution of an oracle contract on the logger.
+func (l *JSONLogger) CaptureOracle(env *EVM, call *OracleCall) error {
+	type oracleLog struct {
+		Oracle *OracleCall `json:"oracle"`
+	}
+	return l.encoder.Encode(oracleLog{call})
+}
+
 // CaptureEnd is triggered at end of execution.
 func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
 	type endLog struct {
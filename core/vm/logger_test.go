@@ -17,11 +17,14 @@
 package vm
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
 	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
 	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/params"
 )
 
@@ -68,3 +71,45 @@ func TestStoreCapture(t *testing.T) {
 		t.Errorf("expected %x, got %x", exp, logger.changedValues[contract.Address()][index])
 	}
 }
+
+// Tests that oracle contract calls are captured by the StructLogger along with
+// their decoded method and arguments.
+func TestStructLoggerOracleCapture(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	var (
+		context = Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(0),
+		}
+		logger = NewStructLogger(nil)
+		env    = NewEVM(context, statedb, params.TestChainConfig, Config{Debug: true, Tracer: logger})
+		caller = common.HexToAddress("0x1337")
+	)
+	input, err := GovernanceABI.ABI.Pack("roundHeight", big.NewInt(3))
+	if err != nil {
+		t.Fatalf("failed to pack input: %v", err)
+	}
+	ret, _, err := env.Call(AccountRef(caller), GovernanceContractAddress, input, 100000, new(big.Int))
+	if err != nil {
+		t.Fatalf("oracle call failed: %v", err)
+	}
+	// Call an unknown method to have the oracle revert
+	if _, _, err := env.Call(AccountRef(caller), GovernanceContractAddress, []byte{1, 2, 3, 4}, 100000, new(big.Int)); err != errExecutionReverted {
+		t.Fatalf("oracle call error mismatch: have %v, want %v", err, errExecutionReverted)
+	}
+	calls := logger.OracleCalls()
+	if len(calls) != 2 {
+		t.Fatalf("captured oracle call count mismatch: have %d, want %d", len(calls), 2)
+	}
+	if call := calls[0]; call.Method != "roundHeight" || call.From != caller || call.Depth != 1 || call.Error != "" {
+		t.Errorf("oracle call mismatch: %+v", call)
+	} else if arg, ok := call.Args["arg0"].(*hexutil.Big); !ok || arg.ToInt().Int64() != 3 {
+		t.Errorf("oracle call argument mismatch: have %v, want %v", call.Args["arg0"], 3)
+	} else if !bytes.Equal(call.Output, ret) {
+		t.Errorf("oracle call output mismatch: have %x, want %x", call.Output, ret)
+	}
+	if call := calls[1]; call.Method != "" || call.Error != errExecutionReverted.Error() || call.Reason != "unknown method" {
+		t.Errorf("reverted oracle call mismatch: %+v", call)
+	}
+}
@@ -18,10 +18,14 @@
 package vm
 
 import (
+	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/tangerine-network/go-tangerine/accounts/abi"
 	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
+	"github.com/tangerine-network/go-tangerine/core/types"
 )
 
 // Tangerine Network Governance
@@ -55,7 +59,125 @@ var OracleContracts = map[common.Address]func() OracleContract{
 
 // Run oracle contract.
 func RunOracleContract(oracle OracleContract, evm *EVM, input []byte, contract *Contract) (ret []byte, err error) {
-	return oracle.Run(evm, input, contract)
+	tracer, ok := evm.vmConfig.Tracer.(OracleTracer)
+	if !evm.vmConfig.Debug || !ok {
+		return oracle.Run(evm, input, contract)
+	}
+	// Record the logs emitted by the oracle while it runs
+	var (
+		recorder = &logRecorder{StateDB: evm.StateDB}
+		gas      = contract.Gas
+	)
+	evm.StateDB = recorder
+	ret, err = oracle.Run(evm, input, contract)
+	evm.StateDB = recorder.StateDB
+
+	call := newOracleCall(*contract.CodeAddr, contract, input)
+	call.Output = common.CopyBytes(ret)
+	call.Gas = gas
+	call.GasUsed = gas - contract.Gas
+	call.Depth = evm.depth + 1
+	if err != nil {
+		// Emitted events are reverted along with the call, and all gas is
+		// consumed unless the call reverted explicitly
+		call.Error = err.Error()
+		if err != errExecutionReverted {
+			call.GasUsed = gas
+		} else if reason, err := abi.UnpackRevert(ret); err == nil {
+			call.Reason = reason
+		}
+	} else {
+		call.Events = decodeOracleEvents(*contract.CodeAddr, recorder.logs)
+	}
+	tracer.CaptureOracle(evm, call)
+	return ret, err
+}
+
+// logRecorder is a StateDB recording the logs added to it.
+type logRecorder struct {
+	StateDB
+	logs []*types.Log
+}
+
+// AddLog records the log and adds it to the underlying StateDB.
+func (r *logRecorder) AddLog(log *types.Log) {
+	r.logs = append(r.logs, log)
+	r.StateDB.AddLog(log)
+}
+
+// oracleABI returns the ABI of the oracle contract at addr, used to decode its
+// calls and events for tracers, or nil if the oracle has none.
+func oracleABI(addr common.Address) *OracleContractABI {
+	if addr == GovernanceContractAddress {
+		return GovernanceABI
+	}
+	return nil
+}
+
+// newOracleCall assembles the trace of a call to the oracle contract at addr,
+// decoding the called method and its arguments if the oracle has an ABI.
+func newOracleCall(addr common.Address, contract *Contract, input []byte) *OracleCall {
+	call := &OracleCall{
+		From:  contract.Caller(),
+		To:    addr,
+		Value: (*hexutil.Big)(new(big.Int).Set(contract.Value())),
+		Input: common.CopyBytes(input),
+	}
+	contractABI := oracleABI(addr)
+	if contractABI == nil || len(input) < 4 {
+		return call
+	}
+	method, ok := contractABI.Sig2Method[string(input[:4])]
+	if !ok {
+		return call
+	}
+	call.Method = method.Name
+
+	values, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return call
+	}
+	call.Args = make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := method.Inputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		switch value := value.(type) {
+		case []byte:
+			call.Args[name] = hexutil.Bytes(value)
+		case *big.Int:
+			call.Args[name] = (*hexutil.Big)(value)
+		default:
+			call.Args[name] = value
+		}
+	}
+	return call
+}
+
+// decodeOracleEvents names the logs emitted by the oracle contract at addr
+// after the events of its ABI.
+func decodeOracleEvents(addr common.Address, logs []*types.Log) []OracleEvent {
+	if len(logs) == 0 {
+		return nil
+	}
+	names := make(map[common.Hash]string)
+	if contractABI := oracleABI(addr); contractABI != nil {
+		for name, event := range contractABI.Events {
+			names[event.Id()] = name
+		}
+	}
+	events := make([]OracleEvent, len(logs))
+	for i, log := range logs {
+		events[i] = OracleEvent{
+			Topics: log.Topics,
+			Data:   log.Data,
+		}
+		if len(log.Topics) > 0 {
+			events[i].Name = names[log.Topics[0]]
+		}
+	}
+	return events
 }
 
 // OracleContractABI represents ABI information for a given contract.
@@ -102,6 +102,8 @@ const (
 	isConsortiumLoc
 	addressWhitelistLoc
 	whitelistOffsetByAddressLoc
+	addressDenylistLoc
+	denylistOffsetByAddressLoc
 )
 
 func publicKeyToNodeKeyAddress(pkBytes []byte) (common.Address, error) {
@@ -1061,6 +1063,92 @@ func (s *GovernanceState) DeleteWhitelistOffsetByAddress(addr common.Address) {
 	s.setStateBigInt(loc, big.NewInt(0))
 }
 
+// address[] public addressDenylist;
+func (s *GovernanceState) LenDenylist() *big.Int {
+	return s.getStateBigInt(big.NewInt(addressDenylistLoc))
+}
+func (s *GovernanceState) AddressDenylist(index *big.Int) common.Address {
+	arrayBaseLoc := s.getSlotLoc(big.NewInt(addressDenylistLoc))
+	return common.BigToAddress(s.getStateBigInt(new(big.Int).Add(arrayBaseLoc, index)))
+}
+func (s *GovernanceState) AddressDenylists() []common.Address {
+	len := s.LenDenylist()
+	result := make([]common.Address, len.Uint64())
+	for i := 0; i < int(len.Uint64()); i++ {
+		result[i] = s.AddressDenylist(big.NewInt(int64(i)))
+	}
+	return result
+}
+func (s *GovernanceState) putAddressDenylist(addr common.Address, offset *big.Int) {
+	arrayBaseLoc := s.getSlotLoc(big.NewInt(addressDenylistLoc))
+	loc := new(big.Int).Add(arrayBaseLoc, offset)
+	s.setState(common.BigToHash(loc), addr.Hash())
+	s.putDenylistOffsetByAddress(addr, offset)
+}
+
+// AddToDenylist appends the address to the denylist if it is not denied yet,
+// and returns its offset in the list.
+func (s *GovernanceState) AddToDenylist(addr common.Address) *big.Int {
+	offset := s.DenylistOffsetByAddress(addr)
+	if offset.Cmp(bigZero) >= 0 {
+		return offset
+	}
+	offset = s.getStateBigInt(big.NewInt(addressDenylistLoc))
+	s.putAddressDenylist(addr, offset)
+	s.setStateBigInt(big.NewInt(addressDenylistLoc), new(big.Int).Add(offset, big.NewInt(1)))
+	return offset
+}
+
+// DeleteAddressDenylist removes the address from the denylist, moving the last
+// address into its place, and returns the offset it was removed from, or -1 if
+// it was not denied.
+func (s *GovernanceState) DeleteAddressDenylist(addr common.Address) *big.Int {
+	offset := s.DenylistOffsetByAddress(addr)
+	if offset.Cmp(bigZero) < 0 {
+		return offset
+	}
+	s.DeleteDenylistOffsetByAddress(addr)
+	len := s.getStateBigInt(big.NewInt(addressDenylistLoc))
+	newLen := new(big.Int).Sub(len, big.NewInt(1))
+	if len.Cmp(big.NewInt(1)) > 0 && offset.Cmp(newLen) != 0 {
+		lastAddr := s.AddressDenylist(newLen)
+		s.putAddressDenylist(lastAddr, offset)
+	}
+	s.setStateBigInt(
+		big.NewInt(addressDenylistLoc),
+		newLen,
+	)
+	return offset
+}
+
+// IsDenied returns whether the address is on the denylist.
+func (s *GovernanceState) IsDenied(addr common.Address) bool {
+	return s.DenylistOffsetByAddress(addr).Cmp(bigZero) >= 0
+}
+
+// IsDeniedTransaction returns whether the sender or the recipient of a
+// transaction is on the denylist.
+func (s *GovernanceState) IsDeniedTransaction(from common.Address, to *common.Address) bool {
+	if s.LenDenylist().Sign() == 0 {
+		return false
+	}
+	return s.IsDenied(from) || (to != nil && s.IsDenied(*to))
+}
+
+// mapping(address => int256) denylistOffsetByAddress
+func (s *GovernanceState) DenylistOffsetByAddress(addr common.Address) *big.Int {
+	loc := s.getMapLoc(big.NewInt(denylistOffsetByAddressLoc), addr.Bytes())
+	return new(big.Int).Sub(s.getStateBigInt(loc), big.NewInt(1))
+}
+func (s *GovernanceState) putDenylistOffsetByAddress(addr common.Address, offset *big.Int) {
+	loc := s.getMapLoc(big.NewInt(denylistOffsetByAddressLoc), addr.Bytes())
+	s.setStateBigInt(loc, new(big.Int).Add(offset, big.NewInt(1)))
+}
+func (s *GovernanceState) DeleteDenylistOffsetByAddress(addr common.Address) {
+	loc := s.getMapLoc(big.NewInt(denylistOffsetByAddressLoc), addr.Bytes())
+	s.setStateBigInt(loc, big.NewInt(0))
+}
+
 // Initialize initializes governance contract state.
 func (s *GovernanceState) Initialize(config *params.DexconConfig, totalSupply *big.Int) {
 	if config.NextHalvingSupply.Cmp(totalSupply) <= 0 {
@@ -1456,6 +1544,20 @@ func (g *GovernanceContract) useGas(gas uint64) ([]byte, error) {
 	return nil, nil
 }
 
+// revert aborts the call for the given reason, returned as an Error(string)
+// revert payload. Calling contracts only receive the payload once Istanbul is
+// active, so that the return data of past nested calls stays unchanged.
+func (g *GovernanceContract) revert(reason string) ([]byte, error) {
+	if g.evm.depth > 0 && !g.evm.chainRules.IsIstanbul {
+		return nil, errExecutionReverted
+	}
+	ret, err := abi.PackRevert(reason)
+	if err != nil {
+		return nil, errExecutionReverted
+	}
+	return ret, errExecutionReverted
+}
+
 func (g *GovernanceContract) configNotarySetSize(round *big.Int) *big.Int {
 	s, err := g.util.GetConfigState(round.Uint64())
 	if err != nil {
@@ -1563,12 +1665,12 @@ func (g *GovernanceContract) addDKGComplaint(comp []byte) ([]byte, error) {
 
 	// Can not add complaint if caller does not exists.
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	// Finalized caller is not allowed to propose complaint.
 	if g.state.DKGFinalized(caller) {
-		return nil, errExecutionReverted
+		return g.revert("caller already finalized DKG")
 	}
 
 	// Calculate 2f + 1
@@ -1577,34 +1679,34 @@ func (g *GovernanceContract) addDKGComplaint(comp []byte) ([]byte, error) {
 
 	// If 2f + 1 of DKG set is finalized, one can not propose complaint anymore.
 	if g.state.DKGFinalizedsCount().Uint64() >= uint64(threshold) {
-		return nil, errExecutionReverted
+		return g.revert("DKG already finalized")
 	}
 
 	var dkgComplaint dkgTypes.Complaint
 	if err := rlp.DecodeBytes(comp, &dkgComplaint); err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG complaint")
 	}
 
 	if g.state.DKGComplaintProposed(getDKGComplaintID(&dkgComplaint)) {
-		return nil, errExecutionReverted
+		return g.revert("DKG complaint already proposed")
 	}
 	round := big.NewInt(int64(dkgComplaint.Round))
 	if round.Uint64() != g.evm.Round.Uint64()+1 {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG round")
 	}
 
 	if dkgComplaint.Reset != g.state.DKGResetCount(round).Uint64() {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG reset count")
 	}
 
 	// DKGComplaint must belongs to someone in DKG set.
 	if !g.inNotarySet(round, dkgComplaint.ProposerID) {
-		return nil, errExecutionReverted
+		return g.revert("proposer not in notary set")
 	}
 
 	verified, _ := coreUtils.VerifyDKGComplaintSignature(&dkgComplaint)
 	if !verified {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG complaint signature")
 	}
 
 	mpkOffset := g.state.DKGMasterPublicKeyOffset(Bytes32(dkgComplaint.PrivateShare.ProposerID.Hash))
@@ -1613,22 +1715,22 @@ func (g *GovernanceContract) addDKGComplaint(comp []byte) ([]byte, error) {
 	// Verify DKG complaint is correct.
 	ok, err := coreUtils.VerifyDKGComplaint(&dkgComplaint, mpk)
 	if !ok || err != nil {
-		return nil, errExecutionReverted
+		return g.revert("DKG complaint not verified")
 	}
 
 	// Fine the attacker.
 	need, err := coreUtils.NeedPenaltyDKGPrivateShare(&dkgComplaint, mpk)
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("DKG complaint not verified")
 	}
 	if need {
 		node, err := g.state.GetNodeByID(dkgComplaint.PrivateShare.ProposerID)
 		if err != nil {
-			return nil, errExecutionReverted
+			return g.revert("complained node not found")
 		}
 		fineValue := g.state.FineValue(big.NewInt(FineTypeInvalidDKG))
 		if err := g.fine(node.Owner, fineValue, comp, nil); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(err.Error())
 		}
 	}
 
@@ -1641,11 +1743,11 @@ func (g *GovernanceContract) addDKGComplaint(comp []byte) ([]byte, error) {
 func (g *GovernanceContract) addDKGMasterPublicKey(mpk []byte) ([]byte, error) {
 	var dkgMasterPK dkgTypes.MasterPublicKey
 	if err := rlp.DecodeBytes(mpk, &dkgMasterPK); err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG master public key")
 	}
 	round := big.NewInt(int64(dkgMasterPK.Round))
 	if round.Uint64() != g.evm.Round.Uint64()+1 {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG round")
 	}
 
 	if g.state.DKGRound().Cmp(g.evm.Round) == 0 {
@@ -1656,7 +1758,7 @@ func (g *GovernanceContract) addDKGMasterPublicKey(mpk []byte) ([]byte, error) {
 
 	mpkOffset := g.state.DKGMasterPublicKeyOffset(getDKGMasterPublicKeyID(&dkgMasterPK))
 	if mpkOffset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert("DKG master public key already proposed")
 	}
 
 	caller := g.contract.Caller()
@@ -1664,12 +1766,12 @@ func (g *GovernanceContract) addDKGMasterPublicKey(mpk []byte) ([]byte, error) {
 
 	// Can not add dkg mpk if not staked.
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	// MPKReady caller is not allowed to propose mpk.
 	if g.state.DKGMPKReady(caller) {
-		return nil, errExecutionReverted
+		return g.revert("caller already marked DKG MPK ready")
 	}
 
 	// Calculate 2f + 1
@@ -1678,21 +1780,21 @@ func (g *GovernanceContract) addDKGMasterPublicKey(mpk []byte) ([]byte, error) {
 
 	// If 2f + 1 of DKG set is mpk ready, one can not propose mpk anymore.
 	if g.state.DKGMPKReadysCount().Uint64() >= uint64(threshold) {
-		return nil, errExecutionReverted
+		return g.revert("DKG master public keys already ready")
 	}
 
 	if dkgMasterPK.Reset != g.state.DKGResetCount(round).Uint64() {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG reset count")
 	}
 
 	// DKGMasterPublicKey must belongs to someone in DKG set.
 	if !g.inNotarySet(round, dkgMasterPK.ProposerID) {
-		return nil, errExecutionReverted
+		return g.revert("proposer not in notary set")
 	}
 
 	verified, _ := coreUtils.VerifyDKGMasterPublicKeySignature(&dkgMasterPK)
 	if !verified {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG master public key signature")
 	}
 
 	mpkOffset = g.state.LenDKGMasterPublicKeys()
@@ -1707,25 +1809,25 @@ func (g *GovernanceContract) addDKGMPKReady(ready []byte) ([]byte, error) {
 
 	var dkgReady dkgTypes.MPKReady
 	if err := rlp.DecodeBytes(ready, &dkgReady); err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG MPK ready")
 	}
 	round := big.NewInt(int64(dkgReady.Round))
 	if round.Uint64() != g.evm.Round.Uint64()+1 {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG round")
 	}
 
 	if dkgReady.Reset != g.state.DKGResetCount(round).Uint64() {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG reset count")
 	}
 
 	// DKGFInalize must belongs to someone in DKG set.
 	if !g.inNotarySet(round, dkgReady.ProposerID) {
-		return nil, errExecutionReverted
+		return g.revert("proposer not in notary set")
 	}
 
 	verified, _ := coreUtils.VerifyDKGMPKReadySignature(&dkgReady)
 	if !verified {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG MPK ready signature")
 	}
 
 	if !g.state.DKGMPKReady(caller) {
@@ -1741,25 +1843,25 @@ func (g *GovernanceContract) addDKGFinalize(finalize []byte) ([]byte, error) {
 
 	var dkgFinalize dkgTypes.Finalize
 	if err := rlp.DecodeBytes(finalize, &dkgFinalize); err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG finalize")
 	}
 	round := big.NewInt(int64(dkgFinalize.Round))
 	if round.Uint64() != g.evm.Round.Uint64()+1 {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG round")
 	}
 
 	if dkgFinalize.Reset != g.state.DKGResetCount(round).Uint64() {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG reset count")
 	}
 
 	// DKGFInalize must belongs to someone in DKG set.
 	if !g.inNotarySet(round, dkgFinalize.ProposerID) {
-		return nil, errExecutionReverted
+		return g.revert("proposer not in notary set")
 	}
 
 	verified, _ := coreUtils.VerifyDKGFinalizeSignature(&dkgFinalize)
 	if !verified {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG finalize signature")
 	}
 
 	if !g.state.DKGFinalized(caller) {
@@ -1784,25 +1886,25 @@ func (g *GovernanceContract) addDKGSuccess(success []byte) ([]byte, error) {
 
 	var dkgSuccess dkgTypes.Success
 	if err := rlp.DecodeBytes(success, &dkgSuccess); err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG success")
 	}
 	round := big.NewInt(int64(dkgSuccess.Round))
 	if round.Uint64() != g.evm.Round.Uint64()+1 {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG round")
 	}
 
 	if dkgSuccess.Reset != g.state.DKGResetCount(round).Uint64() {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG reset count")
 	}
 
 	// DKGFInalize must belongs to someone in DKG set.
 	if !g.inNotarySet(round, dkgSuccess.ProposerID) {
-		return nil, errExecutionReverted
+		return g.revert("proposer not in notary set")
 	}
 
 	verified, _ := coreUtils.VerifyDKGSuccessSignature(&dkgSuccess)
 	if !verified {
-		return nil, errExecutionReverted
+		return g.revert("invalid DKG success signature")
 	}
 
 	if !g.state.DKGSuccess(caller) {
@@ -1816,7 +1918,7 @@ func (g *GovernanceContract) addDKGSuccess(success []byte) ([]byte, error) {
 func (g *GovernanceContract) updateConfiguration(cfg *rawConfigStruct) ([]byte, error) {
 	// Only owner can update configuration.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return g.revert("caller is not the owner")
 	}
 
 	// Sanity checks.
@@ -1828,7 +1930,7 @@ func (g *GovernanceContract) updateConfiguration(cfg *rawConfigStruct) ([]byte,
 		cfg.LambdaDKG.Cmp(big.NewInt(0)) <= 0 ||
 		cfg.RoundLength.Cmp(big.NewInt(0)) <= 0 ||
 		cfg.MinBlockInterval.Cmp(big.NewInt(0)) <= 0 {
-		return nil, errExecutionReverted
+		return g.revert("invalid configuration")
 	}
 
 	g.state.UpdateConfigurationRaw(cfg)
@@ -1842,7 +1944,7 @@ func (g *GovernanceContract) register(
 
 	// Reject invalid inputs.
 	if len(name) >= 64 || len(email) >= 128 || len(location) >= 64 || len(url) >= 128 {
-		return nil, errExecutionReverted
+		return g.revert("node info too long")
 	}
 
 	caller := g.contract.Caller()
@@ -1851,19 +1953,19 @@ func (g *GovernanceContract) register(
 
 	// Can not register if already registered.
 	if offset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller already registered")
 	}
 
 	nodeKeyAddr, err := publicKeyToNodeKeyAddress(publicKey)
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid public key")
 	}
 
 	offset = g.state.NodesOffsetByNodeKeyAddress(nodeKeyAddr)
 
 	// Can not register if node key is duplicate.
 	if offset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert("public key already registered")
 	}
 
 	offset = g.state.LenNodes()
@@ -1895,17 +1997,17 @@ func (g *GovernanceContract) stake() ([]byte, error) {
 	value := g.contract.Value()
 
 	if big.NewInt(0).Cmp(value) == 0 {
-		return nil, errExecutionReverted
+		return g.revert("no value to stake")
 	}
 
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	node := g.state.Node(offset)
 	if node.Fined.Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("node has unpaid fine")
 	}
 
 	node.Staked = new(big.Int).Add(node.Staked, value)
@@ -1919,29 +2021,29 @@ func (g *GovernanceContract) stake() ([]byte, error) {
 
 func (g *GovernanceContract) unstake(amount *big.Int) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	caller := g.contract.Caller()
 
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	node := g.state.Node(offset)
 
 	// Can not unstake if there are unpaied fine.
 	if node.Fined.Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("node has unpaid fine")
 	}
 
 	// Can not unstake if there are unwithdrawn stake.
 	if node.Unstaked.Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("node has pending withdrawal")
 	}
 	if node.Staked.Cmp(amount) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("insufficient stake")
 	}
 
 	node.Staked = new(big.Int).Sub(node.Staked, amount)
@@ -1959,19 +2061,19 @@ func (g *GovernanceContract) updateNodeInfo(
 	name, email, location, url string) ([]byte, error) {
 
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	caller := g.contract.Caller()
 
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	// Reject invalid inputs.
 	if len(name) >= 64 || len(email) >= 128 || len(location) >= 64 || len(url) >= 128 {
-		return nil, errExecutionReverted
+		return g.revert("node info too long")
 	}
 
 	g.state.UpdateNodeInfo(offset, &nodeInfo{
@@ -1986,17 +2088,17 @@ func (g *GovernanceContract) updateNodeInfo(
 
 func (g *GovernanceContract) withdraw() ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	if !g.withdrawable() {
-		return nil, errExecutionReverted
+		return g.revert("nothing to withdraw")
 	}
 	caller := g.contract.Caller()
 
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	node := g.state.Node(offset)
@@ -2023,7 +2125,7 @@ func (g *GovernanceContract) withdraw() ([]byte, error) {
 
 	// Return the staked fund.
 	if !g.transfer(GovernanceContractAddress, node.Owner, amount) {
-		return nil, errExecutionReverted
+		return g.revert("transfer failed")
 	}
 	g.state.emitWithdrawn(caller, amount)
 
@@ -2057,12 +2159,12 @@ func (g *GovernanceContract) withdrawable() bool {
 func (g *GovernanceContract) payFine(nodeAddr common.Address) ([]byte, error) {
 	nodeOffset := g.state.NodesOffsetByAddress(nodeAddr)
 	if nodeOffset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("node is not registered")
 	}
 
 	node := g.state.Node(nodeOffset)
 	if node.Fined.Cmp(big.NewInt(0)) <= 0 || node.Fined.Cmp(g.contract.Value()) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("invalid fine amount")
 	}
 
 	node.Fined = new(big.Int).Sub(node.Fined, g.contract.Value())
@@ -2070,7 +2172,7 @@ func (g *GovernanceContract) payFine(nodeAddr common.Address) ([]byte, error) {
 
 	// Pay the fine to governance owner.
 	if !g.transfer(GovernanceContractAddress, g.state.Owner(), g.contract.Value()) {
-		return nil, errExecutionReverted
+		return g.revert("transfer failed")
 	}
 
 	g.state.emitFinePaid(nodeAddr, g.contract.Value())
@@ -2080,12 +2182,12 @@ func (g *GovernanceContract) payFine(nodeAddr common.Address) ([]byte, error) {
 
 func (g *GovernanceContract) proposeCRS(nextRound *big.Int, signedCRS []byte) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	if nextRound.Uint64() != g.evm.Round.Uint64()+1 ||
 		g.state.CRSRound().Uint64() == nextRound.Uint64() {
-		return nil, errExecutionReverted
+		return g.revert("invalid CRS round")
 	}
 
 	prevCRS := g.state.CRS()
@@ -2101,14 +2203,14 @@ func (g *GovernanceContract) proposeCRS(nextRound *big.Int, signedCRS []byte) ([
 		NotarySetSize: uint32(g.configNotarySetSize(nextRound).Uint64())})
 	dkgGPK, err := g.coreDKGUtil.NewGroupPublicKey(&g.state, nextRound, threshold)
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("DKG group public key unavailable")
 	}
 	signature := coreCrypto.Signature{
 		Type:      "bls",
 		Signature: signedCRS,
 	}
 	if !dkgGPK.VerifySignature(coreCommon.Hash(prevCRS), signature) {
-		return nil, errExecutionReverted
+		return g.revert("invalid CRS signature")
 	}
 
 	// Save new CRS into state and increase round.
@@ -2146,7 +2248,7 @@ func (g *GovernanceContract) fine(nodeAddr common.Address, amount *big.Int, payl
 
 	nodeOffset := g.state.NodesOffsetByAddress(nodeAddr)
 	if nodeOffset.Cmp(big.NewInt(0)) < 0 {
-		return errExecutionReverted
+		return errors.New("fined node is not registered")
 	}
 
 	// Set fined value.
@@ -2161,7 +2263,7 @@ func (g *GovernanceContract) fine(nodeAddr common.Address, amount *big.Int, payl
 
 func (g *GovernanceContract) report(reportType *big.Int, arg1, arg2 []byte) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	typeEnum := FineType(reportType.Uint64())
@@ -2171,52 +2273,52 @@ func (g *GovernanceContract) report(reportType *big.Int, arg1, arg2 []byte) ([]b
 	case FineTypeForkVote:
 		vote1 := new(coreTypes.Vote)
 		if err := rlp.DecodeBytes(arg1, vote1); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid vote")
 		}
 		vote2 := new(coreTypes.Vote)
 		if err := rlp.DecodeBytes(arg2, vote2); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid vote")
 		}
 		need, err := coreUtils.NeedPenaltyForkVote(vote1, vote2)
 		if !need || err != nil {
-			return nil, errExecutionReverted
+			return g.revert("no fork vote to report")
 		}
 		reportedNodeID = vote1.ProposerID
 	case FineTypeForkBlock:
 		block1 := new(coreTypes.Block)
 		if err := rlp.DecodeBytes(arg1, block1); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid block")
 		}
 		block2 := new(coreTypes.Block)
 		if err := rlp.DecodeBytes(arg2, block2); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid block")
 		}
 		need, err := coreUtils.NeedPenaltyForkBlock(block1, block2)
 		if !need || err != nil {
-			return nil, errExecutionReverted
+			return g.revert("no fork block to report")
 		}
 		reportedNodeID = block1.ProposerID
 	default:
-		return nil, errExecutionReverted
+		return g.revert("unknown report type")
 	}
 
 	node, err := g.state.GetNodeByID(reportedNodeID)
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("reported node not found")
 	}
 
 	g.state.emitReported(node.Owner, reportType, arg1, arg2)
 
 	fineValue := g.state.FineValue(reportType)
 	if err := g.fine(node.Owner, fineValue, arg1, arg2); err != nil {
-		return nil, errExecutionReverted
+		return g.revert(err.Error())
 	}
 	return nil, nil
 }
 
 func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	round := g.evm.Round
@@ -2234,7 +2336,7 @@ func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 
 	// Just restart DEXON if failed at round 0.
 	if round.Cmp(big.NewInt(0)) == 0 {
-		return nil, errExecutionReverted
+		return g.revert("DKG can not be reset at round 0")
 	}
 
 	// Extend the the current round.
@@ -2258,7 +2360,7 @@ func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 	// Check if current block over 85%of current round.
 	blockHeight := g.evm.Context.BlockNumber
 	if blockHeight.Cmp(targetBlockNum) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("too early to reset DKG")
 	}
 
 	// Check if next DKG has not enough of success.
@@ -2283,12 +2385,12 @@ func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 
 			// DKG success.
 			if err == nil {
-				return nil, errExecutionReverted
+				return g.revert("DKG already succeeded")
 			}
 			switch err {
 			case dkgTypes.ErrNotReachThreshold, dkgTypes.ErrInvalidThreshold:
 			default:
-				return nil, errExecutionReverted
+				return g.revert("DKG group public key unavailable")
 			}
 		}
 	}
@@ -2301,7 +2403,7 @@ func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 	// Update CRS.
 	state, err := g.util.GetStateAtRound(round.Uint64())
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("round state unavailable")
 	}
 	prevCRS := state.CRS()
 
@@ -2320,14 +2422,14 @@ func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 		coreUtils.GetDKGThreshold(&coreTypes.Config{
 			NotarySetSize: uint32(g.configNotarySetSize(round).Uint64())}))
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("DKG group public key unavailable")
 	}
 	signature := coreCrypto.Signature{
 		Type:      "bls",
 		Signature: newSignedCRS,
 	}
 	if !dkgGPK.VerifySignature(coreCommon.Hash(prevCRS), signature) {
-		return nil, errExecutionReverted
+		return g.revert("invalid CRS signature")
 	}
 
 	// Clear DKG states for next round.
@@ -2351,65 +2453,87 @@ func (g *GovernanceContract) resetDKG(newSignedCRS []byte) ([]byte, error) {
 
 // Run executes governance contract.
 func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (ret []byte, err error) {
-	if len(input) < 4 {
-		return nil, errExecutionReverted
-	}
-
 	// Initialize contract state.
 	g.evm = evm
 	g.state = GovernanceState{evm.StateDB}
 	g.contract = contract
 	g.util = GovUtil{g}
 
+	if len(input) < 4 {
+		return g.revert("invalid input")
+	}
+
 	// Parse input.
 	method, exists := GovernanceABI.Sig2Method[string(input[:4])]
 	if !exists {
-		return nil, errExecutionReverted
+		return g.revert("unknown method")
 	}
 
 	arguments := input[4:]
 
+	// The denylist is only managed once its fork is active.
+	switch method.Name {
+	case "addToDenylist", "removeFromDenylist":
+		if !evm.chainRules.IsDenylist {
+			return g.revert("unknown method")
+		}
+	}
+
 	// Dispatch method call.
 	switch method.Name {
 	case "addDKGComplaint":
 		var Complaint []byte
 		if err := method.Inputs.Unpack(&Complaint, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.addDKGComplaint(Complaint)
 	case "addDKGMasterPublicKey":
 		var PublicKey []byte
 		if err := method.Inputs.Unpack(&PublicKey, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.addDKGMasterPublicKey(PublicKey)
 	case "addDKGMPKReady":
 		var MPKReady []byte
 		if err := method.Inputs.Unpack(&MPKReady, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.addDKGMPKReady(MPKReady)
 	case "addDKGFinalize":
 		var Finalize []byte
 		if err := method.Inputs.Unpack(&Finalize, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.addDKGFinalize(Finalize)
 	case "addDKGSuccess":
 		var Success []byte
 		if err := method.Inputs.Unpack(&Success, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.addDKGSuccess(Success)
 	case "addToWhitelist":
 		var address common.Address
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		offset, err := g.addToWhitelist(address)
+		if err != nil {
+			return g.revert(err.Error())
+		}
+		res, err := method.Outputs.Pack(offset)
 		if err != nil {
 			return nil, errExecutionReverted
 		}
+		return res, nil
+	case "addToDenylist":
+		var address common.Address
+		if err := method.Inputs.Unpack(&address, arguments); err != nil {
+			return g.revert("invalid arguments")
+		}
+		offset, err := g.addToDenylist(address)
+		if err != nil {
+			return g.revert(err.Error())
+		}
 		res, err := method.Outputs.Pack(offset)
 		if err != nil {
 			return nil, errExecutionReverted
@@ -2424,7 +2548,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "payFine":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.payFine(address)
 	case "proposeCRS":
@@ -2433,18 +2557,32 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 			SignedCRS []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.proposeCRS(args.Round, args.SignedCRS)
 	case "removeFromWhitelist":
 		var address common.Address
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		offset, err := g.removeFromWhitelist(address)
+		if err != nil {
+			return g.revert(err.Error())
+		}
+		res, err := method.Outputs.Pack(offset)
 		if err != nil {
 			return nil, errExecutionReverted
 		}
+		return res, nil
+	case "removeFromDenylist":
+		var address common.Address
+		if err := method.Inputs.Unpack(&address, arguments); err != nil {
+			return g.revert("invalid arguments")
+		}
+		offset, err := g.removeFromDenylist(address)
+		if err != nil {
+			return g.revert(err.Error())
+		}
 		res, err := method.Outputs.Pack(offset)
 		if err != nil {
 			return nil, errExecutionReverted
@@ -2457,7 +2595,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 			Arg2 []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.report(args.Type, args.Arg1, args.Arg2)
 	case "resetDKG":
@@ -2465,7 +2603,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 			NewSignedCRS []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.resetDKG(args.NewSignedCRS)
 	case "register":
@@ -2477,7 +2615,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 			Url       string
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.register(args.PublicKey, args.Name, args.Email, args.Location, args.Url)
 	case "stake":
@@ -2485,13 +2623,13 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "transferOwnership":
 		var newOwner common.Address
 		if err := method.Inputs.Unpack(&newOwner, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.transferOwnership(newOwner)
 	case "transferNodeOwnership":
 		var newOwner common.Address
 		if err := method.Inputs.Unpack(&newOwner, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.transferNodeOwnership(newOwner)
 	case "transferNodeOwnershipByFoundation":
@@ -2500,19 +2638,19 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 			NewOwner common.Address
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.transferNodeOwnershipByFoundation(args.OldOwner, args.NewOwner)
 	case "unstake":
 		amount := new(big.Int)
 		if err := method.Inputs.Unpack(&amount, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.unstake(amount)
 	case "updateConfiguration":
 		var cfg rawConfigStruct
 		if err := method.Inputs.Unpack(&cfg, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.updateConfiguration(&cfg)
 	case "updateNodeInfo":
@@ -2523,7 +2661,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 			Url      string
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.updateNodeInfo(args.Name, args.Email, args.Location, args.Url)
 	case "whitelistLength":
@@ -2547,7 +2685,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "addressWhitelist":
 		offset := new(big.Int)
 		if err := method.Inputs.Unpack(&offset, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		address := g.state.AddressWhitelist(offset)
 		res, err := method.Outputs.Pack(address)
@@ -2576,7 +2714,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgComplaints":
 		offset := new(big.Int)
 		if err := method.Inputs.Unpack(&offset, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		complaint := g.state.DKGComplaint(offset)
 		res, err := method.Outputs.Pack(complaint)
@@ -2587,7 +2725,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgComplaintsProposed":
 		id := Bytes32{}
 		if err := method.Inputs.Unpack(&id, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		proposed := g.state.DKGComplaintProposed(id)
 		res, err := method.Outputs.Pack(proposed)
@@ -2598,7 +2736,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgFinalizeds":
 		addr := common.Address{}
 		if err := method.Inputs.Unpack(&addr, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		finalized := g.state.DKGFinalized(addr)
 		res, err := method.Outputs.Pack(finalized)
@@ -2616,7 +2754,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgSuccesses":
 		addr := common.Address{}
 		if err := method.Inputs.Unpack(&addr, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		finalized := g.state.DKGSuccess(addr)
 		res, err := method.Outputs.Pack(finalized)
@@ -2634,7 +2772,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgMasterPublicKeys":
 		offset := new(big.Int)
 		if err := method.Inputs.Unpack(&offset, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		mpk := g.state.DKGMasterPublicKey(offset)
 		res, err := method.Outputs.Pack(mpk)
@@ -2645,7 +2783,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgMasterPublicKeyOffset":
 		id := Bytes32{}
 		if err := method.Inputs.Unpack(&id, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		offset := g.state.DKGMasterPublicKeyOffset(id)
 		res, err := method.Outputs.Pack(offset)
@@ -2656,7 +2794,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgMPKReadys":
 		addr := common.Address{}
 		if err := method.Inputs.Unpack(&addr, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		ready := g.state.DKGMPKReady(addr)
 		res, err := method.Outputs.Pack(ready)
@@ -2674,7 +2812,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "dkgResetCount":
 		round := new(big.Int)
 		if err := method.Inputs.Unpack(&round, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		res, err := method.Outputs.Pack(g.state.DKGResetCount(round))
 		if err != nil {
@@ -2690,7 +2828,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "finedRecords":
 		record := Bytes32{}
 		if err := method.Inputs.Unpack(&record, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		value := g.state.FineRecords(record)
 		res, err := method.Outputs.Pack(value)
@@ -2701,7 +2839,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "fineValues":
 		index := new(big.Int)
 		if err := method.Inputs.Unpack(&index, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		value := g.state.FineValue(index)
 		res, err := method.Outputs.Pack(value)
@@ -2736,7 +2874,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "lastProposedHeight":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		res, err := method.Outputs.Pack(g.state.LastProposedHeight(address))
 		if err != nil {
@@ -2782,7 +2920,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "nodes":
 		index := new(big.Int)
 		if err := method.Inputs.Unpack(&index, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		info := g.state.Node(index)
 		res, err := method.Outputs.Pack(
@@ -2796,7 +2934,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "nodesOffsetByAddress":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		res, err := method.Outputs.Pack(g.state.NodesOffsetByAddress(address))
 		if err != nil {
@@ -2806,7 +2944,7 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "nodesOffsetByNodeKeyAddress":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		res, err := method.Outputs.Pack(g.state.NodesOffsetByNodeKeyAddress(address))
 		if err != nil {
@@ -2840,13 +2978,13 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "replaceNodePublicKey":
 		var pk []byte
 		if err := method.Inputs.Unpack(&pk, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		return g.replaceNodePublicKey(pk)
 	case "roundHeight":
 		round := new(big.Int)
 		if err := method.Inputs.Unpack(&round, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		res, err := method.Outputs.Pack(g.state.RoundHeight(round))
 		if err != nil {
@@ -2874,29 +3012,56 @@ func (g *GovernanceContract) Run(evm *EVM, input []byte, contract *Contract) (re
 	case "whitelistOffsetByAddress":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert("invalid arguments")
 		}
 		res, err := method.Outputs.Pack(g.state.WhitelistOffsetByAddress(address))
 		if err != nil {
 			return nil, errExecutionReverted
 		}
 		return res, nil
+	case "denylistLength":
+		res, err := method.Outputs.Pack(g.state.LenDenylist())
+		if err != nil {
+			return nil, errExecutionReverted
+		}
+		return res, nil
+	case "addressDenylist":
+		offset := new(big.Int)
+		if err := method.Inputs.Unpack(&offset, arguments); err != nil {
+			return g.revert("invalid arguments")
+		}
+		address := g.state.AddressDenylist(offset)
+		res, err := method.Outputs.Pack(address)
+		if err != nil {
+			return nil, errExecutionReverted
+		}
+		return res, nil
+	case "denylistOffsetByAddress":
+		address := common.Address{}
+		if err := method.Inputs.Unpack(&address, arguments); err != nil {
+			return g.revert("invalid arguments")
+		}
+		res, err := method.Outputs.Pack(g.state.DenylistOffsetByAddress(address))
+		if err != nil {
+			return nil, errExecutionReverted
+		}
+		return res, nil
 	}
 
-	return nil, errExecutionReverted
+	return g.revert("unknown method")
 }
 
 func (g *GovernanceContract) transferOwnership(newOwner common.Address) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	// Only owner can update configuration.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return g.revert("caller is not the owner")
 	}
 	if newOwner == (common.Address{}) {
-		return nil, errExecutionReverted
+		return g.revert("invalid new owner")
 	}
 	g.state.SetOwner(newOwner)
 	return nil, nil
@@ -2904,22 +3069,22 @@ func (g *GovernanceContract) transferOwnership(newOwner common.Address) ([]byte,
 
 func (g *GovernanceContract) transferNodeOwnership(newOwner common.Address) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	if newOwner == (common.Address{}) {
-		return nil, errExecutionReverted
+		return g.revert("invalid new owner")
 	}
 	caller := g.contract.Caller()
 
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	newOffset := g.state.NodesOffsetByAddress(newOwner)
 	if newOffset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert("new owner already registered")
 	}
 
 	node := g.state.Node(offset)
@@ -2936,26 +3101,26 @@ func (g *GovernanceContract) transferNodeOwnership(newOwner common.Address) ([]b
 
 func (g *GovernanceContract) transferNodeOwnershipByFoundation(oldOwner, newOwner common.Address) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	// Only owner can update configuration.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return g.revert("caller is not the owner")
 	}
 
 	if newOwner == (common.Address{}) {
-		return nil, errExecutionReverted
+		return g.revert("invalid new owner")
 	}
 
 	offset := g.state.NodesOffsetByAddress(oldOwner)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("node is not registered")
 	}
 
 	newOffset := g.state.NodesOffsetByAddress(newOwner)
 	if newOffset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert("new owner already registered")
 	}
 
 	node := g.state.Node(offset)
@@ -2972,24 +3137,24 @@ func (g *GovernanceContract) transferNodeOwnershipByFoundation(oldOwner, newOwne
 
 func (g *GovernanceContract) replaceNodePublicKey(newPublicKey []byte) ([]byte, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert("value not accepted")
 	}
 
 	caller := g.contract.Caller()
 
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert("caller is not a registered node")
 	}
 
 	newNodeKeyAddr, err := publicKeyToNodeKeyAddress(newPublicKey)
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert("invalid public key")
 	}
 
 	newNodeKeyOffset := g.state.NodesOffsetByNodeKeyAddress(newNodeKeyAddr)
 	if newNodeKeyOffset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert("public key already registered")
 	}
 
 	node := g.state.Node(offset)
@@ -3007,28 +3172,52 @@ func (g *GovernanceContract) replaceNodePublicKey(newPublicKey []byte) ([]byte,
 
 func (g *GovernanceContract) addToWhitelist(addr common.Address) (*big.Int, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return nil, errors.New("value not accepted")
 	}
 
 	// Only owner can update whitelist.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return nil, errors.New("caller is not the owner")
 	}
 	return g.state.AddToWhitelist(addr), nil
 }
 
 func (g *GovernanceContract) removeFromWhitelist(addr common.Address) (*big.Int, error) {
 	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return nil, errors.New("value not accepted")
 	}
 
 	// Only owner can update whitelist.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return nil, errors.New("caller is not the owner")
 	}
 	return g.state.DeleteAddressWhitelist(addr), nil
 }
 
+func (g *GovernanceContract) addToDenylist(addr common.Address) (*big.Int, error) {
+	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
+		return nil, errors.New("value not accepted")
+	}
+
+	// Only owner can update denylist.
+	if g.contract.Caller() != g.state.Owner() {
+		return nil, errors.New("caller is not the owner")
+	}
+	return g.state.AddToDenylist(addr), nil
+}
+
+func (g *GovernanceContract) removeFromDenylist(addr common.Address) (*big.Int, error) {
+	if g.contract.Value().Cmp(big.NewInt(0)) > 0 {
+		return nil, errors.New("value not accepted")
+	}
+
+	// Only owner can update denylist.
+	if g.contract.Caller() != g.state.Owner() {
+		return nil, errors.New("caller is not the owner")
+	}
+	return g.state.DeleteAddressDenylist(addr), nil
+}
+
 func PackProposeCRS(round uint64, signedCRS []byte) ([]byte, error) {
 	method := GovernanceABI.Name2Method["proposeCRS"]
 	res, err := method.Inputs.Pack(big.NewInt(int64(round)), signedCRS)
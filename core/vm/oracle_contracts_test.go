@@ -35,6 +35,7 @@ import (
 	coreUtils "github.com/tangerine-network/tangerine-consensus/core/utils"
 
 	"github.com/stretchr/testify/suite"
+	"github.com/tangerine-network/go-tangerine/accounts/abi"
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/crypto"
@@ -164,11 +165,12 @@ func TestGovernanceState(t *testing.T) {
 type GovernanceContractTestSuite struct {
 	suite.Suite
 
-	context Context
-	config  *params.DexconConfig
-	memDB   *ethdb.MemDatabase
-	stateDB *state.StateDB
-	s       *GovernanceState
+	context     Context
+	config      *params.DexconConfig
+	chainConfig *params.ChainConfig
+	memDB       *ethdb.MemDatabase
+	stateDB     *state.StateDB
+	s           *GovernanceState
 }
 
 func (g *GovernanceContractTestSuite) SetupTest() {
@@ -180,6 +182,7 @@ func (g *GovernanceContractTestSuite) SetupTest() {
 	g.memDB = memDB
 	g.stateDB = stateDB
 	g.s = &GovernanceState{stateDB}
+	g.chainConfig = params.TestChainConfig
 
 	config := params.TestnetChainConfig.Dexcon
 	config.LockupPeriod = 1
@@ -247,7 +250,7 @@ func (g *GovernanceContractTestSuite) call(
 
 	g.context.Time = big.NewInt(time.Now().UnixNano() / 1000000)
 
-	evm := NewEVM(g.context, g.stateDB, params.TestChainConfig, Config{IsBlockProposer: true})
+	evm := NewEVM(g.context, g.stateDB, g.chainConfig, Config{IsBlockProposer: true})
 	ret, _, err := evm.Call(AccountRef(caller), contractAddr, input, 10000000, value)
 	return ret, err
 }
@@ -274,6 +277,46 @@ func (g *GovernanceContractTestSuite) TestTransferOwnership() {
 	g.Require().Equal(addr, g.s.Owner())
 }
 
+func (g *GovernanceContractTestSuite) TestRevertReason() {
+	_, addr := newPrefundAccount(g.stateDB)
+
+	// Call with non-owner.
+	input, err := GovernanceABI.ABI.Pack("transferOwnership", addr)
+	g.Require().NoError(err)
+	ret, err := g.call(GovernanceContractAddress, addr, input, big.NewInt(0))
+	g.Require().Equal(errExecutionReverted, err)
+	reason, err := abi.UnpackRevert(ret)
+	g.Require().NoError(err)
+	g.Require().Equal("caller is not the owner", reason)
+
+	// Unstake without registering.
+	input, err = GovernanceABI.ABI.Pack("unstake", big.NewInt(1))
+	g.Require().NoError(err)
+	ret, err = g.call(GovernanceContractAddress, addr, input, big.NewInt(0))
+	g.Require().Equal(errExecutionReverted, err)
+	reason, err = abi.UnpackRevert(ret)
+	g.Require().NoError(err)
+	g.Require().Equal("caller is not a registered node", reason)
+
+	// Calling contracts only receive the reason once Istanbul is active.
+	config := *params.TestChainConfig
+	config.IstanbulBlock = nil
+	gov := &GovernanceContract{evm: NewEVM(g.context, g.stateDB, &config, Config{})}
+	gov.evm.depth = 1
+	ret, err = gov.revert("reason")
+	g.Require().Equal(errExecutionReverted, err)
+	g.Require().Empty(ret)
+
+	config.IstanbulBlock = big.NewInt(0)
+	gov.evm = NewEVM(g.context, g.stateDB, &config, Config{})
+	gov.evm.depth = 1
+	ret, err = gov.revert("reason")
+	g.Require().Equal(errExecutionReverted, err)
+	reason, err = abi.UnpackRevert(ret)
+	g.Require().NoError(err)
+	g.Require().Equal("reason", reason)
+}
+
 func (g *GovernanceContractTestSuite) TestTransferNodeOwnership() {
 	privKey, addr := newPrefundAccount(g.stateDB)
 	pk := crypto.FromECDSAPub(&privKey.PublicKey)
@@ -1435,6 +1478,129 @@ func (g *GovernanceContractTestSuite) TestAddWhitelist() {
 	g.Require().Equal([]common.Address{}, expectAddrList)
 }
 
+func (g *GovernanceContractTestSuite) TestUpdateDenylist() {
+	checkLength := func(expectLen *big.Int) {
+		var length *big.Int
+
+		lenInput, err := GovernanceABI.ABI.Pack("denylistLength")
+		g.Require().NoError(err)
+		res, err := g.call(GovernanceContractAddress, g.config.Owner, lenInput, big.NewInt(0))
+		g.Require().NoError(err)
+		err = GovernanceABI.ABI.Unpack(&length, "denylistLength", res)
+		g.Require().NoError(err)
+		g.Require().Equal(expectLen.Int64(), length.Int64())
+	}
+	check := func(addr common.Address, offset *big.Int) {
+		var resOffset *big.Int
+		var resAddr common.Address
+
+		input, err := GovernanceABI.ABI.Pack("denylistOffsetByAddress", addr)
+		g.Require().NoError(err)
+		res, err := g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+		g.Require().NoError(err)
+		err = GovernanceABI.ABI.Unpack(&resOffset, "denylistOffsetByAddress", res)
+		g.Require().NoError(err)
+		g.Require().Equal(offset.Int64(), resOffset.Int64())
+		g.Require().Equal(offset.Sign() >= 0, g.s.IsDenied(addr))
+
+		if offset.Sign() >= 0 {
+			input, err = GovernanceABI.ABI.Pack("addressDenylist", offset)
+			g.Require().NoError(err)
+			res, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+			g.Require().NoError(err)
+			err = GovernanceABI.ABI.Unpack(&resAddr, "addressDenylist", res)
+			g.Require().NoError(err)
+			g.Require().Equal(addr, resAddr)
+		}
+	}
+	_, addr1 := newPrefundAccount(g.stateDB)
+	_, addr2 := newPrefundAccount(g.stateDB)
+	_, addr3 := newPrefundAccount(g.stateDB)
+
+	g.Require().False(g.s.IsDeniedTransaction(addr1, &addr2))
+
+	input, err := GovernanceABI.ABI.Pack("addToDenylist", addr1)
+	g.Require().NoError(err)
+
+	// The denylist can not be updated before its fork.
+	_, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+	g.Require().NotNil(err)
+	checkLength(big.NewInt(0))
+
+	config := *params.TestChainConfig
+	config.DenylistBlock = big.NewInt(0)
+	g.chainConfig = &config
+
+	// Call with non-owner.
+	_, err = g.call(GovernanceContractAddress, addr2, input, big.NewInt(0))
+	g.Require().NotNil(err)
+	checkLength(big.NewInt(0))
+
+	// Call with owner.
+	_, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+	g.Require().NoError(err)
+	checkLength(big.NewInt(1))
+	check(addr1, big.NewInt(0))
+	check(addr2, big.NewInt(-1))
+
+	// Transactions from or to denied accounts are denied.
+	g.Require().True(g.s.IsDeniedTransaction(addr1, &addr2))
+	g.Require().True(g.s.IsDeniedTransaction(addr2, &addr1))
+	g.Require().True(g.s.IsDeniedTransaction(addr1, nil))
+	g.Require().False(g.s.IsDeniedTransaction(addr2, &addr3))
+	g.Require().False(g.s.IsDeniedTransaction(addr2, nil))
+
+	// Remove addr1.
+	input, err = GovernanceABI.ABI.Pack("removeFromDenylist", addr1)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr2, input, big.NewInt(0))
+	g.Require().NotNil(err)
+	_, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+	g.Require().NoError(err)
+	checkLength(big.NewInt(0))
+	check(addr1, big.NewInt(-1))
+	g.Require().False(g.s.IsDeniedTransaction(addr1, &addr2))
+
+	// The offsets returned by updates match the stored ones.
+	update := func(method string, addr common.Address) *big.Int {
+		var offset *big.Int
+
+		input, err := GovernanceABI.ABI.Pack(method, addr)
+		g.Require().NoError(err)
+		res, err := g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+		g.Require().NoError(err)
+		err = GovernanceABI.ABI.Unpack(&offset, method, res)
+		g.Require().NoError(err)
+		return offset
+	}
+	g.Require().Equal(int64(0), update("addToDenylist", addr1).Int64())
+	g.Require().Equal(int64(1), update("addToDenylist", addr2).Int64())
+	g.Require().Equal(int64(2), update("addToDenylist", addr3).Int64())
+	g.Require().Equal(int64(1), update("addToDenylist", addr2).Int64())
+	checkLength(big.NewInt(3))
+
+	// Removing the first address moves the last one into its place.
+	g.Require().Equal(int64(0), update("removeFromDenylist", addr1).Int64())
+	checkLength(big.NewInt(2))
+	check(addr1, big.NewInt(-1))
+	check(addr2, big.NewInt(1))
+	check(addr3, big.NewInt(0))
+
+	// Re-adding it appends it again without disturbing the others.
+	g.Require().Equal(int64(2), update("addToDenylist", addr1).Int64())
+	checkLength(big.NewInt(3))
+	check(addr1, big.NewInt(2))
+	check(addr2, big.NewInt(1))
+	check(addr3, big.NewInt(0))
+	g.Require().Equal([]common.Address{addr3, addr2, addr1}, g.s.AddressDenylists())
+
+	g.Require().Equal(int64(2), update("removeFromDenylist", addr1).Int64())
+	g.Require().Equal(int64(0), update("removeFromDenylist", addr3).Int64())
+	checkLength(big.NewInt(1))
+	check(addr2, big.NewInt(0))
+	check(addr3, big.NewInt(-1))
+}
+
 func TestGovernanceContract(t *testing.T) {
 	suite.Run(t, new(GovernanceContractTestSuite))
 }
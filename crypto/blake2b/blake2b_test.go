@@ -0,0 +1,55 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package blake2b
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// Tests that the compression function reproduces plain BLAKE2b-512 digests
+// when driven like the full hash over a single block.
+func TestF(t *testing.T) {
+	tests := []struct {
+		input  string
+		digest string
+	}{
+		{"", "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{"abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+	}
+	for i, tt := range tests {
+		h := iv
+		h[0] ^= 0x01010040 // digest length 64, no key, fanout and depth 1
+
+		var block [128]byte
+		copy(block[:], tt.input)
+		var m [16]uint64
+		for j := range m {
+			m[j] = binary.LittleEndian.Uint64(block[j*8:])
+		}
+		F(&h, m, [2]uint64{uint64(len(tt.input)), 0}, true, 12)
+
+		out := make([]byte, 64)
+		for j, word := range h {
+			binary.LittleEndian.PutUint64(out[j*8:], word)
+		}
+		if have := hex.EncodeToString(out); have != tt.digest {
+			t.Errorf("test %d: digest mismatch: have %s, want %s", i, have, tt.digest)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"os"
 	"strings"
@@ -69,6 +70,39 @@ func (api *PublicEthereumAPI) ChainId() hexutil.Uint64 {
 	return (hexutil.Uint64)(chainID.Uint64())
 }
 
+// RoundRange is the range of canonical blocks of a round, along with the
+// common reference string of the round.
+type RoundRange struct {
+	Round hexutil.Uint64 `json:"round"`
+	First hexutil.Uint64 `json:"first"`
+	Last  hexutil.Uint64 `json:"last"`
+	CRS   *common.Hash   `json:"crs"`
+}
+
+// GetRoundRange returns the numbers of the first and last canonical blocks of a
+// round, the last one being the current head while the round is in progress.
+func (api *PublicEthereumAPI) GetRoundRange(round hexutil.Uint64) (*RoundRange, error) {
+	chain := api.dex.blockchain
+
+	first, ok := chain.GetRoundHeight(uint64(round))
+	if !ok {
+		return nil, fmt.Errorf("round %d not found", round)
+	}
+	last, ok := chain.GetRoundLastHeight(uint64(round))
+	if !ok {
+		return nil, fmt.Errorf("round %d not indexed", round)
+	}
+	result := &RoundRange{
+		Round: round,
+		First: hexutil.Uint64(first),
+		Last:  hexutil.Uint64(last),
+	}
+	if crs, ok := chain.GetRoundCRS(uint64(round)); ok {
+		result.CRS = &crs
+	}
+	return result, nil
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -81,8 +115,56 @@ func NewPrivateAdminAPI(dex *Tangerine) *PrivateAdminAPI {
 	return &PrivateAdminAPI{dex: dex}
 }
 
-// ExportChain exports the current blockchain into a local file.
-func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
+// ChainArchiveArgs holds the optional arguments of the ExportChain and
+// ImportChain admin methods.
+type ChainArchiveArgs struct {
+	// First and last block to export or import, the whole chain by default.
+	First *hexutil.Uint64 `json:"first"`
+	Last  *hexutil.Uint64 `json:"last"`
+
+	// Export into a directory of segments holding this many rounds each,
+	// instead of a single file.
+	RoundsPerSegment hexutil.Uint64 `json:"roundsPerSegment"`
+
+	// Compression of the segments, zstd by default.
+	Compression string `json:"compression"`
+
+	// Keep the intact segments of an interrupted export.
+	Resume bool `json:"resume"`
+
+	// Number of segments decoded and checked in parallel on import.
+	Workers int `json:"workers"`
+}
+
+// ExportChain exports the current blockchain into a local file, or into a
+// directory of round-aligned segments if args.RoundsPerSegment is set.
+func (api *PrivateAdminAPI) ExportChain(file string, args *ChainArchiveArgs) (bool, error) {
+	if args == nil {
+		args = new(ChainArchiveArgs)
+	}
+	first, last := uint64(0), api.dex.BlockChain().CurrentBlock().NumberU64()
+	if args.First != nil {
+		first = uint64(*args.First)
+	}
+	if args.Last != nil {
+		last = uint64(*args.Last)
+	}
+	if args.RoundsPerSegment > 0 {
+		config := core.ChainExportConfig{
+			First:            first,
+			Last:             last,
+			RoundsPerSegment: uint64(args.RoundsPerSegment),
+			Compression:      args.Compression,
+			Resume:           args.Resume,
+		}
+		if config.Compression == "" {
+			config.Compression = core.CompressionZstd
+		}
+		if _, err := core.ExportChainArchive(api.dex.BlockChain(), file, config); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 	// Make sure we can create the file to export into
 	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
@@ -97,7 +179,7 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	}
 
 	// Export the blockchain
-	if err := api.dex.BlockChain().Export(writer); err != nil {
+	if err := api.dex.BlockChain().ExportN(writer, first, last); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -113,8 +195,27 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	return true
 }
 
-// ImportChain imports a blockchain from a local file.
-func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
+// ImportChain imports a blockchain from a local file or from the directory of
+// a segmented export. Blocks already present are skipped, so an interrupted
+// import can be resumed by calling it again.
+func (api *PrivateAdminAPI) ImportChain(file string, args *ChainArchiveArgs) (bool, error) {
+	if args == nil {
+		args = new(ChainArchiveArgs)
+	}
+	first, last := uint64(0), uint64(math.MaxUint64)
+	if args.First != nil {
+		first = uint64(*args.First)
+	}
+	if args.Last != nil {
+		last = uint64(*args.Last)
+	}
+	if core.IsChainArchive(file) {
+		config := core.ChainImportConfig{First: first, Last: last, Workers: args.Workers}
+		if _, err := core.ImportChainArchive(api.dex.BlockChain(), file, config, nil); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 	// Make sure the can access the file to import
 	in, err := os.Open(file)
 	if err != nil {
@@ -142,8 +243,11 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 			} else if err != nil {
 				return false, fmt.Errorf("block %d: failed to parse: %v", index, err)
 			}
-			blocks = append(blocks, block)
 			index++
+			if number := block.NumberU64(); number < first || number > last {
+				continue
+			}
+			blocks = append(blocks, block)
 		}
 		if len(blocks) == 0 {
 			break
@@ -162,6 +266,48 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// TxPoolQueue returns why each queued transaction of the pool is not
+// executable, grouped by account and keyed by nonce.
+func (api *PrivateAdminAPI) TxPoolQueue() map[string]map[string]string {
+	content := make(map[string]map[string]string)
+	for addr, reasons := range api.dex.txPool.QueueReasons() {
+		dump := make(map[string]string)
+		for nonce, reason := range reasons {
+			dump[fmt.Sprintf("%d", nonce)] = reason
+		}
+		content[addr.Hex()] = dump
+	}
+	return content
+}
+
+// TxPoolSlots is the pool slot usage returned by the TxPoolSlots admin method.
+type TxPoolSlots struct {
+	AccountSlots uint64                               `json:"accountSlots"`
+	AccountQueue uint64                               `json:"accountQueue"`
+	Accounts     map[common.Address]core.AccountSlots `json:"accounts"`
+}
+
+// TxPoolSlots returns the pool slot usage of every account along with the
+// per-account limits.
+func (api *PrivateAdminAPI) TxPoolSlots() *TxPoolSlots {
+	return &TxPoolSlots{
+		AccountSlots: api.dex.config.TxPool.AccountSlots,
+		AccountQueue: api.dex.config.TxPool.AccountQueue,
+		Accounts:     api.dex.txPool.Slots(),
+	}
+}
+
+// TxPoolEvict drops a transaction from the pool by hash.
+func (api *PrivateAdminAPI) TxPoolEvict(hash common.Hash) bool {
+	return api.dex.txPool.RemoveTransaction(hash)
+}
+
+// TxPoolEvictSender drops all transactions of an account from the pool and
+// returns the number of transactions dropped.
+func (api *PrivateAdminAPI) TxPoolEvictSender(addr common.Address) int {
+	return api.dex.txPool.RemoveSender(addr)
+}
+
 func (api *PrivateAdminAPI) IsCoreSyncing() bool {
 	return api.dex.IsCoreSyncing()
 }
@@ -373,16 +519,31 @@ func (api *PrivateDebugAPI) getModifiedAccounts(startBlock, endBlock *types.Bloc
 		return nil, err
 	}
 
-	diff, _ := trie.NewDifferenceIterator(oldTrie.NodeIterator([]byte{}), newTrie.NodeIterator([]byte{}))
-	iter := trie.NewIterator(diff)
-
 	var dirty []common.Address
-	for iter.Next() {
-		key := newTrie.GetKey(iter.Key)
+	err = forEachModifiedLeaf(oldTrie, newTrie, func(hash, value []byte) error {
+		key := newTrie.GetKey(hash)
 		if key == nil {
-			return nil, fmt.Errorf("no preimage found for hash %x", iter.Key)
+			return fmt.Errorf("no preimage found for hash %x", hash)
 		}
 		dirty = append(dirty, common.BytesToAddress(key))
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return dirty, nil
 }
+
+// forEachModifiedLeaf calls fn with the hashed key and the value of every leaf
+// of newTrie which is missing from or different in oldTrie.
+func forEachModifiedLeaf(oldTrie, newTrie state.Trie, fn func(key, value []byte) error) error {
+	diff, _ := trie.NewDifferenceIterator(oldTrie.NodeIterator([]byte{}), newTrie.NodeIterator([]byte{}))
+	iter := trie.NewIterator(diff)
+
+	for iter.Next() {
+		if err := fn(iter.Key, iter.Value); err != nil {
+			return err
+		}
+	}
+	return iter.Err
+}
@@ -0,0 +1,384 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/internal/ethapi"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	"github.com/tangerine-network/go-tangerine/rpc"
+)
+
+// PrivateArchiveAPI provides historical state access addressed by round
+// instead of block number. Apart from round boundaries, historical state is
+// only available on nodes running with --gcmode=archive. As whole states can
+// be dumped, it is only exposed over the private debug endpoint.
+type PrivateArchiveAPI struct {
+	dex   *Tangerine
+	chain *ethapi.PublicBlockChainAPI
+}
+
+// NewPrivateArchiveAPI creates a new API definition for the archive methods of
+// the Tangerine service.
+func NewPrivateArchiveAPI(dex *Tangerine) *PrivateArchiveAPI {
+	return &PrivateArchiveAPI{
+		dex:   dex,
+		chain: ethapi.NewPublicBlockChainAPI(dex.APIBackend),
+	}
+}
+
+// resolve maps a round and an offset within it to a block number. A nil
+// offset selects the last block of the round, i.e. the state at round end.
+func (api *PrivateArchiveAPI) resolve(round uint64, offset *uint64) (uint64, error) {
+	first, ok := api.dex.blockchain.GetRoundHeight(round)
+	if !ok {
+		return 0, fmt.Errorf("round %d not found", round)
+	}
+	last, ok := api.dex.blockchain.GetRoundLastHeight(round)
+	if !ok {
+		last = api.dex.blockchain.CurrentBlock().NumberU64()
+	}
+	if last < first {
+		return 0, fmt.Errorf("round %d starts at block #%d, past the chain head #%d", round, first, last)
+	}
+	if offset == nil {
+		return last, nil
+	}
+	if *offset > last-first {
+		return 0, fmt.Errorf("offset %d out of range for round %d (%d blocks)", *offset, round, last-first+1)
+	}
+	return first + *offset, nil
+}
+
+// stateAt returns the state of the given block, reporting pruned state
+// explicitly rather than as a missing trie node.
+func (api *PrivateArchiveAPI) stateAt(number uint64) (*types.Block, *state.StateDB, error) {
+	block := api.dex.blockchain.GetBlockByNumber(number)
+	if block == nil {
+		return nil, nil, fmt.Errorf("block #%d not found", number)
+	}
+	statedb, err := api.dex.blockchain.StateAt(block.Root())
+	if err != nil {
+		return nil, nil, fmt.Errorf("state of block #%d not available (archive mode required): %v", number, err)
+	}
+	return block, statedb, nil
+}
+
+// BlockNumberByRound returns the number of the block at the given offset
+// within a round, or the last block of the round if offset is omitted.
+func (api *PrivateArchiveAPI) BlockNumberByRound(round uint64, offset *uint64) (hexutil.Uint64, error) {
+	number, err := api.resolve(round, offset)
+	return hexutil.Uint64(number), err
+}
+
+// DumpRound retrieves the entire state of the database at the given offset
+// within a round.
+func (api *PrivateArchiveAPI) DumpRound(round uint64, offset *uint64) (state.Dump, error) {
+	number, err := api.resolve(round, offset)
+	if err != nil {
+		return state.Dump{}, err
+	}
+	_, statedb, err := api.stateAt(number)
+	if err != nil {
+		return state.Dump{}, err
+	}
+	return statedb.RawDump(), nil
+}
+
+// GetBalanceByRound returns the balance of an account at the given offset
+// within a round.
+func (api *PrivateArchiveAPI) GetBalanceByRound(ctx context.Context, address common.Address, round uint64, offset *uint64) (*hexutil.Big, error) {
+	number, err := api.resolve(round, offset)
+	if err != nil {
+		return nil, err
+	}
+	return api.chain.GetBalance(ctx, address, rpc.BlockNumber(number))
+}
+
+// GetCodeByRound returns the code of an account at the given offset within a
+// round.
+func (api *PrivateArchiveAPI) GetCodeByRound(ctx context.Context, address common.Address, round uint64, offset *uint64) (hexutil.Bytes, error) {
+	number, err := api.resolve(round, offset)
+	if err != nil {
+		return nil, err
+	}
+	return api.chain.GetCode(ctx, address, rpc.BlockNumber(number))
+}
+
+// GetStorageAtByRound returns a storage slot of an account at the given offset
+// within a round.
+func (api *PrivateArchiveAPI) GetStorageAtByRound(ctx context.Context, address common.Address, key string, round uint64, offset *uint64) (hexutil.Bytes, error) {
+	number, err := api.resolve(round, offset)
+	if err != nil {
+		return nil, err
+	}
+	return api.chain.GetStorageAt(ctx, address, key, rpc.BlockNumber(number))
+}
+
+// CallByRound executes the given message call on top of the state at the
+// given offset within a round, like eth_call.
+func (api *PrivateArchiveAPI) CallByRound(ctx context.Context, args ethapi.CallArgs, round uint64, offset *uint64) (hexutil.Bytes, error) {
+	number, err := api.resolve(round, offset)
+	if err != nil {
+		return nil, err
+	}
+	return api.chain.Call(ctx, args, rpc.BlockNumber(number))
+}
+
+// StorageDiff is the change of a single storage slot. Key is nil if the
+// preimage of the hashed slot is unknown.
+type StorageDiff struct {
+	Key    *common.Hash `json:"key"`
+	Before common.Hash  `json:"before"`
+	After  common.Hash  `json:"after"`
+}
+
+// AccountDiff is the change of a single account between two states.
+type AccountDiff struct {
+	BalanceBefore *hexutil.Big                 `json:"balanceBefore"`
+	BalanceAfter  *hexutil.Big                 `json:"balanceAfter"`
+	BalanceDelta  *hexutil.Big                 `json:"balanceDelta"`
+	NonceBefore   hexutil.Uint64               `json:"nonceBefore"`
+	NonceAfter    hexutil.Uint64               `json:"nonceAfter"`
+	CodeChanged   bool                         `json:"codeChanged"`
+	Created       bool                         `json:"created"`
+	Deleted       bool                         `json:"deleted"`
+	Storage       map[common.Hash]*StorageDiff `json:"storage"`
+}
+
+// StateDiff is the result of a debug_stateDiff or debug_roundStateDiff
+// call. It describes the transition from the state of block From to the
+// state of block To.
+type StateDiff struct {
+	From     hexutil.Uint64                  `json:"from"`
+	To       hexutil.Uint64                  `json:"to"`
+	Accounts map[common.Address]*AccountDiff `json:"accounts"`
+}
+
+// StateDiff returns the per-account balance, nonce, code and storage changes
+// between the states of two blocks. With one parameter, it returns the
+// changes made by the specified block.
+//
+// The diff is computed by walking the two state tries, so the cost depends
+// on the amount of changed state rather than the number of transactions.
+func (api *PrivateArchiveAPI) StateDiff(startNum uint64, endNum *uint64) (*StateDiff, error) {
+	if endNum == nil {
+		if startNum == 0 {
+			return nil, fmt.Errorf("block #0 has no parent")
+		}
+		end := startNum
+		startNum, endNum = startNum-1, &end
+	}
+	return api.stateDiff(startNum, *endNum)
+}
+
+// RoundStateDiff returns the state changes made by all blocks of a round,
+// i.e. the diff between the parent of its first block and its last block.
+func (api *PrivateArchiveAPI) RoundStateDiff(round uint64) (*StateDiff, error) {
+	first, err := api.resolve(round, new(uint64))
+	if err != nil {
+		return nil, err
+	}
+	last, err := api.resolve(round, nil)
+	if err != nil {
+		return nil, err
+	}
+	if first == 0 {
+		return nil, fmt.Errorf("round %d starts at genesis", round)
+	}
+	return api.stateDiff(first-1, last)
+}
+
+func (api *PrivateArchiveAPI) stateDiff(startNum, endNum uint64) (*StateDiff, error) {
+	if startNum >= endNum {
+		return nil, fmt.Errorf("start block height (%d) must be less than end block height (%d)", startNum, endNum)
+	}
+	startBlock := api.dex.blockchain.GetBlockByNumber(startNum)
+	if startBlock == nil {
+		return nil, fmt.Errorf("start block %d not found", startNum)
+	}
+	endBlock := api.dex.blockchain.GetBlockByNumber(endNum)
+	if endBlock == nil {
+		return nil, fmt.Errorf("end block %d not found", endNum)
+	}
+	db := api.dex.blockchain.StateCache()
+	oldTrie, err := db.OpenTrie(startBlock.Root())
+	if err != nil {
+		return nil, fmt.Errorf("state of block #%d not available (archive mode required): %v", startNum, err)
+	}
+	newTrie, err := db.OpenTrie(endBlock.Root())
+	if err != nil {
+		return nil, fmt.Errorf("state of block #%d not available (archive mode required): %v", endNum, err)
+	}
+	leaves, err := diffTrieLeaves(oldTrie, newTrie)
+	if err != nil {
+		return nil, err
+	}
+	result := &StateDiff{
+		From:     hexutil.Uint64(startNum),
+		To:       hexutil.Uint64(endNum),
+		Accounts: make(map[common.Address]*AccountDiff, len(leaves)),
+	}
+	for hash, leaf := range leaves {
+		key := newTrie.GetKey(hash[:])
+		if key == nil {
+			key = oldTrie.GetKey(hash[:])
+		}
+		if key == nil {
+			return nil, fmt.Errorf("no preimage found for hash %x", hash)
+		}
+		diff, err := diffAccount(db, hash, leaf)
+		if err != nil {
+			return nil, err
+		}
+		result.Accounts[common.BytesToAddress(key)] = diff
+	}
+	return result, nil
+}
+
+// leafDiff holds the values of a trie leaf before and after a transition,
+// nil if the leaf did not exist on that side.
+type leafDiff struct {
+	before, after []byte
+}
+
+// diffTrieLeaves returns all leaves that differ between two tries, keyed by
+// their hashed trie key. The modified leaves are walked in both directions,
+// so that deleted leaves are reported too.
+//
+// Leaves walked with the same value on both sides are dropped, so an untouched
+// account below a changed branch is never reported.
+func diffTrieLeaves(oldTrie, newTrie state.Trie) (map[common.Hash]*leafDiff, error) {
+	leaves := make(map[common.Hash]*leafDiff)
+
+	err := forEachModifiedLeaf(oldTrie, newTrie, func(key, value []byte) error {
+		leaves[common.BytesToHash(key)] = &leafDiff{after: common.CopyBytes(value)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = forEachModifiedLeaf(newTrie, oldTrie, func(key, value []byte) error {
+		hash := common.BytesToHash(key)
+		if leaf, ok := leaves[hash]; ok {
+			if bytes.Equal(leaf.after, value) {
+				delete(leaves, hash)
+				return nil
+			}
+			leaf.before = common.CopyBytes(value)
+		} else {
+			leaves[hash] = &leafDiff{before: common.CopyBytes(value)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// diffAccount decodes the two sides of a changed account leaf and diffs the
+// storage tries if they differ.
+func diffAccount(db state.Database, addrHash common.Hash, leaf *leafDiff) (*AccountDiff, error) {
+	var before, after state.Account
+	if err := decodeAccount(leaf.before, &before); err != nil {
+		return nil, err
+	}
+	if err := decodeAccount(leaf.after, &after); err != nil {
+		return nil, err
+	}
+	diff := &AccountDiff{
+		BalanceBefore: (*hexutil.Big)(before.Balance),
+		BalanceAfter:  (*hexutil.Big)(after.Balance),
+		BalanceDelta:  (*hexutil.Big)(new(big.Int).Sub(after.Balance, before.Balance)),
+		NonceBefore:   hexutil.Uint64(before.Nonce),
+		NonceAfter:    hexutil.Uint64(after.Nonce),
+		CodeChanged:   !bytes.Equal(before.CodeHash, after.CodeHash),
+		Created:       leaf.before == nil,
+		Deleted:       leaf.after == nil,
+		Storage:       make(map[common.Hash]*StorageDiff),
+	}
+	if before.Root == after.Root {
+		return diff, nil
+	}
+	oldTrie, err := db.OpenStorageTrie(addrHash, before.Root)
+	if err != nil {
+		return nil, err
+	}
+	newTrie, err := db.OpenStorageTrie(addrHash, after.Root)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := diffTrieLeaves(oldTrie, newTrie)
+	if err != nil {
+		return nil, err
+	}
+	for hash, slot := range slots {
+		entry := new(StorageDiff)
+		if entry.Before, err = decodeStorageValue(slot.before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = decodeStorageValue(slot.after); err != nil {
+			return nil, err
+		}
+		key := newTrie.GetKey(hash[:])
+		if key == nil {
+			key = oldTrie.GetKey(hash[:])
+		}
+		if key != nil {
+			preimage := common.BytesToHash(key)
+			entry.Key = &preimage
+		}
+		diff.Storage[hash] = entry
+	}
+	return diff, nil
+}
+
+// decodeAccount decodes an account leaf, treating a missing leaf as an empty
+// account.
+func decodeAccount(blob []byte, account *state.Account) error {
+	if blob == nil {
+		*account = state.Account{
+			Balance:  new(big.Int),
+			Root:     types.EmptyRootHash,
+			CodeHash: crypto.Keccak256(nil),
+		}
+		return nil
+	}
+	return rlp.DecodeBytes(blob, account)
+}
+
+// decodeStorageValue decodes a storage leaf, treating a missing leaf as zero.
+func decodeStorageValue(blob []byte) (common.Hash, error) {
+	if blob == nil {
+		return common.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/dex/downloader"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+)
+
+// Tests that rounds and offsets within them are resolved to block numbers
+// through the round index, with an open round ending at the chain head.
+func TestArchiveResolve(t *testing.T) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 10, nil, nil)
+	defer pm.Stop()
+
+	rawdb.WriteRoundHeight(db, 1, 3)
+	rawdb.WriteRoundLastHeight(db, 1, 5)
+	rawdb.WriteRoundHeight(db, 2, 6)
+	rawdb.WriteRoundHeight(db, 4, 20)
+
+	api := NewPrivateArchiveAPI(&Tangerine{blockchain: pm.blockchain})
+	offset := func(n uint64) *uint64 { return &n }
+
+	tests := []struct {
+		round  uint64
+		offset *uint64
+		number uint64
+		fail   bool
+	}{
+		{round: 1, offset: nil, number: 5},
+		{round: 1, offset: offset(0), number: 3},
+		{round: 1, offset: offset(2), number: 5},
+		{round: 1, offset: offset(3), fail: true},
+		{round: 2, offset: nil, number: 10},
+		{round: 2, offset: offset(4), number: 10},
+		{round: 2, offset: offset(5), fail: true},
+		{round: 3, offset: nil, fail: true},
+		{round: 4, offset: nil, fail: true},
+		{round: 4, offset: offset(0), fail: true},
+	}
+	for i, tt := range tests {
+		number, err := api.resolve(tt.round, tt.offset)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: round %d resolved to %d, want error", i, tt.round, number)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to resolve round %d: %v", i, tt.round, err)
+		} else if number != tt.number {
+			t.Errorf("test %d: block number mismatch: have %d, want %d", i, number, tt.number)
+		}
+	}
+}
+
+var (
+	archiveChanged   = common.Address{1}
+	archiveDeleted   = common.Address{2}
+	archiveCreated   = common.Address{3}
+	archiveUntouched = common.Address{4}
+)
+
+// newArchiveTestStates commits two states, the second derived from the first
+// by changing, creating and deleting accounts and storage slots.
+func newArchiveTestStates(t *testing.T) (state.Database, common.Hash, common.Hash) {
+	db := state.NewDatabase(ethdb.NewMemDatabase())
+
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.SetBalance(archiveChanged, big.NewInt(10))
+	statedb.SetNonce(archiveChanged, 1)
+	statedb.SetState(archiveChanged, common.Hash{1}, common.Hash{1})
+	statedb.SetState(archiveChanged, common.Hash{2}, common.Hash{2})
+	statedb.SetBalance(archiveDeleted, big.NewInt(5))
+	statedb.SetBalance(archiveUntouched, big.NewInt(1))
+	oldRoot, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit old state: %v", err)
+	}
+	statedb, _ = state.New(oldRoot, db)
+	statedb.SetBalance(archiveChanged, big.NewInt(7))
+	statedb.SetNonce(archiveChanged, 2)
+	statedb.SetCode(archiveChanged, []byte{0x60, 0x00})
+	statedb.SetState(archiveChanged, common.Hash{1}, common.Hash{3})
+	statedb.SetState(archiveChanged, common.Hash{2}, common.Hash{})
+	statedb.SetState(archiveChanged, common.Hash{3}, common.Hash{4})
+	statedb.Suicide(archiveDeleted)
+	statedb.SetBalance(archiveCreated, big.NewInt(1))
+	newRoot, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit new state: %v", err)
+	}
+	return db, oldRoot, newRoot
+}
+
+// Tests that walking two tries yields exactly the changed, created and deleted
+// leaves with the right sides set.
+func TestDiffTrieLeaves(t *testing.T) {
+	db, oldRoot, newRoot := newArchiveTestStates(t)
+
+	oldTrie, _ := db.OpenTrie(oldRoot)
+	newTrie, _ := db.OpenTrie(newRoot)
+	leaves, err := diffTrieLeaves(oldTrie, newTrie)
+	if err != nil {
+		t.Fatalf("failed to diff tries: %v", err)
+	}
+	if len(leaves) != 3 {
+		t.Fatalf("changed leaf count mismatch: have %d, want %d", len(leaves), 3)
+	}
+	tests := []struct {
+		addr          common.Address
+		before, after bool
+	}{
+		{archiveChanged, true, true},
+		{archiveDeleted, true, false},
+		{archiveCreated, false, true},
+	}
+	for _, tt := range tests {
+		leaf, ok := leaves[crypto.Keccak256Hash(tt.addr[:])]
+		if !ok {
+			t.Errorf("account %x: leaf missing from diff", tt.addr)
+			continue
+		}
+		if (leaf.before != nil) != tt.before || (leaf.after != nil) != tt.after {
+			t.Errorf("account %x: leaf sides mismatch: have before %v after %v, want before %v after %v",
+				tt.addr, leaf.before != nil, leaf.after != nil, tt.before, tt.after)
+		}
+	}
+	// Identical tries must not yield any leaves
+	if leaves, err := diffTrieLeaves(newTrie, newTrie); err != nil || len(leaves) != 0 {
+		t.Errorf("identical tries diffed to %d leaves, error %v", len(leaves), err)
+	}
+}
+
+// Tests that a leaf whose path changed because of a new sibling, but whose
+// value did not, is not reported as changed.
+func TestDiffTrieLeavesUnchangedSibling(t *testing.T) {
+	db := state.NewDatabase(ethdb.NewMemDatabase())
+
+	// A lone account is the root of the trie, the new one splits it into a branch
+	statedb, _ := state.New(common.Hash{}, db)
+	statedb.SetBalance(archiveUntouched, big.NewInt(1))
+	oldRoot, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit old state: %v", err)
+	}
+	statedb, _ = state.New(oldRoot, db)
+	statedb.SetBalance(archiveCreated, big.NewInt(1))
+	newRoot, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit new state: %v", err)
+	}
+	oldTrie, _ := db.OpenTrie(oldRoot)
+	newTrie, _ := db.OpenTrie(newRoot)
+
+	for i, tries := range [][2]state.Trie{{oldTrie, newTrie}, {newTrie, oldTrie}} {
+		leaves, err := diffTrieLeaves(tries[0], tries[1])
+		if err != nil {
+			t.Fatalf("direction %d: failed to diff tries: %v", i, err)
+		}
+		if len(leaves) != 1 {
+			t.Errorf("direction %d: changed leaf count mismatch: have %d, want %d", i, len(leaves), 1)
+		}
+		if _, ok := leaves[crypto.Keccak256Hash(archiveCreated[:])]; !ok {
+			t.Errorf("direction %d: created account missing from diff", i)
+		}
+		if _, ok := leaves[crypto.Keccak256Hash(archiveUntouched[:])]; ok {
+			t.Errorf("direction %d: untouched account reported as changed", i)
+		}
+	}
+}
+
+// Tests that account leaves are decoded into balance, nonce, code and storage
+// changes, including created and deleted accounts.
+func TestDiffAccount(t *testing.T) {
+	db, oldRoot, newRoot := newArchiveTestStates(t)
+
+	oldTrie, _ := db.OpenTrie(oldRoot)
+	newTrie, _ := db.OpenTrie(newRoot)
+	leaves, err := diffTrieLeaves(oldTrie, newTrie)
+	if err != nil {
+		t.Fatalf("failed to diff tries: %v", err)
+	}
+	diff := func(addr common.Address) *AccountDiff {
+		hash := crypto.Keccak256Hash(addr[:])
+		diff, err := diffAccount(db, hash, leaves[hash])
+		if err != nil {
+			t.Fatalf("account %x: failed to diff: %v", addr, err)
+		}
+		return diff
+	}
+	changed := diff(archiveChanged)
+	if changed.BalanceBefore.ToInt().Int64() != 10 || changed.BalanceAfter.ToInt().Int64() != 7 || changed.BalanceDelta.ToInt().Int64() != -3 {
+		t.Errorf("changed account: balance mismatch: have %v -> %v (%v), want 10 -> 7 (-3)",
+			changed.BalanceBefore, changed.BalanceAfter, changed.BalanceDelta)
+	}
+	if changed.NonceBefore != 1 || changed.NonceAfter != 2 {
+		t.Errorf("changed account: nonce mismatch: have %d -> %d, want 1 -> 2", changed.NonceBefore, changed.NonceAfter)
+	}
+	if !changed.CodeChanged || changed.Created || changed.Deleted {
+		t.Errorf("changed account: flags mismatch: code %v created %v deleted %v", changed.CodeChanged, changed.Created, changed.Deleted)
+	}
+	slots := map[common.Hash][2]common.Hash{
+		{1}: {{1}, {3}},
+		{2}: {{2}, {}},
+		{3}: {{}, {4}},
+	}
+	if len(changed.Storage) != len(slots) {
+		t.Errorf("changed account: storage diff count mismatch: have %d, want %d", len(changed.Storage), len(slots))
+	}
+	for key, want := range slots {
+		slot, ok := changed.Storage[crypto.Keccak256Hash(key[:])]
+		if !ok {
+			t.Errorf("changed account: slot %x missing from diff", key)
+			continue
+		}
+		if slot.Key == nil || *slot.Key != key {
+			t.Errorf("changed account: slot %x preimage mismatch: have %v", key, slot.Key)
+		}
+		if slot.Before != want[0] || slot.After != want[1] {
+			t.Errorf("changed account: slot %x mismatch: have %x -> %x, want %x -> %x", key, slot.Before, slot.After, want[0], want[1])
+		}
+	}
+	deleted := diff(archiveDeleted)
+	if !deleted.Deleted || deleted.Created || deleted.BalanceBefore.ToInt().Int64() != 5 || deleted.BalanceAfter.ToInt().Sign() != 0 {
+		t.Errorf("deleted account: diff mismatch: %+v", deleted)
+	}
+	created := diff(archiveCreated)
+	if !created.Created || created.Deleted || created.BalanceAfter.ToInt().Int64() != 1 || created.CodeChanged || len(created.Storage) != 0 {
+		t.Errorf("created account: diff mismatch: %+v", created)
+	}
+}
@@ -706,7 +706,7 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
-	// Assemble the structured logger or the JavaScript tracer
+	// Assemble the structured logger or the native or JavaScript tracer
 	var (
 		tracer vm.Tracer
 		err    error
@@ -720,15 +720,17 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Constuct the native or JavaScript tracer to execute with
+		resultTracer, err := tracers.NewTracer(*config.Tracer)
+		if err != nil {
 			return nil, err
 		}
+		tracer = resultTracer
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			resultTracer.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -753,9 +755,10 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			Failed:      failed,
 			ReturnValue: fmt.Sprintf("%x", ret),
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
+			OracleCalls: tracer.OracleCalls(),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.ResultTracer:
 		return tracer.GetResult()
 
 	default:
@@ -31,6 +31,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/event"
 	"github.com/tangerine-network/go-tangerine/log"
@@ -116,6 +117,16 @@ func (d *DexconApp) validateGasPrice(txs types.Transactions, round uint64) bool
 	return true
 }
 
+// denylist returns the governance state holding the denylist the block at the
+// given position is executed against, which is the one of the configuration
+// of its round, or nil if the denylist is not active yet.
+func (d *DexconApp) denylist(position coreTypes.Position) (*vm.GovernanceState, error) {
+	if !d.blockchain.Config().IsDenylist(new(big.Int).SetUint64(position.Height)) {
+		return nil, nil
+	}
+	return d.gov.GetConfigState(position.Round)
+}
+
 // PreparePayload is called when consensus core is preparing payload for block.
 func (d *DexconApp) PreparePayload(position coreTypes.Position) (payload []byte, err error) {
 	// softLimit limits the runtime of inner call to preparePayload.
@@ -194,66 +205,121 @@ func (d *DexconApp) preparePayload(ctx context.Context, position coreTypes.Posit
 	blockGasUsed := new(big.Int)
 	allTxs := make([]*types.Transaction, 0, 10000)
 
-addressMap:
-	for address, txs := range txsMap {
-		select {
-		case <-ctx.Done():
-			break addressMap
-		default:
-		}
+	denylist, err := d.denylist(position)
+	if err != nil {
+		return
+	}
 
-		balance := state.GetBalance(address)
-		cost, exist := d.addressCost[address]
-		if exist {
-			balance = new(big.Int).Sub(balance, cost)
+	var (
+		next     = make(map[common.Address]int)      // Index of the next transaction of an account
+		balances = make(map[common.Address]*big.Int) // Balance left for the next transaction
+	)
+	// include appends the executable transactions of an account in nonce
+	// order, continuing after the ones already included. If govOnly is set,
+	// it stops at the first transaction not calling the governance contract.
+	// It reports whether the block gas limit is reached.
+	include := func(address common.Address, govOnly bool) (bool, error) {
+		txs := txsMap[address]
+		if len(txs) == 0 {
+			return false, nil
 		}
-
-		var expectNonce uint64
-		lastConfirmedNonce, exist := d.addressNonce[address]
+		i, exist := next[address]
+		balance := balances[address]
 		if !exist {
-			expectNonce = state.GetNonce(address)
-		} else {
-			expectNonce = lastConfirmedNonce + 1
-		}
+			balance = state.GetBalance(address)
+			cost, exist := d.addressCost[address]
+			if exist {
+				balance = new(big.Int).Sub(balance, cost)
+			}
 
-		if len(txs) == 0 {
-			continue
-		}
+			var expectNonce uint64
+			lastConfirmedNonce, exist := d.addressNonce[address]
+			if !exist {
+				expectNonce = state.GetNonce(address)
+			} else {
+				expectNonce = lastConfirmedNonce + 1
+			}
 
-		firstNonce := txs[0].Nonce()
-		startIndex := int(expectNonce - firstNonce)
+			firstNonce := txs[0].Nonce()
+			i = int(expectNonce - firstNonce)
+		}
+		defer func() {
+			next[address], balances[address] = i, balance
+		}()
 
 		// Warning: the pending tx will also affect by syncing, so startIndex maybe negative
-		for i := startIndex; i >= 0 && i < len(txs); i++ {
+		for ; i >= 0 && i < len(txs); i++ {
 			tx := txs[i]
+			if govOnly && (tx.To() == nil || *tx.To() != vm.GovernanceContractAddress) {
+				return false, nil
+			}
 			if config.MinGasPrice.Cmp(tx.GasPrice()) > 0 {
 				log.Error("Invalid gas price minGas(%v) > get(%v)", config.MinGasPrice, tx.GasPrice())
 				break
 			}
+			if denylist != nil && denylist.IsDeniedTransaction(address, tx.To()) {
+				log.Debug("Skip denied transaction", "txHash", tx.Hash().String())
+				break
+			}
 
-			intrGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true)
+			intrGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true,
+				d.blockchain.Config().IsIstanbul(new(big.Int).SetUint64(position.Height)))
 			if err != nil {
 				log.Error("Failed to calculate intrinsic gas", "error", err)
-				return nil, fmt.Errorf("calculate intrinsic gas error: %v", err)
+				return false, fmt.Errorf("calculate intrinsic gas error: %v", err)
 			}
 			if tx.Gas() < intrGas {
 				log.Error("Intrinsic gas too low", "txHash", tx.Hash().String())
 				break
 			}
 
-			balance = new(big.Int).Sub(balance, tx.Cost())
-			if balance.Cmp(big.NewInt(0)) < 0 {
+			remaining := new(big.Int).Sub(balance, tx.Cost())
+			if remaining.Cmp(big.NewInt(0)) < 0 {
 				log.Warn("Insufficient funds for gas * price + value", "txHash", tx.Hash().String())
 				break
 			}
 
-			blockGasUsed = new(big.Int).Add(blockGasUsed, big.NewInt(int64(tx.Gas())))
-			if blockGasUsed.Cmp(blockGasLimit) > 0 {
-				break addressMap
+			gasUsed := new(big.Int).Add(blockGasUsed, big.NewInt(int64(tx.Gas())))
+			if gasUsed.Cmp(blockGasLimit) > 0 {
+				return true, nil
 			}
 
+			balance, blockGasUsed = remaining, gasUsed
 			allTxs = append(allTxs, tx)
 		}
+		// The account has no further includable transaction
+		i = -1
+		return false, nil
+	}
+
+	// Place the governance transactions of priority accounts first so they
+	// always find room in the payload. Their other transactions are ordered
+	// along with the ones of all other accounts.
+	addresses := make([]common.Address, 0, len(txsMap))
+	for _, address := range d.txPool.PriorityAccounts() {
+		if _, exist := txsMap[address]; exist {
+			addresses = append(addresses, address)
+		}
+	}
+	govOnly := len(addresses)
+	for address := range txsMap {
+		addresses = append(addresses, address)
+	}
+
+	for n, address := range addresses {
+		select {
+		case <-ctx.Done():
+			return rlp.EncodeToBytes(&allTxs)
+		default:
+		}
+
+		full, err := include(address, n < govOnly)
+		if err != nil {
+			return nil, err
+		}
+		if full {
+			break
+		}
 	}
 
 	return rlp.EncodeToBytes(&allTxs)
@@ -391,14 +457,25 @@ func (d *DexconApp) VerifyBlock(block *coreTypes.Block) coreTypes.BlockVerifySta
 	blockGasLimit := new(big.Int).SetUint64(config.BlockGasLimit)
 	blockGasUsed := new(big.Int)
 
+	denylist, err := d.denylist(block.Position)
+	if err != nil {
+		log.Error("Failed to get denylist", "err", err)
+		return coreTypes.VerifyRetryLater
+	}
+
 	for _, tx := range transactions {
 		msg, err := tx.AsMessage(types.MakeSigner(d.blockchain.Config(), new(big.Int)))
 		if err != nil {
 			log.Error("Failed to convert tx to message", "error", err)
 			return coreTypes.VerifyInvalidBlock
 		}
+		if denylist != nil && denylist.IsDeniedTransaction(msg.From(), msg.To()) {
+			log.Error("Denied transaction", "txHash", tx.Hash().String())
+			return coreTypes.VerifyInvalidBlock
+		}
 		balance := addressesBalance[msg.From()]
-		intrGas, err := core.IntrinsicGas(msg.Data(), msg.To() == nil, true)
+		intrGas, err := core.IntrinsicGas(msg.Data(), msg.To() == nil, true,
+			d.blockchain.Config().IsIstanbul(new(big.Int).SetUint64(block.Position.Height)))
 		if err != nil {
 			log.Error("Failed to calculate intrinsic gas", "err", err)
 			return coreTypes.VerifyInvalidBlock
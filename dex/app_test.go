@@ -2228,7 +2228,7 @@ func TestTangerineApp(t *testing.T) {
 		t.Fatalf("Generate key fail: %v", err)
 	}
 
-	dex, keys, err := newTangerine(masterKey, 15)
+	dex, keys, err := newTangerine(masterKey, 15, "")
 	if err != nil {
 		t.Fatalf("New dexon fail: %v", err)
 	}
@@ -2301,7 +2301,9 @@ func TestTangerineApp(t *testing.T) {
 	}
 }
 
-func newTangerine(masterKey *ecdsa.PrivateKey, accountNum int) (*Tangerine, []*ecdsa.PrivateKey, error) {
+// newTangerine creates a test node with the given number of funded accounts.
+// The pool journals local transactions to journal, if not empty.
+func newTangerine(masterKey *ecdsa.PrivateKey, accountNum int, journal string) (*Tangerine, []*ecdsa.PrivateKey, error) {
 	db := ethdb.NewMemDatabase()
 
 	genesis := core.DefaultTestnetGenesisBlock()
@@ -2340,6 +2342,7 @@ func newTangerine(masterKey *ecdsa.PrivateKey, accountNum int) (*Tangerine, []*e
 	engine := dexcon.New()
 
 	dex := &Tangerine{
+		config:      &config,
 		chainDb:     db,
 		chainConfig: chainConfig,
 		networkID:   config.NetworkId,
@@ -2352,6 +2355,7 @@ func newTangerine(masterKey *ecdsa.PrivateKey, accountNum int) (*Tangerine, []*e
 	}
 
 	txPoolConfig := core.DefaultTxPoolConfig
+	txPoolConfig.Journal = journal
 	dex.txPool = core.NewTxPool(txPoolConfig, chainConfig, dex.blockchain)
 
 	dex.APIBackend = &DexAPIBackend{dex, nil}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/tangerine-network/go-tangerine/accounts"
+	"github.com/tangerine-network/go-tangerine/accounts/keystore"
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/consensus"
 	"github.com/tangerine-network/go-tangerine/consensus/dexcon"
@@ -30,6 +31,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/crypto"
+	dexDB "github.com/tangerine-network/go-tangerine/dex/db"
 	"github.com/tangerine-network/go-tangerine/dex/downloader"
 	"github.com/tangerine-network/go-tangerine/eth/filters"
 	"github.com/tangerine-network/go-tangerine/eth/gasprice"
@@ -45,6 +47,14 @@ import (
 	"github.com/tangerine-network/tangerine-consensus/core/syncer"
 )
 
+// LesServer serves light clients of the Tangerine network.
+type LesServer interface {
+	Start(srvr *p2p.Server)
+	Stop()
+	Protocols() []p2p.Protocol
+	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
+}
+
 // Tangerine implements the DEXON fullnode service.
 type Tangerine struct {
 	config      *Config
@@ -57,9 +67,11 @@ type Tangerine struct {
 	txPool          *core.TxPool
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
+	lesServer       LesServer
 
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
+	dkgDb   *dexDB.DB      // Consensus database sealing the DKG records
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -91,6 +103,16 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Move the finalized blocks of old rounds into the ancient store
+	freezer := config.DatabaseFreezer
+	if freezer == "" {
+		freezer = "chaindata/ancient"
+	}
+	if freezer = ctx.ResolvePath(freezer); freezer != "" {
+		if chainDb, err = rawdb.NewDatabaseWithFreezer(chainDb, freezer, config.AncientRounds); err != nil {
+			return nil, err
+		}
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb,
 		config.Genesis)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
@@ -100,9 +122,15 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 
 	if !config.SkipBcVersionCheck {
 		bcVersion := rawdb.ReadDatabaseVersion(chainDb)
-		if bcVersion != nil && *bcVersion != core.BlockChainVersion {
+		switch {
+		case bcVersion == nil || *bcVersion == core.BlockChainVersion:
+		case *bcVersion == 3 && core.BlockChainVersion == 4:
+			// Version 4 receipts only append the revert reason, and the ones
+			// written by version 3 still decode, so no resync is needed
+			log.Warn("Upgrade blockchain database version", "from", *bcVersion, "to", core.BlockChainVersion)
+		default:
 			return nil, fmt.Errorf("Blockchain DB version mismatch (%d / %d).\n",
-				bcVersion, core.BlockChainVersion)
+				*bcVersion, core.BlockChainVersion)
 		}
 		rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 	}
@@ -128,7 +156,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 			EVMInterpreter:          config.EVMInterpreter,
 			IsBlockProposer:         config.BlockProposerEnabled,
 		}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout, FinalityPruning: config.FinalityPruning, StateRetention: config.StateRetention}
 	)
 	dex.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, dex.chainConfig, dex.engine, vmConfig, nil)
 
@@ -151,6 +179,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	dex.txPool = core.NewTxPool(config.TxPool, dex.chainConfig, dex.blockchain)
 
 	dex.APIBackend = &DexAPIBackend{dex, nil}
@@ -162,6 +193,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 
 	// Dexcon related objects.
 	dex.governance = NewDexconGovernance(dex.APIBackend, dex.chainConfig, config.PrivateKey)
+	dex.txPool.AddPriorityAccount(dex.governance.address)
 	dex.app = NewDexconApp(dex.txPool, dex.blockchain, dex.governance, chainDb, config)
 
 	// Set config fetcher so engine can fetch current system configuration from state.
@@ -175,9 +207,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 		config.SyncMode = downloader.FullSync
 	}
 
+	// Anchor header verification on the configured checkpoint, if any
+	checkpoint := config.Checkpoint
+	if checkpoint == nil {
+		checkpoint = params.GovernanceCheckpoints[dex.blockchain.Genesis().Hash()]
+	}
 	pm, err := NewProtocolManager(dex.chainConfig, config.SyncMode,
 		config.NetworkId, dex.eventMux, dex.txPool, dex.engine, dex.blockchain,
-		chainDb, config.Whitelist, config.BlockProposerEnabled, dex.governance, dex.app)
+		chainDb, config.Whitelist, checkpoint, config.BlockProposerEnabled, dex.governance, dex.app)
 	if err != nil {
 		return nil, err
 	}
@@ -190,14 +227,51 @@ func New(ctx *node.ServiceContext, config *Config) (*Tangerine, error) {
 	watchCat := syncer.NewWatchCat(recovery, dex.governance, 10*time.Second,
 		time.Duration(chainConfig.Recovery.Timeout)*time.Second, log.Root())
 
+	if config.BlockProposerEnabled {
+		if dex.dkgDb, err = openDKGDatabase(chainDb, config); err != nil {
+			return nil, err
+		}
+	}
 	dex.bp = NewBlockProposer(dex, watchCat, dMoment)
 
 	dex.etherbase = crypto.PubkeyToAddress(config.PrivateKey.PublicKey)
 	return dex, nil
 }
 
+// openDKGDatabase unlocks the consensus database sealing the DKG private keys
+// and protocol state, encrypting any records still stored in plain.
+func openDKGDatabase(chainDb ethdb.Database, config *Config) (*dexDB.DB, error) {
+	// A passphrase needs the full key derivation hardness, while the one
+	// derived from the node key already carries full entropy.
+	auth, scryptN, scryptP := config.DKGPassphrase, keystore.StandardScryptN, keystore.StandardScryptP
+	if auth == "" {
+		auth, scryptN, scryptP = dexDB.NodeKeyAuth(config.PrivateKey), keystore.LightScryptN, keystore.LightScryptP
+	}
+	db, err := dexDB.NewEncryptedDatabase(chainDb, auth, scryptN, scryptP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock DKG records: %v", err)
+	}
+	migrated, err := db.MigrateDKGRecords()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt DKG records: %v", err)
+	}
+	if migrated > 0 {
+		log.Info("Encrypted DKG records at rest", "count", migrated)
+	}
+	return db, nil
+}
+
+// AddLesServer registers the light client server of the node.
+func (s *Tangerine) AddLesServer(ls LesServer) {
+	s.lesServer = ls
+	ls.SetBloomBitsIndexer(s.bloomIndexer)
+}
+
 func (s *Tangerine) Protocols() []p2p.Protocol {
-	return s.protocolManager.SubProtocols
+	if s.lesServer == nil {
+		return s.protocolManager.SubProtocols
+	}
+	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
 }
 
 func (s *Tangerine) APIs() []rpc.API {
@@ -236,6 +310,10 @@ func (s *Tangerine) APIs() []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(s.chainConfig, s),
+		}, {
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   NewPrivateArchiveAPI(s),
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -260,15 +338,22 @@ func (s *Tangerine) Start(srvr *p2p.Server) error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
+	// Advertise the dex protocol in the local node record
+	s.startDexEntryUpdate(srvr.LocalNode())
+
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(srvr, maxPeers)
+	if s.lesServer != nil {
+		s.lesServer.Start(srvr)
+	}
+	s.governance.Start()
 
 	if s.config.BlockProposerEnabled {
 		go func() {
 			// Since we might be in fast sync mode when started. wait for
 			// ChainHeadEvent before starting blockproposer, or else we will trigger
 			// watchcat.
-			if s.config.SyncMode == downloader.FastSync &&
+			if (s.config.SyncMode == downloader.FastSync || s.config.SyncMode == downloader.SnapSync) &&
 				s.blockchain.CurrentBlock().NumberU64() == 0 {
 				ch := make(chan core.ChainHeadEvent)
 				sub := s.blockchain.SubscribeChainHeadEvent(ch)
@@ -283,10 +368,14 @@ func (s *Tangerine) Start(srvr *p2p.Server) error {
 }
 
 func (s *Tangerine) Stop() error {
+	s.governance.Stop()
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
 	s.protocolManager.Stop()
+	if s.lesServer != nil {
+		s.lesServer.Stop()
+	}
 	s.txPool.Stop()
 	s.eventMux.Stop()
 	s.bp.Stop()
@@ -14,7 +14,6 @@ import (
 	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
 
 	"github.com/tangerine-network/go-tangerine/core"
-	"github.com/tangerine-network/go-tangerine/dex/db"
 	"github.com/tangerine-network/go-tangerine/log"
 	"github.com/tangerine-network/go-tangerine/node"
 	"github.com/tangerine-network/go-tangerine/rlp"
@@ -115,7 +114,7 @@ func (b *blockProposer) IsProposing() bool {
 }
 
 func (b *blockProposer) initConsensus() *dexCore.Consensus {
-	db := db.NewDatabase(b.dex.chainDb)
+	db := b.dex.dkgDb
 	privkey := coreEcdsa.NewPrivateKeyFromECDSA(b.dex.config.PrivateKey)
 	return dexCore.NewConsensus(b.dMoment,
 		b.dex.app, b.dex.governance, db, b.dex.network, privkey, log.Root())
@@ -127,7 +126,7 @@ func (b *blockProposer) syncConsensus() (*dexCore.Consensus, error) {
 
 	cb := b.dex.blockchain.CurrentBlock()
 
-	db := db.NewDatabase(b.dex.chainDb)
+	db := b.dex.dkgDb
 	privkey := coreEcdsa.NewPrivateKeyFromECDSA(b.dex.config.PrivateKey)
 	consensusSync := syncer.NewConsensus(cb.NumberU64(), b.dMoment, b.dex.app,
 		b.dex.governance, db, b.dex.network, privkey, log.Root())
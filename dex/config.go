@@ -77,14 +77,27 @@ type Config struct {
 	// PrivateKey, also represents the node identity.
 	PrivateKey *ecdsa.PrivateKey `toml:",omitempty"`
 
+	// DKGPassphrase protects the DKG private keys at rest. If empty, it is
+	// derived from the node key.
+	DKGPassphrase string `toml:"-"`
+
 	// Protocol options
 	NetworkId uint64 // Network ID to use for selecting peers to connect to
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Finality pruning only retains the most recent and the round boundary
+	// states, dropping everything else as soon as it leaves the window.
+	FinalityPruning bool
+	StateRetention  uint64 `toml:",omitempty"` // Number of recent states to retain (0 = default)
+
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// Trusted governance checkpoint to start header verification from,
+	// overriding the one known for the genesis
+	Checkpoint *params.GovernanceCheckpoint `toml:",omitempty"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
@@ -94,6 +107,8 @@ type Config struct {
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
 	DatabaseDir        string
+	DatabaseFreezer    string
+	AncientRounds      uint64 `toml:",omitempty"` // Number of recent rounds kept out of the ancient store (0 = no freezing)
 	TrieCleanCache     int
 	TrieDirtyCache     int
 	TrieTimeout        time.Duration
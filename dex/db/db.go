@@ -18,6 +18,8 @@
 package db
 
 import (
+	"fmt"
+
 	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
 	coreDKG "github.com/tangerine-network/tangerine-consensus/core/crypto/dkg"
 	coreDb "github.com/tangerine-network/tangerine-consensus/core/db"
@@ -26,15 +28,17 @@ import (
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/rlp"
 )
 
 // DB implement dexon-consensus BlockDatabase interface.
 type DB struct {
-	db ethdb.Database
+	db     ethdb.Database
+	secret *sealedStore // DKG records view, encrypted if a data key is set
 }
 
 func NewDatabase(db ethdb.Database) *DB {
-	return &DB{db}
+	return &DB{db: db, secret: &sealedStore{db: db}}
 }
 
 func (d *DB) HasBlock(hash coreCommon.Hash) bool {
@@ -70,8 +74,14 @@ func (d *DB) PutBlock(block coreTypes.Block) error {
 }
 
 func (d *DB) GetDKGPrivateKey(round, reset uint64) (coreDKG.PrivateKey, error) {
-	key := rawdb.ReadCoreDKGPrivateKey(d.db, round, reset)
+	key := rawdb.ReadCoreDKGPrivateKey(d.secret, round, reset)
 	if key == nil {
+		err := d.checkReadable(func(db rawdb.DatabaseReader) rlp.RawValue {
+			return rawdb.ReadCoreDKGPrivateKeyRLP(db, round)
+		}, fmt.Sprintf("DKG private key of round %d", round))
+		if err != nil {
+			return coreDKG.PrivateKey{}, err
+		}
 		return coreDKG.PrivateKey{}, coreDb.ErrDKGPrivateKeyDoesNotExist
 	}
 	return *key, nil
@@ -86,7 +96,7 @@ func (d *DB) PutDKGPrivateKey(round, reset uint64, key coreDKG.PrivateKey) error
 		return err
 	}
 
-	return rawdb.WriteCoreDKGPrivateKey(d.db, round, reset, &key)
+	return rawdb.WriteCoreDKGPrivateKey(d.secret, round, reset, &key)
 }
 
 func (d *DB) PutCompactionChainTipInfo(hash coreCommon.Hash, height uint64) error {
@@ -103,13 +113,16 @@ func (d *DB) GetCompactionChainTipInfo() (hash coreCommon.Hash, height uint64) {
 
 func (d *DB) PutOrUpdateDKGProtocol(
 	protocol coreDb.DKGProtocolInfo) error {
-	return rawdb.WriteCoreDKGProtocol(d.db, &protocol)
+	return rawdb.WriteCoreDKGProtocol(d.secret, &protocol)
 }
 
 func (d *DB) GetDKGProtocol() (
 	protocol coreDb.DKGProtocolInfo, err error) {
-	dkgProtocol := rawdb.ReadCoreDKGProtocol(d.db)
+	dkgProtocol := rawdb.ReadCoreDKGProtocol(d.secret)
 	if dkgProtocol == nil {
+		if err := d.checkReadable(rawdb.ReadCoreDKGProtocolRLP, "DKG protocol"); err != nil {
+			return coreDb.DKGProtocolInfo{}, err
+		}
 		return coreDb.DKGProtocolInfo{}, coreDb.ErrDKGProtocolDoesNotExist
 	}
 	return *dkgProtocol, nil
@@ -0,0 +1,279 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tangerine-network/go-tangerine/accounts/keystore"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// sealedRecordVersion prefixes every encrypted DKG record. Plain records are
+// RLP lists, whose first byte is never below 0xc0, so the two never collide.
+const sealedRecordVersion = 0x01
+
+var (
+	// ErrDKGKeyMissing is returned when rotating the DKG encryption key of a
+	// database whose records are not encrypted.
+	ErrDKGKeyMissing = errors.New("DKG records are not encrypted")
+
+	// ErrDKGRecordUnreadable is returned when a stored DKG record fails to
+	// decrypt, telling it apart from a missing one.
+	ErrDKGRecordUnreadable = errors.New("failed to decrypt DKG record")
+
+	errDatabaseNotIterable  = errors.New("database does not support iteration")
+	errSealedRecordTooShort = errors.New("sealed DKG record too short")
+)
+
+// NodeKeyAuth derives the passphrase protecting the DKG records from the node
+// key, for nodes not configured with a separate one.
+func NodeKeyAuth(key *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(crypto.Keccak256([]byte("dkg-at-rest"), crypto.FromECDSA(key)))
+}
+
+// NewEncryptedDatabase creates a database sealing the DKG private keys and the
+// DKG protocol state with a random data key. The data key itself is stored in
+// the database encrypted keystore-style with the given passphrase, and created
+// on first use.
+func NewEncryptedDatabase(db ethdb.Database, auth string, scryptN, scryptP int) (*DB, error) {
+	var (
+		key []byte
+		err error
+	)
+	if envelope := rawdb.ReadCoreDKGKeyEnvelope(db); len(envelope) != 0 {
+		if key, err = openEnvelope(envelope, auth); err != nil {
+			return nil, err
+		}
+	} else {
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if envelope, err = sealEnvelope(key, auth, scryptN, scryptP); err != nil {
+			return nil, err
+		}
+		if err := rawdb.WriteCoreDKGKeyEnvelope(db, envelope); err != nil {
+			return nil, err
+		}
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{db: db, secret: &sealedStore{db: db, aead: aead}}, nil
+}
+
+// MigrateDKGRecords encrypts the DKG records still stored in plain. It returns
+// the number of migrated records.
+func (d *DB) MigrateDKGRecords() (int, error) {
+	if d.secret.aead == nil {
+		return 0, ErrDKGKeyMissing
+	}
+	rounds, err := d.dkgRounds()
+	if err != nil {
+		return 0, err
+	}
+	migrated := 0
+	for _, round := range rounds {
+		data := rawdb.ReadCoreDKGPrivateKeyRLP(d.db, round)
+		if len(data) == 0 || isSealed(data) {
+			continue
+		}
+		if err := rawdb.WriteCoreDKGPrivateKeyRLP(d.secret, round, data); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	if data := rawdb.ReadCoreDKGProtocolRLP(d.db); len(data) != 0 && !isSealed(data) {
+		if err := rawdb.WriteCoreDKGProtocolRLP(d.secret, data); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// RotateDKGKey re-encrypts all DKG records with a fresh data key, protected by
+// the given passphrase. The records and the new key envelope are written
+// atomically, a record failing to decrypt aborts the rotation leaving the
+// database untouched.
+func (d *DB) RotateDKGKey(auth string, scryptN, scryptP int) error {
+	if d.secret.aead == nil {
+		return ErrDKGKeyMissing
+	}
+	rounds, err := d.dkgRounds()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	envelope, err := sealEnvelope(key, auth, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	var (
+		batch  = d.db.NewBatch()
+		sealed = &sealedWriter{w: batch, aead: aead}
+	)
+	// The accessors return nothing for records failing to decrypt, so abort on
+	// any stored record read back empty instead of dropping it
+	for _, round := range rounds {
+		data := rawdb.ReadCoreDKGPrivateKeyRLP(d.secret, round)
+		if len(data) == 0 {
+			return fmt.Errorf("%v: DKG private key of round %d", ErrDKGRecordUnreadable, round)
+		}
+		if err := rawdb.WriteCoreDKGPrivateKeyRLP(sealed, round, data); err != nil {
+			return err
+		}
+	}
+	if len(rawdb.ReadCoreDKGProtocolRLP(d.db)) != 0 {
+		data := rawdb.ReadCoreDKGProtocolRLP(d.secret)
+		if len(data) == 0 {
+			return fmt.Errorf("%v: DKG protocol", ErrDKGRecordUnreadable)
+		}
+		if err := rawdb.WriteCoreDKGProtocolRLP(sealed, data); err != nil {
+			return err
+		}
+	}
+	if err := rawdb.WriteCoreDKGKeyEnvelope(batch, envelope); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	d.secret = &sealedStore{db: d.db, aead: aead}
+	return nil
+}
+
+// checkReadable returns an error if a stored DKG record fails to open through
+// the sealed view, which the accessors would report as missing.
+func (d *DB) checkReadable(read func(rawdb.DatabaseReader) rlp.RawValue, record string) error {
+	if len(read(d.db)) != 0 && len(read(d.secret)) == 0 {
+		return fmt.Errorf("%v: %s", ErrDKGRecordUnreadable, record)
+	}
+	return nil
+}
+
+// dkgRounds lists the rounds of all the stored DKG private keys.
+func (d *DB) dkgRounds() ([]uint64, error) {
+	it, ok := rawdb.KeyValueStore(d.db).(ethdb.Iteratee)
+	if !ok {
+		return nil, errDatabaseNotIterable
+	}
+	return rawdb.ReadCoreDKGPrivateKeyRounds(it), nil
+}
+
+// sealEnvelope encrypts the data key with the given passphrase.
+func sealEnvelope(key []byte, auth string, scryptN, scryptP int) ([]byte, error) {
+	cryptoJSON, err := keystore.EncryptDataV3(key, []byte(auth), scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cryptoJSON)
+}
+
+// openEnvelope decrypts the data key with the given passphrase.
+func openEnvelope(envelope []byte, auth string) ([]byte, error) {
+	var cryptoJSON keystore.CryptoJSON
+	if err := json.Unmarshal(envelope, &cryptoJSON); err != nil {
+		return nil, err
+	}
+	return keystore.DecryptDataV3(cryptoJSON, auth)
+}
+
+// newAEAD creates the AES-GCM cipher sealing the DKG records.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// isSealed reports whether a stored DKG record is encrypted.
+func isSealed(data []byte) bool {
+	return len(data) > 0 && data[0] == sealedRecordVersion
+}
+
+// sealedWriter encrypts the values written through it. The database key is
+// authenticated along with the value, binding each record to its slot.
+type sealedWriter struct {
+	w    ethdb.Putter
+	aead cipher.AEAD
+}
+
+// Put implements rawdb.DatabaseWriter, sealing the value before storing it.
+func (s *sealedWriter) Put(key []byte, value []byte) error {
+	if s.aead == nil {
+		return s.w.Put(key, value)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := append([]byte{sealedRecordVersion}, nonce...)
+	return s.w.Put(key, s.aead.Seal(sealed, nonce, value, key))
+}
+
+// sealedStore is a view over the database transparently encrypting and
+// decrypting the DKG records. Without a cipher it reads and writes in plain.
+type sealedStore struct {
+	db   ethdb.Database
+	aead cipher.AEAD
+}
+
+// Has implements rawdb.DatabaseReader.
+func (s *sealedStore) Has(key []byte) (bool, error) {
+	return s.db.Has(key)
+}
+
+// Get implements rawdb.DatabaseReader, opening sealed records. Records not
+// yet migrated are returned as they are.
+func (s *sealedStore) Get(key []byte) ([]byte, error) {
+	data, err := s.db.Get(key)
+	if err != nil || s.aead == nil || !isSealed(data) {
+		return data, err
+	}
+	size := 1 + s.aead.NonceSize()
+	if len(data) < size {
+		return nil, errSealedRecordTooShort
+	}
+	return s.aead.Open(nil, data[1:size], data[size:], key)
+}
+
+// Put implements rawdb.DatabaseWriter.
+func (s *sealedStore) Put(key []byte, value []byte) error {
+	return (&sealedWriter{w: s.db, aead: s.aead}).Put(key, value)
+}
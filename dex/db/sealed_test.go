@@ -0,0 +1,165 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	coreDKG "github.com/tangerine-network/tangerine-consensus/core/crypto/dkg"
+	coreDb "github.com/tangerine-network/tangerine-consensus/core/db"
+
+	"github.com/tangerine-network/go-tangerine/accounts/keystore"
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+)
+
+const (
+	testScryptN = 2
+	testScryptP = 1
+)
+
+func TestSealedRecords(t *testing.T) {
+	chainDb := ethdb.NewMemDatabase()
+
+	// Store legacy records in plain and check they get encrypted on migration,
+	// whatever their round
+	plain := []byte{0xc2, 0x01, 0x02}
+	rawdb.WriteCoreDKGPrivateKeyRLP(chainDb, 1, plain)
+	rawdb.WriteCoreDKGPrivateKeyRLP(chainDb, 1000, plain)
+
+	db, err := NewEncryptedDatabase(chainDb, "secret", testScryptN, testScryptP)
+	if err != nil {
+		t.Fatalf("failed to create encrypted database: %v", err)
+	}
+	if data := rawdb.ReadCoreDKGPrivateKeyRLP(db.secret, 1); !bytes.Equal(data, plain) {
+		t.Fatalf("legacy record mismatch: have %x, want %x", data, plain)
+	}
+	if migrated, err := db.MigrateDKGRecords(); err != nil || migrated != 2 {
+		t.Fatalf("migration mismatch: have %d (%v), want 2", migrated, err)
+	}
+	stored := rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1)
+	if !isSealed(stored) || bytes.Contains(stored, plain) {
+		t.Fatalf("record not encrypted at rest: %x", stored)
+	}
+	if distant := rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1000); !isSealed(distant) {
+		t.Fatalf("distant record not encrypted at rest: %x", distant)
+	}
+	if data := rawdb.ReadCoreDKGPrivateKeyRLP(db.secret, 1); !bytes.Equal(data, plain) {
+		t.Fatalf("sealed record mismatch: have %x, want %x", data, plain)
+	}
+	// Records must be bound to their slot
+	rawdb.WriteCoreDKGPrivateKeyRLP(chainDb, 2, stored)
+	if data := rawdb.ReadCoreDKGPrivateKeyRLP(db.secret, 2); data != nil {
+		t.Fatalf("moved record opened: %x", data)
+	}
+	rawdb.WriteCoreDKGPrivateKeyRLP(db.secret, 2, plain)
+	// Reopening needs the right passphrase
+	if _, err := NewEncryptedDatabase(chainDb, "wrong", testScryptN, testScryptP); err != keystore.ErrDecrypt {
+		t.Fatalf("wrong passphrase error mismatch: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	// Rotate the key and check the old passphrase is not accepted anymore
+	if err := db.RotateDKGKey("rotated", testScryptN, testScryptP); err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+	if bytes.Equal(rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1), stored) {
+		t.Fatalf("record not re-encrypted")
+	}
+	if _, err := NewEncryptedDatabase(chainDb, "secret", testScryptN, testScryptP); err != keystore.ErrDecrypt {
+		t.Fatalf("old passphrase error mismatch: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	reopened, err := NewEncryptedDatabase(chainDb, "rotated", testScryptN, testScryptP)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	for _, round := range []uint64{1, 2, 1000} {
+		if data := rawdb.ReadCoreDKGPrivateKeyRLP(reopened.secret, round); !bytes.Equal(data, plain) {
+			t.Fatalf("round %d: rotated record mismatch: have %x, want %x", round, data, plain)
+		}
+	}
+}
+
+// Tests that a DKG record failing to decrypt aborts the key rotation instead of
+// being dropped.
+func TestRotateUnreadableRecord(t *testing.T) {
+	chainDb := ethdb.NewMemDatabase()
+
+	db, err := NewEncryptedDatabase(chainDb, "secret", testScryptN, testScryptP)
+	if err != nil {
+		t.Fatalf("failed to create encrypted database: %v", err)
+	}
+	rawdb.WriteCoreDKGPrivateKeyRLP(db.secret, 1, []byte{0xc2, 0x01, 0x02})
+
+	// Corrupt the sealed record and ensure the rotation refuses to proceed
+	stored := common.CopyBytes(rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1))
+	stored[len(stored)-1] ^= 0xff
+	rawdb.WriteCoreDKGPrivateKeyRLP(chainDb, 1, stored)
+
+	envelope := rawdb.ReadCoreDKGKeyEnvelope(chainDb)
+	if err := db.RotateDKGKey("rotated", testScryptN, testScryptP); err == nil {
+		t.Fatalf("rotation succeeded with unreadable record")
+	}
+	if !bytes.Equal(rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1), stored) {
+		t.Fatalf("unreadable record modified")
+	}
+	if !bytes.Equal(rawdb.ReadCoreDKGKeyEnvelope(chainDb), envelope) {
+		t.Fatalf("key envelope replaced")
+	}
+}
+
+// Tests that DKG records failing to decrypt are reported as such, instead of
+// as missing records which would be regenerated over them.
+func TestGetUnreadableRecord(t *testing.T) {
+	chainDb := ethdb.NewMemDatabase()
+
+	db, err := NewEncryptedDatabase(chainDb, "secret", testScryptN, testScryptP)
+	if err != nil {
+		t.Fatalf("failed to create encrypted database: %v", err)
+	}
+	if _, err := db.GetDKGPrivateKey(1, 0); err != coreDb.ErrDKGPrivateKeyDoesNotExist {
+		t.Fatalf("missing key error mismatch: have %v, want %v", err, coreDb.ErrDKGPrivateKeyDoesNotExist)
+	}
+	if _, err := db.GetDKGProtocol(); err != coreDb.ErrDKGProtocolDoesNotExist {
+		t.Fatalf("missing protocol error mismatch: have %v, want %v", err, coreDb.ErrDKGProtocolDoesNotExist)
+	}
+	rawdb.WriteCoreDKGPrivateKeyRLP(db.secret, 1, []byte{0xc2, 0x01, 0x02})
+	rawdb.WriteCoreDKGProtocolRLP(db.secret, []byte{0xc2, 0x01, 0x02})
+
+	// Corrupt the sealed records and ensure they're not reported missing
+	stored := common.CopyBytes(rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1))
+	stored[len(stored)-1] ^= 0xff
+	rawdb.WriteCoreDKGPrivateKeyRLP(chainDb, 1, stored)
+
+	protocol := common.CopyBytes(rawdb.ReadCoreDKGProtocolRLP(chainDb))
+	protocol[len(protocol)-1] ^= 0xff
+	rawdb.WriteCoreDKGProtocolRLP(chainDb, protocol)
+
+	if _, err := db.GetDKGPrivateKey(1, 0); err == nil || !strings.HasPrefix(err.Error(), ErrDKGRecordUnreadable.Error()) {
+		t.Fatalf("unreadable key error mismatch: have %v, want %v", err, ErrDKGRecordUnreadable)
+	}
+	if err := db.PutDKGPrivateKey(1, 0, coreDKG.PrivateKey{}); err == nil || err == coreDb.ErrDKGPrivateKeyExists {
+		t.Fatalf("unreadable key overwritten: %v", err)
+	}
+	if !bytes.Equal(rawdb.ReadCoreDKGPrivateKeyRLP(chainDb, 1), stored) {
+		t.Fatalf("unreadable key modified")
+	}
+	if _, err := db.GetDKGProtocol(); err == nil || !strings.HasPrefix(err.Error(), ErrDKGRecordUnreadable.Error()) {
+		t.Fatalf("unreadable protocol error mismatch: have %v, want %v", err, ErrDKGRecordUnreadable)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"sync/atomic"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/p2p/enode"
+	"github.com/tangerine-network/go-tangerine/p2p/enr"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// dexEntry is the "dex" ENR entry which advertises the dex protocol on the
+// discovery network, along with the round the node is following.
+type dexEntry struct {
+	Round   uint64         // Round of the node's current head block
+	Address common.Address // Address derived from the node key
+	Version uint           // Primary dex protocol version
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e dexEntry) ENRKey() string {
+	return "dex"
+}
+
+// startDexEntryUpdate keeps the dex ENR entry of the local node up to date
+// with the round of the current chain head until the protocol manager stops.
+func (s *Tangerine) startDexEntryUpdate(ln *enode.LocalNode) {
+	var (
+		newHead = make(chan core.ChainHeadEvent, 10)
+		sub     = s.blockchain.SubscribeChainHeadEvent(newHead)
+	)
+	ln.Set(s.currentDexEntry(ln))
+
+	pm := s.protocolManager
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-newHead:
+				ln.Set(s.currentDexEntry(ln))
+			case <-sub.Err():
+				return
+			case <-pm.quitSync:
+				return
+			}
+		}
+	}()
+}
+
+// currentDexEntry assembles the dex ENR entry of the local node.
+func (s *Tangerine) currentDexEntry(ln *enode.LocalNode) *dexEntry {
+	return &dexEntry{
+		Round:   s.blockchain.CurrentBlock().Round(),
+		Address: crypto.PubkeyToAddress(*ln.Node().Pubkey()),
+		Version: ProtocolVersions[0],
+	}
+}
+
+// notaryIterator resolves the endpoints of a set of notary nodes through the
+// discovery network, yielding the ones found to be reachable. Nodes whose
+// record carries a dex entry not matching their key are skipped.
+type notaryIterator struct {
+	resolve func(*enode.Node) *enode.Node
+	nodes   []*enode.Node
+	cur     *enode.Node
+	closed  int32
+}
+
+// newNotaryIterator creates an iterator looking up the given notary nodes.
+func newNotaryIterator(resolve func(*enode.Node) *enode.Node, nodes []*enode.Node) *notaryIterator {
+	return &notaryIterator{resolve: resolve, nodes: nodes}
+}
+
+// Next resolves the next reachable notary node, returning false once all of
+// them were looked up or the iterator was closed.
+func (it *notaryIterator) Next() bool {
+	for len(it.nodes) > 0 && atomic.LoadInt32(&it.closed) == 0 {
+		n := it.resolve(it.nodes[0])
+		it.nodes = it.nodes[1:]
+		if n == nil || n.IP() == nil || n.TCP() == 0 {
+			continue
+		}
+		var entry dexEntry
+		if err := n.Load(&entry); err != nil {
+			if !enr.IsNotFound(err) {
+				log.Debug("Invalid dex entry in notary record", "id", n.ID(), "err", err)
+				continue
+			}
+		} else if entry.Address != crypto.PubkeyToAddress(*n.Pubkey()) {
+			log.Debug("Mismatched dex entry in notary record", "id", n.ID(), "address", entry.Address)
+			continue
+		}
+		it.cur = n
+		return true
+	}
+	it.cur = nil
+	return false
+}
+
+// Node returns the node resolved by the last successful call to Next.
+func (it *notaryIterator) Node() *enode.Node {
+	return it.cur
+}
+
+// Close aborts the iteration. It is safe to call concurrently with Next,
+// which will return false after the lookup in progress finishes.
+func (it *notaryIterator) Close() {
+	atomic.StoreInt32(&it.closed, 1)
+}
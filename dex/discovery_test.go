@@ -0,0 +1,92 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"net"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/p2p/enode"
+	"github.com/tangerine-network/go-tangerine/p2p/enr"
+)
+
+// newTestRecordNode creates a signed node record reachable on a local
+// endpoint, optionally carrying the given dex entry.
+func newTestRecordNode(t *testing.T, entry *dexEntry) *enode.Node {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var r enr.Record
+	r.Set(enr.IP(net.IP{127, 0, 0, 1}))
+	r.Set(enr.TCP(30303))
+	if entry != nil {
+		if entry.Address == (dexEntry{}).Address {
+			entry.Address = crypto.PubkeyToAddress(key.PublicKey)
+		}
+		r.Set(entry)
+	}
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	return n
+}
+
+func TestNotaryIterator(t *testing.T) {
+	var (
+		plain      = newTestRecordNode(t, nil)
+		advertised = newTestRecordNode(t, &dexEntry{Round: 3, Version: dex64})
+		forged     = newTestRecordNode(t, &dexEntry{Round: 3, Version: dex64, Address: crypto.PubkeyToAddress(*plain.Pubkey())})
+		missing    = newTestRecordNode(t, nil)
+	)
+	resolved := map[enode.ID]*enode.Node{
+		plain.ID():      plain,
+		advertised.ID(): advertised,
+		forged.ID():     forged,
+	}
+	resolve := func(n *enode.Node) *enode.Node {
+		return resolved[n.ID()]
+	}
+	var nodes []*enode.Node
+	for _, n := range []*enode.Node{plain, advertised, forged, missing} {
+		nodes = append(nodes, enode.NewV4(n.Pubkey(), nil, 0, 0))
+	}
+	it := newNotaryIterator(resolve, nodes)
+
+	var found []*enode.Node
+	for it.Next() {
+		found = append(found, it.Node())
+	}
+	if len(found) != 2 || found[0].ID() != plain.ID() || found[1].ID() != advertised.ID() {
+		t.Fatalf("resolved nodes mismatch: have %v, want [%v %v]", found, plain, advertised)
+	}
+	if found[1].IP() == nil {
+		t.Fatalf("resolved node missing endpoint")
+	}
+
+	// A closed iterator must not resolve any more nodes
+	it = newNotaryIterator(resolve, nodes)
+	it.Close()
+	if it.Next() {
+		t.Fatalf("closed iterator yielded node %v", it.Node())
+	}
+}
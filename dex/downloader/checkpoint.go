@@ -0,0 +1,209 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/params"
+)
+
+var (
+	errCheckpointMismatch   = errors.New("checkpoint mismatch")
+	errCheckpointUnendorsed = errors.New("checkpoint not endorsed by notary set")
+)
+
+// useCheckpoint reports whether the trusted checkpoint can anchor a sync
+// starting at origin and targeting height: it needs to be above the local
+// chain and reachable on the remote one.
+func (d *Downloader) useCheckpoint(origin, height uint64) bool {
+	return d.checkpoint != nil && origin < d.checkpoint.Number && d.checkpoint.Number <= height
+}
+
+// fetchCheckpoint retrieves the checkpoint header and the governance states of
+// the rounds needed to verify it from the remote peer, stores them into the
+// sync governance and verifies the notary set endorsement of the checkpoint.
+//
+// The notary set is derived from the governance states of the earlier rounds.
+// Their headers cannot be verified yet, so they are only accepted if their
+// state roots are the ones of the checkpoint. Their hashes are recorded as
+// anchors and checked once the header chain below the checkpoint links up to
+// it.
+func (d *Downloader) fetchCheckpoint(p *peerConnection) (map[uint64]common.Hash, error) {
+	cp := d.checkpoint
+
+	// The notary set is derived from the states of up to three previous rounds
+	rounds := cp.Round
+	if rounds > 3 {
+		rounds = 3
+	}
+	if uint64(len(cp.ConfigRoots)) != rounds {
+		log.Warn("Checkpoint without config state roots", "round", cp.Round, "roots", len(cp.ConfigRoots))
+		return nil, errCheckpointMismatch
+	}
+	header, err := d.fetchHeader(p, cp.Number)
+	if err != nil {
+		return nil, err
+	}
+	if header.Hash() != cp.Hash || header.Root != cp.GovStateRoot || header.Round != cp.Round {
+		p.log.Warn("Checkpoint header mismatch", "number", cp.Number, "hash", header.Hash(), "want", cp.Hash)
+		return nil, errCheckpointMismatch
+	}
+	if h := d.gov.GetRoundHeight(cp.Round); h != cp.Number {
+		p.log.Warn("Checkpoint is not a round height", "round", cp.Round, "number", cp.Number, "height", h)
+		return nil, errCheckpointMismatch
+	}
+	anchors := map[uint64]common.Hash{cp.Number: cp.Hash}
+	if err := d.fetchCheckpointState(p, header); err != nil {
+		return nil, err
+	}
+	// Prepare the states of the previous rounds as done for a local origin
+	for i := uint64(1); i < 4 && cp.Round >= i; i++ {
+		h := d.gov.GetRoundHeight(cp.Round - i)
+		header, err := d.fetchHeader(p, h)
+		if err != nil {
+			return nil, err
+		}
+		if header.Root != cp.ConfigRoots[i-1] {
+			p.log.Warn("Checkpoint config header mismatch", "round", cp.Round-i, "number", h, "root", header.Root, "want", cp.ConfigRoots[i-1])
+			return nil, errCheckpointMismatch
+		}
+		if err := d.fetchCheckpointState(p, header); err != nil {
+			return nil, err
+		}
+		anchors[h] = header.Hash()
+	}
+	if err := d.verifyCheckpoint(cp); err != nil {
+		return nil, err
+	}
+	log.Info("Anchored header sync on checkpoint", "round", cp.Round, "number", cp.Number, "hash", cp.Hash)
+	return anchors, nil
+}
+
+// fetchCheckpointState retrieves the proven governance state of header and
+// stores it into the sync governance.
+func (d *Downloader) fetchCheckpointState(p *peerConnection, header *types.Header) error {
+	s, err := d.fetchGovState(p, header.Hash(), header.Root)
+	if err != nil {
+		return err
+	}
+	if s.Root != header.Root || s.Number == nil || s.Number.Uint64() != header.Number.Uint64() {
+		p.log.Debug("Gov state does not match header", "number", header.Number, "root", header.Root)
+		return errBadPeer
+	}
+	d.gov.StoreState(s)
+	return nil
+}
+
+// verifyCheckpoint checks that more than two thirds of the notary set of the
+// checkpoint round signed it.
+func (d *Downloader) verifyCheckpoint(cp *params.GovernanceCheckpoint) error {
+	notarySet, err := d.gov.NotarySet(cp.Round)
+	if err != nil {
+		return err
+	}
+	var (
+		hash    = cp.SigHash()
+		signers = make(map[string]struct{})
+	)
+	for _, sig := range cp.Signatures {
+		pubkey, err := crypto.Ecrecover(hash[:], sig)
+		if err != nil {
+			log.Debug("Invalid checkpoint signature", "err", err)
+			continue
+		}
+		id := hex.EncodeToString(pubkey)
+		if _, ok := notarySet[id]; ok {
+			signers[id] = struct{}{}
+		}
+	}
+	if len(signers) < len(notarySet)*2/3+1 {
+		log.Warn("Checkpoint not endorsed", "round", cp.Round, "signers", len(signers), "notaries", len(notarySet))
+		return errCheckpointUnendorsed
+	}
+	return nil
+}
+
+// withGov reports whether headers up to the given number need to carry the
+// governance states of their rounds, which is only the case above the
+// checkpoint anchoring the current sync.
+func (d *Downloader) withGov(to uint64) bool {
+	return to > d.trusted
+}
+
+// insertHeaders inserts a chunk of headers into the local chain. The first
+// trusted headers are secured by the checkpoint, they are only checked against
+// the anchors and to link up instead of being verified against governance.
+func (d *Downloader) insertHeaders(chunk []*types.HeaderWithGovState, trusted int) (int, error) {
+	if trusted > 0 {
+		for i, header := range chunk[:trusted] {
+			if hash, ok := d.anchors[header.Number.Uint64()]; ok && header.Hash() != hash {
+				return i, errCheckpointMismatch
+			}
+		}
+		if n, err := d.lightchain.InsertTangerineHeaderChain(chunk[:trusted], nil, nil); err != nil {
+			return n, err
+		}
+	}
+	if trusted == len(chunk) {
+		return 0, nil
+	}
+	n, err := d.lightchain.InsertTangerineHeaderChain(chunk[trusted:], d.gov, d.verifierCache)
+	return trusted + n, err
+}
+
+// fetchHeader retrieves a single header by number from the remote peer.
+func (d *Downloader) fetchHeader(p *peerConnection, number uint64) (*types.Header, error) {
+	p.log.Debug("Retrieving remote header", "number", number)
+	go p.peer.RequestHeadersByNumber(number, 1, 0, false, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCancelBlockFetch
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 || headers[0].Number.Uint64() != number {
+				p.log.Debug("Invalid header for single request", "headers", len(headers))
+				return nil, errBadPeer
+			}
+			return headers[0].Header, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for header timed out", "elapsed", ttl)
+			return nil, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/params"
+)
+
+// makeCheckpoint creates a checkpoint of the first header of the given round
+// of the chain, signed by the given keys.
+func makeCheckpoint(t *testing.T, chain *testChain, round uint64, keys []*ecdsa.PrivateKey) *params.GovernanceCheckpoint {
+	// Collect the first header of each round
+	firsts := make(map[uint64]*types.Header)
+	for _, hash := range chain.chain {
+		header := chain.headerm[hash]
+		if _, ok := firsts[header.Round]; !ok {
+			firsts[header.Round] = header
+		}
+	}
+	header, ok := firsts[round]
+	if !ok {
+		t.Fatalf("round %d not in test chain", round)
+	}
+	cp := &params.GovernanceCheckpoint{
+		Round:        round,
+		Number:       header.Number.Uint64(),
+		Hash:         header.Hash(),
+		GovStateRoot: header.Root,
+	}
+	for i := uint64(1); i < 4 && round >= i; i++ {
+		cp.ConfigRoots = append(cp.ConfigRoots, firsts[round-i].Root)
+	}
+	signCheckpoint(t, cp, keys)
+	return cp
+}
+
+// signCheckpoint replaces the signatures of a checkpoint with the given keys.
+func signCheckpoint(t *testing.T, cp *params.GovernanceCheckpoint, keys []*ecdsa.PrivateKey) {
+	hash := cp.SigHash()
+
+	cp.Signatures = nil
+	for _, key := range keys {
+		sig, err := crypto.Sign(hash[:], key)
+		if err != nil {
+			t.Fatalf("failed to sign checkpoint: %v", err)
+		}
+		cp.Signatures = append(cp.Signatures, hexutil.Bytes(sig))
+	}
+}
+
+// Tests that header sync can be anchored on a checkpoint endorsed by the
+// notary set of its round, and that checkpoints not endorsed by it or not
+// matching the remote chain are rejected.
+func TestCheckpointSync64Fast(t *testing.T)  { testCheckpointSync(t, 64, FastSync) }
+func TestCheckpointSync64Light(t *testing.T) { testCheckpointSync(t, 64, LightSync) }
+
+func testCheckpointSync(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	chain := testChainBase.shorten(testChainBase.len())
+
+	var strangers []*ecdsa.PrivateKey
+	for i := 0; i < len(testNodeKeys); i++ {
+		key, _ := crypto.GenerateKey()
+		strangers = append(strangers, key)
+	}
+	tests := []struct {
+		name   string
+		modify func(cp *params.GovernanceCheckpoint)
+		err    error
+	}{
+		{"valid", func(cp *params.GovernanceCheckpoint) {}, nil},
+		{"wrong notary set", func(cp *params.GovernanceCheckpoint) { signCheckpoint(t, cp, strangers) }, errCheckpointUnendorsed},
+		{"no quorum", func(cp *params.GovernanceCheckpoint) { signCheckpoint(t, cp, testNodeKeys[:2]) }, errCheckpointUnendorsed},
+		{"gov root mismatch", func(cp *params.GovernanceCheckpoint) {
+			cp.GovStateRoot = common.Hash{0x01}
+			signCheckpoint(t, cp, testNodeKeys)
+		}, errCheckpointMismatch},
+		{"config root mismatch", func(cp *params.GovernanceCheckpoint) {
+			cp.ConfigRoots[0] = common.Hash{0x01}
+			signCheckpoint(t, cp, testNodeKeys)
+		}, errCheckpointMismatch},
+		{"config roots missing", func(cp *params.GovernanceCheckpoint) {
+			cp.ConfigRoots = nil
+			signCheckpoint(t, cp, testNodeKeys)
+		}, errCheckpointMismatch},
+		{"not a round height", func(cp *params.GovernanceCheckpoint) {
+			header := chain.headerm[chain.chain[cp.Number+1]]
+			cp.Number, cp.Hash, cp.GovStateRoot = header.Number.Uint64(), header.Hash(), header.Root
+			signCheckpoint(t, cp, testNodeKeys)
+		}, errCheckpointMismatch},
+	}
+	for _, tt := range tests {
+		cp := makeCheckpoint(t, chain, 1, testNodeKeys)
+		tt.modify(cp)
+
+		tester := newTester()
+		tester.downloader.checkpoint = cp
+		tester.newPeer("peer", protocol, chain)
+
+		err := tester.sync("peer", 0, mode)
+		tester.terminate()
+
+		if err != tt.err {
+			t.Errorf("%s: sync error mismatch: have %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil {
+			if tester.downloader.trusted != cp.Number {
+				t.Errorf("%s: sync not anchored on checkpoint: trusted %d, want %d", tt.name, tester.downloader.trusted, cp.Number)
+			}
+			assertOwnChain(t, tester, chain.len())
+		}
+	}
+}
@@ -111,6 +111,10 @@ type Downloader struct {
 	gov           *governance
 	verifierCache *dexCore.TSigVerifierCache
 
+	checkpoint *params.GovernanceCheckpoint // Trusted checkpoint to start header verification from
+	trusted    uint64                       // Height up to which headers are secured by the checkpoint (current sync)
+	anchors    map[uint64]common.Hash       // Header hashes below the checkpoint to verify once linked up (current sync)
+
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
@@ -146,6 +150,7 @@ type Downloader struct {
 	stateSyncStart chan *stateSync
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [eth/63] Channel receiving inbound node state data
+	snapCh         chan dataPack // [dex/65] Channel receiving inbound account and storage ranges
 
 	// Cancellation and termination
 	cancelPeer string         // Identifier of the peer currently being used as the master (cancel on drop)
@@ -178,7 +183,8 @@ type LightChain interface {
 
 	GetGovStateByNumber(number uint64) (*types.GovState, error)
 
-	// InsertTangerineHeaderChain inserts a batch of headers into the local chain.
+	// InsertTangerineHeaderChain inserts a batch of headers into the local chain,
+	// a nil governance skipping the verification of checkpoint secured headers.
 	InsertTangerineHeaderChain([]*types.HeaderWithGovState,
 		dexcon.GovernanceStateFetcher, *dexCore.TSigVerifierCache) (int, error)
 
@@ -216,13 +222,14 @@ type BlockChain interface {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
+func New(mode SyncMode, checkpoint *params.GovernanceCheckpoint, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
 	if lightchain == nil {
 		lightchain = chain
 	}
 
 	dl := &Downloader{
 		mode:           mode,
+		checkpoint:     checkpoint,
 		stateDB:        stateDb,
 		mux:            mux,
 		queue:          newQueue(),
@@ -241,6 +248,7 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 		headerProcCh:   make(chan []*types.HeaderWithGovState, 1),
 		quitCh:         make(chan struct{}),
 		stateCh:        make(chan dataPack),
+		snapCh:         make(chan dataPack, snapChanSize),
 		stateSyncStart: make(chan *stateSync),
 		syncStatsState: stateSyncStats{
 			processed: rawdb.ReadFastTrieProgress(stateDb),
@@ -268,7 +276,7 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	switch d.mode {
 	case FullSync:
 		current = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
 	case LightSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
@@ -383,7 +391,7 @@ func (d *Downloader) synchronise(id string, hash common.Hash, number uint64, mod
 		default:
 		}
 	}
-	for _, ch := range []chan dataPack{d.headerCh, d.bodyCh, d.receiptCh} {
+	for _, ch := range []chan dataPack{d.headerCh, d.bodyCh, d.receiptCh, d.snapCh} {
 		for empty := false; !empty; {
 			select {
 			case <-ch:
@@ -459,7 +467,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, number ui
 
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		if height <= uint64(fsMinFullBlocks) {
 			origin = 0
 		} else {
@@ -470,11 +478,12 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, number ui
 		}
 	}
 	d.committed = 1
-	if d.mode == FastSync && pivot != 0 {
+	if (d.mode == FastSync || d.mode == SnapSync) && pivot != 0 {
 		d.committed = 0
 	}
 
-	if d.mode == FastSync || d.mode == LightSync {
+	d.trusted, d.anchors = 0, nil
+	if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 		// fetch gov state
 		govState, err := d.fetchGovState(p, latest.Hash(), latest.Root)
 		if err != nil {
@@ -486,16 +495,25 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, number ui
 			return fmt.Errorf("origin header not exists, number: %d", origin)
 		}
 
-		// prepare state origin - 3
 		d.gov = newGovernance(govState)
-		for i := uint64(0); i < 4; i++ {
-			if originHeader.Round >= i {
-				h := d.gov.GetRoundHeight(originHeader.Round - i)
-				s, err := d.lightchain.GetGovStateByNumber(h)
-				if err != nil {
-					return err
+		if d.useCheckpoint(origin, height) {
+			// prepare state checkpoint - 3
+			anchors, err := d.fetchCheckpoint(p)
+			if err != nil {
+				return err
+			}
+			d.trusted, d.anchors = d.checkpoint.Number, anchors
+		} else {
+			// prepare state origin - 3
+			for i := uint64(0); i < 4; i++ {
+				if originHeader.Round >= i {
+					h := d.gov.GetRoundHeight(originHeader.Round - i)
+					s, err := d.lightchain.GetGovStateByNumber(h)
+					if err != nil {
+						return err
+					}
+					d.gov.StoreState(s)
 				}
-				d.gov.StoreState(s)
 			}
 		}
 
@@ -514,7 +532,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, number ui
 		func() error { return d.fetchReceipts(origin + 1) },        // Receipts are retrieved during fast sync
 		func() error { return d.processHeaders(origin+1, pivot, number) },
 	}
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
@@ -762,7 +780,7 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 	switch d.mode {
 	case FullSync:
 		localHeight = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync:
 		localHeight = d.blockchain.CurrentFastBlock().NumberU64()
 	default:
 		localHeight = d.lightchain.CurrentHeader().Number.Uint64()
@@ -843,7 +861,7 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 				switch d.mode {
 				case FullSync:
 					known = d.blockchain.HasBlock(h, n)
-				case FastSync:
+				case FastSync, SnapSync:
 					known = d.blockchain.HasFastBlock(h, n)
 				default:
 					known = d.lightchain.HasHeader(h, n)
@@ -914,7 +932,7 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 				switch d.mode {
 				case FullSync:
 					known = d.blockchain.HasBlock(h, n)
-				case FastSync:
+				case FastSync, SnapSync:
 					known = d.blockchain.HasFastBlock(h, n)
 				default:
 					known = d.lightchain.HasHeader(h, n)
@@ -978,10 +996,10 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64, pivot uint64)
 
 		if skeleton {
 			p.log.Trace("Fetching skeleton headers", "count", MaxHeaderFetch, "from", from)
-			go p.peer.RequestHeadersByNumber(from+uint64(MaxHeaderFetch)-1, MaxSkeletonSize, MaxHeaderFetch-1, false, true)
+			go p.peer.RequestHeadersByNumber(from+uint64(MaxHeaderFetch)-1, MaxSkeletonSize, MaxHeaderFetch-1, false, d.withGov(from+uint64(MaxSkeletonSize*MaxHeaderFetch)-1))
 		} else {
 			p.log.Trace("Fetching full headers", "count", MaxHeaderFetch, "from", from)
-			go p.peer.RequestHeadersByNumber(from, MaxHeaderFetch, 0, false, true)
+			go p.peer.RequestHeadersByNumber(from, MaxHeaderFetch, 0, false, d.withGov(from+uint64(MaxHeaderFetch)-1))
 		}
 	}
 	// Start pulling the header chain skeleton until all is done
@@ -1138,7 +1156,9 @@ func (d *Downloader) fillHeaderSkeleton(from uint64, skeleton []*types.HeaderWit
 		reserve  = func(p *peerConnection, count int) (*fetchRequest, bool, error) {
 			return d.queue.ReserveHeaders(p, count), false, nil
 		}
-		fetch    = func(p *peerConnection, req *fetchRequest) error { return p.FetchHeaders(req.From, MaxHeaderFetch) }
+		fetch = func(p *peerConnection, req *fetchRequest) error {
+			return p.FetchHeaders(req.From, MaxHeaderFetch, d.withGov(req.From+uint64(MaxHeaderFetch)-1))
+		}
 		capacity = func(p *peerConnection) int { return p.HeaderCapacity(d.requestRTT()) }
 		setIdle  = func(p *peerConnection, accepted int) { p.SetHeadersIdle(accepted) }
 	)
@@ -1459,7 +1479,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, number uint64)
 				// This check cannot be executed "as is" for full imports, since blocks may still be
 				// queued for processing when the header download completes. However, as long as the
 				// peer gave us something useful, we're already happy/progressed (above check).
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					head := d.lightchain.CurrentHeader()
 					if number > head.Number.Uint64() {
 						return errStallingPeer
@@ -1487,7 +1507,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, number uint64)
 				chunk := headersWithGovState[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headersWithGovState))
 					for _, header := range chunk {
@@ -1496,14 +1516,19 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, number uint64)
 						}
 					}
 
-					for _, header := range chunk {
+					// Headers secured by the checkpoint are only checked to link up to it
+					trusted := 0
+					for trusted < len(chunk) && chunk[trusted].Number.Uint64() <= d.trusted {
+						trusted++
+					}
+					for _, header := range chunk[trusted:] {
 						if header.GovState != nil {
 							log.Debug("Got gov state, store it", "round", header.Round, "number", header.Number.Uint64())
 							d.gov.StoreState(header.GovState)
 						}
 					}
 
-					if n, err := d.lightchain.InsertTangerineHeaderChain(chunk, d.gov, d.verifierCache); err != nil {
+					if n, err := d.insertHeaders(chunk, trusted); err != nil {
 						// If some headers were inserted, add them too to the rollback list
 						if n > 0 {
 							for _, h := range chunk[:n] {
@@ -1521,7 +1546,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, number uint64)
 
 				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode == FullSync || d.mode == FastSync || d.mode == SnapSync {
 					// If we've reached the allowed number of pending headers, stall a bit
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -1790,6 +1815,42 @@ func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
+// DeliverAccountRange injects a new batch of proven accounts received from a
+// remote node.
+func (d *Downloader) DeliverAccountRange(id string, reqID uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	return d.deliverSnap(&accountRangePack{id, reqID, hashes, accounts, proof})
+}
+
+// DeliverStorageRange injects a new batch of proven storage slots received
+// from a remote node.
+func (d *Downloader) DeliverStorageRange(id string, reqID uint64, hashes []common.Hash, slots [][]byte, proof [][]byte) error {
+	return d.deliverSnap(&storageRangePack{id, reqID, hashes, slots, proof})
+}
+
+// deliverSnap injects a range response received from a remote node. Unlike
+// deliver, it never blocks the peer: ranges are only consumed while a snap
+// sync is retrieving them, anything arriving outside of that is stale.
+func (d *Downloader) deliverSnap(packet dataPack) (err error) {
+	snapInMeter.Mark(int64(packet.Items()))
+	defer func() {
+		if err != nil {
+			snapDropMeter.Mark(int64(packet.Items()))
+		}
+	}()
+	d.cancelLock.RLock()
+	cancel := d.cancelCh
+	d.cancelLock.RUnlock()
+	if cancel == nil {
+		return errNoSyncActive
+	}
+	select {
+	case d.snapCh <- packet:
+		return nil
+	default:
+		return errNoSyncActive
+	}
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 	// Update the delivery metrics for both good and failed deliveries
@@ -76,7 +76,7 @@ func newTester() *downloadTester {
 	}
 	tester.stateDb = ethdb.NewMemDatabase()
 	tester.stateDb.Put(testGenesis.Root().Bytes(), []byte{0x00})
-	tester.downloader = New(FullSync, tester.stateDb, new(event.TypeMux), tester, nil, tester.dropPeer)
+	tester.downloader = New(FullSync, nil, tester.stateDb, new(event.TypeMux), tester, nil, tester.dropPeer)
 	return tester
 }
 
@@ -423,6 +423,36 @@ func (dlp *downloadTesterPeer) RequestNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// RequestAccountRange constructs a getAccountRange method associated with a
+// particular peer in the download tester, serving proven account ranges of the
+// peer database.
+func (dlp *downloadTesterPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	hashes, accounts, proof, err := AccountRange(state.NewDatabase(dlp.dl.peerDb), root, origin, limit, bytes)
+	if err != nil {
+		return err
+	}
+	go dlp.dl.downloader.DeliverAccountRange(dlp.id, id, hashes, accounts, proof)
+	return nil
+}
+
+// RequestStorageRange constructs a getStorageRange method associated with a
+// particular peer in the download tester, serving proven storage ranges of the
+// peer database.
+func (dlp *downloadTesterPeer) RequestStorageRange(id uint64, root, account, origin, limit common.Hash, bytes uint64) error {
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	hashes, slots, proof, err := StorageRange(state.NewDatabase(dlp.dl.peerDb), root, account, origin, limit, bytes)
+	if err != nil {
+		return err
+	}
+	go dlp.dl.downloader.DeliverStorageRange(dlp.id, id, hashes, slots, proof)
+	return nil
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int) {
@@ -473,6 +503,8 @@ func TestCanonicalSynchronisation63Fast(t *testing.T)  { testCanonicalSynchronis
 func TestCanonicalSynchronisation64Full(t *testing.T)  { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T)  { testCanonicalSynchronisation(t, 64, FastSync) }
 func TestCanonicalSynchronisation64Light(t *testing.T) { testCanonicalSynchronisation(t, 64, LightSync) }
+func TestCanonicalSynchronisation64Snap(t *testing.T)  { testCanonicalSynchronisation(t, 64, SnapSync) }
+func TestCanonicalSynchronisation65Snap(t *testing.T)  { testCanonicalSynchronisation(t, 65, SnapSync) }
 
 func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -648,6 +680,8 @@ func TestMultiSynchronisation63Fast(t *testing.T)  { testMultiSynchronisation(t,
 func TestMultiSynchronisation64Full(t *testing.T)  { testMultiSynchronisation(t, 64, FullSync) }
 func TestMultiSynchronisation64Fast(t *testing.T)  { testMultiSynchronisation(t, 64, FastSync) }
 func TestMultiSynchronisation64Light(t *testing.T) { testMultiSynchronisation(t, 64, LightSync) }
+func TestMultiSynchronisation64Snap(t *testing.T)  { testMultiSynchronisation(t, 64, SnapSync) }
+func TestMultiSynchronisation65Snap(t *testing.T)  { testMultiSynchronisation(t, 65, SnapSync) }
 
 func testMultiSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -1346,6 +1380,12 @@ func (ftp *floodingTestPeer) RequestReceipts(hashes []common.Hash) error {
 func (ftp *floodingTestPeer) RequestNodeData(hashes []common.Hash) error {
 	return ftp.peer.RequestNodeData(hashes)
 }
+func (ftp *floodingTestPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	return ftp.peer.RequestAccountRange(id, root, origin, limit, bytes)
+}
+func (ftp *floodingTestPeer) RequestStorageRange(id uint64, root, account, origin, limit common.Hash, bytes uint64) error {
+	return ftp.peer.RequestStorageRange(id, root, account, origin, limit, bytes)
+}
 
 func (ftp *floodingTestPeer) RequestHeadersByNumber(from uint64, count, skip int, reverse, withGov bool) error {
 	deliveriesDone := make(chan struct{}, 500)
@@ -22,6 +22,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core"
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/core/types"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 )
@@ -171,3 +172,25 @@ func (p *FakePeer) RequestNodeData(hashes []common.Hash) error {
 	p.dl.DeliverNodeData(p.id, data)
 	return nil
 }
+
+// RequestAccountRange implements downloader.Peer, returning a proven range of
+// accounts from the state trie identified by root.
+func (p *FakePeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	hashes, accounts, proof, err := AccountRange(state.NewDatabase(p.db), root, origin, limit, bytes)
+	if err != nil {
+		return err
+	}
+	p.dl.DeliverAccountRange(p.id, id, hashes, accounts, proof)
+	return nil
+}
+
+// RequestStorageRange implements downloader.Peer, returning a proven range of
+// storage slots of an account in the state trie identified by root.
+func (p *FakePeer) RequestStorageRange(id uint64, root, account, origin, limit common.Hash, bytes uint64) error {
+	hashes, slots, proof, err := StorageRange(state.NewDatabase(p.db), root, account, origin, limit, bytes)
+	if err != nil {
+		return err
+	}
+	p.dl.DeliverStorageRange(p.id, id, hashes, slots, proof)
+	return nil
+}
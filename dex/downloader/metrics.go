@@ -45,4 +45,7 @@ var (
 
 	stateInMeter   = metrics.NewRegisteredMeter("dex/downloader/states/in", nil)
 	stateDropMeter = metrics.NewRegisteredMeter("dex/downloader/states/drop", nil)
+
+	snapInMeter   = metrics.NewRegisteredMeter("dex/downloader/snap/in", nil)
+	snapDropMeter = metrics.NewRegisteredMeter("dex/downloader/snap/drop", nil)
 )
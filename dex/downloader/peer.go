@@ -88,6 +88,8 @@ type Peer interface {
 	DownloadBodies([]common.Hash) error
 	RequestReceipts([]common.Hash) error
 	RequestNodeData([]common.Hash) error
+	RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error
+	RequestStorageRange(id uint64, root, account, origin, limit common.Hash, bytes uint64) error
 }
 
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
@@ -114,6 +116,12 @@ func (w *lightPeerWrapper) RequestReceipts([]common.Hash) error {
 func (w *lightPeerWrapper) RequestNodeData([]common.Hash) error {
 	panic("RequestNodeData not supported in light client mode sync")
 }
+func (w *lightPeerWrapper) RequestAccountRange(uint64, common.Hash, common.Hash, common.Hash, uint64) error {
+	panic("RequestAccountRange not supported in light client mode sync")
+}
+func (w *lightPeerWrapper) RequestStorageRange(uint64, common.Hash, common.Hash, common.Hash, common.Hash, uint64) error {
+	panic("RequestStorageRange not supported in light client mode sync")
+}
 
 // newPeerConnection creates a new downloader peer.
 func newPeerConnection(id string, version int, peer Peer, logger log.Logger) *peerConnection {
@@ -146,8 +154,9 @@ func (p *peerConnection) Reset() {
 	p.lacking = make(map[common.Hash]struct{})
 }
 
-// FetchHeaders sends a header retrieval request to the remote peer.
-func (p *peerConnection) FetchHeaders(from uint64, count int) error {
+// FetchHeaders sends a header retrieval request to the remote peer, asking for
+// the governance states of round heights too if withGov is set.
+func (p *peerConnection) FetchHeaders(from uint64, count int, withGov bool) error {
 	// Sanity check the protocol version
 	if p.version < 62 {
 		panic(fmt.Sprintf("header fetch [eth/62+] requested on eth/%d", p.version))
@@ -159,7 +168,7 @@ func (p *peerConnection) FetchHeaders(from uint64, count int) error {
 	p.headerStarted = time.Now()
 
 	// Issue the header retrieval request (absolut upwards without gaps)
-	go p.peer.RequestHeadersByNumber(from, count, 0, false, true)
+	go p.peer.RequestHeadersByNumber(from, count, 0, false, withGov)
 
 	return nil
 }
@@ -225,6 +234,42 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// FetchAccountRange sends an account range retrieval request to the remote peer.
+// The range shares the node data activity state, as snap and node data
+// retrievals are never run concurrently.
+func (p *peerConnection) FetchAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	// Sanity check the protocol version
+	if p.version < 65 {
+		panic(fmt.Sprintf("account range fetch [dex/65+] requested on eth/%d", p.version))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go p.peer.RequestAccountRange(id, root, origin, limit, bytes)
+
+	return nil
+}
+
+// FetchStorageRange sends a storage range retrieval request to the remote peer.
+func (p *peerConnection) FetchStorageRange(id uint64, root, account, origin, limit common.Hash, bytes uint64) error {
+	// Sanity check the protocol version
+	if p.version < 65 {
+		panic(fmt.Sprintf("storage range fetch [dex/65+] requested on eth/%d", p.version))
+	}
+	// Short circuit if the peer is already fetching
+	if !atomic.CompareAndSwapInt32(&p.stateIdle, 0, 1) {
+		return errAlreadyFetching
+	}
+	p.stateStarted = time.Now()
+
+	go p.peer.RequestStorageRange(id, root, account, origin, limit, bytes)
+
+	return nil
+}
+
 // SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
@@ -473,7 +518,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.headerThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 // BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
@@ -487,7 +532,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 // ReceiptIdlePeers retrieves a flat list of all the currently receipt-idle peers
@@ -501,7 +546,7 @@ func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.receiptThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
 }
 
 // NodeDataIdlePeers retrieves a flat list of all the currently node-data-idle
@@ -515,7 +560,21 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.stateThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
+}
+
+// SnapIdlePeers retrieves a flat list of all the currently node-data-idle
+// peers able to serve state ranges, ordered by their reputation.
+func (ps *peerSet) SnapIdlePeers() ([]*peerConnection, int) {
+	idle := func(p *peerConnection) bool {
+		return atomic.LoadInt32(&p.stateIdle) == 0
+	}
+	throughput := func(p *peerConnection) float64 {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.stateThroughput
+	}
+	return ps.idlePeers(65, 65, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
@@ -336,7 +336,7 @@ func (q *queue) Schedule(headers []*types.HeaderWithGovState, from uint64) []*ty
 		q.blockTaskPool[hash] = header.Header
 		q.blockTaskQueue.Push(header.Header, -int64(header.Number.Uint64()))
 
-		if q.mode == FastSync {
+		if q.mode == FastSync || q.mode == SnapSync {
 			q.receiptTaskPool[hash] = header.Header
 			q.receiptTaskQueue.Push(header.Header, -int64(header.Number.Uint64()))
 		}
@@ -514,7 +514,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 		}
 		if q.resultCache[index] == nil {
 			components := 1
-			if q.mode == FastSync {
+			if q.mode == FastSync || q.mode == SnapSync {
 				components = 2
 			}
 			q.resultCache[index] = &fetchResult{
@@ -0,0 +1,773 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/log"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	"github.com/tangerine-network/go-tangerine/trie"
+)
+
+var (
+	MaxRangeFetch = 4096 // Amount of accounts or storage slots to allow fetching per range request
+
+	snapAccountConcurrency = 16              // Number of account hash space chunks retrieved in parallel
+	snapResponseLimit      = uint64(1 << 19) // Soft byte limit requested for each range response
+	snapChanSize           = 64              // Capacity of the range response channel
+	snapFlushThreshold     = 1 << 16         // Number of retrieved items after which the partial tries are flushed
+)
+
+var (
+	errSnapStateUnavailable = errors.New("snap state unavailable from all peers")
+	errSnapEmptyProof       = errors.New("partial range delivered without proof")
+)
+
+// emptyCodeHash is the known hash of an empty contract code.
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// snapReqID is the identifier of the last range request sent, unique across
+// sync cycles so responses to abandoned requests are never mistaken.
+var snapReqID uint64
+
+// maxHash is the last hash of the 256 bit hash space.
+var maxHash = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+// AccountRange collects the accounts of the state trie identified by root,
+// starting at origin and stopping after the first account past limit, or
+// when either the byte or the item cap is reached. The returned proof holds
+// the edge proofs of origin and of the last returned account, unless the
+// whole trie was returned starting from the zero hash.
+//
+// If the state is not available, no error is returned and the response is
+// empty, which signals the requester that we are not able to serve it.
+func AccountRange(db state.Database, root, origin, limit common.Hash, bytes uint64) ([]common.Hash, [][]byte, [][]byte, error) {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, nil, nil, nil
+	}
+	return serviceRange(tr, origin, limit, bytes)
+}
+
+// StorageRange collects the storage slots of the given account in the state
+// trie identified by root, with the same limits and proof semantics as
+// AccountRange.
+func StorageRange(db state.Database, root, account, origin, limit common.Hash, bytes uint64) ([]common.Hash, [][]byte, [][]byte, error) {
+	// The account is already hashed, look it up in the raw account trie
+	tr, err := trie.New(root, db.TrieDB())
+	if err != nil {
+		return nil, nil, nil, nil
+	}
+	blob, err := tr.TryGet(account[:])
+	if err != nil || len(blob) == 0 {
+		return nil, nil, nil, nil
+	}
+	var acc state.Account
+	if err := rlp.DecodeBytes(blob, &acc); err != nil {
+		return nil, nil, nil, err
+	}
+	st, err := db.OpenStorageTrie(account, acc.Root)
+	if err != nil {
+		return nil, nil, nil, nil
+	}
+	return serviceRange(st, origin, limit, bytes)
+}
+
+// serviceRange iterates the leaves of a trie and proves the collected range.
+func serviceRange(tr state.Trie, origin, limit common.Hash, limitBytes uint64) ([]common.Hash, [][]byte, [][]byte, error) {
+	var (
+		hashes []common.Hash
+		values [][]byte
+		size   uint64
+		more   bool
+	)
+	it := trie.NewIterator(tr.NodeIterator(origin[:]))
+	for it.Next() {
+		if size >= limitBytes || len(hashes) >= MaxRangeFetch {
+			more = true
+			break
+		}
+		hash := common.BytesToHash(it.Key)
+		hashes = append(hashes, hash)
+		values = append(values, common.CopyBytes(it.Value))
+		size += uint64(common.HashLength + len(it.Value))
+
+		if bytes.Compare(hash[:], limit[:]) >= 0 {
+			more = true
+			break
+		}
+	}
+	if it.Err != nil {
+		// Missing trie nodes, the state is (partially) pruned
+		return nil, nil, nil, nil
+	}
+	// The complete leaf set doesn't need any proof to be verified
+	if origin == (common.Hash{}) && !more {
+		return hashes, values, nil, nil
+	}
+	proofDb := ethdb.NewMemDatabase()
+	if err := tr.Prove(origin[:], 0, proofDb); err != nil {
+		return nil, nil, nil, nil
+	}
+	if len(hashes) > 0 {
+		if err := tr.Prove(hashes[len(hashes)-1][:], 0, proofDb); err != nil {
+			return nil, nil, nil, nil
+		}
+	}
+	var proof [][]byte
+	for _, key := range proofDb.Keys() {
+		node, _ := proofDb.Get(key)
+		proof = append(proof, node)
+	}
+	return hashes, values, proof, nil
+}
+
+// accountTask is a contiguous chunk of the account hash space whose accounts
+// are still to be retrieved.
+type accountTask struct {
+	next common.Hash // Next account hash to retrieve
+	last common.Hash // Last account hash belonging to this chunk
+	busy bool        // Whether a request is in flight for this chunk
+	done bool        // Whether all accounts of the chunk were retrieved
+}
+
+// storageTask is the storage trie of a single account whose slots are still
+// to be retrieved.
+type storageTask struct {
+	account common.Hash // Hash of the account owning the storage
+	root    common.Hash // Storage root the retrieved slots must add up to
+	next    common.Hash // Next slot hash to retrieve
+	trie    *trie.Trie  // Partially reassembled storage trie
+	busy    bool        // Whether a request is in flight for this storage
+}
+
+// snapReq is a single range request sent to a remote peer.
+type snapReq struct {
+	id      uint64 // Request ID to match up the response with
+	peer    *peerConnection
+	origin  common.Hash
+	account *accountTask // Account chunk being retrieved (nil for storage)
+	storage *storageTask // Storage being retrieved (nil for accounts)
+	timer   *time.Timer
+}
+
+// snapSync retrieves the state identified by a root as contiguous, proven
+// account and storage ranges instead of trie node by trie node.
+//
+// The partially reassembled tries are flushed to disk every snapFlushThreshold
+// items along with a progress marker, bounding the memory use and allowing an
+// interrupted sync to resume. The complete account trie is only persisted once
+// the contract codes were fetched too, so an existing state root always implies
+// a complete state.
+type snapSync struct {
+	d      *Downloader
+	root   common.Hash
+	triedb *trie.Database
+	tr     *trie.Trie // Account trie being reassembled
+
+	accountTasks []*accountTask
+	storageTasks []*storageTask
+	codes        map[common.Hash]struct{} // Contract codes missing locally
+
+	active    map[string]*snapReq // Currently in-flight requests
+	stateless map[string]struct{} // Peers not having the state available
+	timeout   chan *snapReq
+	quit      chan struct{}
+
+	accounts uint64 // Number of accounts retrieved
+	slots    uint64 // Number of storage slots retrieved
+	unsaved  int    // Number of items retrieved since the last flush
+}
+
+// snapProgress is the persisted progress of a snap sync, referencing the
+// partial tries flushed to disk.
+type snapProgress struct {
+	Root     common.Hash // State root being retrieved
+	Partial  common.Hash // Root of the flushed partial account trie
+	Tasks    []snapTaskProgress
+	Storages []snapStorageProgress
+	Codes    []common.Hash
+	Accounts uint64
+	Slots    uint64
+}
+
+// snapTaskProgress is the persisted progress of an account chunk.
+type snapTaskProgress struct {
+	Next common.Hash
+	Last common.Hash
+	Done bool
+}
+
+// snapStorageProgress is the persisted progress of an incomplete storage.
+type snapStorageProgress struct {
+	Account common.Hash
+	Root    common.Hash
+	Next    common.Hash
+	Partial common.Hash // Root of the flushed partial storage trie
+}
+
+// newSnapSync creates a range based state retriever for the given root. If
+// the progress of an interrupted sync is found, it resumes from there, even if
+// it was retrieving an older root, otherwise the account hash space is split
+// into equal chunks.
+func newSnapSync(d *Downloader, root common.Hash) *snapSync {
+	triedb := trie.NewDatabase(d.stateDB)
+	tr, _ := trie.New(common.Hash{}, triedb)
+
+	s := &snapSync{
+		d:         d,
+		root:      root,
+		triedb:    triedb,
+		tr:        tr,
+		codes:     make(map[common.Hash]struct{}),
+		active:    make(map[string]*snapReq),
+		stateless: make(map[string]struct{}),
+		timeout:   make(chan *snapReq),
+		quit:      make(chan struct{}),
+	}
+	if s.restore() {
+		log.Info("Resuming snap sync", "root", root, "accounts", s.accounts, "slots", s.slots, "storages", len(s.storageTasks))
+		return s
+	}
+	step := new(big.Int).Div(new(big.Int).Exp(common.Big2, common.Big256, nil), big.NewInt(int64(snapAccountConcurrency)))
+	next := new(big.Int)
+	for i := 0; i < snapAccountConcurrency; i++ {
+		last := new(big.Int).Sub(new(big.Int).Add(next, step), common.Big1)
+		task := &accountTask{next: common.BigToHash(next), last: common.BigToHash(last)}
+		if i == snapAccountConcurrency-1 {
+			task.last = maxHash
+		}
+		s.accountTasks = append(s.accountTasks, task)
+		next = new(big.Int).Add(last, common.Big1)
+	}
+	return s
+}
+
+// restore loads the persisted progress of an interrupted sync, returning
+// whether there was any.
+//
+// If the progress belongs to an older root, the pivot moved since. The ranges
+// retrieved so far are kept and the remaining ones are retrieved from the new
+// root, leaving the reassembled trie to be healed once all ranges are in (see
+// stale). Storages only partially retrieved can't be continued against the
+// new root, so their accounts are dropped from the trie for the healing to
+// revisit them, reusing the storage nodes already flushed.
+func (s *snapSync) restore() bool {
+	blob := rawdb.ReadSnapSyncProgress(s.d.stateDB)
+	if len(blob) == 0 {
+		return false
+	}
+	var progress snapProgress
+	if err := rlp.DecodeBytes(blob, &progress); err != nil {
+		log.Warn("Failed to decode snap sync progress", "err", err)
+		return false
+	}
+	tr, err := trie.New(progress.Partial, s.triedb)
+	if err != nil {
+		log.Warn("Snap sync progress without partial state", "root", progress.Partial, "err", err)
+		return false
+	}
+	storages := make([]*storageTask, 0, len(progress.Storages))
+	for _, st := range progress.Storages {
+		if progress.Root != s.root {
+			if err := tr.TryDelete(st.Account[:]); err != nil {
+				log.Warn("Failed to drop stale snap storage", "account", st.Account, "err", err)
+				return false
+			}
+			continue
+		}
+		str, err := trie.New(st.Partial, s.triedb)
+		if err != nil {
+			log.Warn("Snap sync progress without partial storage", "root", st.Partial, "err", err)
+			return false
+		}
+		storages = append(storages, &storageTask{account: st.Account, root: st.Root, next: st.Next, trie: str})
+	}
+	if progress.Root != s.root {
+		log.Info("Snap sync pivot moved, keeping retrieved ranges", "old", progress.Root, "new", s.root, "dropped", len(progress.Storages))
+	}
+	s.tr, s.storageTasks = tr, storages
+	for _, task := range progress.Tasks {
+		s.accountTasks = append(s.accountTasks, &accountTask{next: task.Next, last: task.Last, done: task.Done})
+	}
+	for _, hash := range progress.Codes {
+		s.codes[hash] = struct{}{}
+	}
+	s.accounts, s.slots = progress.Accounts, progress.Slots
+	return true
+}
+
+// flush persists the partially reassembled tries along with a progress marker
+// and reopens them from disk, releasing the memory held by the retrieved nodes.
+// The account trie is kept in memory if it's already complete, as it may only
+// be persisted once all the contract codes are present.
+func (s *snapSync) flush() error {
+	partial, err := s.tr.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if partial == s.root {
+		return nil
+	}
+	if err := s.triedb.Commit(partial, false); err != nil {
+		return err
+	}
+	if s.tr, err = trie.New(partial, s.triedb); err != nil {
+		return err
+	}
+	progress := snapProgress{
+		Root:     s.root,
+		Partial:  partial,
+		Accounts: s.accounts,
+		Slots:    s.slots,
+	}
+	for _, task := range s.accountTasks {
+		progress.Tasks = append(progress.Tasks, snapTaskProgress{Next: task.next, Last: task.last, Done: task.done})
+	}
+	for _, task := range s.storageTasks {
+		root, err := task.trie.Commit(nil)
+		if err != nil {
+			return err
+		}
+		if err := s.triedb.Commit(root, false); err != nil {
+			return err
+		}
+		if task.trie, err = trie.New(root, s.triedb); err != nil {
+			return err
+		}
+		progress.Storages = append(progress.Storages, snapStorageProgress{Account: task.account, Root: task.root, Next: task.next, Partial: root})
+	}
+	for hash := range s.codes {
+		progress.Codes = append(progress.Codes, hash)
+	}
+	blob, err := rlp.EncodeToBytes(&progress)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteSnapSyncProgress(s.d.stateDB, blob)
+	s.unsaved = 0
+
+	log.Debug("Flushed snap sync progress", "partial", partial, "accounts", s.accounts, "slots", s.slots, "storages", len(s.storageTasks))
+	return nil
+}
+
+// dropStorages abandons the partially retrieved storages, removing their
+// accounts from the reassembled account trie for a trie sync to revisit them.
+func (s *snapSync) dropStorages() error {
+	for _, task := range s.storageTasks {
+		if err := s.tr.TryDelete(task.account[:]); err != nil {
+			return err
+		}
+	}
+	s.storageTasks = nil
+	return nil
+}
+
+// stale returns whether the reassembled account trie doesn't add up to the
+// requested root, as some of its ranges were retrieved before the pivot moved.
+// Such a trie needs to be healed against the requested root.
+func (s *snapSync) stale() bool {
+	return s.tr.Hash() != s.root
+}
+
+// finished returns whether all accounts and storage slots were retrieved.
+func (s *snapSync) finished() bool {
+	for _, task := range s.accountTasks {
+		if !task.done {
+			return false
+		}
+	}
+	return len(s.storageTasks) == 0
+}
+
+// sync runs the range retrieval until all accounts and storages are available
+// or the sync is canceled. The contract codes found missing are collected for
+// retrieval before the state can be committed.
+func (s *snapSync) sync(cancel chan struct{}) (err error) {
+	defer close(s.quit)
+
+	newPeer := make(chan *peerConnection, 1024)
+	peerSub := s.d.peers.SubscribeNewPeers(newPeer)
+	defer peerSub.Unsubscribe()
+
+	peerDrop := make(chan *peerConnection, 1024)
+	dropSub := s.d.peers.SubscribePeerDrops(peerDrop)
+	defer dropSub.Unsubscribe()
+
+	defer func() {
+		// Cancel active request timers on exit and set the peers to idle so
+		// they are available for the node data retrieval.
+		for _, req := range s.active {
+			req.timer.Stop()
+			req.peer.SetNodeDataIdle(0)
+		}
+	}()
+	for !s.finished() {
+		s.assignTasks()
+
+		// Give up if no connected peer is left to serve the ranges, either as
+		// none has the state or none speaks dex/65
+		if _, capable := s.d.peers.SnapIdlePeers(); len(s.active) == 0 && s.d.peers.Len() > 0 && len(s.stateless) >= capable {
+			return errSnapStateUnavailable
+		}
+		select {
+		case <-newPeer:
+			// New peer arrived, try to assign it download tasks
+
+		case p := <-peerDrop:
+			if req := s.active[p.id]; req != nil {
+				req.timer.Stop()
+				s.revert(req)
+			}
+			delete(s.stateless, p.id)
+
+		case req := <-s.timeout:
+			// If the peer already delivered, ignore the stale timeout
+			if s.active[req.peer.id] != req {
+				continue
+			}
+			req.peer.log.Debug("Range request timed out", "origin", req.origin)
+			s.revert(req)
+			req.peer.SetNodeDataIdle(0)
+
+		case pack := <-s.d.snapCh:
+			req := s.active[pack.PeerId()]
+			if req == nil || req.id != snapPackID(pack) {
+				// Unrequested or stale data (e.g. from an abandoned pivot), ignore
+				log.Debug("Unrequested state range", "peer", pack.PeerId(), "len", pack.Items())
+				continue
+			}
+			req.timer.Stop()
+			delete(s.active, req.peer.id)
+
+			var delivered int
+			switch pack := pack.(type) {
+			case *accountRangePack:
+				if req.account == nil {
+					err = errors.New("account range delivered for storage request")
+				} else {
+					delivered, err = s.processAccounts(req, pack)
+				}
+			case *storageRangePack:
+				if req.storage == nil {
+					err = errors.New("storage range delivered for account request")
+				} else {
+					delivered, err = s.processStorage(req, pack)
+				}
+			}
+			if err != nil {
+				if err == errCancelStateFetch {
+					return err
+				}
+				log.Warn("Invalid state range, dropping peer", "peer", req.peer.id, "err", err)
+				s.revert(req)
+				s.d.dropPeer(req.peer.id)
+				err = nil
+				continue
+			}
+			req.peer.SetNodeDataIdle(delivered)
+
+			if s.unsaved += delivered; s.unsaved >= snapFlushThreshold {
+				if err := s.flush(); err != nil {
+					return err
+				}
+			}
+
+		case <-cancel:
+			return s.abort()
+
+		case <-s.d.cancelCh:
+			return s.abort()
+		}
+	}
+	return nil
+}
+
+// abort flushes the progress of a canceled sync so it can be resumed later.
+func (s *snapSync) abort() error {
+	if s.unsaved > 0 {
+		if err := s.flush(); err != nil {
+			log.Warn("Failed to flush snap sync progress", "err", err)
+		}
+	}
+	return errCancelStateFetch
+}
+
+// revert marks the task of a failed request as available for retrieval again.
+func (s *snapSync) revert(req *snapReq) {
+	delete(s.active, req.peer.id)
+	if req.account != nil {
+		req.account.busy = false
+	}
+	if req.storage != nil {
+		req.storage.busy = false
+	}
+}
+
+// assignTasks attempts to assign a pending account chunk or storage to all
+// idle peers having the state available.
+func (s *snapSync) assignTasks() {
+	peers, _ := s.d.peers.SnapIdlePeers()
+	for _, p := range peers {
+		if _, ok := s.stateless[p.id]; ok {
+			continue
+		}
+		if _, ok := s.active[p.id]; ok {
+			continue
+		}
+		req := &snapReq{id: atomic.AddUint64(&snapReqID, 1), peer: p}
+		for _, task := range s.accountTasks {
+			if !task.busy && !task.done {
+				req.account, req.origin = task, task.next
+				break
+			}
+		}
+		if req.account == nil {
+			for _, task := range s.storageTasks {
+				if !task.busy {
+					req.storage, req.origin = task, task.next
+					break
+				}
+			}
+		}
+		if req.account == nil && req.storage == nil {
+			return
+		}
+		var err error
+		if req.account != nil {
+			err = p.FetchAccountRange(req.id, s.root, req.origin, req.account.last, snapResponseLimit)
+		} else {
+			err = p.FetchStorageRange(req.id, s.root, req.storage.account, req.origin, maxHash, snapResponseLimit)
+		}
+		if err != nil {
+			continue
+		}
+		if req.account != nil {
+			req.account.busy = true
+		} else {
+			req.storage.busy = true
+		}
+		req.peer.log.Trace("Requesting state range", "origin", req.origin, "storage", req.storage != nil)
+		req.timer = time.AfterFunc(s.d.requestTTL(), func() {
+			select {
+			case s.timeout <- req:
+			case <-s.quit:
+			}
+		})
+		s.active[p.id] = req
+	}
+}
+
+// snapPackID returns the request ID a range response answers.
+func snapPackID(pack dataPack) uint64 {
+	switch pack := pack.(type) {
+	case *accountRangePack:
+		return pack.id
+	case *storageRangePack:
+		return pack.id
+	}
+	return 0
+}
+
+// verifyRange checks a delivered range against the given trie root, returning
+// whether there are more leaves in the trie after the last delivered one.
+func verifyRange(root, origin common.Hash, hashes []common.Hash, values [][]byte, proof [][]byte) (bool, error) {
+	keys := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	if len(proof) == 0 {
+		// Only the complete leaf set may be delivered without edge proofs
+		if origin != (common.Hash{}) {
+			return false, errSnapEmptyProof
+		}
+		return trie.VerifyRangeProof(root, nil, nil, keys, values, nil)
+	}
+	proofDb := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	last := origin[:]
+	if len(keys) > 0 {
+		last = keys[len(keys)-1]
+	}
+	return trie.VerifyRangeProof(root, origin[:], last, keys, values, proofDb)
+}
+
+// processAccounts verifies and injects a delivered account range into the
+// account trie, scheduling the storages and codes of the new accounts.
+func (s *snapSync) processAccounts(req *snapReq, pack *accountRangePack) (int, error) {
+	task := req.account
+	task.busy = false
+
+	if len(pack.hashes) == 0 && len(pack.proof) == 0 {
+		// The peer doesn't have the requested state, don't ask it again
+		req.peer.log.Debug("Peer doesn't have the snap state", "root", s.root)
+		s.stateless[req.peer.id] = struct{}{}
+		return 0, nil
+	}
+	if len(pack.hashes) != len(pack.accounts) {
+		return 0, fmt.Errorf("account range mismatch: %d hashes, %d accounts", len(pack.hashes), len(pack.accounts))
+	}
+	more, err := verifyRange(s.root, req.origin, pack.hashes, pack.accounts, pack.proof)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for i, hash := range pack.hashes {
+		// Accounts past the chunk belong to the next one, leave them to it
+		if bytes.Compare(hash[:], task.last[:]) > 0 {
+			more = false
+			break
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(pack.accounts[i], &acc); err != nil {
+			return 0, err
+		}
+		if err := s.tr.TryUpdate(hash[:], pack.accounts[i]); err != nil {
+			return 0, err
+		}
+		if acc.Root != types.EmptyRootHash {
+			if ok, _ := s.d.stateDB.Has(acc.Root[:]); !ok {
+				st, _ := trie.New(common.Hash{}, s.triedb)
+				s.storageTasks = append(s.storageTasks, &storageTask{
+					account: hash,
+					root:    acc.Root,
+					trie:    st,
+				})
+			}
+		}
+		if codeHash := common.BytesToHash(acc.CodeHash); codeHash != emptyCodeHash {
+			if ok, _ := s.d.stateDB.Has(codeHash[:]); !ok {
+				s.codes[codeHash] = struct{}{}
+			}
+		}
+		delivered++
+	}
+	if n := len(pack.hashes); !more || n == 0 || bytes.Compare(pack.hashes[n-1][:], task.last[:]) >= 0 {
+		task.done = true
+	} else {
+		task.next = incHash(pack.hashes[n-1])
+	}
+	s.accounts += uint64(delivered)
+	s.updateStats(delivered)
+	return delivered, nil
+}
+
+// processStorage verifies and injects a delivered storage range into the
+// storage trie of its account, committing the trie once it is complete.
+func (s *snapSync) processStorage(req *snapReq, pack *storageRangePack) (int, error) {
+	task := req.storage
+	task.busy = false
+
+	if len(pack.hashes) == 0 && len(pack.proof) == 0 {
+		req.peer.log.Debug("Peer doesn't have the snap state", "root", s.root)
+		s.stateless[req.peer.id] = struct{}{}
+		return 0, nil
+	}
+	if len(pack.hashes) != len(pack.slots) {
+		return 0, fmt.Errorf("storage range mismatch: %d hashes, %d slots", len(pack.hashes), len(pack.slots))
+	}
+	more, err := verifyRange(task.root, req.origin, pack.hashes, pack.slots, pack.proof)
+	if err != nil {
+		return 0, err
+	}
+	for i, hash := range pack.hashes {
+		if err := task.trie.TryUpdate(hash[:], pack.slots[i]); err != nil {
+			return 0, err
+		}
+	}
+	if more && len(pack.hashes) > 0 {
+		task.next = incHash(pack.hashes[len(pack.hashes)-1])
+	} else {
+		root, err := task.trie.Commit(nil)
+		if err != nil {
+			return 0, err
+		}
+		if root != task.root {
+			return 0, fmt.Errorf("storage root mismatch: have %x, want %x", root, task.root)
+		}
+		if err := s.triedb.Commit(root, false); err != nil {
+			return 0, err
+		}
+		for i, t := range s.storageTasks {
+			if t == task {
+				s.storageTasks = append(s.storageTasks[:i], s.storageTasks[i+1:]...)
+				break
+			}
+		}
+	}
+	s.slots += uint64(len(pack.hashes))
+	s.updateStats(len(pack.hashes))
+	return len(pack.hashes), nil
+}
+
+// commit verifies the reassembled account trie against the requested root and
+// persists it. It must only be called once all the contract codes are present.
+// A stale trie is not persisted again, it must have been healed into the
+// requested state instead.
+func (s *snapSync) commit() error {
+	root, err := s.tr.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if root != s.root {
+		if ok, _ := s.d.stateDB.Has(s.root[:]); !ok {
+			return fmt.Errorf("state root mismatch: have %x, want %x", root, s.root)
+		}
+	} else if err := s.triedb.Commit(root, false); err != nil {
+		return err
+	}
+	rawdb.DeleteSnapSyncProgress(s.d.stateDB)
+	log.Info("Snap sync state committed", "root", root, "accounts", s.accounts, "slots", s.slots)
+	return nil
+}
+
+// updateStats bumps the state sync progress counters and persists them.
+func (s *snapSync) updateStats(written int) {
+	s.d.syncStatsLock.Lock()
+	defer s.d.syncStatsLock.Unlock()
+
+	s.d.syncStatsState.processed += uint64(written)
+	s.d.syncStatsState.pending = uint64(len(s.storageTasks))
+	if written > 0 {
+		log.Debug("Imported new state ranges", "count", written, "accounts", s.accounts, "slots", s.slots, "storages", len(s.storageTasks))
+	}
+}
+
+// incHash returns the hash following the given one.
+func incHash(h common.Hash) common.Hash {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,300 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	"github.com/tangerine-network/go-tangerine/trie"
+)
+
+// makeSnapState creates a state database with the given number of plain
+// accounts, returning it along with its root.
+func makeSnapState(t *testing.T, n int) (state.Database, common.Hash) {
+	db := state.NewDatabase(ethdb.NewMemDatabase())
+	tr, _ := trie.New(common.Hash{}, db.TrieDB())
+
+	for i := 0; i < n; i++ {
+		blob, _ := rlp.EncodeToBytes(&state.Account{
+			Nonce:    uint64(i),
+			Balance:  big.NewInt(int64(i)),
+			Root:     types.EmptyRootHash,
+			CodeHash: emptyCodeHash[:],
+		})
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(i))
+		tr.Update(crypto.Keccak256(key), blob)
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return db, root
+}
+
+// Tests that range proofs are accepted if complete and rejected if any leaf
+// is missing or modified.
+func TestSnapRangeProofs(t *testing.T) {
+	db, root := makeSnapState(t, 512)
+
+	// A complete trie may be delivered without proofs
+	hashes, values, proof, err := AccountRange(db, root, common.Hash{}, maxHash, 1<<20)
+	if err != nil || len(proof) != 0 || len(hashes) != 512 {
+		t.Fatalf("full range mismatch: %d accounts, %d proofs, err %v", len(hashes), len(proof), err)
+	}
+	if more, err := verifyRange(root, common.Hash{}, hashes, values, nil); err != nil || more {
+		t.Fatalf("full range rejected: more %v, err %v", more, err)
+	}
+	// A middle chunk needs the edge proofs
+	origin := common.HexToHash("0x4000000000000000000000000000000000000000000000000000000000000000")
+	limit := common.HexToHash("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+	hashes, values, proof, err = AccountRange(db, root, origin, limit, 1<<20)
+	if err != nil || len(hashes) < 3 || len(proof) == 0 {
+		t.Fatalf("partial range mismatch: %d accounts, %d proofs, err %v", len(hashes), len(proof), err)
+	}
+	if more, err := verifyRange(root, origin, hashes, values, proof); err != nil || !more {
+		t.Fatalf("valid range rejected: more %v, err %v", more, err)
+	}
+	if _, err := verifyRange(root, origin, hashes, values, nil); err != errSnapEmptyProof {
+		t.Fatalf("unproven range error mismatch: have %v, want %v", err, errSnapEmptyProof)
+	}
+	// Leaving a gap in the range must be detected
+	gapped := append(append([]common.Hash{}, hashes[:1]...), hashes[2:]...)
+	gappedValues := append(append([][]byte{}, values[:1]...), values[2:]...)
+	if _, err := verifyRange(root, origin, gapped, gappedValues, proof); err == nil {
+		t.Fatalf("gapped range accepted")
+	}
+	// Modifying an account must be detected
+	tampered := make([][]byte, len(values))
+	copy(tampered, values)
+	tampered[1], _ = rlp.EncodeToBytes(&state.Account{
+		Nonce:    1000,
+		Balance:  big.NewInt(1000),
+		Root:     types.EmptyRootHash,
+		CodeHash: emptyCodeHash[:],
+	})
+	if _, err := verifyRange(root, origin, hashes, tampered, proof); err == nil {
+		t.Fatalf("tampered range accepted")
+	}
+	// Serving a range of a different state must be detected
+	_, other := makeSnapState(t, 511)
+	if _, err := verifyRange(other, origin, hashes, values, proof); err == nil {
+		t.Fatalf("range of foreign state accepted")
+	}
+}
+
+// deliverSnapTask retrieves the next range of an account chunk from the source
+// state and injects it into the snap sync.
+func deliverSnapTask(t *testing.T, s *snapSync, src state.Database, task *accountTask) {
+	hashes, values, proof, err := AccountRange(src, s.root, task.next, task.last, 1<<20)
+	if err != nil {
+		t.Fatalf("failed to retrieve range: %v", err)
+	}
+	req := &snapReq{origin: task.next, account: task}
+	pack := &accountRangePack{hashes: hashes, accounts: values, proof: proof}
+	if _, err := s.processAccounts(req, pack); err != nil {
+		t.Fatalf("failed to process range: %v", err)
+	}
+}
+
+// Tests that an interrupted snap sync resumes from its flushed progress and
+// still reassembles the requested state.
+func TestSnapSyncResume(t *testing.T) {
+	src, root := makeSnapState(t, 512)
+
+	d := &Downloader{stateDB: ethdb.NewMemDatabase()}
+	s := newSnapSync(d, root)
+	for _, task := range s.accountTasks[:snapAccountConcurrency/2] {
+		deliverSnapTask(t, s, src, task)
+	}
+	if err := s.flush(); err != nil {
+		t.Fatalf("failed to flush progress: %v", err)
+	}
+	// Resume the sync and ensure only the remaining chunks are retrieved
+	s = newSnapSync(d, root)
+	for i, task := range s.accountTasks {
+		if done := i < snapAccountConcurrency/2; task.done != done {
+			t.Fatalf("task %d: done mismatch: have %v, want %v", i, task.done, done)
+		}
+	}
+	if s.accounts == 0 {
+		t.Fatalf("retrieved account count not restored")
+	}
+	for _, task := range s.accountTasks {
+		if !task.done {
+			deliverSnapTask(t, s, src, task)
+		}
+	}
+	if !s.finished() {
+		t.Fatalf("snap sync not finished")
+	}
+	if s.accounts != 512 {
+		t.Fatalf("retrieved account count mismatch: have %d, want %d", s.accounts, 512)
+	}
+	if err := s.commit(); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if _, err := state.New(root, state.NewDatabase(d.stateDB)); err != nil {
+		t.Fatalf("synced state unavailable: %v", err)
+	}
+	if blob := rawdb.ReadSnapSyncProgress(d.stateDB); len(blob) != 0 {
+		t.Fatalf("progress marker left after commit")
+	}
+	// Undecodable progress must be ignored
+	rawdb.WriteSnapSyncProgress(d.stateDB, []byte{0xc0})
+	if s := newSnapSync(d, common.Hash{1}); s.accounts != 0 || len(s.accountTasks) != snapAccountConcurrency {
+		t.Fatalf("invalid progress restored")
+	}
+}
+
+// Tests that the ranges retrieved before the pivot moved are kept, and that the
+// resulting trie is healed into the new state by only retrieving the nodes
+// missing locally.
+func TestSnapSyncPivotMove(t *testing.T) {
+	src, root := makeSnapState(t, 512)
+
+	d := &Downloader{stateDB: ethdb.NewMemDatabase()}
+	s := newSnapSync(d, root)
+	for _, task := range s.accountTasks[:snapAccountConcurrency/2] {
+		deliverSnapTask(t, s, src, task)
+	}
+	if err := s.flush(); err != nil {
+		t.Fatalf("failed to flush progress: %v", err)
+	}
+	// Modify a few accounts and move the pivot to the new state
+	tr, _ := trie.New(root, src.TrieDB())
+	for i := 0; i < 512; i += 64 {
+		blob, _ := rlp.EncodeToBytes(&state.Account{
+			Nonce:    uint64(i) + 1,
+			Balance:  big.NewInt(int64(i) + 1),
+			Root:     types.EmptyRootHash,
+			CodeHash: emptyCodeHash[:],
+		})
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(i))
+		tr.Update(crypto.Keccak256(key), blob)
+	}
+	moved, _ := tr.Commit(nil)
+	if err := src.TrieDB().Commit(moved, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	s = newSnapSync(d, moved)
+	for i, task := range s.accountTasks {
+		if done := i < snapAccountConcurrency/2; task.done != done {
+			t.Fatalf("task %d: done mismatch: have %v, want %v", i, task.done, done)
+		}
+		if !task.done {
+			deliverSnapTask(t, s, src, task)
+		}
+	}
+	if !s.finished() || !s.stale() {
+		t.Fatalf("snap sync state mismatch: finished %v, stale %v", s.finished(), s.stale())
+	}
+	if err := s.commit(); err == nil {
+		t.Fatalf("unhealed state committed")
+	}
+	if err := s.flush(); err != nil {
+		t.Fatalf("failed to flush progress: %v", err)
+	}
+	// Heal the trie and ensure only a fraction of the nodes are retrieved
+	var healed, total int
+	sched := state.NewStateSync(moved, d.stateDB)
+	for missing := sched.Missing(0); len(missing) > 0; missing = sched.Missing(0) {
+		for _, hash := range missing {
+			blob, err := src.TrieDB().Node(hash)
+			if err != nil {
+				t.Fatalf("failed to retrieve node %x: %v", hash, err)
+			}
+			if _, _, err := sched.Process([]trie.SyncResult{{Hash: hash, Data: blob}}); err != nil {
+				t.Fatalf("failed to process node %x: %v", hash, err)
+			}
+			healed++
+		}
+		batch := d.stateDB.NewBatch()
+		if _, err := sched.Commit(batch); err != nil {
+			t.Fatalf("failed to commit healed nodes: %v", err)
+		}
+		batch.Write()
+	}
+	for it := tr.NodeIterator(nil); it.Next(true); {
+		if it.Hash() != (common.Hash{}) {
+			total++
+		}
+	}
+	if healed == 0 || healed >= total {
+		t.Fatalf("healed node count mismatch: have %d, want (0, %d)", healed, total)
+	}
+	if err := s.commit(); err != nil {
+		t.Fatalf("failed to commit healed state: %v", err)
+	}
+	healedTrie, err := trie.New(moved, trie.NewDatabase(d.stateDB))
+	if err != nil {
+		t.Fatalf("healed state unavailable: %v", err)
+	}
+	accounts := 0
+	for it := trie.NewIterator(healedTrie.NodeIterator(nil)); it.Next(); {
+		accounts++
+	}
+	if accounts != 512 {
+		t.Fatalf("healed account count mismatch: have %d, want %d", accounts, 512)
+	}
+	if blob := rawdb.ReadSnapSyncProgress(d.stateDB); len(blob) != 0 {
+		t.Fatalf("progress marker left after commit")
+	}
+}
+
+// Tests that storage ranges are served by account hash and verify against the
+// storage root of the account.
+func TestSnapStorageRange(t *testing.T) {
+	db := state.NewDatabase(ethdb.NewMemDatabase())
+	statedb, _ := state.New(common.Hash{}, db)
+
+	addr := common.HexToAddress("0x0102030405")
+	for i := 0; i < 64; i++ {
+		statedb.SetState(addr, common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i+1))))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	account := crypto.Keccak256Hash(addr[:])
+
+	hashes, slots, proof, err := StorageRange(db, root, account, common.Hash{}, maxHash, 1<<20)
+	if err != nil || len(hashes) != 64 || len(proof) != 0 {
+		t.Fatalf("storage range mismatch: %d slots, %d proofs, err %v", len(hashes), len(proof), err)
+	}
+	statedb, _ = state.New(root, db)
+	if _, err := verifyRange(statedb.StorageTrie(addr).Hash(), common.Hash{}, hashes, slots, nil); err != nil {
+		t.Fatalf("storage range rejected: %v", err)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/log"
 	"github.com/tangerine-network/go-tangerine/trie"
@@ -214,6 +215,7 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 type stateSync struct {
 	d *Downloader // Downloader instance to access and manage current peerset
 
+	snap   *snapSync                  // Range retriever preceding the trie sync in snap mode
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
 	tasks  map[common.Hash]*stateTask // Set of tasks currently queued for retrieval
@@ -237,7 +239,7 @@ type stateTask struct {
 // newStateSync creates a new state trie download scheduler. This method does not
 // yet start the sync. The user needs to call run to initiate.
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
-	return &stateSync{
+	s := &stateSync{
 		d:       d,
 		sched:   state.NewStateSync(root, d.stateDB),
 		keccak:  sha3.NewLegacyKeccak256(),
@@ -246,16 +248,60 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 		cancel:  make(chan struct{}),
 		done:    make(chan struct{}),
 	}
+	// In snap mode, the state is retrieved as ranges and the trie sync only
+	// fetches the contract codes afterwards.
+	if d.mode == SnapSync && s.sched.Pending() > 0 {
+		s.snap = newSnapSync(d, root)
+		s.sched = trie.NewSync(types.EmptyRootHash, d.stateDB, nil)
+	}
+	return s
 }
 
 // run starts the task assignment and response processing loop, blocking until
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
-	s.err = s.loop()
+	if s.snap != nil {
+		s.err = s.runSnap()
+	} else {
+		s.err = s.loop()
+	}
 	close(s.done)
 }
 
+// runSnap retrieves the state ranges, then the contract codes as node data
+// and finally persists the reassembled account trie.
+//
+// If the pivot moved while the ranges were retrieved, the reassembled trie
+// mixes several states. It is flushed and healed by a regular trie sync of the
+// requested root, which only retrieves the nodes missing locally along with
+// the storages and codes of the accounts they lead to. The same trie sync
+// retrieves the rest of the state if no peer is able to serve the ranges.
+func (s *stateSync) runSnap() error {
+	err := s.snap.sync(s.cancel)
+	if err == errSnapStateUnavailable {
+		log.Warn("Snap state unavailable, falling back to trie sync", "root", s.snap.root)
+		err = s.snap.dropStorages()
+	}
+	if err != nil {
+		return err
+	}
+	if s.snap.stale() {
+		if err := s.snap.flush(); err != nil {
+			return err
+		}
+		log.Info("Healing snap synced state", "root", s.snap.root)
+		s.sched = state.NewStateSync(s.snap.root, s.d.stateDB)
+	}
+	for hash := range s.snap.codes {
+		s.sched.AddRawEntry(hash, 64, common.Hash{})
+	}
+	if err := s.loop(); err != nil {
+		return err
+	}
+	return s.snap.commit()
+}
+
 // Wait blocks until the sync is done or canceled.
 func (s *stateSync) Wait() error {
 	<-s.done
@@ -43,6 +43,22 @@ var (
 	testGenesis, testNodes = genesisBlockForTesting(testDB, testAddress, big.NewInt(1000000000))
 )
 
+// Keys of the genesis node set, also signing the test checkpoints.
+var testNodeKeys = []*ecdsa.PrivateKey{
+	mustHexToECDSA("3cf5bdee098cc34536a7b0e80d85e07a380efca76fc12136299b9e5ba24193c8"),
+	mustHexToECDSA("96c9f1435d53577db18d45411326311529a0e8affb19218e27f65769a482c0fb"),
+	mustHexToECDSA("b25e955e30dd87cbaec83287beea6ec9c4c72498bc66905590756bf48da5d1fc"),
+	mustHexToECDSA("35577f65312f4a5e0b5391f5385043a6bc7b51fa4851a579e845b5fea33efded"),
+}
+
+func mustHexToECDSA(hexkey string) *ecdsa.PrivateKey {
+	key, err := crypto.HexToECDSA(hexkey)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
 // The common prefix of all test chains:
 var testChainBase = newTestChain(blockCacheItems+200, testGenesis, testNodes)
 
@@ -50,14 +66,14 @@ func genesisBlockForTesting(db ethdb.Database,
 	addr common.Address, balance *big.Int) (*types.Block, *dexcon.NodeSet) {
 	var (
 		// genesis node set
-		nodekey1, _ = crypto.HexToECDSA("3cf5bdee098cc34536a7b0e80d85e07a380efca76fc12136299b9e5ba24193c8")
-		nodekey2, _ = crypto.HexToECDSA("96c9f1435d53577db18d45411326311529a0e8affb19218e27f65769a482c0fb")
-		nodekey3, _ = crypto.HexToECDSA("b25e955e30dd87cbaec83287beea6ec9c4c72498bc66905590756bf48da5d1fc")
-		nodekey4, _ = crypto.HexToECDSA("35577f65312f4a5e0b5391f5385043a6bc7b51fa4851a579e845b5fea33efded")
-		nodeaddr1   = crypto.PubkeyToAddress(nodekey1.PublicKey)
-		nodeaddr2   = crypto.PubkeyToAddress(nodekey2.PublicKey)
-		nodeaddr3   = crypto.PubkeyToAddress(nodekey3.PublicKey)
-		nodeaddr4   = crypto.PubkeyToAddress(nodekey4.PublicKey)
+		nodekey1  = testNodeKeys[0]
+		nodekey2  = testNodeKeys[1]
+		nodekey3  = testNodeKeys[2]
+		nodekey4  = testNodeKeys[3]
+		nodeaddr1 = crypto.PubkeyToAddress(nodekey1.PublicKey)
+		nodeaddr2 = crypto.PubkeyToAddress(nodekey2.PublicKey)
+		nodeaddr3 = crypto.PubkeyToAddress(nodekey3.PublicKey)
+		nodeaddr4 = crypto.PubkeyToAddress(nodekey4.PublicKey)
 	)
 
 	ether := big.NewInt(1e18)
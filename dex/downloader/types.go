@@ -19,6 +19,7 @@ package downloader
 import (
 	"fmt"
 
+	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/types"
 )
 
@@ -86,3 +87,31 @@ type statePack struct {
 func (p *statePack) PeerId() string { return p.peerID }
 func (p *statePack) Items() int     { return len(p.states) }
 func (p *statePack) Stats() string  { return fmt.Sprintf("%d", len(p.states)) }
+
+// accountRangePack is a batch of consecutive accounts returned by a peer,
+// along with the edge proofs of the range.
+type accountRangePack struct {
+	peerID   string
+	id       uint64
+	hashes   []common.Hash
+	accounts [][]byte
+	proof    [][]byte
+}
+
+func (p *accountRangePack) PeerId() string { return p.peerID }
+func (p *accountRangePack) Items() int     { return len(p.accounts) }
+func (p *accountRangePack) Stats() string  { return fmt.Sprintf("%d:%d", len(p.accounts), len(p.proof)) }
+
+// storageRangePack is a batch of consecutive storage slots of an account
+// returned by a peer, along with the edge proofs of the range.
+type storageRangePack struct {
+	peerID string
+	id     uint64
+	hashes []common.Hash
+	slots  [][]byte
+	proof  [][]byte
+}
+
+func (p *storageRangePack) PeerId() string { return p.peerID }
+func (p *storageRangePack) Items() int     { return len(p.slots) }
+func (p *storageRangePack) Stats() string  { return fmt.Sprintf("%d:%d", len(p.slots), len(p.proof)) }
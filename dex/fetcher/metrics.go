@@ -41,3 +41,12 @@ var (
 	bodyFilterInMeter    = metrics.NewRegisteredMeter("dex/fetcher/filter/bodies/in", nil)
 	bodyFilterOutMeter   = metrics.NewRegisteredMeter("dex/fetcher/filter/bodies/out", nil)
 )
+
+var (
+	txAnnounceInMeter   = metrics.NewRegisteredMeter("dex/fetcher/tx/announces/in", nil)
+	txAnnounceDOSMeter  = metrics.NewRegisteredMeter("dex/fetcher/tx/announces/dos", nil)
+	txBroadcastInMeter  = metrics.NewRegisteredMeter("dex/fetcher/tx/broadcasts/in", nil)
+	txReplyInMeter      = metrics.NewRegisteredMeter("dex/fetcher/tx/replies/in", nil)
+	txFetchMeter        = metrics.NewRegisteredMeter("dex/fetcher/tx/fetch", nil)
+	txFetchTimeoutMeter = metrics.NewRegisteredMeter("dex/fetcher/tx/fetch/timeout", nil)
+)
@@ -0,0 +1,415 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/mclock"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/log"
+)
+
+const (
+	txArriveTimeout = 500 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	txFetchTimeout  = 5 * time.Second        // Maximum allotted time to return an explicitly requested transaction
+	txHashLimit     = 4096                   // Maximum number of unique transactions a peer may have announced
+	maxTxRetrievals = 256                    // Maximum number of transactions to request from a peer at once
+)
+
+// txKnownFn is a callback type for checking whether a transaction is already
+// known by the local pool.
+type txKnownFn func(common.Hash) bool
+
+// txAddFn is a callback type for injecting a batch of transactions delivered
+// by a peer into the local pool.
+type txAddFn func(peer string, txs []*types.Transaction) []error
+
+// txRequesterFn is a callback type for sending a transaction retrieval request
+// to a specific peer.
+type txRequesterFn func(peer string, hashes []common.Hash) error
+
+// txAnnounce is the hash notification of the availability of a transaction in
+// the network.
+type txAnnounce struct {
+	hash   common.Hash    // Hash of the transaction being announced
+	origin string         // Identifier of the peer originating the notification
+	time   mclock.AbsTime // Timestamp of the announcement (or of the fetch start)
+}
+
+// txNotification is a batch of transaction hashes announced by a peer.
+type txNotification struct {
+	origin string
+	hashes []common.Hash
+}
+
+// txDelivery is a batch of transactions hashes that arrived from a peer,
+// either as a reply to a retrieval request or as a direct propagation.
+type txDelivery struct {
+	origin string
+	hashes []common.Hash
+	direct bool
+}
+
+// TxFetcher is responsible for accumulating transaction announcements from
+// various peers and scheduling them for retrieval, spreading the requests
+// across the announcing peers.
+type TxFetcher struct {
+	notify  chan *txNotification
+	deliver chan *txDelivery
+	drop    chan struct{} // Wakes the loop up to process the dropped peers
+	quit    chan struct{}
+
+	dropped  []string   // Peers dropped since the loop last processed drops
+	dropLock sync.Mutex // Protects the dropped peers
+
+	clock mclock.Clock // Clock to time announcements and retrievals with
+
+	announces map[string]int                // Per peer announce counts to prevent memory exhaustion
+	announced map[common.Hash][]*txAnnounce // Announced transactions, scheduled for fetching
+	fetching  map[common.Hash]*txAnnounce   // Announced transactions, currently fetching
+
+	// Callbacks
+	hasTx    txKnownFn     // Checks whether a transaction is already in the pool
+	addTxs   txAddFn       // Injects a batch of transactions into the pool
+	fetchTxs txRequesterFn // Requests a batch of transactions from a peer
+
+	// Testing hooks
+	fetchingHook func(string, []common.Hash) // Method to call upon starting a transaction fetch
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(hasTx txKnownFn, addTxs txAddFn, fetchTxs txRequesterFn) *TxFetcher {
+	return newTxFetcher(hasTx, addTxs, fetchTxs, mclock.System{})
+}
+
+// newTxFetcher creates a transaction fetcher timing its operations with the
+// given clock.
+func newTxFetcher(hasTx txKnownFn, addTxs txAddFn, fetchTxs txRequesterFn, clock mclock.Clock) *TxFetcher {
+	return &TxFetcher{
+		notify:    make(chan *txNotification),
+		deliver:   make(chan *txDelivery),
+		drop:      make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+		clock:     clock,
+		announces: make(map[string]int),
+		announced: make(map[common.Hash][]*txAnnounce),
+		fetching:  make(map[common.Hash]*txAnnounce),
+		hasTx:     hasTx,
+		addTxs:    addTxs,
+		fetchTxs:  fetchTxs,
+	}
+}
+
+// Start boots up the announcement based transaction retriever.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based transaction retriever, canceling
+// all pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of
+// transactions in the network.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
+	unknown := make([]common.Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		if !f.hasTx(hash) {
+			unknown = append(unknown, hash)
+		}
+	}
+	txAnnounceInMeter.Mark(int64(len(hashes)))
+	if len(unknown) == 0 {
+		return nil
+	}
+	select {
+	case f.notify <- &txNotification{origin: peer, hashes: unknown}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue imports a batch of transactions into the pool, removing them from
+// the announce and fetch schedules. Direct denotes whether the transactions
+// were propagated by the peer as opposed to explicitly requested.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	if direct {
+		txBroadcastInMeter.Mark(int64(len(txs)))
+	} else {
+		txReplyInMeter.Mark(int64(len(txs)))
+	}
+	f.addTxs(peer, txs)
+
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	select {
+	case f.deliver <- &txDelivery{origin: peer, hashes: hashes, direct: direct}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all announcements originating from a disconnected peer,
+// rescheduling its in-flight retrievals to alternate announcers. It never
+// blocks, so it is safe to call while tearing down a peer.
+func (f *TxFetcher) Drop(peer string) {
+	f.dropLock.Lock()
+	f.dropped = append(f.dropped, peer)
+	f.dropLock.Unlock()
+
+	select {
+	case f.drop <- struct{}{}:
+	default:
+	}
+}
+
+// loop is the main fetcher loop, checking and processing various notification
+// events.
+func (f *TxFetcher) loop() {
+	var (
+		timeout   <-chan time.Time // Fires when the earliest announce or retrieval expires
+		timeoutAt mclock.AbsTime   // Time at which the pending timeout fires
+	)
+	// reschedule arms the timeout for the next announce or retrieval expiry,
+	// unless a pending timeout already fires before that
+	reschedule := func() {
+		next, ok := f.nextTimeout()
+		if !ok || (timeout != nil && timeoutAt <= next) {
+			return
+		}
+		timeout, timeoutAt = f.clock.After(time.Duration(next-f.clock.Now())), next
+	}
+	for {
+		// Clean up any expired transaction fetches, falling back to the
+		// alternate announcers if there are any left
+		now := f.clock.Now()
+		for hash, announce := range f.fetching {
+			if time.Duration(now-announce.time) >= txFetchTimeout {
+				txFetchTimeoutMeter.Mark(1)
+				f.forgetAnnounce(announce)
+				delete(f.fetching, hash)
+				f.expedite(hash)
+			}
+		}
+		// Wait for an outside event to occur
+		select {
+		case <-f.quit:
+			return
+
+		case notification := <-f.notify:
+			count := f.announces[notification.origin]
+			for _, hash := range notification.hashes {
+				if count >= txHashLimit {
+					log.Debug("Peer exceeded outstanding transaction announces", "peer", notification.origin, "limit", txHashLimit)
+					txAnnounceDOSMeter.Mark(1)
+					break
+				}
+				// Skip the hash if it's already being fetched or announced by this peer
+				if f.fetching[hash] != nil && f.fetching[hash].origin == notification.origin {
+					continue
+				}
+				duplicate := false
+				for _, announce := range f.announced[hash] {
+					if announce.origin == notification.origin {
+						duplicate = true
+						break
+					}
+				}
+				if duplicate {
+					continue
+				}
+				count++
+				f.announced[hash] = append(f.announced[hash], &txAnnounce{
+					hash:   hash,
+					origin: notification.origin,
+					time:   f.clock.Now(),
+				})
+			}
+			f.announces[notification.origin] = count
+			reschedule()
+
+		case <-timeout:
+			timeout = nil
+
+			// At least one transaction's timer ran out, group the expired hashes
+			// by announcer, preferring the least loaded peer for each of them
+			request := make(map[string][]common.Hash)
+			for hash, announces := range f.announced {
+				if f.fetching[hash] != nil {
+					continue
+				}
+				if time.Duration(f.clock.Now()-announces[0].time) < txArriveTimeout {
+					continue
+				}
+				if f.hasTx(hash) {
+					f.forgetHash(hash)
+					continue
+				}
+				// Pick the announcer with the fewest scheduled retrievals, breaking
+				// ties randomly so requests spread across peers
+				best := -1
+				for _, i := range rand.Perm(len(announces)) {
+					if len(request[announces[i].origin]) >= maxTxRetrievals {
+						continue
+					}
+					if best < 0 || len(request[announces[i].origin]) < len(request[announces[best].origin]) {
+						best = i
+					}
+				}
+				if best < 0 {
+					continue
+				}
+				announce := announces[best]
+				announce.time = f.clock.Now()
+				f.fetching[hash] = announce
+
+				if len(announces) == 1 {
+					delete(f.announced, hash)
+				} else {
+					f.announced[hash] = append(announces[:best:best], announces[best+1:]...)
+				}
+				request[announce.origin] = append(request[announce.origin], hash)
+			}
+			// Send out all transaction requests
+			for peer, hashes := range request {
+				log.Trace("Fetching scheduled transactions", "peer", peer, "count", len(hashes))
+				if f.fetchingHook != nil {
+					f.fetchingHook(peer, hashes)
+				}
+				txFetchMeter.Mark(int64(len(hashes)))
+				go func(peer string, hashes []common.Hash) {
+					if err := f.fetchTxs(peer, hashes); err != nil {
+						log.Debug("Failed to request transactions", "peer", peer, "err", err)
+					}
+				}(peer, hashes)
+			}
+			// Schedule the next fetch if transactions are still pending
+			reschedule()
+
+		case delivery := <-f.deliver:
+			for _, hash := range delivery.hashes {
+				f.forgetHash(hash)
+			}
+
+		case <-f.drop:
+			f.dropLock.Lock()
+			dropped := f.dropped
+			f.dropped = nil
+			f.dropLock.Unlock()
+
+			for _, peer := range dropped {
+				f.forgetPeer(peer)
+			}
+			reschedule()
+		}
+	}
+}
+
+// forgetPeer removes all announcements originating from a peer, rescheduling
+// its in-flight retrievals to alternate announcers.
+func (f *TxFetcher) forgetPeer(peer string) {
+	for hash, announces := range f.announced {
+		for i, announce := range announces {
+			if announce.origin == peer {
+				announces = append(announces[:i], announces[i+1:]...)
+				break
+			}
+		}
+		if len(announces) == 0 {
+			delete(f.announced, hash)
+		} else {
+			f.announced[hash] = announces
+		}
+	}
+	for hash, announce := range f.fetching {
+		if announce.origin == peer {
+			delete(f.fetching, hash)
+			f.expedite(hash)
+		}
+	}
+	delete(f.announces, peer)
+}
+
+// expedite marks the alternate announcements of a transaction as expired, so
+// it gets requested from them without waiting for the arrival timeout.
+func (f *TxFetcher) expedite(hash common.Hash) {
+	expired := f.clock.Now().Add(-txArriveTimeout)
+	for _, alt := range f.announced[hash] {
+		alt.time = expired
+	}
+}
+
+// nextTimeout returns the time of the earliest announce or retrieval expiry,
+// or false if no transactions are announced or fetching.
+func (f *TxFetcher) nextTimeout() (mclock.AbsTime, bool) {
+	// Short circuit if no transactions are announced or fetching
+	if len(f.announced) == 0 && len(f.fetching) == 0 {
+		return 0, false
+	}
+	// Otherwise find the earliest expiring announcement or retrieval
+	next := f.clock.Now().Add(txFetchTimeout)
+	for hash, announces := range f.announced {
+		if f.fetching[hash] != nil {
+			continue
+		}
+		if at := announces[0].time.Add(txArriveTimeout); at < next {
+			next = at
+		}
+	}
+	for _, announce := range f.fetching {
+		if at := announce.time.Add(txFetchTimeout); at < next {
+			next = at
+		}
+	}
+	if now := f.clock.Now(); next < now {
+		next = now
+	}
+	return next, true
+}
+
+// forgetHash removes all traces of a transaction announcement from the fetcher's
+// internal state.
+func (f *TxFetcher) forgetHash(hash common.Hash) {
+	for _, announce := range f.announced[hash] {
+		f.forgetAnnounce(announce)
+	}
+	delete(f.announced, hash)
+
+	if announce := f.fetching[hash]; announce != nil {
+		f.forgetAnnounce(announce)
+		delete(f.fetching, hash)
+	}
+}
+
+// forgetAnnounce releases the DOS allowance held by a single announcement.
+func (f *TxFetcher) forgetAnnounce(announce *txAnnounce) {
+	f.announces[announce.origin]--
+	if f.announces[announce.origin] <= 0 {
+		delete(f.announces, announce.origin)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/mclock"
+	"github.com/tangerine-network/go-tangerine/core/types"
+)
+
+// txFetcherTester is a test simulator for mocking out a local transaction pool.
+type txFetcherTester struct {
+	fetcher *TxFetcher
+	clock   *mclock.Simulated
+
+	pool map[common.Hash]*types.Transaction
+	lock sync.RWMutex
+}
+
+// newTxTester creates a new transaction fetcher test mocker, running on a
+// simulated clock.
+func newTxTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		clock: new(mclock.Simulated),
+		pool:  make(map[common.Hash]*types.Transaction),
+	}
+	tester.fetcher = newTxFetcher(tester.hasTx, tester.addTxs, func(string, []common.Hash) error { return nil }, tester.clock)
+	tester.fetcher.Start()
+	return tester
+}
+
+// hasTx checks whether the tester pool contains a transaction.
+func (f *txFetcherTester) hasTx(hash common.Hash) bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.pool[hash] != nil
+}
+
+// addTxs injects a batch of transactions into the tester pool.
+func (f *txFetcherTester) addTxs(peer string, txs []*types.Transaction) []error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, tx := range txs {
+		f.pool[tx.Hash()] = tx
+	}
+	return make([]error, len(txs))
+}
+
+// makeTxs creates a batch of distinct dummy transactions.
+func makeTxs(n int) []*types.Transaction {
+	txs := make([]*types.Transaction, n)
+	for i := range txs {
+		txs[i] = types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+	}
+	return txs
+}
+
+func txHashes(txs []*types.Transaction) []common.Hash {
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	return hashes
+}
+
+type txFetch struct {
+	peer   string
+	hashes []common.Hash
+}
+
+// waitFetch waits for the fetcher to request transactions.
+func waitFetch(t *testing.T, fetches chan txFetch) txFetch {
+	select {
+	case fetch := <-fetches:
+		return fetch
+	case <-time.After(time.Second):
+		t.Fatalf("transactions not fetched")
+	}
+	return txFetch{}
+}
+
+// Tests that announced transactions are requested after the arrival timeout,
+// spreading the requests across the announcing peers.
+func TestTxFetcherScheduling(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	fetches := make(chan txFetch, 16)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) { fetches <- txFetch{peer, hashes} }
+
+	hashes := txHashes(makeTxs(8))
+	tester.fetcher.Notify("A", hashes)
+	tester.fetcher.Notify("B", hashes)
+
+	// Nothing may be requested before the arrival timeout
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txArriveTimeout - 1)
+	select {
+	case fetch := <-fetches:
+		t.Fatalf("transactions requested early from %s", fetch.peer)
+	default:
+	}
+	tester.clock.Run(1)
+
+	requested := make(map[common.Hash]string)
+	for len(requested) < len(hashes) {
+		fetch := waitFetch(t, fetches)
+		for _, hash := range fetch.hashes {
+			if peer, ok := requested[hash]; ok {
+				t.Fatalf("transaction %x requested twice: from %s and %s", hash, peer, fetch.peer)
+			}
+			requested[hash] = fetch.peer
+		}
+	}
+	load := make(map[string]int)
+	for _, peer := range requested {
+		load[peer]++
+	}
+	if load["A"] != len(hashes)/2 || load["B"] != len(hashes)/2 {
+		t.Fatalf("requests not spread across peers: %v", load)
+	}
+}
+
+// Tests that transactions arriving through direct propagation are not
+// requested anymore.
+func TestTxFetcherDirectDelivery(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	fetches := make(chan txFetch, 16)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) { fetches <- txFetch{peer, hashes} }
+
+	txs := makeTxs(2)
+	tester.fetcher.Notify("A", txHashes(txs[:1]))
+	tester.fetcher.Enqueue("B", txs[:1], true)
+
+	if !tester.hasTx(txs[0].Hash()) {
+		t.Fatalf("delivered transaction not imported")
+	}
+	// Announcing a known transaction must not schedule a retrieval either
+	tester.fetcher.Notify("C", txHashes(txs[:1]))
+
+	// Announce a fresh transaction and ensure it's the only one requested
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txArriveTimeout)
+
+	tester.fetcher.Notify("C", txHashes(txs[1:]))
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txArriveTimeout)
+
+	fetch := waitFetch(t, fetches)
+	if len(fetch.hashes) != 1 || fetch.hashes[0] != txs[1].Hash() {
+		t.Fatalf("requested transactions mismatch: have %x, want %x", fetch.hashes, txs[1].Hash())
+	}
+}
+
+// Tests that dropping a peer with an in-flight retrieval reschedules the
+// transaction from an alternate announcer.
+func TestTxFetcherDropRescheduling(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	fetches := make(chan txFetch, 16)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) { fetches <- txFetch{peer, hashes} }
+
+	hashes := txHashes(makeTxs(1))
+	tester.fetcher.Notify("A", hashes)
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txArriveTimeout)
+
+	if fetch := waitFetch(t, fetches); fetch.peer != "A" {
+		t.Fatalf("fetch peer mismatch: have %s, want A", fetch.peer)
+	}
+	// Dropping the peer must reschedule the fetch without waiting for the
+	// retrieval or the arrival timeouts
+	tester.fetcher.Notify("B", hashes)
+	tester.fetcher.Drop("A")
+
+	tester.clock.WaitForTimers(2)
+	tester.clock.Run(0)
+
+	if fetch := waitFetch(t, fetches); fetch.peer != "B" {
+		t.Fatalf("fetch peer mismatch: have %s, want B", fetch.peer)
+	}
+}
+
+// Tests that timed out retrievals are rescheduled from alternate announcers.
+func TestTxFetcherFetchTimeout(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	fetches := make(chan txFetch, 16)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) { fetches <- txFetch{peer, hashes} }
+
+	hashes := txHashes(makeTxs(1))
+	tester.fetcher.Notify("A", hashes)
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txArriveTimeout)
+
+	first := waitFetch(t, fetches)
+	tester.fetcher.Notify(map[string]string{"A": "B", "B": "A"}[first.peer], hashes)
+
+	tester.clock.WaitForTimers(1)
+	tester.clock.Run(txFetchTimeout)
+
+	if fetch := waitFetch(t, fetches); fetch.peer == first.peer {
+		t.Fatalf("timed out fetch rescheduled to the same peer %s", fetch.peer)
+	}
+}
+
+// Tests that dropping a peer doesn't block, even if the fetcher isn't running.
+func TestTxFetcherDropNonBlocking(t *testing.T) {
+	fetcher := newTxFetcher(func(common.Hash) bool { return false }, nil, nil, new(mclock.Simulated))
+
+	done := make(chan struct{})
+	go func() {
+		fetcher.Drop("A")
+		fetcher.Drop("B")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("dropping peers blocked")
+	}
+}
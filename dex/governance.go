@@ -21,6 +21,8 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"math/big"
+	"sync"
+	"time"
 
 	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
 	dkgTypes "github.com/tangerine-network/tangerine-consensus/core/types/dkg"
@@ -34,6 +36,30 @@ import (
 	"github.com/tangerine-network/go-tangerine/params"
 )
 
+const (
+	// govTxStuckTimeout is the time after which an unconfirmed governance
+	// transaction is considered stuck and resent with a bumped gas price.
+	govTxStuckTimeout = 30 * time.Second
+
+	// govTxPriceFactor is the multiple of the suggested gas price governance
+	// transactions are sent with, to make sure they are included in time.
+	// Replacements are never priced below it either, as the suggested price
+	// may have risen since a stuck transaction was sent.
+	govTxPriceFactor = 10
+
+	// govTxMaxBumps is the number of times a stuck governance transaction is
+	// replaced at most. Together with the doubling wait between replacements
+	// it bounds the price a node pays for a transaction that never makes it.
+	govTxMaxBumps = 5
+)
+
+// govTx is a governance transaction sent by the node and not yet confirmed.
+type govTx struct {
+	tx    *types.Transaction
+	sent  time.Time
+	bumps int // Number of times the transaction was replaced
+}
+
 type DexconGovernance struct {
 	*core.Governance
 
@@ -41,6 +67,12 @@ type DexconGovernance struct {
 	chainConfig *params.ChainConfig
 	privateKey  *ecdsa.PrivateKey
 	address     common.Address
+
+	govTxs  map[uint64]*govTx // Unconfirmed governance transactions by nonce
+	govTxMu sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
 }
 
 // NewDexconGovernance returns a governance implementation of the DEXON
@@ -54,10 +86,64 @@ func NewDexconGovernance(backend *DexAPIBackend, chainConfig *params.ChainConfig
 		chainConfig: chainConfig,
 		privateKey:  privKey,
 		address:     crypto.PubkeyToAddress(privKey.PublicKey),
+		govTxs:      make(map[uint64]*govTx),
+		quit:        make(chan struct{}),
 	}
 	return g
 }
 
+// Start launches the loop replacing the stuck governance transactions.
+func (d *DexconGovernance) Start() {
+	d.wg.Add(1)
+	go d.loop()
+}
+
+// Stop terminates the loop replacing the stuck governance transactions.
+func (d *DexconGovernance) Stop() {
+	close(d.quit)
+	d.wg.Wait()
+}
+
+// loop checks the unconfirmed governance transactions on every new chain head,
+// so stuck ones are replaced even if no further governance transaction is sent.
+func (d *DexconGovernance) loop() {
+	defer d.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 16)
+	sub := d.b.dex.BlockChain().SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-headCh:
+			d.replaceStuckGovTxs()
+		case <-sub.Err():
+			return
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// replaceStuckGovTxs resends the stuck governance transactions, if any.
+func (d *DexconGovernance) replaceStuckGovTxs() {
+	d.govTxMu.Lock()
+	defer d.govTxMu.Unlock()
+
+	if len(d.govTxs) == 0 {
+		return
+	}
+	ctx := context.Background()
+	gasPrice, err := d.b.SuggestPrice(ctx)
+	if err != nil {
+		log.Warn("Failed to get the suggested gas price", "err", err)
+		return
+	}
+	if err := d.bumpStuckGovTxs(ctx, gasPrice); err != nil {
+		log.Warn("Failed to bump stuck governance transactions", "err", err)
+	}
+}
+
 // RawConfiguration return raw config in state.
 func (d *DexconGovernance) RawConfiguration(round uint64) (*params.DexconConfig, error) {
 	gs, err := d.GetConfigState(round)
@@ -68,21 +154,28 @@ func (d *DexconGovernance) RawConfiguration(round uint64) (*params.DexconConfig,
 }
 
 func (d *DexconGovernance) sendGovTx(ctx context.Context, data []byte) error {
+	d.govTxMu.Lock()
+	defer d.govTxMu.Unlock()
+
 	gasPrice, err := d.b.SuggestPrice(ctx)
 	if err != nil {
 		return err
 	}
 
+	// Replace the previous submissions stuck in the pool first, otherwise the
+	// new transaction would be queued behind them.
+	if err := d.bumpStuckGovTxs(ctx, gasPrice); err != nil {
+		log.Warn("Failed to bump stuck governance transactions", "err", err)
+	}
+
 	nonce, err := d.b.GetPoolNonce(ctx, d.address)
 	if err != nil {
 		return err
 	}
 
-	// Increase gasPrice to 10 times of suggested gas price to make sure it will
-	// be included in time.
-	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(10))
+	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(govTxPriceFactor))
 
-	gasLimit, err := core.IntrinsicGas(data, false, false)
+	gasLimit, err := core.IntrinsicGas(data, false, false, false)
 	if err != nil {
 		return err
 	}
@@ -104,7 +197,70 @@ func (d *DexconGovernance) sendGovTx(ctx context.Context, data []byte) error {
 
 	log.Info("Send governance transaction", "fullhash", tx.Hash().Hex(), "nonce", nonce)
 
-	return d.b.SendTx(ctx, tx)
+	if err := d.b.SendTx(ctx, tx); err != nil {
+		return err
+	}
+	d.govTxs[nonce] = &govTx{tx: tx, sent: time.Now()}
+	return nil
+}
+
+// bumpStuckGovTxs forgets the confirmed governance transactions and resends
+// the stuck ones with a gas price high enough to replace them in the pool. A
+// transaction is stuck once pending for govTxStuckTimeout, doubled after each
+// replacement, and is left alone after govTxMaxBumps replacements.
+func (d *DexconGovernance) bumpStuckGovTxs(ctx context.Context, suggested *big.Int) error {
+	statedb, err := d.b.dex.BlockChain().State()
+	if err != nil {
+		return err
+	}
+	confirmed := statedb.GetNonce(d.address)
+
+	signer := types.NewEIP155Signer(d.chainConfig.ChainID)
+	priceBump := d.b.dex.config.TxPool.PriceBump
+	if priceBump < 1 {
+		// Sanitized the same way by the pool
+		priceBump = core.DefaultTxPoolConfig.PriceBump
+	}
+	bump := big.NewInt(int64(100 + priceBump))
+
+	for nonce, sent := range d.govTxs {
+		if nonce < confirmed {
+			delete(d.govTxs, nonce)
+			continue
+		}
+		if sent.bumps >= govTxMaxBumps {
+			continue
+		}
+		if time.Since(sent.sent) < govTxStuckTimeout<<uint(sent.bumps) {
+			continue
+		}
+		old := sent.tx.GasPrice()
+		gasPrice := new(big.Int).Div(new(big.Int).Mul(old, bump), big.NewInt(100))
+		if gasPrice.Cmp(old) <= 0 {
+			gasPrice = new(big.Int).Add(old, big.NewInt(1))
+		}
+		if floor := new(big.Int).Mul(suggested, big.NewInt(govTxPriceFactor)); gasPrice.Cmp(floor) < 0 {
+			gasPrice = floor
+		}
+		tx := types.NewTransaction(nonce, vm.GovernanceContractAddress, big.NewInt(0),
+			sent.tx.Gas(), gasPrice, sent.tx.Data())
+		tx, err := types.SignTx(tx, signer, d.privateKey)
+		if err != nil {
+			return err
+		}
+		log.Info("Replace stuck governance transaction", "fullhash", tx.Hash().Hex(),
+			"nonce", nonce, "old", old, "price", gasPrice, "bumps", sent.bumps+1)
+
+		if err := d.b.SendTx(ctx, tx); err != nil {
+			log.Warn("Failed to replace governance transaction", "nonce", nonce, "err", err)
+			continue
+		}
+		d.govTxs[nonce] = &govTx{tx: tx, sent: time.Now(), bumps: sent.bumps + 1}
+		if sent.bumps+1 == govTxMaxBumps {
+			log.Warn("Governance transaction hit the replacement limit", "nonce", nonce, "price", gasPrice)
+		}
+	}
+	return nil
 }
 
 func (d *DexconGovernance) Round() uint64 {
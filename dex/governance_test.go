@@ -0,0 +1,105 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/crypto"
+)
+
+// Tests that governance transactions left unconfirmed for too long are
+// replaced in the pool with a bumped gas price.
+func TestReplaceStuckGovTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	// Governance transactions are local, keep their journal out of the tree
+	dir, err := ioutil.TempDir("", "govtx-journal")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dex, _, err := newTangerine(key, 0, filepath.Join(dir, "transactions.rlp"))
+	if err != nil {
+		t.Fatalf("failed to create tangerine: %v", err)
+	}
+	defer dex.txPool.Stop()
+	gov := dex.governance
+
+	if err := gov.sendGovTx(context.Background(), []byte{0x01}); err != nil {
+		t.Fatalf("failed to send governance transaction: %v", err)
+	}
+	sent := gov.govTxs[0]
+	if sent == nil {
+		t.Fatalf("governance transaction not tracked")
+	}
+	// A recent transaction is left alone
+	gov.replaceStuckGovTxs()
+	if tx := gov.govTxs[0].tx; tx.Hash() != sent.tx.Hash() {
+		t.Fatalf("recent transaction replaced")
+	}
+	// A stuck one is replaced with the pool's price bump
+	sent.sent = time.Now().Add(-govTxStuckTimeout)
+	gov.replaceStuckGovTxs()
+
+	replaced := gov.govTxs[0].tx
+	if replaced.Hash() == sent.tx.Hash() {
+		t.Fatalf("stuck transaction not replaced")
+	}
+	if replaced.Nonce() != sent.tx.Nonce() {
+		t.Errorf("nonce mismatch: have %d, want %d", replaced.Nonce(), sent.tx.Nonce())
+	}
+	want := new(big.Int).Div(new(big.Int).Mul(sent.tx.GasPrice(), big.NewInt(110)), big.NewInt(100))
+	if replaced.GasPrice().Cmp(want) < 0 {
+		t.Errorf("gas price too low: have %v, want at least %v", replaced.GasPrice(), want)
+	}
+	if dex.txPool.Get(sent.tx.Hash()) != nil {
+		t.Errorf("stuck transaction still in the pool")
+	}
+	if dex.txPool.Get(replaced.Hash()) == nil {
+		t.Errorf("replacement missing from the pool")
+	}
+	// The wait doubles after each replacement
+	gov.govTxs[0].sent = time.Now().Add(-govTxStuckTimeout)
+	gov.replaceStuckGovTxs()
+	if tx := gov.govTxs[0].tx; tx.Hash() != replaced.Hash() {
+		t.Fatalf("transaction replaced before the backoff expired")
+	}
+	// No more replacements are made once the limit is hit
+	for i := 1; i < govTxMaxBumps; i++ {
+		gov.govTxs[0].sent = time.Now().Add(-govTxStuckTimeout << uint(gov.govTxs[0].bumps))
+		gov.replaceStuckGovTxs()
+	}
+	if bumps := gov.govTxs[0].bumps; bumps != govTxMaxBumps {
+		t.Fatalf("replacement count mismatch: have %d, want %d", bumps, govTxMaxBumps)
+	}
+	last := gov.govTxs[0].tx
+	gov.govTxs[0].sent = time.Now().Add(-time.Hour)
+	gov.replaceStuckGovTxs()
+	if tx := gov.govTxs[0].tx; tx.Hash() != last.Hash() {
+		t.Errorf("transaction replaced past the limit")
+	}
+}
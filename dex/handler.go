@@ -108,6 +108,7 @@ type ProtocolManager struct {
 	networkID uint64
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync should retrieve state by ranges (requires fastSync)
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool        txPool
@@ -121,6 +122,7 @@ type ProtocolManager struct {
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
+	txFetcher  *fetcher.TxFetcher
 	peers      *peerSet
 
 	SubProtocols []p2p.Protocol
@@ -169,7 +171,8 @@ func NewProtocolManager(
 	config *params.ChainConfig, mode downloader.SyncMode, networkID uint64,
 	mux *event.TypeMux, txpool txPool, engine consensus.Engine,
 	blockchain *core.BlockChain, chaindb ethdb.Database, whitelist map[uint64]common.Hash,
-	isBlockProposer bool, gov governance, app dexconApp) (*ProtocolManager, error) {
+	checkpoint *params.GovernanceCheckpoint, isBlockProposer bool, gov governance,
+	app dexconApp) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkID:          networkID,
@@ -195,13 +198,16 @@ func NewProtocolManager(
 	}
 
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
@@ -236,7 +242,7 @@ func NewProtocolManager(
 		return nil, errIncompatibleConfig
 	}
 	// Construct the different synchronisation mechanisms
-	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader = downloader.New(mode, checkpoint, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
 	validator := func(header *types.Header) error {
 		return blockchain.VerifyTangerineHeader(header)
@@ -255,9 +261,65 @@ func NewProtocolManager(
 	}
 	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
 
+	hasTx := func(hash common.Hash) bool {
+		return manager.txpool.Get(hash) != nil
+	}
+	fetchTxs := func(id string, hashes []common.Hash) error {
+		p := manager.peers.Peer(id)
+		if p == nil {
+			return errNotRegistered
+		}
+		return p.RequestTxs(hashes)
+	}
+	manager.txFetcher = fetcher.NewTxFetcher(hasTx, manager.addRemoteTxs, fetchTxs)
+
 	return manager, nil
 }
 
+// addRemoteTxs injects a batch of transactions relayed by a peer into the pool,
+// dropping the ones over the admission rate of the peer and disconnecting peers
+// relaying too many invalid transactions. Transactions dropped over the rate of
+// the peer or of their sender are not held against it.
+func (pm *ProtocolManager) addRemoteTxs(id string, txs []*types.Transaction) []error {
+	p := pm.peers.Peer(id)
+	if p == nil {
+		return pm.txpool.AddRemotes(txs)
+	}
+	now := time.Now()
+	admitted := p.AdmitTransactions(len(txs), now)
+	if admitted < len(txs) {
+		p.Log().Debug("Dropping transactions over peer rate", "count", len(txs)-admitted)
+		propTxnThrottledMeter.Mark(int64(len(txs) - admitted))
+	}
+	errs := pm.txpool.AddRemotes(txs[:admitted])
+
+	var junk int
+	for _, err := range errs {
+		if isJunkTxError(err) {
+			junk++
+		}
+	}
+	if junk > 0 && p.ScoreJunkTransactions(junk, now) {
+		p.Log().Debug("Peer relayed too many junk transactions, removing")
+		pm.removePeer(id)
+	}
+	return errs
+}
+
+// isJunkTxError reports whether a pool rejection indicates an intrinsically
+// invalid transaction, with a bad signature, a negative value, oversized data
+// or too little gas, that no well-behaving peer would relay. Rejections that
+// depend on the local state, like stale nonces, the block gas limit, sender
+// rate limits or the denylist, do not count.
+func isJunkTxError(err error) bool {
+	switch err {
+	case core.ErrInvalidSender, core.ErrNegativeValue, core.ErrOversizedData,
+		core.ErrIntrinsicGas:
+		return true
+	}
+	return false
+}
+
 func (pm *ProtocolManager) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	peer := pm.peers.Peer(id)
@@ -271,6 +333,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 	// Unregister the peer from the downloader and Ethereum peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.txFetcher.Drop(id)
 	log.Debug("after downloader unregister peer", "id", id)
 	if err := pm.peers.Unregister(id); err != nil {
 		log.Error("Peer removal failed", "peer", id, "err", err)
@@ -293,6 +356,7 @@ func (pm *ProtocolManager) Start(srvr p2pServer, maxPeers int) {
 	pm.txsCh = make(chan core.NewTxsEvent, txChanSize)
 	pm.txsSub = pm.txpool.SubscribeNewTxsEvent(pm.txsCh)
 	go pm.txBroadcastLoop()
+	pm.txFetcher.Start()
 
 	if pm.isBlockProposer {
 		// broadcast finalized blocks
@@ -320,6 +384,7 @@ func (pm *ProtocolManager) Stop() {
 	log.Info("Stopping protocol manager")
 
 	pm.txsSub.Unsubscribe() // quits txBroadcastLoop
+	pm.txFetcher.Stop()
 	pm.chainHeadSub.Unsubscribe()
 
 	if pm.isBlockProposer {
@@ -888,7 +953,76 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			p.MarkTransaction(tx.Hash())
 		}
 		types.GlobalSigCache.Add(types.NewEIP155Signer(pm.blockchain.Config().ChainID), txs)
-		pm.txpool.AddRemotes(txs)
+		pm.txFetcher.Enqueue(p.id, txs, true)
+
+	case p.version >= dex65 && msg.Code == NewPooledTransactionHashesMsg:
+		// New transaction announcement arrived, make sure we have a valid
+		// and fresh chain to handle them
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var hashes []common.Hash
+		if err := msg.Decode(&hashes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// Mark the hashes as present at the remote node and schedule them
+		for _, hash := range hashes {
+			p.MarkTransaction(hash)
+		}
+		pm.txFetcher.Notify(p.id, hashes)
+
+	case p.version >= dex65 && msg.Code == GetPooledTransactionsMsg:
+		// Decode the retrieval message
+		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
+		if _, err := msgStream.List(); err != nil {
+			return err
+		}
+		// Gather transactions until the fetch or network limits is reached
+		var (
+			hash  common.Hash
+			bytes int
+			txs   []rlp.RawValue
+		)
+		for bytes < softResponseLimit {
+			// Retrieve the hash of the next transaction
+			if err := msgStream.Decode(&hash); err == rlp.EOL {
+				break
+			} else if err != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, err)
+			}
+			// Retrieve the requested transaction, skipping if unknown to us
+			tx := pm.txpool.Get(hash)
+			if tx == nil {
+				continue
+			}
+			// If known, encode and queue for response packet
+			if encoded, err := rlp.EncodeToBytes(tx); err != nil {
+				log.Error("Failed to encode transaction", "err", err)
+			} else {
+				txs = append(txs, encoded)
+				bytes += len(encoded)
+			}
+		}
+		return p.SendPooledTransactionsRLP(txs)
+
+	case p.version >= dex65 && msg.Code == PooledTransactionsMsg:
+		// Transactions arrived as a reply to one of our previous requests
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var txs []*types.Transaction
+		if err := msg.Decode(&txs); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, tx := range txs {
+			// Validate and mark the remote transaction
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			p.MarkTransaction(tx.Hash())
+		}
+		types.GlobalSigCache.Add(types.NewEIP155Signer(pm.blockchain.Config().ChainID), txs)
+		pm.txFetcher.Enqueue(p.id, txs, false)
 
 	// Block proposer-only messages.
 	case msg.Code == CoreBlockMsg:
@@ -1031,6 +1165,48 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := pm.downloader.DeliverGovState(p.id, &govState); err != nil {
 			log.Debug("Failed to deliver govstates", "err", err)
 		}
+	case p.version >= dex65 && msg.Code == GetAccountRangeMsg:
+		var query getAccountRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if query.Bytes > softResponseLimit {
+			query.Bytes = softResponseLimit
+		}
+		hashes, accounts, proof, err := downloader.AccountRange(pm.blockchain.StateCache(), query.Root, query.Origin, query.Limit, query.Bytes)
+		if err != nil {
+			p.Log().Debug("Failed to serve account range", "root", query.Root, "err", err)
+		}
+		return p.SendAccountRange(query.ID, hashes, accounts, proof)
+	case p.version >= dex65 && msg.Code == AccountRangeMsg:
+		var res accountRangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverAccountRange(p.id, res.ID, res.Hashes, res.Accounts, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+	case p.version >= dex65 && msg.Code == GetStorageRangeMsg:
+		var query getStorageRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if query.Bytes > softResponseLimit {
+			query.Bytes = softResponseLimit
+		}
+		hashes, slots, proof, err := downloader.StorageRange(pm.blockchain.StateCache(), query.Root, query.Account, query.Origin, query.Limit, query.Bytes)
+		if err != nil {
+			p.Log().Debug("Failed to serve storage range", "root", query.Root, "account", query.Account, "err", err)
+		}
+		return p.SendStorageRange(query.ID, hashes, slots, proof)
+	case p.version >= dex65 && msg.Code == StorageRangeMsg:
+		var res storageRangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverStorageRange(p.id, res.ID, res.Hashes, res.Slots, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage range", "err", err)
+		}
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -1066,8 +1242,10 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 	}
 }
 
-// BroadcastTxs will propagate a batch of transactions to all peers which are not known to
-// already have the given transaction.
+// BroadcastTxs will propagate a batch of transactions to a square root subset of
+// the peers which are not known to already have the given transaction, and
+// announce their hashes to the remaining ones. Peers running dex/64 can't pull
+// announced transactions, so those still receive them in full.
 func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	round := pm.blockchain.CurrentBlock().Round()
 	label := peerLabel{
@@ -1093,11 +1271,32 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 		maxReceiver = minTxReceiver
 	}
 
-	var txset = make(map[*peer]types.Transactions)
+	var (
+		signer = types.NewEIP155Signer(pm.blockchain.Config().ChainID)
+		txset  = make(map[*peer]types.Transactions)
+		annset = make(map[*peer][]common.Hash)
+	)
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		receivers := make(map[*peer]struct{})
 
+		// Senders over their admission budget are only announced to a subset
+		// of the dex/65 peers, without pushing the transactions or announcing
+		// further
+		if from, err := types.Sender(signer, tx); err == nil && pm.txpool.SenderThrottled(from) {
+			for _, peer := range peers {
+				if len(receivers) >= maxReceiver {
+					break
+				}
+				if peer.version >= dex65 && !peer.knownTxs.Contains(tx.Hash()) {
+					receivers[peer] = struct{}{}
+					annset[peer] = append(annset[peer], tx.Hash())
+				}
+			}
+			log.Trace("Throttled transaction broadcast", "hash", tx.Hash(), "from", from, "announced", len(receivers))
+			continue
+		}
+
 		// notary peers first
 		for _, peer := range notaryPeers {
 			if !peer.knownTxs.Contains(tx.Hash()) {
@@ -1122,12 +1321,29 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 		for peer := range receivers {
 			txset[peer] = append(txset[peer], tx)
 		}
-		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(receivers))
+		// Announce the hash to the remaining peers, letting them pull it
+		// only if they haven't received it from elsewhere already
+		announced := 0
+		for _, peer := range peers {
+			if _, ok := receivers[peer]; ok || peer.knownTxs.Contains(tx.Hash()) {
+				continue
+			}
+			if peer.version < dex65 {
+				txset[peer] = append(txset[peer], tx)
+				continue
+			}
+			annset[peer] = append(annset[peer], tx.Hash())
+			announced++
+		}
+		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(receivers), "announced", announced)
 	}
 
 	for peer, txs := range txset {
 		peer.AsyncSendTransactions(txs)
 	}
+	for peer, hashes := range annset {
+		peer.AsyncSendPooledTransactionHashes(hashes)
+	}
 }
 
 // BroadcastFinalizedBlock broadcasts the finalized core block to some of its peers.
@@ -1345,6 +1561,8 @@ func (pm *ProtocolManager) peerSetLoop() {
 		round = CRSRound
 		reset = pm.gov.DKGResetCount(round)
 	}
+	discovery := pm.discoverNotaries(round)
+	defer func() { discovery.Close() }()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -1402,12 +1620,30 @@ func (pm *ProtocolManager) peerSetLoop() {
 
 			round = newRound
 			reset = newReset
+
+			// Look up the upcoming notary set ahead of the round start.
+			discovery.Close()
+			discovery = pm.discoverNotaries(round)
 		case <-pm.chainHeadSub.Err():
 			return
 		}
 	}
 }
 
+// discoverNotaries proactively looks up the endpoints of the notary set of the
+// given round through the discovery network, so connections to them can be
+// established before the round starts.
+func (pm *ProtocolManager) discoverNotaries(round uint64) *notaryIterator {
+	it := newNotaryIterator(pm.srvr.Resolve, pm.peers.NotaryNodes(round))
+	go func() {
+		for it.Next() {
+			log.Trace("Resolved notary node", "round", round, "node", it.Node())
+			pm.peers.UpdateNotaryNode(round, it.Node())
+		}
+	}()
+	return it
+}
+
 // NodeInfo represents a short summary of the Ethereum sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
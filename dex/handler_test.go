@@ -519,3 +519,30 @@ func testHandlerWithConsoritum(t *testing.T, version int) {
 		t.Errorf("err not match, expect: %s, but got: %s", expectError, err)
 	}
 }
+
+// Tests that only intrinsically invalid transactions count against the peer
+// relaying them, not the ones rejected by the local policy or state.
+func TestJunkTxError(t *testing.T) {
+	tests := []struct {
+		err  error
+		junk bool
+	}{
+		{core.ErrInvalidSender, true},
+		{core.ErrNegativeValue, true},
+		{core.ErrOversizedData, true},
+		{core.ErrIntrinsicGas, true},
+		{core.ErrNonceTooLow, false},
+		{core.ErrGasLimit, false},
+		{core.ErrSenderRateLimited, false},
+		{core.ErrDenied, false},
+		{core.ErrUnderpriced, false},
+		{core.ErrReplaceUnderpriced, false},
+		{core.ErrInsufficientFunds, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if junk := isJunkTxError(tt.err); junk != tt.junk {
+			t.Errorf("%v: junk mismatch: have %v, want %v", tt.err, junk, tt.junk)
+		}
+	}
+}
@@ -85,6 +85,10 @@ func (s *testP2PServer) RemoveDirectPeer(node *enode.Node) {
 	delete(s.direct, node.ID())
 }
 
+func (s *testP2PServer) Resolve(node *enode.Node) *enode.Node {
+	return nil
+}
+
 func (s *testP2PServer) AddGroup(
 	name string, nodes []*enode.Node, num uint64) {
 	s.mu.Lock()
@@ -138,7 +142,7 @@ func newTestProtocolManager(mode downloader.SyncMode,
 		notarySetFunc: func(uint64) (map[string]struct{}, error) { return nil, nil },
 	}
 
-	pm, err := NewProtocolManager(gspec.Config, mode, DefaultConfig.NetworkId, evmux, &testTxPool{added: newtx}, engine, blockchain, db, nil, true, tgov, &testApp{})
+	pm, err := NewProtocolManager(gspec.Config, mode, DefaultConfig.NetworkId, evmux, &testTxPool{added: newtx}, engine, blockchain, db, nil, nil, true, tgov, &testApp{})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -200,10 +204,29 @@ func (p *testTxPool) Pending() (map[common.Address]types.Transactions, error) {
 	return batches, nil
 }
 
+// Get returns the transaction with the given hash, or nil if unknown.
+func (p *testTxPool) Get(hash common.Hash) *types.Transaction {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for _, tx := range p.pool {
+		if tx.Hash() == hash {
+			return tx
+		}
+	}
+	return nil
+}
+
 func (p *testTxPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return p.txFeed.Subscribe(ch)
 }
 
+// SenderThrottled returns whether the sender is throttled, which never happens
+// in the test pool.
+func (p *testTxPool) SenderThrottled(addr common.Address) bool {
+	return false
+}
+
 // newTestTransaction create a new dummy transaction.
 func newTestTransaction(from *ecdsa.PrivateKey, nonce uint64, datasize int) *types.Transaction {
 	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(0), make([]byte, datasize))
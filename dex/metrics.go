@@ -27,6 +27,7 @@ var (
 	propTxnInTrafficMeter                  = metrics.NewRegisteredMeter("dex/prop/txns/in/traffic", nil)
 	propTxnOutPacketsMeter                 = metrics.NewRegisteredMeter("dex/prop/txns/out/packets", nil)
 	propTxnOutTrafficMeter                 = metrics.NewRegisteredMeter("dex/prop/txns/out/traffic", nil)
+	propTxnThrottledMeter                  = metrics.NewRegisteredMeter("dex/prop/txns/throttled", nil)
 	propHashInPacketsMeter                 = metrics.NewRegisteredMeter("dex/prop/hashes/in/packets", nil)
 	propHashInTrafficMeter                 = metrics.NewRegisteredMeter("dex/prop/hashes/in/traffic", nil)
 	propHashOutPacketsMeter                = metrics.NewRegisteredMeter("dex/prop/hashes/out/packets", nil)
@@ -71,6 +71,10 @@ const (
 	// contain a single transaction, or thousands.
 	maxQueuedTxs = 1024
 
+	// maxQueuedTxAnns is the maximum number of transaction announcement lists to
+	// queue up before dropping them. Announcements are cheap, so allow more.
+	maxQueuedTxAnns = 4096
+
 	// maxQueuedProps is the maximum number of block propagations to queue up before
 	// dropping broadcasts. There's not much point in queueing stale blocks, so a few
 	// that might cover uncles should be enough.
@@ -90,6 +94,16 @@ const (
 	maxQueuedPullVotes            = 128
 	maxQueuedPullRandomness       = 128
 
+	// txPeerRate and txPeerBurst bound the rate at which transactions relayed
+	// by a single peer are admitted into the pool.
+	txPeerRate  = 512
+	txPeerBurst = 8192
+
+	// maxJunkTxScore is the number of junk transactions a peer may relay before
+	// being dropped. The score decays by junkTxDecayRate each second.
+	maxJunkTxScore  = 1024
+	junkTxDecayRate = 4
+
 	handshakeTimeout = 5 * time.Second
 
 	groupConnNum     = 3
@@ -143,6 +157,7 @@ type peer struct {
 	knownAgreements                mapset.Set
 	knownDKGPrivateShares          mapset.Set
 	queuedTxs                      chan []*types.Transaction // Queue of transactions to broadcast to the peer
+	queuedTxAnns                   chan []common.Hash        // Queue of transaction hashes to announce to the peer
 	queuedProps                    chan *types.Block         // Queue of blocks to broadcast to the peer
 	queuedAnns                     chan *types.Block         // Queue of blocks to announce to the peer
 	queuedCoreBlocks               chan []*coreTypes.Block
@@ -154,6 +169,11 @@ type peer struct {
 	queuedPullVotes                chan coreTypes.Position
 	queuedPullRandomness           chan coreCommon.Hashes
 	term                           chan struct{} // Termination channel to stop the broadcaster
+
+	txLock      sync.Mutex
+	txAllowance float64   // Number of relayed transactions currently admitted
+	junkScore   float64   // Decaying count of junk transactions relayed
+	txUpdated   time.Time // Last time the allowance and score were updated
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -167,6 +187,7 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		knownAgreements:            mapset.NewSet(),
 		knownDKGPrivateShares:      mapset.NewSet(),
 		queuedTxs:                  make(chan []*types.Transaction, maxQueuedTxs),
+		queuedTxAnns:               make(chan []common.Hash, maxQueuedTxAnns),
 		queuedProps:                make(chan *types.Block, maxQueuedProps),
 		queuedAnns:                 make(chan *types.Block, maxQueuedAnns),
 		queuedCoreBlocks:           make(chan []*coreTypes.Block, maxQueuedCoreBlocks),
@@ -178,6 +199,8 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		queuedPullVotes:            make(chan coreTypes.Position, maxQueuedPullVotes),
 		queuedPullRandomness:       make(chan coreCommon.Hashes, maxQueuedPullRandomness),
 		term:                       make(chan struct{}),
+		txAllowance:                txPeerBurst,
+		txUpdated:                  time.Now(),
 	}
 }
 
@@ -262,6 +285,14 @@ func (p *peer) broadcast() {
 			p.Log().Trace("Broadcast transactions", "count", len(txs))
 		default:
 		}
+		select {
+		case hashes := <-p.queuedTxAnns:
+			if err := p.SendPooledTransactionHashes(hashes); err != nil {
+				return
+			}
+			p.Log().Trace("Announced transactions", "count", len(hashes))
+		default:
+		}
 	}
 }
 
@@ -350,6 +381,49 @@ func (p *peer) logSend(err error, code uint64) error {
 	return err
 }
 
+// updateTxScores refills the transaction allowance and decays the junk score
+// of the peer up to now. The caller must hold txLock.
+func (p *peer) updateTxScores(now time.Time) {
+	elapsed := now.Sub(p.txUpdated).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	p.txAllowance += elapsed * txPeerRate
+	if p.txAllowance > txPeerBurst {
+		p.txAllowance = txPeerBurst
+	}
+	p.junkScore -= elapsed * junkTxDecayRate
+	if p.junkScore < 0 {
+		p.junkScore = 0
+	}
+	p.txUpdated = now
+}
+
+// AdmitTransactions consumes the allowance of the peer for n relayed
+// transactions, returning how many of them may be admitted into the pool.
+func (p *peer) AdmitTransactions(n int, now time.Time) int {
+	p.txLock.Lock()
+	defer p.txLock.Unlock()
+
+	p.updateTxScores(now)
+	if admitted := int(p.txAllowance); n > admitted {
+		n = admitted
+	}
+	p.txAllowance -= float64(n)
+	return n
+}
+
+// ScoreJunkTransactions adds n junk transactions relayed by the peer to its
+// score, returning whether the peer went over the tolerated limit.
+func (p *peer) ScoreJunkTransactions(n int, now time.Time) bool {
+	p.txLock.Lock()
+	defer p.txLock.Unlock()
+
+	p.updateTxScores(now)
+	p.junkScore += float64(n)
+	return p.junkScore > maxJunkTxScore
+}
+
 // SendTransactions sends transactions to the peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *peer) SendTransactions(txs types.Transactions) error {
@@ -372,6 +446,41 @@ func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
 	}
 }
 
+// SendPooledTransactionHashes announces the availability of a number of
+// transactions through a hash notification.
+func (p *peer) SendPooledTransactionHashes(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		p.MarkTransaction(hash)
+	}
+	return p.logSend(p2p.Send(p.rw, NewPooledTransactionHashesMsg, hashes), NewPooledTransactionHashesMsg)
+}
+
+// AsyncSendPooledTransactionHashes queues a batch of transaction hashes for
+// announcement to a remote peer. If the peer's announce queue is full, the
+// event is silently dropped.
+func (p *peer) AsyncSendPooledTransactionHashes(hashes []common.Hash) {
+	select {
+	case p.queuedTxAnns <- hashes:
+		for _, hash := range hashes {
+			p.MarkTransaction(hash)
+		}
+	default:
+		p.Log().Debug("Dropping transaction announcement", "count", len(hashes))
+	}
+}
+
+// SendPooledTransactionsRLP sends requested transactions to the peer from an
+// already RLP encoded format.
+func (p *peer) SendPooledTransactionsRLP(txs []rlp.RawValue) error {
+	return p.logSend(p2p.Send(p.rw, PooledTransactionsMsg, txs), PooledTransactionsMsg)
+}
+
+// RequestTxs fetches a batch of pooled transactions from a remote node.
+func (p *peer) RequestTxs(hashes []common.Hash) error {
+	p.Log().Debug("Fetching batch of transactions", "count", len(hashes))
+	return p.logSend(p2p.Send(p.rw, GetPooledTransactionsMsg, hashes), GetPooledTransactionsMsg)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -530,6 +639,22 @@ func (p *peer) SendGovState(govState *types.GovState) error {
 	return p.logSend(p2p.Send(p.rw, GovStateMsg, govState), GovStateMsg)
 }
 
+// SendAccountRange sends a proven range of accounts, corresponding to the
+// account range query with the given id.
+func (p *peer) SendAccountRange(id uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	return p.logSend(p2p.Send(p.rw, AccountRangeMsg, &accountRangeData{
+		ID: id, Hashes: hashes, Accounts: accounts, Proof: proof,
+	}), AccountRangeMsg)
+}
+
+// SendStorageRange sends a proven range of storage slots, corresponding to the
+// storage range query with the given id.
+func (p *peer) SendStorageRange(id uint64, hashes []common.Hash, slots [][]byte, proof [][]byte) error {
+	return p.logSend(p2p.Send(p.rw, StorageRangeMsg, &storageRangeData{
+		ID: id, Hashes: hashes, Slots: slots, Proof: proof,
+	}), StorageRangeMsg)
+}
+
 // RequestOneHeader is a wrapper around the header query functions to fetch a
 // single header. It is used solely by the fetcher.
 func (p *peer) RequestOneHeader(hash common.Hash) error {
@@ -583,6 +708,24 @@ func (p *peer) RequestNodeData(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetNodeDataMsg, hashes)
 }
 
+// RequestAccountRange fetches a proven range of accounts from the account trie
+// rooted at root, starting at origin and ending at or after limit.
+func (p *peer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "id", id, "root", root, "origin", origin, "limit", limit, "bytes", bytes)
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{
+		ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes,
+	})
+}
+
+// RequestStorageRange fetches a proven range of storage slots of a single
+// account in the state rooted at root.
+func (p *peer) RequestStorageRange(id uint64, root, account, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of storage slots", "id", id, "root", root, "account", account, "origin", origin, "limit", limit, "bytes", bytes)
+	return p2p.Send(p.rw, GetStorageRangeMsg, &getStorageRangeData{
+		ID: id, Root: root, Account: account, Origin: origin, Limit: limit, Bytes: bytes,
+	})
+}
+
 // RequestReceipts fetches a batch of transaction receipts from a remote node.
 func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of receipts", "count", len(hashes))
@@ -990,6 +1133,40 @@ func (ps *peerSet) removeDirectPeer(id string, label peerLabel) {
 	}
 }
 
+// NotaryNodes returns the nodes of the notary set of the given round, or nil
+// if no connection was built for it.
+func (ps *peerSet) NotaryNodes(round uint64) []*enode.Node {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	label := peerLabel{set: notaryset, round: round}
+	nodes := make([]*enode.Node, 0, len(ps.label2Nodes[label]))
+	for id, n := range ps.label2Nodes[label] {
+		if id == ps.srvr.Self().ID().String() {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// UpdateNotaryNode replaces the endpoint of a notary node of the given round
+// with a resolved one, redialing it if a direct connection is maintained.
+func (ps *peerSet) UpdateNotaryNode(round uint64, node *enode.Node) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	label := peerLabel{set: notaryset, round: round}
+	id := node.ID().String()
+	if _, ok := ps.label2Nodes[label][id]; !ok {
+		return
+	}
+	ps.label2Nodes[label][id] = node
+	if _, ok := ps.allDirectPeers[id][label]; ok && ps.peers[id] == nil {
+		ps.srvr.AddDirectPeer(node)
+	}
+}
+
 func (ps *peerSet) pksToNodes(pks map[string]struct{}) map[string]*enode.Node {
 	nodes := map[string]*enode.Node{}
 	for pk := range pks {
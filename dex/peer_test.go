@@ -5,8 +5,10 @@ import (
 	"encoding/hex"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/p2p"
 	"github.com/tangerine-network/go-tangerine/p2p/enode"
 )
 
@@ -221,6 +223,31 @@ func TestPeerSetBuildAndForgetConn(t *testing.T) {
 	}
 }
 
+func TestPeerTransactionAdmissionAndScoring(t *testing.T) {
+	p := newPeer(dex64, p2p.NewPeerWithEnode(randomV4CompactNode(), "test", nil), nil)
+	now := p.txUpdated
+
+	if admitted := p.AdmitTransactions(txPeerBurst+10, now); admitted != txPeerBurst {
+		t.Errorf("admitted transactions mismatch: have %d, want %d", admitted, txPeerBurst)
+	}
+	if admitted := p.AdmitTransactions(1, now); admitted != 0 {
+		t.Errorf("admitted transactions over burst: have %d, want 0", admitted)
+	}
+	if admitted := p.AdmitTransactions(txPeerRate*2, now.Add(time.Second)); admitted != txPeerRate {
+		t.Errorf("refilled admissions mismatch: have %d, want %d", admitted, txPeerRate)
+	}
+
+	if p.ScoreJunkTransactions(maxJunkTxScore, now.Add(time.Second)) {
+		t.Errorf("peer dropped at the junk score limit")
+	}
+	if !p.ScoreJunkTransactions(1, now.Add(time.Second)) {
+		t.Errorf("peer not dropped over the junk score limit")
+	}
+	if p.ScoreJunkTransactions(1, now.Add(2*time.Second)) {
+		t.Errorf("junk score did not decay")
+	}
+}
+
 func newTestNodeSet(nodes []*enode.Node) map[string]struct{} {
 	m := make(map[string]struct{})
 	for _, node := range nodes {
@@ -50,16 +50,17 @@ import (
 // Constants to match up protocol versions and messages
 const (
 	dex64 = 64
+	dex65 = 65
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "dex"
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{dex64}
+var ProtocolVersions = []uint{dex65, dex64}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{43}
+var ProtocolLengths = []uint64{50, 43}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -92,6 +93,16 @@ const (
 
 	GetGovStateMsg = 0x29
 	GovStateMsg    = 0x2a
+
+	// Protocol messages belonging to dex/65
+	GetAccountRangeMsg = 0x2b
+	AccountRangeMsg    = 0x2c
+	GetStorageRangeMsg = 0x2d
+	StorageRangeMsg    = 0x2e
+
+	NewPooledTransactionHashesMsg = 0x2f
+	GetPooledTransactionsMsg      = 0x30
+	PooledTransactionsMsg         = 0x31
 )
 
 type errCode int
@@ -140,9 +151,17 @@ type txPool interface {
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
 
+	// Get should return the pooled transaction with the given hash, or nil
+	// if it is not known.
+	Get(common.Hash) *types.Transaction
+
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
+
+	// SenderThrottled should report whether remote transactions of the
+	// account were recently rejected for exceeding its admission rate.
+	SenderThrottled(common.Address) bool
 }
 
 type governance interface {
@@ -172,6 +191,8 @@ type p2pServer interface {
 	AddDirectPeer(*enode.Node)
 
 	RemoveDirectPeer(*enode.Node)
+
+	Resolve(*enode.Node) *enode.Node
 }
 
 // statusData is the network packet for the status message.
@@ -275,3 +296,38 @@ func rlpHash(x interface{}) (h common.Hash) {
 	hw.Sum(h[:0])
 	return h
 }
+
+// getAccountRangeData represents an account range query against a state root.
+type getAccountRangeData struct {
+	ID     uint64      // Request ID to match up the response with
+	Root   common.Hash // Root hash of the account trie to serve
+	Origin common.Hash // Hash of the first account to retrieve
+	Limit  common.Hash // Hash of the last account to retrieve
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// accountRangeData is the network packet for a proven range of accounts.
+type accountRangeData struct {
+	ID       uint64        // ID of the request this is a response for
+	Hashes   []common.Hash // Hashes of the accounts in the range
+	Accounts [][]byte      // RLP encoded accounts in the range
+	Proof    [][]byte      // Merkle proofs for the range boundaries
+}
+
+// getStorageRangeData represents a storage range query of a single account.
+type getStorageRangeData struct {
+	ID      uint64      // Request ID to match up the response with
+	Root    common.Hash // Root hash of the account trie to serve
+	Account common.Hash // Hash of the account whose storage to serve
+	Origin  common.Hash // Hash of the first storage slot to retrieve
+	Limit   common.Hash // Hash of the last storage slot to retrieve
+	Bytes   uint64      // Soft limit at which to stop returning data
+}
+
+// storageRangeData is the network packet for a proven range of storage slots.
+type storageRangeData struct {
+	ID     uint64        // ID of the request this is a response for
+	Hashes []common.Hash // Hashes of the storage slots in the range
+	Slots  [][]byte      // RLP encoded storage values in the range
+	Proof  [][]byte      // Merkle proofs for the range boundaries
+}
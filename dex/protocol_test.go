@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -187,6 +188,62 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+// Tests that transactions are only announced to dex/65 peers, while dex/64
+// peers keep receiving them in full.
+func TestBroadcastTransactionsByVersion(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+
+	var (
+		wg        sync.WaitGroup
+		announced int32
+	)
+	checkTx := func(p *testPeer, version int) {
+		defer wg.Done()
+		defer p.close()
+
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			t.Errorf("%v: read error: %v", p.Peer, err)
+			return
+		}
+		switch {
+		case msg.Code == TxMsg:
+			var txs []*types.Transaction
+			if err := msg.Decode(&txs); err != nil || len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+				t.Errorf("%v: transaction mismatch: %v, err %v", p.Peer, txs, err)
+			}
+		case msg.Code == NewPooledTransactionHashesMsg && version >= dex65:
+			var hashes []common.Hash
+			if err := msg.Decode(&hashes); err != nil || len(hashes) != 1 || hashes[0] != tx.Hash() {
+				t.Errorf("%v: announcement mismatch: %v, err %v", p.Peer, hashes, err)
+			}
+			atomic.AddInt32(&announced, 1)
+		default:
+			t.Errorf("%v: unexpected message on dex/%d: code %d", p.Peer, version, msg.Code)
+		}
+	}
+	// More peers of each version than the transaction is pushed to
+	peers := 0
+	for _, version := range []int{dex64, dex65} {
+		for i := 0; i < minTxReceiver+1; i++ {
+			p, _ := newTestPeer(fmt.Sprintf("peer dex/%d #%d", version, i), version, pm, true)
+			wg.Add(1)
+			go checkTx(p, version)
+			peers++
+		}
+	}
+	waitForRegister(pm, peers)
+	pm.BroadcastTxs(types.Transactions{tx})
+	wg.Wait()
+
+	if announced == 0 {
+		t.Errorf("transaction not announced to any dex/65 peer")
+	}
+}
+
 // Tests that the custom union field encoder and decoder works correctly.
 func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 	// Create a "random" hash for testing
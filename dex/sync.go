@@ -192,6 +192,9 @@ func (pm *ProtocolManager) synchronise(peer *peer, force bool) {
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		// Fast sync was explicitly requested, and explicitly granted
 		mode = downloader.FastSync
+		if atomic.LoadUint32(&pm.snapSync) == 1 {
+			mode = downloader.SnapSync
+		}
 	} else if currentBlock.NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 {
 		// The database seems empty as the current block is the genesis. Yet the fast
 		// block is ahead, so fast sync was enabled for this node at a certain point.
@@ -202,7 +205,7 @@ func (pm *ProtocolManager) synchronise(peer *peer, force bool) {
 		mode = downloader.FastSync
 	}
 
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		// Make sure the peer's total difficulty we are synchronizing is higher.
 		if pm.blockchain.CurrentFastBlock().NumberU64() >= pNumber {
 			return
@@ -216,6 +219,7 @@ func (pm *ProtocolManager) synchronise(peer *peer, force bool) {
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		log.Info("Fast sync complete, auto disabling")
 		atomic.StoreUint32(&pm.fastSync, 0)
+		atomic.StoreUint32(&pm.snapSync, 0)
 	}
 	atomic.StoreUint32(&pm.acceptTxs, 1) // Mark initial sync done
 	if head := pm.blockchain.CurrentBlock(); head.NumberU64() > 0 {
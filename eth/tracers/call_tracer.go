@@ -0,0 +1,297 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+)
+
+// callFrame is a single call reported by the call tracer, with the same fields
+// and JSON layout as the JavaScript callTracer.
+type callFrame struct {
+	Type    string                 `json:"type"`
+	From    *common.Address        `json:"from,omitempty"`
+	To      *common.Address        `json:"to,omitempty"`
+	Value   *hexutil.Big           `json:"value,omitempty"`
+	Gas     *hexutil.Uint64        `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64        `json:"gasUsed,omitempty"`
+	Input   *hexutil.Bytes         `json:"input,omitempty"`
+	Output  *hexutil.Bytes         `json:"output,omitempty"`
+	Method  string                 `json:"method,omitempty"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	Events  []vm.OracleEvent       `json:"events,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Time    string                 `json:"time,omitempty"`
+	Calls   []*callFrame           `json:"calls,omitempty"`
+
+	gasIn   uint64   // Gas available before the call opcode
+	gasCost uint64   // Cost of the call opcode, including forwarded gas
+	outOff  *big.Int // Memory offset of the call output
+	outLen  *big.Int // Memory size of the call output
+	oracle  bool     // Whether the call targets an oracle contract
+}
+
+// callTracer is a native Go implementation of the JavaScript callTracer,
+// extracting and reporting all the internal calls made by a transaction. Calls
+// to oracle contracts are reported as call frames too.
+type callTracer struct {
+	interrupter
+
+	callstack []*callFrame // Current recursive call stack of the EVM execution
+	descended bool         // Whether we've just descended into an inner call
+	root      *callFrame   // Outer transaction call assembled from the start and end events
+	err       error        // Error hit while tracing
+}
+
+// newCallTracer creates a native call tracer.
+func newCallTracer() ResultTracer {
+	return &callTracer{
+		callstack: []*callFrame{{}},
+		root:      new(callFrame),
+	}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing
+// operation.
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.root.Type = "CALL"
+	if create {
+		t.root.Type = "CREATE"
+	}
+	t.root.From = &from
+	t.root.To = &to
+	t.root.Input = (*hexutil.Bytes)(&input)
+	t.root.Gas = (*hexutil.Uint64)(&gas)
+	t.root.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM
+// execution.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if t.err = t.interrupted(); t.err != nil {
+		return nil
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// If a new contract is being created, add to the call stack
+		from := contract.Address()
+		input := hexutil.Bytes(memorySlice(memory, stack.Back(1), stack.Back(2)))
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    &from,
+			Input:   &input,
+			Value:   (*hexutil.Big)(new(big.Int).Set(stack.Back(0))),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, &callFrame{Type: op.String()})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := stackAddress(stack, 1)
+		if isPrecompiled(env, to) {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		from := contract.Address()
+		input := hexutil.Bytes(memorySlice(memory, stack.Back(2+off), stack.Back(3+off)))
+		call := &callFrame{
+			Type:    op.String(),
+			From:    &from,
+			To:      &to,
+			Input:   &input,
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  new(big.Int).Set(stack.Back(4 + off)),
+			outLen:  new(big.Int).Set(stack.Back(5 + off)),
+			oracle:  vm.OracleContracts[to] != nil,
+		}
+		if op != vm.DELEGATECALL && op != vm.STATICCALL {
+			call.Value = (*hexutil.Big)(new(big.Int).Set(stack.Back(2)))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. We
+	// need to extract if from within the call as there may be funky gas dynamics
+	// with regard to requested and actually given gas (2300 stipend, 63/64 rule).
+	// Calls to plain accounts don't execute any opcodes, so their true allowance
+	// is unknown, while oracle contracts report theirs through CaptureOracle.
+	if t.descended {
+		if depth >= len(t.callstack) {
+			allowance := hexutil.Uint64(gas)
+			t.callstack[len(t.callstack)-1].Gas = &allowance
+		}
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.callstack)-1 {
+		// Pop off the last call and get the execution results
+		call := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		ret := stack.Back(0)
+		switch {
+		case call.Type == "CREATE" || call.Type == "CREATE2":
+			// If the call was a CREATE, retrieve the contract address and output code
+			gasUsed := hexutil.Uint64(call.gasIn - call.gasCost - gas)
+			call.GasUsed = &gasUsed
+
+			if ret.Sign() != 0 {
+				to := common.BigToAddress(ret)
+				code := hexutil.Bytes(env.StateDB.GetCode(to))
+				call.To, call.Output = &to, &code
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+
+		case call.Gas != nil || call.oracle:
+			// If the call was a contract call, retrieve the gas usage and output
+			if call.Gas != nil {
+				gasUsed := hexutil.Uint64(call.gasIn - call.gasCost + uint64(*call.Gas) - gas)
+				call.GasUsed = &gasUsed
+			}
+			if ret.Sign() != 0 {
+				output := hexutil.Bytes(memorySlice(memory, call.outOff, call.outLen))
+				call.Output = &output
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		// Inject the call into the previous one
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault handles the failure of the actual execution of an opcode.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	// Pop off the just failed call
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.Error = err.Error()
+
+	// Consume all available gas
+	if call.Gas != nil {
+		call.GasUsed = call.Gas
+	}
+	// Flatten the failed call into its parent
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	// Last call failed too, leave it in the stack
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureOracle implements the OracleTracer interface to complete the call frame
+// of an oracle contract with its true gas allowance, decoded method and events.
+func (t *callTracer) CaptureOracle(env *vm.EVM, call *vm.OracleCall) error {
+	if t.err != nil {
+		return nil
+	}
+	var frame *callFrame
+	switch top := t.callstack[len(t.callstack)-1]; {
+	case call.Depth == 1 && len(t.callstack) == 1:
+		// The transaction itself called the oracle
+		frame = t.root
+
+	case call.Depth == len(t.callstack) && top.oracle:
+		frame = top
+		gas := hexutil.Uint64(call.Gas)
+		frame.Gas = &gas
+		frame.Error = call.Error
+		t.descended = false
+
+	default:
+		return nil
+	}
+	frame.Method, frame.Args, frame.Events = call.Method, call.Args, call.Events
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.root.Output = (*hexutil.Bytes)(&output)
+	t.root.GasUsed = (*hexutil.Uint64)(&gasUsed)
+	t.root.Time = d.String()
+	if err != nil {
+		t.root.Error = err.Error()
+	}
+	return nil
+}
+
+// GetResult returns the outer call with all the internal calls nested into it.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	result := *t.root
+	result.Calls = t.callstack[0].Calls
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	}
+	if result.Error != "" {
+		result.Output = nil
+	}
+	return json.Marshal(&result)
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/log"
+)
+
+// ResultTracer is a vm.Tracer which can be interrupted and which assembles a
+// JSON result once the traced execution finishes. It is implemented both by
+// the JavaScript Tracer and by the native Go tracers.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON result of the trace, or any error hit while
+	// tracing.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing, reporting err as the reason.
+	Stop(err error)
+}
+
+// natives contains the built in native Go tracers by name. They take priority
+// over the JavaScript tracers of the same name.
+var natives = map[string]func() ResultTracer{
+	"callTracer":     newCallTracer,
+	"prestateTracer": newPrestateTracer,
+}
+
+// NewTracer instantiates the native tracer registered under the given name,
+// falling back to a JavaScript tracer from a built in name or source code.
+func NewTracer(code string) (ResultTracer, error) {
+	if ctor, ok := natives[code]; ok {
+		return ctor(), nil
+	}
+	return New(code)
+}
+
+// interrupter implements the interruption of native tracers.
+type interrupter struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (i *interrupter) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.interrupt, 1)
+}
+
+// interrupted returns the reason of the interruption, or nil if the tracer is
+// still running.
+func (i *interrupter) interrupted() error {
+	if atomic.LoadUint32(&i.interrupt) > 0 {
+		return i.reason
+	}
+	return nil
+}
+
+// memorySlice returns a copy of the memory within the given range, or an empty
+// slice if the range is out of bounds.
+func memorySlice(memory *vm.Memory, offset, size *big.Int) []byte {
+	if !offset.IsUint64() || !size.IsUint64() {
+		return []byte{}
+	}
+	start, end := offset.Uint64(), offset.Uint64()+size.Uint64()
+	if end < start || end > uint64(memory.Len()) {
+		log.Warn("Tracer accessed out of bound memory", "available", memory.Len(), "offset", start, "size", size)
+		return []byte{}
+	}
+	return common.CopyBytes(memory.Data()[start:end])
+}
+
+// stackAddress interprets the stack item at the given depth as an address.
+func stackAddress(stack *vm.Stack, n int) common.Address {
+	return common.BigToAddress(stack.Back(n))
+}
+
+// isPrecompiled returns whether the address is a precompiled contract at the
+// block the EVM is executing, picking the same set as the EVM itself.
+func isPrecompiled(env *vm.EVM, addr common.Address) bool {
+	var precompiles map[common.Address]vm.PrecompiledContract
+	switch {
+	case env.ChainConfig().IsIstanbul(env.BlockNumber) && env.ChainConfig().IsBls(env.BlockNumber):
+		precompiles = vm.PrecompiledContractsBls
+	case env.ChainConfig().IsIstanbul(env.BlockNumber):
+		precompiles = vm.PrecompiledContractsIstanbul
+	case env.ChainConfig().IsByzantium(env.BlockNumber):
+		precompiles = vm.PrecompiledContractsByzantium
+	default:
+		precompiles = vm.PrecompiledContractsHomestead
+	}
+	_, ok := precompiles[addr]
+	return ok
+}
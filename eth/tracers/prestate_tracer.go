@@ -0,0 +1,168 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+)
+
+// prestateAccount is the state of an account before the traced transaction,
+// in the layout of a genesis allocation.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// prestateTracer is a native Go implementation of the JavaScript prestateTracer,
+// outputting sufficient information to create a local execution of the
+// transaction from a custom assembled genesis block.
+type prestateTracer struct {
+	interrupter
+
+	prestate map[common.Address]*prestateAccount // Genesis that we're building
+	db       vm.StateDB                          // State database accessed by the transaction
+	create   bool                                // Whether the transaction creates a contract
+	from     common.Address                      // Sender of the transaction
+	to       common.Address                      // Recipient or created contract of the transaction
+	value    *big.Int                            // Value transferred by the transaction
+	err      error                               // Error hit while tracing
+}
+
+// newPrestateTracer creates a native prestate tracer.
+func newPrestateTracer() ResultTracer {
+	return new(prestateTracer)
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+		Nonce:   t.db.GetNonce(addr),
+		Code:    common.CopyBytes(t.db.GetCode(addr)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	if _, ok := t.prestate[addr].Storage[key]; !ok {
+		t.prestate[addr].Storage[key] = t.db.GetState(addr, key)
+	}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing
+// operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to = create, from, to
+	t.value = new(big.Int).Set(value)
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM
+// execution, adding any newly accessed state to the prestate.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if t.err = t.interrupted(); t.err != nil {
+		return nil
+	}
+	// Add the current account if we just started tracing. Balance will
+	// potentially be wrong here, since this will include the value sent
+	// along with the message. We fix that in GetResult.
+	if t.prestate == nil {
+		t.prestate = make(map[common.Address]*prestateAccount)
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+	}
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(stackAddress(stack, 0))
+
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		from := contract.Address()
+		code := memorySlice(memory, stack.Back(1), stack.Back(2))
+		salt := common.BigToHash(stack.Back(3))
+		t.lookupAccount(crypto.CreateAddress2(from, salt, crypto.Keccak256(code)))
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(stackAddress(stack, 1))
+
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(stack.Back(0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled allocations of the prestate.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	// No code was executed, nothing but the sender and recipient was touched,
+	// neither of which can be reconstructed without state access
+	if t.prestate == nil {
+		return json.Marshal(map[common.Address]*prestateAccount{})
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction,
+	// and move it back to the origin
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+
+	toBal := t.prestate[t.to].Balance.ToInt()
+	toBal.Sub(toBal, t.value)
+	fromBal := t.prestate[t.from].Balance.ToInt()
+	fromBal.Add(fromBal, t.value)
+
+	// Decrement the caller's nonce, and remove empty create targets
+	t.prestate[t.from].Nonce--
+	if t.create {
+		// We can blindly delete the contract prestate, as any existing state would
+		// have caused the transaction to be rejected as invalid in the first place.
+		delete(t.prestate, t.to)
+	}
+	return json.Marshal(t.prestate)
+}
@@ -108,6 +108,22 @@ func TestOpcodes(t *testing.T) {
 	}
 }
 
+func TestIsPrecompiled(t *testing.T) {
+	// The test chain never activates Istanbul, blake2F (0x09) is not yet enabled
+	tracer, err := New("{res: null, step: function() { this.res = [isPrecompiled(toAddress('0x01')), isPrecompiled(toAddress('0x09'))]; }, fault: function() {}, result: function() { return this.res; }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := runTrace(tracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ret, []byte("[true,false]")) {
+		t.Errorf("Expected return value to be [true,false], got %s", string(ret))
+	}
+}
+
 func TestHalt(t *testing.T) {
 	t.Skip("duktape doesn't support abortion")
 
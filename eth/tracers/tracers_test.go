@@ -17,6 +17,7 @@
 package tracers
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
@@ -121,6 +122,14 @@ type callTracerTest struct {
 }
 
 func TestPrestateTracerCreate2(t *testing.T) {
+	testPrestateTracerCreate2(t, func() (ResultTracer, error) { return New("prestateTracer") })
+}
+
+func TestNativePrestateTracerCreate2(t *testing.T) {
+	testPrestateTracerCreate2(t, func() (ResultTracer, error) { return NewTracer("prestateTracer") })
+}
+
+func testPrestateTracerCreate2(t *testing.T, newTracer func() (ResultTracer, error)) {
 	unsigned_tx := types.NewTransaction(1, common.HexToAddress("0x00000000000000000000000000000000deadbeef"),
 		new(big.Int), 5000000, big.NewInt(1), []byte{})
 
@@ -169,7 +178,7 @@ func TestPrestateTracerCreate2(t *testing.T) {
 	}
 	statedb := tests.MakePreState(ethdb.NewMemDatabase(), alloc)
 	// Create the tracer, the EVM environment and run it
-	tracer, err := New("prestateTracer")
+	tracer, err := newTracer()
 	if err != nil {
 		t.Fatalf("failed to create call tracer: %v", err)
 	}
@@ -201,6 +210,74 @@ func TestPrestateTracerCreate2(t *testing.T) {
 // Iterates over all the input-output datasets in the tracer test harness and
 // runs the JavaScript tracers against them.
 func TestCallTracer(t *testing.T) {
+	testCallTracer(t, func() (ResultTracer, error) { return New("callTracer") })
+}
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// runs the native tracers against them.
+func TestNativeCallTracer(t *testing.T) {
+	testCallTracer(t, func() (ResultTracer, error) { return NewTracer("callTracer") })
+}
+
+// Tests that the native call tracer reports calls to oracle contracts as call
+// frames, along with their output.
+func TestNativeCallTracerOracle(t *testing.T) {
+	var (
+		origin   = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+		contract = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+	)
+	// The code calls the random oracle, writing 32 bytes of output into memory,
+	// and returns them
+	code := append(hexutil.MustDecode("0x6020600060006000600073"), vm.RandomContractAddress.Bytes()...)
+	code = append(code, hexutil.MustDecode("0x5af15060206000f3")...)
+
+	alloc := core.GenesisAlloc{
+		contract: core.GenesisAccount{Code: code, Balance: new(big.Int)},
+		origin:   core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	statedb := tests.MakePreState(ethdb.NewMemDatabase(), alloc)
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		BlockNumber: new(big.Int).SetUint64(1),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		GasPrice:    big.NewInt(1),
+		Randomness:  []byte{0x01},
+	}
+	tracer, err := NewTracer("callTracer")
+	if err != nil {
+		t.Fatalf("failed to create call tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+
+	output, _, err := evm.Call(vm.AccountRef(origin), contract, nil, 100000, new(big.Int))
+	if err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	ret := new(callTrace)
+	if err := json.Unmarshal(res, ret); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	if len(ret.Calls) != 1 {
+		t.Fatalf("inner call count mismatch: have %d, want %d", len(ret.Calls), 1)
+	}
+	if call := ret.Calls[0]; call.To != vm.RandomContractAddress {
+		t.Errorf("oracle call recipient mismatch: have %x, want %x", call.To, vm.RandomContractAddress)
+	} else if !bytes.Equal(call.Output, output) {
+		t.Errorf("oracle call output mismatch: have %x, want %x", call.Output, output)
+	} else if call.GasUsed == nil || uint64(*call.GasUsed) != params.RandGas {
+		t.Errorf("oracle call gas used mismatch: have %v, want %v", call.GasUsed, params.RandGas)
+	}
+}
+
+func testCallTracer(t *testing.T, newTracer func() (ResultTracer, error)) {
 	files, err := ioutil.ReadDir("testdata")
 	if err != nil {
 		t.Fatalf("failed to retrieve tracer test suite: %v", err)
@@ -244,7 +321,7 @@ func TestCallTracer(t *testing.T) {
 			statedb := tests.MakePreState(ethdb.NewMemDatabase(), test.Genesis.Alloc)
 
 			// Create the tracer, the EVM environment and run it
-			tracer, err := New("callTracer")
+			tracer, err := newTracer()
 			if err != nil {
 				t.Fatalf("failed to create call tracer: %v", err)
 			}
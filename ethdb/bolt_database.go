@@ -0,0 +1,441 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !js
+// +build !js
+
+package ethdb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/log"
+)
+
+const (
+	// boltFile is the name of the database file within a bolt database directory.
+	boltFile = "bolt.db"
+
+	// boltIteratorChunk is the amount of data an iterator loads per read
+	// transaction. Iterators never keep a transaction open across calls, as that
+	// would block writers from growing the memory map.
+	boltIteratorChunk = 1024 * 1024
+
+	// boltSyncInterval is how often writes are synced to disk. Transactions are
+	// committed without syncing, so a crash of the machine, but not of the
+	// process, may lose the writes of the last interval.
+	boltSyncInterval = time.Second
+)
+
+var (
+	// boltBucket is the single bucket holding all data of a bolt database.
+	boltBucket = []byte("ethdb")
+
+	errBoltNotFound = errors.New("not found")
+)
+
+// boltKey converts a key to its stored form. Bolt rejects empty keys, which
+// leveldb allows, so every key is stored behind a one byte marker. The marker
+// is shared by all keys, preserving the key order.
+func boltKey(key []byte) []byte {
+	return append([]byte{'k'}, key...)
+}
+
+// BoltDatabase is a pure Go key-value store built on a copy-on-write B+tree.
+// Unlike leveldb, it never runs background compactions that stall writes.
+//
+// Every Put and Delete is a transaction of its own and a Batch is committed in
+// a single one, none of them synced to disk on return. Committed transactions
+// are synced periodically instead, like leveldb leaves its unsynced writes to
+// the operating system.
+type BoltDatabase struct {
+	fn   string         // filename for reporting
+	db   *bolt.DB       // bolt instance
+	opts *bolt.Options  // options to reopen the database with after compaction
+	lock sync.RWMutex   // guards swapping the bolt instance against the syncer
+	quit chan chan bool // quit channel to stop the syncer
+
+	dirty uint32 // set if transactions were committed since the last sync
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// NewBoltDatabase opens a bolt database in the given directory, creating it if
+// it does not exist yet. The cache allowance sizes the initial memory map.
+func NewBoltDatabase(dir string, cache int) (*BoltDatabase, error) {
+	logger := log.New("database", dir)
+
+	if cache < 16 {
+		cache = 16
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	opts := &bolt.Options{
+		InitialMmapSize: cache * 1024 * 1024,
+		NoFreelistSync:  true,
+		NoSync:          true,
+		FreelistType:    bolt.FreelistMapType,
+	}
+	db, err := bolt.Open(filepath.Join(dir, boltFile), 0600, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Allocated memory map", "cache", cache)
+
+	bdb := &BoltDatabase{
+		fn:   dir,
+		db:   db,
+		opts: opts,
+		quit: make(chan chan bool),
+		log:  logger,
+	}
+	go bdb.syncer()
+
+	return bdb, nil
+}
+
+// syncer periodically syncs the committed transactions to disk.
+func (db *BoltDatabase) syncer() {
+	ticker := time.NewTicker(boltSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := db.sync(); err != nil {
+				db.log.Error("Failed to sync database", "err", err)
+			}
+		case done := <-db.quit:
+			done <- true
+			return
+		}
+	}
+}
+
+// sync flushes the committed transactions to disk if there are any.
+func (db *BoltDatabase) sync() error {
+	if !atomic.CompareAndSwapUint32(&db.dirty, 1, 0) {
+		return nil
+	}
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db.Sync()
+}
+
+// update commits a write transaction, marking the database for the next sync.
+func (db *BoltDatabase) update(fn func(*bolt.Tx) error) error {
+	err := db.db.Update(fn)
+	atomic.StoreUint32(&db.dirty, 1)
+	return err
+}
+
+// Path returns the path to the database directory.
+func (db *BoltDatabase) Path() string {
+	return db.fn
+}
+
+// Put inserts the given key / value into the database. The write is committed
+// in its own transaction.
+func (db *BoltDatabase) Put(key []byte, value []byte) error {
+	return db.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(boltKey(key), value)
+	})
+}
+
+// Has checks whether the key is present in the database.
+func (db *BoltDatabase) Has(key []byte) (bool, error) {
+	var has bool
+	err := db.db.View(func(tx *bolt.Tx) error {
+		has = tx.Bucket(boltBucket).Get(boltKey(key)) != nil
+		return nil
+	})
+	return has, err
+}
+
+// Get returns the value of the given key if it's present.
+func (db *BoltDatabase) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(tx *bolt.Tx) error {
+		// Values are only valid during the transaction, copy them out
+		data := tx.Bucket(boltBucket).Get(boltKey(key))
+		if data == nil {
+			return errBoltNotFound
+		}
+		value = common.CopyBytes(data)
+		return nil
+	})
+	return value, err
+}
+
+// Delete removes the key from the database. The removal is committed in its
+// own transaction.
+func (db *BoltDatabase) Delete(key []byte) error {
+	return db.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(boltKey(key))
+	})
+}
+
+// NewIterator returns an iterator over the entire database.
+func (db *BoltDatabase) NewIterator() Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns an iterator over the keys with a particular
+// prefix.
+func (db *BoltDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &boltIterator{
+		db:     db.db,
+		prefix: boltKey(prefix),
+		next:   boltKey(prefix),
+		index:  -1,
+	}
+}
+
+// Compact rewrites the entire database into a new file and swaps it in. Bolt
+// reuses the pages freed by deletions but never shrinks its file, so this is
+// the only way to reclaim the space. The range is ignored, and the database
+// must not be accessed while compacting.
+func (db *BoltDatabase) Compact(start []byte, limit []byte) error {
+	var (
+		path = filepath.Join(db.fn, boltFile)
+		temp = path + ".compact"
+	)
+	os.Remove(temp)
+	dst, err := bolt.Open(temp, 0600, db.opts)
+	if err != nil {
+		return err
+	}
+	if err := db.copyTo(dst); err != nil {
+		dst.Close()
+		os.Remove(temp)
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		os.Remove(temp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if err := db.db.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return err
+	}
+	if db.db, err = bolt.Open(path, 0600, db.opts); err != nil {
+		return err
+	}
+	db.log.Info("Compacted database", "path", path)
+	return nil
+}
+
+// copyTo copies every entry of the database into the given one, committing a
+// write transaction for every ideal batch worth of data.
+func (db *BoltDatabase) copyTo(dst *bolt.DB) error {
+	return db.db.View(func(src *bolt.Tx) error {
+		tx, err := dst.Begin(true)
+		if err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		// Entries are inserted in order, fill the pages completely
+		bucket.FillPercent = 1.0
+
+		size := 0
+		cursor := src.Bucket(boltBucket).Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if err := bucket.Put(k, v); err != nil {
+				tx.Rollback()
+				return err
+			}
+			if size += len(k) + len(v); size >= IdealBatchSize {
+				if err := tx.Commit(); err != nil {
+					return err
+				}
+				if tx, err = dst.Begin(true); err != nil {
+					return err
+				}
+				bucket = tx.Bucket(boltBucket)
+				bucket.FillPercent = 1.0
+				size = 0
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// Close syncs and closes the database file.
+func (db *BoltDatabase) Close() {
+	done := make(chan bool)
+	db.quit <- done
+	<-done
+
+	if err := db.sync(); err != nil {
+		db.log.Error("Failed to sync database", "err", err)
+	}
+	if err := db.db.Close(); err != nil {
+		db.log.Error("Failed to close database", "err", err)
+		return
+	}
+	db.log.Info("Database closed")
+}
+
+// NewBatch creates a write batch committed in a single transaction.
+func (db *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: db}
+}
+
+// boltIterator iterates over a bolt database in chunks, each read in its own
+// transaction. Writes made between chunks may or may not be observed.
+type boltIterator struct {
+	db     *bolt.DB
+	prefix []byte // Stored form of the prefix to iterate
+	next   []byte // Stored key to resume the iteration from, nil if exhausted
+
+	keys   [][]byte
+	values [][]byte
+	index  int
+	err    error
+}
+
+// Next moves the iterator to the next key / value pair.
+func (it *boltIterator) Next() bool {
+	if it.index+1 < len(it.keys) {
+		it.index++
+		return true
+	}
+	if it.next == nil || it.err != nil {
+		it.keys, it.values, it.index = nil, nil, -1
+		return false
+	}
+	it.keys, it.values, it.index = it.keys[:0], it.values[:0], -1
+	it.err = it.db.View(func(tx *bolt.Tx) error {
+		var (
+			cursor = tx.Bucket(boltBucket).Cursor()
+			size   int
+		)
+		for k, v := cursor.Seek(it.next); k != nil && bytes.HasPrefix(k, it.prefix); k, v = cursor.Next() {
+			if size >= boltIteratorChunk {
+				it.next = common.CopyBytes(k)
+				return nil
+			}
+			it.keys = append(it.keys, common.CopyBytes(k[1:]))
+			it.values = append(it.values, common.CopyBytes(v))
+			size += len(k) + len(v)
+		}
+		it.next = nil
+		return nil
+	})
+	return it.Next()
+}
+
+// Error returns any accumulated error.
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key / value pair, or nil if done.
+func (it *boltIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.index]
+}
+
+// Value returns the value of the current key / value pair, or nil if done.
+func (it *boltIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+// Release discards the remaining items of the iterator.
+func (it *boltIterator) Release() {
+	it.keys, it.values, it.next = nil, nil, nil
+}
+
+type boltBatch struct {
+	db     *BoltDatabase
+	writes []kv
+	size   int
+}
+
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{boltKey(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+func (b *boltBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{boltKey(key), nil, true})
+	b.size += 1
+	return nil
+}
+
+func (b *boltBatch) Write() error {
+	return b.db.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, kv := range b.writes {
+			if kv.del {
+				if err := bucket.Delete(kv.k); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := bucket.Put(kv.k, kv.v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *boltBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
@@ -66,3 +66,8 @@ func (db *LDBDatabase) Meter(prefix string) {
 func (db *LDBDatabase) NewBatch() Batch {
 	return nil
 }
+
+// Open opens the database in the given directory.
+func Open(engine string, dir string, cache int, handles int) (Database, error) {
+	return nil, errNotSupported
+}
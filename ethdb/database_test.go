@@ -23,10 +23,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
 
+	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 )
 
@@ -46,6 +48,22 @@ func newTestLDB() (*ethdb.LDBDatabase, func()) {
 	}
 }
 
+func newTestBoltDB() (*ethdb.BoltDatabase, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		panic("failed to create test file: " + err.Error())
+	}
+	db, err := ethdb.NewBoltDatabase(dirname, 0)
+	if err != nil {
+		panic("failed to create test database: " + err.Error())
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
 var test_values = []string{"", "a", "1251", "\x00123\x00"}
 
 func TestLDB_PutGet(t *testing.T) {
@@ -54,6 +72,12 @@ func TestLDB_PutGet(t *testing.T) {
 	testPutGet(db, t)
 }
 
+func TestBoltDB_PutGet(t *testing.T) {
+	db, remove := newTestBoltDB()
+	defer remove()
+	testPutGet(db, t)
+}
+
 func TestMemoryDB_PutGet(t *testing.T) {
 	testPutGet(ethdb.NewMemDatabase(), t)
 }
@@ -153,6 +177,12 @@ func TestLDB_ParallelPutGet(t *testing.T) {
 	testParallelPutGet(db, t)
 }
 
+func TestBoltDB_ParallelPutGet(t *testing.T) {
+	db, remove := newTestBoltDB()
+	defer remove()
+	testParallelPutGet(db, t)
+}
+
 func TestMemoryDB_ParallelPutGet(t *testing.T) {
 	testParallelPutGet(ethdb.NewMemDatabase(), t)
 }
@@ -212,3 +242,127 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_Iterator(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestBoltDB_Iterator(t *testing.T) {
+	db, remove := newTestBoltDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestMemoryDB_Iterator(t *testing.T) {
+	testIterator(ethdb.NewMemDatabase(), t)
+}
+
+func testIterator(db ethdb.KeyValueStore, t *testing.T) {
+	t.Parallel()
+
+	// Insert enough data to span multiple iterator chunks
+	batch := db.NewBatch()
+	value := make([]byte, 1024)
+	for i := 0; i < 4096; i++ {
+		batch.Put([]byte(fmt.Sprintf("a%05d", i)), value)
+		batch.Put([]byte(fmt.Sprintf("b%05d", i)), []byte{byte(i)})
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	it := db.NewIterator()
+	count := 0
+	var last []byte
+	for it.Next() {
+		if last != nil && bytes.Compare(last, it.Key()) >= 0 {
+			t.Fatalf("keys out of order: %q after %q", it.Key(), last)
+		}
+		last = common.CopyBytes(it.Key())
+		count++
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if count != 8192 {
+		t.Fatalf("iterated item count mismatch: have %d, want %d", count, 8192)
+	}
+	it = db.NewIteratorWithPrefix([]byte("b"))
+	for i := 0; it.Next(); i++ {
+		if want := fmt.Sprintf("b%05d", i); string(it.Key()) != want {
+			t.Fatalf("key %d mismatch: have %q, want %q", i, it.Key(), want)
+		}
+		if !bytes.Equal(it.Value(), []byte{byte(i)}) {
+			t.Fatalf("value %d mismatch: have %x, want %x", i, it.Value(), []byte{byte(i)})
+		}
+		count--
+	}
+	it.Release()
+	if count != 4096 {
+		t.Fatalf("prefixed item count mismatch: have %d, want %d", 8192-count, 4096)
+	}
+}
+
+func TestBoltDB_Compact(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		t.Fatalf("failed to create test dir: %v", err)
+	}
+	defer os.RemoveAll(dirname)
+
+	db, err := ethdb.NewBoltDatabase(dirname, 0)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	// Fill the database and delete most of it again, bolt keeps the pages
+	batch := db.NewBatch()
+	for i := 0; i < 4096; i++ {
+		batch.Put([]byte(fmt.Sprintf("key%05d", i)), bytes.Repeat([]byte{byte(i)}, 1024))
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	for i := 0; i < 4096; i += 2 {
+		if err := db.Delete([]byte(fmt.Sprintf("key%05d", i))); err != nil {
+			t.Fatalf("failed to delete key %d: %v", i, err)
+		}
+	}
+	size := func() int64 {
+		info, err := os.Stat(filepath.Join(dirname, "bolt.db"))
+		if err != nil {
+			t.Fatalf("failed to stat database: %v", err)
+		}
+		return info.Size()
+	}
+	before := size()
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatalf("failed to compact database: %v", err)
+	}
+	if after := size(); after >= before {
+		t.Errorf("database not shrunk: before %d, after %d", before, after)
+	}
+	for i := 0; i < 4096; i++ {
+		key := []byte(fmt.Sprintf("key%05d", i))
+		data, err := db.Get(key)
+		if i%2 == 0 {
+			if err == nil {
+				t.Errorf("deleted key %d resurrected", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to get key %d: %v", i, err)
+		}
+		if !bytes.Equal(data, bytes.Repeat([]byte{byte(i)}, 1024)) {
+			t.Errorf("key %d: data mismatch", i)
+		}
+	}
+	// The compacted database must remain writable
+	if err := db.Put([]byte("fresh"), []byte("value")); err != nil {
+		t.Fatalf("failed to write after compaction: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !js
+// +build !js
+
+package ethdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The key-value engines a database can be backed by.
+const (
+	EngineLevelDB = "leveldb"
+	EngineBolt    = "bolt"
+)
+
+// DetectEngine returns the engine of the database in the given directory, or
+// an empty string if there is none.
+func DetectEngine(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err == nil {
+		return EngineLevelDB
+	}
+	if _, err := os.Stat(filepath.Join(dir, boltFile)); err == nil {
+		return EngineBolt
+	}
+	return ""
+}
+
+// Open opens the database in the given directory. The engine of an existing
+// database always takes precedence, the requested one is only used to create
+// new databases.
+func Open(engine string, dir string, cache int, handles int) (KeyValueStore, error) {
+	if existing := DetectEngine(dir); existing != "" {
+		engine = existing
+	}
+	switch engine {
+	case EngineLevelDB, "":
+		return NewLDBDatabase(dir, cache, handles)
+	case EngineBolt:
+		return NewBoltDatabase(dir, cache)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+}
@@ -40,6 +40,55 @@ type Database interface {
 	NewBatch() Batch
 }
 
+// Iterator iterates over the key-value pairs of a database in ascending key
+// order. It must be released after use.
+type Iterator interface {
+	Next() bool
+	Error() error
+	Key() []byte
+	Value() []byte
+	Release()
+}
+
+// Iteratee wraps the iterator creation methods of a key-value data store.
+type Iteratee interface {
+	// NewIterator creates an iterator over the entire key space.
+	NewIterator() Iterator
+
+	// NewIteratorWithPrefix creates an iterator over the keys with a particular
+	// prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+}
+
+// Compacter wraps the compaction method of a key-value data store.
+type Compacter interface {
+	// Compact flattens the underlying data store for the given key range. A nil
+	// start is treated as the beginning of the key space, a nil limit as the end.
+	Compact(start []byte, limit []byte) error
+}
+
+// KeyValueStore contains all the methods required of the key-value engines
+// backing the chain database.
+type KeyValueStore interface {
+	Database
+	Iteratee
+	Compacter
+}
+
+// AncientReader contains the methods required to read from the immutable
+// ancient data of a database.
+type AncientReader interface {
+	// HasAncient returns an indicator whether the specified data exists in the
+	// ancient store.
+	HasAncient(kind string, number uint64) (bool, error)
+
+	// Ancient retrieves an ancient binary blob from the append-only immutable files.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// Ancients returns the number of items frozen into the ancient store.
+	Ancients() (uint64, error)
+}
+
 // Batch is a write-only database that commits changes to its host database
 // when Write is called. Batch cannot be used concurrently.
 type Batch interface {
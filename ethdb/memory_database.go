@@ -18,6 +18,8 @@ package ethdb
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/tangerine-network/go-tangerine/common"
@@ -90,12 +92,80 @@ func (db *MemDatabase) Delete(key []byte) error {
 
 func (db *MemDatabase) Close() {}
 
+// NewIterator returns an iterator over a snapshot of the entire database.
+func (db *MemDatabase) NewIterator() Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns an iterator over a snapshot of the keys with a
+// particular prefix.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	it := &memIterator{index: -1}
+	for key, value := range db.db {
+		if strings.HasPrefix(key, string(prefix)) {
+			it.keys = append(it.keys, key)
+			it.values = append(it.values, value)
+		}
+	}
+	sort.Sort(it)
+	return it
+}
+
+// Compact is a no-op, there is nothing to flatten in memory.
+func (db *MemDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
 func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
 
 func (db *MemDatabase) Len() int { return len(db.db) }
 
+// memIterator iterates over a sorted snapshot of a memory database.
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index+1 >= len(it.keys) {
+		it.index = len(it.keys)
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *memIterator) Error() error { return nil }
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return common.CopyBytes(it.values[it.index])
+}
+
+func (it *memIterator) Release() { it.keys, it.values = nil, nil }
+
+func (it *memIterator) Len() int           { return len(it.keys) }
+func (it *memIterator) Less(i, j int) bool { return it.keys[i] < it.keys[j] }
+func (it *memIterator) Swap(i, j int) {
+	it.keys[i], it.keys[j] = it.keys[j], it.keys[i]
+	it.values[i], it.values[j] = it.values[j], it.values[i]
+}
+
 type kv struct {
 	k, v []byte
 	del  bool
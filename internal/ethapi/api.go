@@ -29,6 +29,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/tangerine-network/go-tangerine/accounts"
+	"github.com/tangerine-network/go-tangerine/accounts/abi"
 	"github.com/tangerine-network/go-tangerine/accounts/keystore"
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/common/hexutil"
@@ -704,6 +705,9 @@ func (s *PublicBlockChainAPI) GetBlockReceiptsByHash(ctx context.Context, blockH
 		if receipt.ContractAddress != (common.Address{}) {
 			fields["contractAddress"] = receipt.ContractAddress
 		}
+		if receipt.RevertReason != "" {
+			fields["revertReason"] = receipt.RevertReason
+		}
 		resp = append(resp, fields)
 	}
 	return resp, nil
@@ -785,10 +789,44 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	return res, gas, failed, err
 }
 
+// revertError is an API error reporting a reverted call along with the revert
+// data returned by it.
+type revertError struct {
+	error
+	data string // Hex encoded revert data
+}
+
+// newRevertError creates a revertError from the revert data of a call, decoding
+// its reason if it has one.
+func newRevertError(data []byte) *revertError {
+	err := errors.New("execution reverted")
+	if reason, errUnpack := abi.UnpackRevert(data); errUnpack == nil {
+		err = fmt.Errorf("execution reverted: %v", reason)
+	}
+	return &revertError{
+		error: err,
+		data:  hexutil.Encode(data),
+	}
+}
+
+// ErrorCode returns the JSON error code of a reverted call.
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+// ErrorData returns the hex encoded revert data.
+func (e *revertError) ErrorData() interface{} {
+	return e.data
+}
+
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, 5*time.Second, s.b.RPCGasCap())
+	result, _, failed, err := s.doCall(ctx, args, blockNr, 5*time.Second, s.b.RPCGasCap())
+	// If the call reverted with data, report the reason as an error
+	if err == nil && failed && len(result) > 0 {
+		return nil, newRevertError(result)
+	}
 	return (hexutil.Bytes)(result), err
 }
 
@@ -818,20 +856,24 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	}
 	cap = hi
 
-	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	// Create a helper to check if a gas allowance results in an executable transaction,
+	// along with the revert data of a failed execution.
+	executable := func(gas uint64) (bool, []byte) {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, 0, gasCap)
-		if err != nil || failed {
-			return false
+		res, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, 0, gasCap)
+		if err != nil {
+			return false, nil
+		}
+		if failed {
+			return false, res
 		}
-		return true
+		return true, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		if ok, _ := executable(mid); !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -839,7 +881,10 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		if ok, ret := executable(hi); !ok {
+			if len(ret) > 0 {
+				return 0, newRevertError(ret)
+			}
 			return 0, fmt.Errorf("gas required exceeds allowance (%d) or always failing transaction", cap)
 		}
 	}
@@ -850,10 +895,11 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
 type ExecutionResult struct {
-	Gas         uint64         `json:"gas"`
-	Failed      bool           `json:"failed"`
-	ReturnValue string         `json:"returnValue"`
-	StructLogs  []StructLogRes `json:"structLogs"`
+	Gas         uint64          `json:"gas"`
+	Failed      bool            `json:"failed"`
+	ReturnValue string          `json:"returnValue"`
+	StructLogs  []StructLogRes  `json:"structLogs"`
+	OracleCalls []vm.OracleCall `json:"oracleCalls,omitempty"`
 }
 
 // StructLogRes stores a structured log emitted by the EVM while replaying a
@@ -1217,6 +1263,9 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	if receipt.RevertReason != "" {
+		fields["revertReason"] = receipt.RevertReason
+	}
 	return fields, nil
 }
 
@@ -166,14 +166,26 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
-			params: 1,
-			inputFormatter: [null]
+			params: 2,
+			inputFormatter: [null, null]
 		}),
 		new web3._extend.Method({
 			name: 'importChain',
 			call: 'admin_importChain',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'txPoolEvict',
+			call: 'admin_txPoolEvict',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'txPoolEvictSender',
+			call: 'admin_txPoolEvictSender',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -233,6 +245,14 @@ web3._extend({
 			name: 'notaryInfo',
 			getter: 'admin_notaryInfo'
 		}),
+		new web3._extend.Property({
+			name: 'txPoolQueue',
+			getter: 'admin_txPoolQueue'
+		}),
+		new web3._extend.Property({
+			name: 'txPoolSlots',
+			getter: 'admin_txPoolSlots'
+		}),
 	]
 });
 `
@@ -463,6 +483,54 @@ web3._extend({
 			params: 2,
 			inputFormatter:[null, null],
 		}),
+		new web3._extend.Method({
+			name: 'blockNumberByRound',
+			call: 'debug_blockNumberByRound',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'dumpRound',
+			call: 'debug_dumpRound',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getBalanceByRound',
+			call: 'debug_getBalanceByRound',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null],
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Method({
+			name: 'getCodeByRound',
+			call: 'debug_getCodeByRound',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getStorageAtByRound',
+			call: 'debug_getStorageAtByRound',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.toHex, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'callByRound',
+			call: 'debug_callByRound',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'stateDiff',
+			call: 'debug_stateDiff',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'roundStateDiff',
+			call: 'debug_roundStateDiff',
+			params: 1
+		}),
 	],
 	properties: []
 });
@@ -520,6 +588,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getRoundRange',
+			call: 'eth_getRoundRange',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
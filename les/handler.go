@@ -33,6 +33,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/eth/downloader"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/event"
@@ -59,6 +60,7 @@ const (
 	MaxHelperTrieProofsFetch = 64  // Amount of merkle proofs to be fetched per retrieval request
 	MaxTxSend                = 64  // Amount of transactions to be send per request
 	MaxTxStatus              = 256 // Amount of transactions to queried per request
+	MaxGovStateFetch         = 4   // Amount of governance states to be fetched per retrieval request
 
 	disableClientRemovePeer = false
 )
@@ -328,9 +330,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 }
 
 var (
-	reqList   = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+	reqList   = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetGovStateMsg}
 	reqListV1 = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, GetHeaderProofsMsg}
-	reqListV2 = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, SendTxV2Msg, GetTxStatusMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+	reqListV2 = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, SendTxV2Msg, GetTxStatusMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetGovStateMsg}
 )
 
 // handleMsg is invoked whenever an inbound message is received from a remote
@@ -1107,6 +1109,66 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
 
+	case GetGovStateMsg:
+		p.Log().Trace("Received gov state request")
+		// Decode the retrieval message
+		var req struct {
+			ReqID  uint64
+			Hashes []common.Hash
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqCnt := len(req.Hashes)
+		if reject(uint64(reqCnt), MaxGovStateFetch) {
+			return errResp(ErrRequestRejected, "")
+		}
+		// Gather the governance states of the requested blocks, proving the
+		// governance contract account against their state roots
+		var govStates []*types.GovState
+		for _, hash := range req.Hashes {
+			header := pm.blockchain.GetHeaderByHash(hash)
+			if header == nil {
+				continue
+			}
+			statedb, err := pm.blockchain.State()
+			if err != nil {
+				continue
+			}
+			statedb, err = state.New(header.Root, statedb.Database())
+			if err != nil {
+				continue
+			}
+			govState, err := state.GetGovState(statedb, header, vm.GovernanceContractAddress)
+			if err != nil {
+				continue
+			}
+			govStates = append(govStates, govState)
+		}
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		return p.SendGovStates(req.ReqID, bv, govStates)
+
+	case GovStateMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received gov state response")
+		var resp struct {
+			ReqID, BV uint64
+			GovStates []*types.GovState
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgGovState,
+			ReqID:   resp.ReqID,
+			Obj:     resp.GovStates,
+		}
+
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
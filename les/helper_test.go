@@ -32,7 +32,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/core/types"
 	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/crypto"
-	"github.com/tangerine-network/go-tangerine/eth"
+	"github.com/tangerine-network/go-tangerine/dex"
 	"github.com/tangerine-network/go-tangerine/ethdb"
 	"github.com/tangerine-network/go-tangerine/event"
 	"github.com/tangerine-network/go-tangerine/les/flowcontrol"
@@ -127,7 +127,7 @@ func testChainGen(i int, block *core.BlockGen) {
 // testIndexers creates a set of indexers with specified params for testing purpose.
 func testIndexers(db ethdb.Database, odr light.OdrBackend, iConfig *light.IndexerConfig) (*core.ChainIndexer, *core.ChainIndexer, *core.ChainIndexer) {
 	chtIndexer := light.NewChtIndexer(db, odr, iConfig.ChtSize, iConfig.ChtConfirms)
-	bloomIndexer := eth.NewBloomIndexer(db, iConfig.BloomSize, iConfig.BloomConfirms)
+	bloomIndexer := dex.NewBloomIndexer(db, iConfig.BloomSize, iConfig.BloomConfirms)
 	bloomTrieIndexer := light.NewBloomTrieIndexer(db, odr, iConfig.BloomSize, iConfig.BloomTrieSize)
 	bloomIndexer.AddChildIndexer(bloomTrieIndexer)
 	return chtIndexer, bloomIndexer, bloomTrieIndexer
@@ -152,7 +152,13 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 		engine = ethash.NewFaker()
 		gspec  = core.Genesis{
 			Config: params.TestChainConfig,
-			Alloc:  core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+			Alloc: core.GenesisAlloc{
+				testBankAddress: {Balance: testBankFunds},
+				vm.GovernanceContractAddress: {
+					Balance: big.NewInt(1),
+					Storage: map[common.Hash]common.Hash{{0x01}: {0x02}},
+				},
+			},
 		}
 		genesis = gspec.MustCommit(db)
 		chain   BlockChain
@@ -68,6 +68,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
 		return (*BloomRequest)(r)
+	case *light.GovStateRequest:
+		return (*GovStateRequest)(r)
 	default:
 		return nil
 	}
@@ -309,6 +311,48 @@ func (r *CodeRequest) Validate(db ethdb.Database, msg *Msg) error {
 	return nil
 }
 
+// ODR request type for proven governance states, see LesOdrRequest interface
+type GovStateRequest light.GovStateRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *GovStateRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetGovStateMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *GovStateRequest) CanSend(peer *peer) bool {
+	return peer.version >= lpv2 && peer.HasBlock(r.Header.Hash(), r.Header.Number.Uint64(), true)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *GovStateRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting gov state", "number", r.Header.Number, "hash", r.Header.Hash())
+	return peer.RequestGovStates(reqID, r.GetCost(peer), []common.Hash{r.Header.Hash()})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *GovStateRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating gov state", "number", r.Header.Number, "hash", r.Header.Hash())
+
+	// Ensure we have a correct message with a single gov state
+	if msg.MsgType != MsgGovState {
+		return errInvalidMessageType
+	}
+	reply := msg.Obj.([]*types.GovState)
+	if len(reply) != 1 {
+		return errInvalidEntryCount
+	}
+	// Verify the gov state against the header and store if checks out
+	if err := light.VerifyGovState(r.Header, reply[0]); err != nil {
+		return err
+	}
+	r.GovState = reply[0]
+	return nil
+}
+
 const (
 	// helper trie type constants
 	htCanonical = iota // Canonical hash trie
@@ -26,7 +26,7 @@ import (
 
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core/types"
-	"github.com/tangerine-network/go-tangerine/eth"
+	"github.com/tangerine-network/go-tangerine/dex"
 	"github.com/tangerine-network/go-tangerine/les/flowcontrol"
 	"github.com/tangerine-network/go-tangerine/light"
 	"github.com/tangerine-network/go-tangerine/p2p"
@@ -103,11 +103,11 @@ func (p *peer) queueSend(f func()) {
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
-func (p *peer) Info() *eth.PeerInfo {
-	return &eth.PeerInfo{
-		Version:    p.version,
-		Difficulty: p.Td(),
-		Head:       fmt.Sprintf("%x", p.Head()),
+func (p *peer) Info() *dex.PeerInfo {
+	return &dex.PeerInfo{
+		Version: p.version,
+		Number:  p.headBlockInfo().Number,
+		Head:    fmt.Sprintf("%x", p.Head()),
 	}
 }
 
@@ -246,6 +246,12 @@ func (p *peer) SendReceiptsRLP(reqID, bv uint64, receipts []rlp.RawValue) error
 	return sendResponse(p.rw, ReceiptsMsg, reqID, bv, receipts)
 }
 
+// SendGovStates sends a batch of proven governance states, corresponding to
+// the blocks requested.
+func (p *peer) SendGovStates(reqID, bv uint64, govStates []*types.GovState) error {
+	return sendResponse(p.rw, GovStateMsg, reqID, bv, govStates)
+}
+
 // SendProofs sends a batch of legacy LES/1 merkle proofs, corresponding to the ones requested.
 func (p *peer) SendProofs(reqID, bv uint64, proofs proofsData) error {
 	return sendResponse(p.rw, ProofsV1Msg, reqID, bv, proofs)
@@ -305,6 +311,13 @@ func (p *peer) RequestReceipts(reqID, cost uint64, hashes []common.Hash) error {
 	return sendRequest(p.rw, GetReceiptsMsg, reqID, cost, hashes)
 }
 
+// RequestGovStates fetches the proven governance states of a batch of blocks
+// from a remote node.
+func (p *peer) RequestGovStates(reqID, cost uint64, hashes []common.Hash) error {
+	p.Log().Debug("Fetching batch of gov states", "count", len(hashes))
+	return sendRequest(p.rw, GetGovStateMsg, reqID, cost, hashes)
+}
+
 // RequestProofs fetches a batch of merkle proofs from a remote node.
 func (p *peer) RequestProofs(reqID, cost uint64, reqs []ProofReq) error {
 	p.Log().Debug("Fetching batch of proofs", "count", len(reqs))
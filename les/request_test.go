@@ -81,6 +81,15 @@ func tfCodeAccess(db ethdb.Database, bhash common.Hash, num uint64) light.OdrReq
 	return &light.CodeRequest{Id: ci, Hash: crypto.Keccak256Hash(testContractCodeDeployed)}
 }
 
+func TestGovStateAccessLes2(t *testing.T) { testAccess(t, 2, tfGovStateAccess) }
+
+func tfGovStateAccess(db ethdb.Database, bhash common.Hash, number uint64) light.OdrRequest {
+	if number := rawdb.ReadHeaderNumber(db, bhash); number != nil {
+		return &light.GovStateRequest{Header: rawdb.ReadHeader(db, bhash, *number)}
+	}
+	return nil
+}
+
 func testAccess(t *testing.T, protocol int, fn accessTestFn) {
 	// Assemble the test environment
 	server, client, tearDown := newClientServerEnv(t, 4, protocol, nil, true)
@@ -0,0 +1,168 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/rlp"
+	"github.com/tangerine-network/go-tangerine/trie"
+	coreCrypto "github.com/tangerine-network/tangerine-consensus/core/crypto"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+	coreUtils "github.com/tangerine-network/tangerine-consensus/core/utils"
+)
+
+var (
+	errDKGNotReady        = errors.New("DKG of round not finished")
+	errInvalidRandomness  = errors.New("invalid block randomness")
+	errWitnessMismatch    = errors.New("witness mismatch")
+	errDexconMetaMismatch = errors.New("header does not match dexcon meta")
+	errGovStateMismatch   = errors.New("gov state does not match header")
+)
+
+// governanceStateDB serves the governance contract state of the light chain.
+// The governance state of a header is retrieved on demand through ODR, proven
+// against the state root of the header, and then read from the local database.
+// It implements core.GovernanceStateDB.
+type governanceStateDB struct {
+	ctx   context.Context
+	chain *LightChain
+}
+
+func (g *governanceStateDB) State() (*state.StateDB, error) {
+	return g.stateOf(g.chain.CurrentHeader())
+}
+
+func (g *governanceStateDB) StateAt(height uint64) (*state.StateDB, error) {
+	header := g.chain.GetHeaderByNumber(height)
+	if header == nil {
+		return nil, fmt.Errorf("header at %d not exists", height)
+	}
+	return g.stateOf(header)
+}
+
+// stateOf retrieves the proven governance state of the header if not yet
+// available locally and returns the state of the header.
+func (g *governanceStateDB) stateOf(header *types.Header) (*state.StateDB, error) {
+	if !g.hasGovState(header) {
+		if _, err := GetGovState(g.ctx, g.chain.odr, header); err != nil {
+			return nil, err
+		}
+	}
+	return NewState(g.ctx, header, g.chain.odr), nil
+}
+
+// hasGovState reports whether the governance contract account of the header
+// and its storage root are available locally. Nodes are stored by hash, so any
+// node found is authentic, and missing storage nodes are still retrieved with
+// proofs by the ODR state.
+func (g *governanceStateDB) hasGovState(header *types.Header) bool {
+	db := g.chain.odr.Database()
+	tr, err := trie.New(header.Root, trie.NewDatabase(db))
+	if err != nil {
+		return false
+	}
+	blob, err := tr.TryGet(crypto.Keccak256(vm.GovernanceContractAddress[:]))
+	if err != nil || len(blob) == 0 {
+		return false
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return false
+	}
+	ok, _ := db.Has(account.Root[:])
+	return ok
+}
+
+// insertTangerineHeaderChain inserts the headers round by round: the headers
+// of a round can only be verified once the governance state finalizing its DKG
+// is part of the local chain.
+func (self *LightChain) insertTangerineHeaderChain(chain []*types.Header, checkFreq int) (int, error) {
+	for start := 0; start < len(chain); {
+		end := start + 1
+		for end < len(chain) && chain[end].Round == chain[start].Round {
+			end++
+		}
+		if i, err := self.insertHeaderChain(chain[start:end], checkFreq); err != nil {
+			return start + i, err
+		}
+		start = end
+	}
+	return 0, nil
+}
+
+// verifyTangerineHeaders checks a contiguous batch of headers against their
+// dexcon meta: the randomness must be a threshold signature of the notary set
+// of the round over the core block hash, and the witnessed blocks must be part
+// of the chain.
+func (self *LightChain) verifyTangerineHeaders(chain []*types.Header) (int, error) {
+	for i, header := range chain {
+		var coreBlock coreTypes.Block
+		if err := rlp.DecodeBytes(header.DexconMeta, &coreBlock); err != nil {
+			return i, err
+		}
+		hash, err := coreUtils.HashBlock(&coreBlock)
+		if err != nil {
+			return i, err
+		}
+		if hash != coreBlock.Hash ||
+			header.Number.Uint64() != coreBlock.Position.Height ||
+			header.Round != coreBlock.Position.Round ||
+			header.Time != uint64(coreBlock.Timestamp.UnixNano()/1000000) ||
+			!bytes.Equal(header.Randomness, coreBlock.Randomness) {
+			return i, errDexconMetaMismatch
+		}
+		if header.Round > 0 {
+			v, ok, err := self.verifierCache.UpdateAndGet(header.Round)
+			if err != nil {
+				return i, err
+			}
+			if !ok {
+				return i, errDKGNotReady
+			}
+			if !v.VerifySignature(coreBlock.Hash, coreCrypto.Signature{
+				Type:      "bls",
+				Signature: header.Randomness}) {
+				return i, errInvalidRandomness
+			}
+		}
+		if coreBlock.IsEmpty() {
+			continue
+		}
+		var witness common.Hash
+		if err := rlp.DecodeBytes(coreBlock.Witness.Data, &witness); err != nil {
+			return i, err
+		}
+		index := int64(coreBlock.Witness.Height) - int64(chain[0].Number.Uint64())
+		if index >= 0 {
+			if index >= int64(i) || chain[index].Hash() != witness {
+				return i, errWitnessMismatch
+			}
+		} else if h := self.GetHeaderByNumber(coreBlock.Witness.Height); h == nil || h.Hash() != witness {
+			return i, errWitnessMismatch
+		}
+	}
+	return 0, nil
+}
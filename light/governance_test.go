@@ -0,0 +1,264 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	coreCommon "github.com/tangerine-network/tangerine-consensus/common"
+	dexCore "github.com/tangerine-network/tangerine-consensus/core"
+	coreTypes "github.com/tangerine-network/tangerine-consensus/core/types"
+	coreUtils "github.com/tangerine-network/tangerine-consensus/core/utils"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/consensus"
+	"github.com/tangerine-network/go-tangerine/consensus/dexcon"
+	"github.com/tangerine-network/go-tangerine/core"
+	"github.com/tangerine-network/go-tangerine/core/state"
+	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
+	"github.com/tangerine-network/go-tangerine/crypto"
+	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/params"
+	"github.com/tangerine-network/go-tangerine/rlp"
+)
+
+// dexconTestEngine is a fake dexcon engine producing headers which pass the
+// light client verification: the dexcon meta of a proposed block matches the
+// header and its randomness is signed by the notary set of the round.
+type dexconTestEngine struct {
+	*dexcon.FakeDexcon
+	nodes *dexcon.NodeSet
+}
+
+func (e *dexconTestEngine) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	var coreBlock coreTypes.Block
+	if err := rlp.DecodeBytes(header.DexconMeta, &coreBlock); err != nil {
+		return err
+	}
+	// Proposed blocks carry witnesses to be verified, unlike empty ones. The
+	// proposer itself is not checked by light clients.
+	coreBlock.ProposerID = coreTypes.NodeID{Hash: coreCommon.Hash{0x01}}
+	coreBlock.Timestamp = time.Unix(0, int64(header.Time)*int64(time.Millisecond))
+	hash, err := coreUtils.HashBlock(&coreBlock)
+	if err != nil {
+		return err
+	}
+	coreBlock.Hash = hash
+	coreBlock.Randomness = e.nodes.Randomness(header.Round, common.Hash(hash))
+	header.Randomness = coreBlock.Randomness
+
+	header.DexconMeta, err = rlp.EncodeToBytes(&coreBlock)
+	return err
+}
+
+// dexconTestFetcher serves the genesis governance state to the engine.
+type dexconTestFetcher struct {
+	db   state.Database
+	root common.Hash
+}
+
+func (f *dexconTestFetcher) GetConfigState(round uint64) (*vm.GovernanceState, error) {
+	s, err := state.New(f.root, f.db)
+	if err != nil {
+		return nil, err
+	}
+	return &vm.GovernanceState{StateDB: s}, nil
+}
+
+func (f *dexconTestFetcher) DKGSetNodeKeyAddresses(round uint64) (map[common.Address]struct{}, error) {
+	return make(map[common.Address]struct{}), nil
+}
+
+const dexconTestRoundLength = 10
+
+// newDexconTestChain creates a Tangerine chain of the given length into sdb,
+// running the DKG of every next round within the current one so that the
+// headers of all rounds carry valid randomness. The genesis is committed into
+// ldb as well.
+func newDexconTestChain(t *testing.T, sdb, ldb ethdb.Database, n int) (*core.Genesis, []*types.Header, *dexcon.NodeSet) {
+	var (
+		keys  []*ecdsa.PrivateKey
+		ether = big.NewInt(1e18)
+		gspec = &core.Genesis{Config: params.TestnetChainConfig, Alloc: core.GenesisAlloc{}}
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		gspec.Alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{
+			Balance:   new(big.Int).Mul(big.NewInt(2e6), ether),
+			Staked:    new(big.Int).Mul(big.NewInt(1e6), ether),
+			PublicKey: crypto.FromECDSAPub(&key.PublicKey),
+		}
+	}
+	genesis := gspec.MustCommit(sdb)
+	gspec.MustCommit(ldb)
+
+	signer := types.NewEIP155Signer(gspec.Config.ChainID)
+	nodes := dexcon.NewNodeSet(0, []byte(gspec.Config.Dexcon.GenesisCRSText), signer, keys)
+	engine := &dexconTestEngine{FakeDexcon: dexcon.NewFaker(nodes), nodes: nodes}
+	engine.SetGovStateFetcher(&dexconTestFetcher{db: state.NewDatabase(sdb), root: genesis.Root()})
+
+	round := uint64(0)
+	addTx := func(b *core.TangerineBlockGen, node *dexcon.Node, data []byte, err error) {
+		if err != nil {
+			t.Fatalf("failed to pack governance call: %v", err)
+		}
+		b.AddTx(node.CreateGovTx(b.TxNonce(node.Address()), data))
+	}
+	blocks, _ := core.GenerateTangerineChain(gspec.Config, genesis, engine, sdb, n, func(i int, b *core.TangerineBlockGen) {
+		b.SetPosition(coreTypes.Position{Round: round, Height: uint64(i + 1)})
+
+		half := dexconTestRoundLength / 2
+		switch i % dexconTestRoundLength {
+		case half:
+			// The CRS of the rounds up to the DKG delay is derived, not proposed
+			nodes.SignCRS(round)
+			if round >= dexCore.DKGDelayRound {
+				data, err := vm.PackProposeCRS(round+1, nodes.SignedCRS(round+1))
+				addTx(b, nodes.Nodes(round)[0], data, err)
+			}
+		case half + 1:
+			nodes.RunDKG(round+1, 2)
+			for _, node := range nodes.Nodes(round + 1) {
+				data, err := vm.PackAddDKGMasterPublicKey(node.MasterPublicKey(round + 1))
+				addTx(b, node, data, err)
+			}
+		case half + 2:
+			for _, node := range nodes.Nodes(round + 1) {
+				data, err := vm.PackAddDKGMPKReady(node.DKGMPKReady(round + 1))
+				addTx(b, node, data, err)
+			}
+		case half + 3:
+			for _, node := range nodes.Nodes(round + 1) {
+				data, err := vm.PackAddDKGFinalize(node.DKGFinalize(round + 1))
+				addTx(b, node, data, err)
+			}
+		case dexconTestRoundLength - 1:
+			round++
+		}
+	})
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	return gspec, headers, nodes
+}
+
+// forgeHeader re-encodes the dexcon meta of a copy of the header after
+// modifying its core block, setting the randomness to the signature returned
+// by sign for the new core block hash.
+func forgeHeader(t *testing.T, header *types.Header, modify func(*coreTypes.Block), sign func(common.Hash) []byte) *types.Header {
+	header = types.CopyHeader(header)
+
+	var coreBlock coreTypes.Block
+	if err := rlp.DecodeBytes(header.DexconMeta, &coreBlock); err != nil {
+		t.Fatalf("failed to decode dexcon meta: %v", err)
+	}
+	modify(&coreBlock)
+	hash, err := coreUtils.HashBlock(&coreBlock)
+	if err != nil {
+		t.Fatalf("failed to hash core block: %v", err)
+	}
+	coreBlock.Hash = hash
+	coreBlock.Randomness = sign(common.Hash(hash))
+	header.Randomness = coreBlock.Randomness
+
+	if header.DexconMeta, err = rlp.EncodeToBytes(&coreBlock); err != nil {
+		t.Fatalf("failed to encode dexcon meta: %v", err)
+	}
+	return header
+}
+
+// Tests that light headers are verified against the notary set of their round:
+// a valid chain is inserted, while headers with randomness not signed by the
+// notary set or witnessing blocks outside the chain are rejected.
+func TestTangerineHeaderVerification(t *testing.T) {
+	var (
+		sdb = ethdb.NewMemDatabase()
+		ldb = ethdb.NewMemDatabase()
+	)
+	gspec, headers, nodes := newDexconTestChain(t, sdb, ldb, 25)
+
+	// A header of round 1 beyond the first one, so its witness is in the chain
+	target := 2*dexconTestRoundLength - 5
+	if headers[target].Round != 1 {
+		t.Fatalf("target header in round %d, want 1", headers[target].Round)
+	}
+	resign := func(hash common.Hash) []byte {
+		return nodes.Randomness(1, hash)
+	}
+	tests := []struct {
+		name   string
+		header *types.Header
+		err    error
+	}{
+		{"valid", headers[target], nil},
+		{"bad randomness", forgeHeader(t, headers[target], func(*coreTypes.Block) {}, func(common.Hash) []byte {
+			return nodes.Randomness(1, common.Hash{0x01})
+		}), errInvalidRandomness},
+		{"bad witness", forgeHeader(t, headers[target], func(b *coreTypes.Block) {
+			b.Witness.Data, _ = rlp.EncodeToBytes(common.Hash{0x01})
+		}, resign), errWitnessMismatch},
+		{"witness ahead", forgeHeader(t, headers[target], func(b *coreTypes.Block) {
+			b.Witness.Height = uint64(target + 1)
+			b.Witness.Data, _ = rlp.EncodeToBytes(headers[target].Hash())
+		}, resign), errWitnessMismatch},
+	}
+	for _, tt := range tests {
+		odr := &testOdr{sdb: sdb, ldb: ethdb.NewMemDatabase(), indexerConfig: TestClientIndexerConfig}
+		gspec.MustCommit(odr.ldb)
+
+		lightchain, err := NewLightChain(odr, gspec.Config, dexcon.New())
+		if err != nil {
+			t.Fatalf("%s: failed to create light chain: %v", tt.name, err)
+		}
+		chain := append(append([]*types.Header{}, headers[:target]...), tt.header)
+		n, err := lightchain.InsertHeaderChain(chain, 1)
+		if err != tt.err {
+			t.Errorf("%s: insert error mismatch: have %v, want %v", tt.name, err, tt.err)
+		} else if err != nil && n != target {
+			t.Errorf("%s: failed header index mismatch: have %d, want %d", tt.name, n, target)
+		}
+		head := lightchain.CurrentHeader()
+		if want := chain[len(chain)-1]; err == nil && head.Hash() != want.Hash() {
+			t.Errorf("%s: head mismatch: have #%d, want #%d", tt.name, head.Number, want.Number)
+		}
+		if err != nil && head.Number.Uint64() >= uint64(target+1) {
+			t.Errorf("%s: rejected header inserted", tt.name)
+		}
+		lightchain.Stop()
+	}
+	// The whole chain, spanning rounds verified against different notary sets,
+	// inserts too
+	odr := &testOdr{sdb: sdb, ldb: ldb, indexerConfig: TestClientIndexerConfig}
+	lightchain, err := NewLightChain(odr, gspec.Config, dexcon.New())
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	defer lightchain.Stop()
+
+	if n, err := lightchain.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header #%d: %v", headers[n].Number, err)
+	}
+	if head := lightchain.CurrentHeader(); head.Hash() != headers[len(headers)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", head.Number, len(headers))
+	}
+}
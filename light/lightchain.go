@@ -36,6 +36,7 @@ import (
 	"github.com/tangerine-network/go-tangerine/log"
 	"github.com/tangerine-network/go-tangerine/params"
 	"github.com/tangerine-network/go-tangerine/rlp"
+	dexCore "github.com/tangerine-network/tangerine-consensus/core"
 )
 
 var (
@@ -71,6 +72,12 @@ type LightChain struct {
 	wg            sync.WaitGroup
 
 	engine consensus.Engine
+
+	// Tangerine header verification, tracking governance through state proofs
+	config        *params.ChainConfig
+	gov           *core.Governance
+	verifierCache *dexCore.TSigVerifierCache
+	govCancel     context.CancelFunc
 }
 
 // NewLightChain returns a fully initialised light chain using information
@@ -90,6 +97,7 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 		bodyRLPCache:  bodyRLPCache,
 		blockCache:    blockCache,
 		engine:        engine,
+		config:        config,
 	}
 	var err error
 	bc.hc, err = core.NewHeaderChain(odr.Database(), config, bc.engine, bc.getProcInterrupt)
@@ -106,6 +114,12 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	if config.Dexcon != nil {
+		var ctx context.Context
+		ctx, bc.govCancel = context.WithCancel(context.Background())
+		bc.gov = core.NewGovernance(&governanceStateDB{ctx: ctx, chain: bc})
+		bc.verifierCache = dexCore.NewTSigVerifierCache(bc.gov, 5)
+	}
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range core.BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -307,6 +321,9 @@ func (bc *LightChain) Stop() {
 	}
 	close(bc.quit)
 	atomic.StoreInt32(&bc.procInterrupt, 1)
+	if bc.govCancel != nil {
+		bc.govCancel()
+	}
 
 	bc.wg.Wait()
 	log.Info("Blockchain manager stopped")
@@ -355,10 +372,24 @@ func (self *LightChain) postChainEvents(events []interface{}) {
 // In the case of a light chain, InsertHeaderChain also creates and posts light
 // chain events when necessary.
 func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int) (int, error) {
+	if self.gov != nil {
+		return self.insertTangerineHeaderChain(chain, checkFreq)
+	}
+	return self.insertHeaderChain(chain, checkFreq)
+}
+
+// insertHeaderChain validates and inserts a batch of headers, verifying them
+// against the governance on Tangerine chains.
+func (self *LightChain) insertHeaderChain(chain []*types.Header, checkFreq int) (int, error) {
 	start := time.Now()
 	if i, err := self.hc.ValidateHeaderChain(chain, checkFreq); err != nil {
 		return i, err
 	}
+	if self.gov != nil {
+		if i, err := self.verifyTangerineHeaders(chain); err != nil {
+			return i, err
+		}
+	}
 
 	// Make sure only one thread manipulates the chain at once
 	self.chainmu.Lock()
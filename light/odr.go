@@ -27,7 +27,9 @@ import (
 	"github.com/tangerine-network/go-tangerine/core"
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/crypto"
 	"github.com/tangerine-network/go-tangerine/ethdb"
+	"github.com/tangerine-network/go-tangerine/trie"
 )
 
 // NoOdr is the default context passed to an ODR capable function when the ODR
@@ -134,6 +136,28 @@ func (req *ReceiptsRequest) StoreResult(db ethdb.Database) {
 	rawdb.WriteReceipts(db, req.Hash, req.Number, req.Receipts)
 }
 
+// GovStateRequest is the ODR request type for retrieving the governance
+// contract state of a block, proven against the state root of its header
+type GovStateRequest struct {
+	OdrRequest
+	Header   *types.Header
+	GovState *types.GovState
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *GovStateRequest) StoreResult(db ethdb.Database) {
+	for _, node := range req.GovState.Proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	triedb := trie.NewDatabase(db)
+	t, _ := trie.New(common.Hash{}, triedb)
+	for _, kv := range req.GovState.Storage {
+		t.TryUpdate(kv[0], kv[1])
+	}
+	root, _ := t.Commit(nil)
+	triedb.Commit(root, false)
+}
+
 // ChtRequest is the ODR request type for state/storage trie entries
 type ChtRequest struct {
 	OdrRequest
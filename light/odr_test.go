@@ -51,6 +51,8 @@ var (
 
 	testContractCode = common.Hex2Bytes("606060405260cc8060106000396000f360606040526000357c01000000000000000000000000000000000000000000000000000000009004806360cd2685146041578063c16431b914606b57603f565b005b6055600480803590602001909190505060a9565b6040518082815260200191505060405180910390f35b60886004808035906020019091908035906020019091905050608a565b005b80600060005083606481101560025790900160005b50819055505b5050565b6000600060005082606481101560025790900160005b5054905060c7565b91905056")
 	testContractAddr common.Address
+
+	testGovKey = common.HexToHash("0x01")
 )
 
 type testOdr struct {
@@ -58,6 +60,7 @@ type testOdr struct {
 	indexerConfig *IndexerConfig
 	sdb, ldb      ethdb.Database
 	disable       bool
+	tamper        bool // Whether to serve governance states not matching the proof
 }
 
 func (odr *testOdr) Database() ethdb.Database {
@@ -88,6 +91,20 @@ func (odr *testOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 		req.Proof = nodes
 	case *CodeRequest:
 		req.Data, _ = odr.sdb.Get(req.Hash[:])
+	case *GovStateRequest:
+		st, err := state.New(req.Header.Root, state.NewDatabase(odr.sdb))
+		if err != nil {
+			return err
+		}
+		if req.GovState, err = state.GetGovState(st, req.Header, vm.GovernanceContractAddress); err != nil {
+			return err
+		}
+		if odr.tamper {
+			req.GovState.Storage = append(req.GovState.Storage, [2][]byte{common.Hash{0xff}.Bytes(), {0x01}})
+		}
+		if err := VerifyGovState(req.Header, req.GovState); err != nil {
+			return err
+		}
 	}
 	req.StoreResult(odr.ldb)
 	return nil
@@ -207,6 +224,76 @@ func odrContractCall(ctx context.Context, db ethdb.Database, bc *core.BlockChain
 	return res, nil
 }
 
+func TestOdrGovStateLes1(t *testing.T) { testChainOdr(t, 1, odrGovState) }
+
+func odrGovState(ctx context.Context, db ethdb.Database, bc *core.BlockChain, lc *LightChain, bhash common.Hash) ([]byte, error) {
+	var value common.Hash
+	if bc == nil {
+		header := lc.GetHeaderByHash(bhash)
+		st, err := (&governanceStateDB{ctx: ctx, chain: lc}).StateAt(header.Number.Uint64())
+		if err != nil {
+			return nil, err
+		}
+		value = st.GetState(vm.GovernanceContractAddress, testGovKey)
+	} else {
+		header := bc.GetHeaderByHash(bhash)
+		st, _ := state.New(header.Root, state.NewDatabase(db))
+		value = st.GetState(vm.GovernanceContractAddress, testGovKey)
+	}
+	return value.Bytes(), nil
+}
+
+// Tests that governance states are only accepted if proven against the state
+// root of their header.
+func TestOdrGovStateVerification(t *testing.T) {
+	var (
+		sdb     = ethdb.NewMemDatabase()
+		ldb     = ethdb.NewMemDatabase()
+		gspec   = core.Genesis{Alloc: testGenesisAlloc()}
+		genesis = gspec.MustCommit(sdb)
+		header  = genesis.Header()
+	)
+	gspec.MustCommit(ldb)
+
+	odr := &testOdr{sdb: sdb, ldb: ldb, indexerConfig: TestClientIndexerConfig}
+	govState, err := GetGovState(NoOdr, odr, header)
+	if err != nil {
+		t.Fatalf("failed to retrieve gov state: %v", err)
+	}
+	if err := VerifyGovState(header, govState); err != nil {
+		t.Fatalf("valid gov state rejected: %v", err)
+	}
+	// Gov states of other headers must be rejected
+	other := types.CopyHeader(header)
+	other.Root = common.Hash{0x01}
+	if err := VerifyGovState(other, govState); err != errGovStateMismatch {
+		t.Fatalf("foreign gov state error mismatch: have %v, want %v", err, errGovStateMismatch)
+	}
+	// Storage not matching the proven storage root must be rejected
+	odr.tamper = true
+	if _, err := GetGovState(NoOdr, odr, header); err == nil {
+		t.Fatalf("tampered gov state accepted")
+	}
+	// A tampered account proof must be rejected too
+	tampered := *govState
+	tampered.Proof = [][]byte{govState.Proof[len(govState.Proof)-1]}
+	if err := VerifyGovState(header, &tampered); err == nil {
+		t.Fatalf("gov state with broken proof accepted")
+	}
+}
+
+// testGenesisAlloc returns the genesis allocation of the test chains, holding
+// the governance contract with some storage.
+func testGenesisAlloc() core.GenesisAlloc {
+	return core.GenesisAlloc{
+		testBankAddress: {Balance: testBankFunds},
+		vm.GovernanceContractAddress: {
+			Balance: big.NewInt(1),
+			Storage: map[common.Hash]common.Hash{testGovKey: common.HexToHash("0x1234")},
+		},
+	}
+}
+
 func testChainGen(i int, block *core.BlockGen) {
 	signer := types.HomesteadSigner{}
 	switch i {
@@ -252,7 +339,7 @@ func testChainOdr(t *testing.T, protocol int, fn odrTestFn) {
 	var (
 		sdb     = ethdb.NewMemDatabase()
 		ldb     = ethdb.NewMemDatabase()
-		gspec   = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		gspec   = core.Genesis{Alloc: testGenesisAlloc()}
 		genesis = gspec.MustCommit(sdb)
 	)
 	gspec.MustCommit(ldb)
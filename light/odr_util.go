@@ -23,7 +23,9 @@ import (
 	"github.com/tangerine-network/go-tangerine/common"
 	"github.com/tangerine-network/go-tangerine/core"
 	"github.com/tangerine-network/go-tangerine/core/rawdb"
+	"github.com/tangerine-network/go-tangerine/core/state"
 	"github.com/tangerine-network/go-tangerine/core/types"
+	"github.com/tangerine-network/go-tangerine/core/vm"
 	"github.com/tangerine-network/go-tangerine/crypto"
 	"github.com/tangerine-network/go-tangerine/rlp"
 )
@@ -124,6 +126,30 @@ func GetBlock(ctx context.Context, odr OdrBackend, hash common.Hash, number uint
 	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles), nil
 }
 
+// GetGovState retrieves the governance contract state of the given header,
+// proven against its state root, storing it locally so the governance can be
+// read without further retrievals.
+func GetGovState(ctx context.Context, odr OdrBackend, header *types.Header) (*types.GovState, error) {
+	r := &GovStateRequest{Header: header}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.GovState, nil
+}
+
+// VerifyGovState checks that a retrieved governance state belongs to the given
+// header and is proven against its state root.
+func VerifyGovState(header *types.Header, govState *types.GovState) error {
+	if govState == nil {
+		return errGovStateMismatch
+	}
+	if govState.BlockHash != header.Hash() || govState.Root != header.Root ||
+		govState.Number == nil || govState.Number.Cmp(header.Number) != 0 {
+		return errGovStateMismatch
+	}
+	return state.VerifyGovState(govState, vm.GovernanceContractAddress)
+}
+
 // GetBlockReceipts retrieves the receipts generated by the transactions included
 // in a block given by its hash.
 func GetBlockReceipts(ctx context.Context, odr OdrBackend, hash common.Hash, number uint64) (types.Receipts, error) {
@@ -25,8 +25,8 @@ import (
 	"path/filepath"
 
 	"github.com/tangerine-network/go-tangerine/core"
-	"github.com/tangerine-network/go-tangerine/eth"
-	"github.com/tangerine-network/go-tangerine/eth/downloader"
+	"github.com/tangerine-network/go-tangerine/dex"
+	"github.com/tangerine-network/go-tangerine/dex/downloader"
 	"github.com/tangerine-network/go-tangerine/ethclient"
 	"github.com/tangerine-network/go-tangerine/ethstats"
 	"github.com/tangerine-network/go-tangerine/internal/debug"
@@ -155,7 +155,7 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 	}
 	// Register the Ethereum protocol if requested
 	if config.EthereumEnabled {
-		ethConf := eth.DefaultConfig
+		ethConf := dex.DefaultConfig
 		ethConf.Genesis = genesis
 		ethConf.SyncMode = downloader.LightSync
 		ethConf.NetworkId = uint64(config.EthereumNetworkID)
@@ -68,6 +68,10 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value engine to create new databases with. Existing
+	// databases are always opened with the engine they were created with.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
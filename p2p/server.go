@@ -385,6 +385,25 @@ func (srv *Server) Self() *enode.Node {
 	return ln.Node()
 }
 
+// LocalNode returns the local node record.
+func (srv *Server) LocalNode() *enode.LocalNode {
+	return srv.localnode
+}
+
+// Resolve searches for a specific node with the given ID through the
+// discovery network. It returns nil if discovery is disabled or the node
+// could not be found.
+func (srv *Server) Resolve(n *enode.Node) *enode.Node {
+	srv.lock.Lock()
+	ntab := srv.ntab
+	srv.lock.Unlock()
+
+	if ntab == nil {
+		return nil
+	}
+	return ntab.Resolve(n)
+}
+
 func (srv *Server) makeSelf(listener net.Listener, ntab discoverTable) *enode.Node {
 	// If the node is running but discovery is off, manually assemble the node infos.
 	if ntab == nil {
@@ -0,0 +1,63 @@
+// Copyright 2019 The go-tangerine Authors
+// This file is part of the go-tangerine library.
+//
+// The go-tangerine library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-tangerine library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-tangerine library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"encoding/binary"
+
+	"github.com/tangerine-network/go-tangerine/common"
+	"github.com/tangerine-network/go-tangerine/common/hexutil"
+	"golang.org/x/crypto/sha3"
+)
+
+// GovernanceCheckpoints associates each known governance checkpoint with the
+// genesis hash of the chain it belongs to.
+var GovernanceCheckpoints = map[common.Hash]*GovernanceCheckpoint{}
+
+// GovernanceCheckpoint is a block header endorsed by the notary set of its
+// round. It is used to start header verification from the checkpoint instead
+// of reconstructing the governance state of every round since genesis: headers
+// below it are trusted by hash linkage, headers above it are verified against
+// the governance state proven by the checkpoint. The notary set endorsing the
+// checkpoint is derived from the governance states of the previous rounds, so
+// their state roots are part of the checkpoint as well.
+type GovernanceCheckpoint struct {
+	Round        uint64          `json:"round"`        // Round the checkpoint header starts
+	Number       uint64          `json:"number"`       // Height of the checkpoint header (round height)
+	Hash         common.Hash     `json:"hash"`         // Hash of the checkpoint header
+	GovStateRoot common.Hash     `json:"govStateRoot"` // State root the governance state is proven against
+	ConfigRoots  []common.Hash   `json:"configRoots"`  // State roots of the round heights of the (up to) three previous rounds
+	Signatures   []hexutil.Bytes `json:"signatures"`   // Notary set signatures over SigHash
+}
+
+// SigHash returns the hash the notary set members sign to endorse the
+// checkpoint, covering the header hash, the round height and the state roots.
+func (c *GovernanceCheckpoint) SigHash() common.Hash {
+	var number [8]byte
+	binary.BigEndian.PutUint64(number[:], c.Number)
+
+	var h common.Hash
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(c.Hash[:])
+	hasher.Write(number[:])
+	hasher.Write(c.GovStateRoot[:])
+	for _, root := range c.ConfigRoots {
+		hasher.Write(root[:])
+	}
+	hasher.Sum(h[:0])
+	return h
+}
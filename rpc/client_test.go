@@ -49,6 +49,25 @@ func TestClientRequest(t *testing.T) {
 	}
 }
 
+func TestClientErrorData(t *testing.T) {
+	server := newTestServer("service", new(ErrorService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var resp string
+	err := client.Call(&resp, "service_returnError")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if code := err.(Error).ErrorCode(); code != 3 {
+		t.Errorf("error code mismatch: have %d, want %d", code, 3)
+	}
+	if data := err.(DataError).ErrorData(); data != "0xdeadbeef" {
+		t.Errorf("error data mismatch: have %v, want %v", data, "0xdeadbeef")
+	}
+}
+
 func TestClientBatchRequest(t *testing.T) {
 	server := newTestServer("service", new(Service))
 	defer server.Stop()
@@ -313,8 +313,14 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+			var rpcErr Error = &callbackError{e.Error()}
+			if ec, ok := e.(Error); ok {
+				rpcErr = ec
+			}
+			if de, ok := e.(DataError); ok {
+				return codec.CreateErrorResponseWithInfo(&req.id, rpcErr, de.ErrorData()), nil
+			}
+			return codec.CreateErrorResponse(&req.id, rpcErr), nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
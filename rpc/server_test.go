@@ -59,6 +59,20 @@ func (s *Service) Rets() (string, error) {
 	return "", nil
 }
 
+// dataError is an error carrying a custom code and additional data.
+type dataError struct{}
+
+func (e *dataError) Error() string          { return "data error" }
+func (e *dataError) ErrorCode() int         { return 3 }
+func (e *dataError) ErrorData() interface{} { return "0xdeadbeef" }
+
+// ErrorService is a service whose methods fail with a dataError.
+type ErrorService struct{}
+
+func (s *ErrorService) ReturnError() (string, error) {
+	return "", &dataError{}
+}
+
 func (s *Service) InvalidRets1() (error, string) {
 	return nil, ""
 }
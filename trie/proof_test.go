@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -143,6 +144,205 @@ func TestMissingKeyProof(t *testing.T) {
 	}
 }
 
+type entrySlice []*kv
+
+func (p entrySlice) Len() int           { return len(p) }
+func (p entrySlice) Less(i, j int) bool { return bytes.Compare(p[i].k, p[j].k) < 0 }
+func (p entrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// sortedEntries returns the entries of a random trie in key order.
+func sortedEntries(vals map[string]*kv) []*kv {
+	var entries entrySlice
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Sort(entries)
+	return entries
+}
+
+// Tests that a sub-range of the trie leaves can be proven with the two edge
+// proofs of the first and the last leaf.
+func TestRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		proof := ethdb.NewMemDatabase()
+		if err := trie.Prove(entries[start].k, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		if err := trie.Prove(entries[end-1].k, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the last node %v", err)
+		}
+		var keys, values [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, entries[i].k)
+			values = append(values, entries[i].v)
+		}
+		more, err := VerifyRangeProof(trie.Hash(), keys[0], keys[len(keys)-1], keys, values, proof)
+		if err != nil {
+			t.Fatalf("Case %d(%d->%d) expect no error, got %v", i, start, end-1, err)
+		}
+		if more != (end != len(entries)) {
+			t.Fatalf("Case %d(%d->%d) more elements mismatch: have %v", i, start, end-1, more)
+		}
+	}
+}
+
+// Tests that a range can be proven with non-existent edge proofs, which is
+// how a range request with an arbitrary origin and limit is answered.
+func TestRangeProofWithNonExistentProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		first := decreaseKey(common.CopyBytes(entries[start].k))
+		if start != 0 && bytes.Equal(first, entries[start-1].k) {
+			continue
+		}
+		last := increaseKey(common.CopyBytes(entries[end-1].k))
+		if end != len(entries) && bytes.Equal(last, entries[end].k) {
+			continue
+		}
+		proof := ethdb.NewMemDatabase()
+		if err := trie.Prove(first, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		if err := trie.Prove(last, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the last node %v", err)
+		}
+		var keys, values [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, entries[i].k)
+			values = append(values, entries[i].v)
+		}
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, values, proof); err != nil {
+			t.Fatalf("Case %d(%d->%d) expect no error, got %v", i, start, end-1, err)
+		}
+	}
+}
+
+// Tests that the whole leaf set can be verified without any edge proof.
+func TestAllElementsProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	var keys, values [][]byte
+	for _, entry := range entries {
+		keys = append(keys, entry.k)
+		values = append(values, entry.v)
+	}
+	more, err := VerifyRangeProof(trie.Hash(), nil, nil, keys, values, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if more {
+		t.Fatalf("Expected no more elements")
+	}
+	// Dropping any element must invalidate the full range
+	if _, err := VerifyRangeProof(trie.Hash(), nil, nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatalf("Expected error for incomplete leaf set")
+	}
+}
+
+// Tests that an empty range is only accepted if there are no more leaves on
+// the right side of the origin.
+func TestEmptyRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	// The last leaf is followed by nothing, an empty range after it is valid
+	last := increaseKey(common.CopyBytes(entries[len(entries)-1].k))
+	proof := ethdb.NewMemDatabase()
+	if err := trie.Prove(last, 0, proof); err != nil {
+		t.Fatalf("Failed to prove the last node %v", err)
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), last, nil, nil, nil, proof); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// A range with leaves following the origin hides data, it must be rejected
+	first := increaseKey(common.CopyBytes(entries[0].k))
+	proof = ethdb.NewMemDatabase()
+	if err := trie.Prove(first, 0, proof); err != nil {
+		t.Fatalf("Failed to prove the first node %v", err)
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), first, nil, nil, nil, proof); err == nil {
+		t.Fatalf("Expected error for hidden leaves")
+	}
+}
+
+// Tests that tampered ranges (modified, missing or unordered leaves) are all
+// rejected by the range verification.
+func TestBadRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	entries := sortedEntries(vals)
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+		if end-start < 3 {
+			continue
+		}
+		proof := ethdb.NewMemDatabase()
+		if err := trie.Prove(entries[start].k, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		if err := trie.Prove(entries[end-1].k, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the last node %v", err)
+		}
+		var keys, values [][]byte
+		for i := start; i < end; i++ {
+			keys = append(keys, common.CopyBytes(entries[i].k))
+			values = append(values, common.CopyBytes(entries[i].v))
+		}
+		first, last := keys[0], keys[len(keys)-1]
+
+		index := mrand.Intn(end - start)
+		switch mrand.Intn(3) {
+		case 0:
+			// Modified leaf value
+			values[index] = randBytes(20)
+		case 1:
+			// Missing leaf in the middle of the range
+			index = 1 + mrand.Intn(len(keys)-2)
+			keys = append(keys[:index], keys[index+1:]...)
+			values = append(values[:index], values[index+1:]...)
+		case 2:
+			// Out of order leaves
+			index = 1 + mrand.Intn(len(keys)-2)
+			keys[index], keys[index+1] = keys[index+1], keys[index]
+			values[index], values[index+1] = values[index+1], values[index]
+		}
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, values, proof); err == nil {
+			t.Fatalf("%d Case %d index %d range: (%d->%d) expect error, got nil", i, index, index, start, end-1)
+		}
+	}
+}
+
+func increaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]++
+		if key[i] != 0x0 {
+			break
+		}
+	}
+	return key
+}
+
+func decreaseKey(key []byte) []byte {
+	for i := len(key) - 1; i >= 0; i-- {
+		key[i]--
+		if key[i] != 0xff {
+			break
+		}
+	}
+	return key
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {